@@ -4,13 +4,17 @@
 
 import type {
     ETLSourceSpec,
+    ETLDestinationSpec,
     ETLJobInput,
     ETLSyncJob,
     ETLSyncResult,
     ETLPreviewResult,
+    ETLDebugResult,
     ETLRunLog,
     ETLSchemaInfo,
     PageBlockRef,
+    ETLPipelineImportOptions,
+    ETLPipelineImportResult,
 } from '../wails'
 
 function go() { return window.go.app.App }
@@ -18,6 +22,8 @@ function go() { return window.go.app.App }
 export const etlAPI = {
     listSources: (): Promise<ETLSourceSpec[]> =>
         go().ListETLSources(),
+    listDestinations: (): Promise<ETLDestinationSpec[]> =>
+        go().ListETLDestinations(),
     createJob: (input: ETLJobInput): Promise<ETLSyncJob> =>
         go().CreateETLJob(input),
     getJob: (id: string): Promise<ETLSyncJob> =>
@@ -26,12 +32,24 @@ export const etlAPI = {
         go().ListETLJobs(),
     updateJob: (id: string, input: ETLJobInput): Promise<void> =>
         go().UpdateETLJob(id, input),
+    setJobEnabled: (id: string, enabled: boolean): Promise<void> =>
+        go().SetETLJobEnabled(id, enabled),
     deleteJob: (id: string): Promise<void> =>
         go().DeleteETLJob(id),
     runJob: (id: string): Promise<ETLSyncResult> =>
         go().RunETLJob(id),
+    cancelJob: (id: string): Promise<void> =>
+        go().CancelETLJob(id),
+    getWebhookURL: (id: string): Promise<string> =>
+        go().GetETLWebhookURL(id),
+    resetCursor: (id: string): Promise<void> =>
+        go().ResetETLJobCursor(id),
     previewSource: (sourceType: string, sourceConfigJSON: string): Promise<ETLPreviewResult> =>
         go().PreviewETLSource(sourceType, sourceConfigJSON),
+    querySQL: (query: string, rows: Record<string, unknown>[]): Promise<ETLPreviewResult> =>
+        go().QueryETLSQL(query, rows),
+    debugJob: (id: string, maxRows = 0): Promise<ETLDebugResult> =>
+        go().DebugETLJob(id, maxRows),
     listRunLogs: (jobID: string): Promise<ETLRunLog[]> =>
         go().ListETLRunLogs(jobID),
     pickFile: (): Promise<string> =>
@@ -42,4 +60,12 @@ export const etlAPI = {
         go().DiscoverETLSchema(sourceType, sourceConfigJSON),
     listPageHTTPBlocks: (pageID: string): Promise<PageBlockRef[]> =>
         go().ListPageHTTPBlocks(pageID),
+    exportJobs: (ids: string[], format: 'yaml' | 'json'): Promise<string> =>
+        go().ExportETLJobs(ids, format),
+    exportJobsToFile: (ids: string[], format: 'yaml' | 'json'): Promise<string> =>
+        go().ExportETLJobsToFile(ids, format),
+    readPipelineFile: (): Promise<string> =>
+        go().ReadETLPipelineFile(),
+    importJobs: (content: string, opts: ETLPipelineImportOptions): Promise<ETLPipelineImportResult> =>
+        go().ImportETLJobs(content, opts),
 }
@@ -13,6 +13,7 @@ export { etlAPI } from './etl'
 export { localdbAPI } from './localdb'
 export { databaseAPI, httpAPI, terminalAPI, connectionAPI } from './database'
 export { meetingAPI } from './meeting'
+export { secretAPI } from './secret'
 
 // ── Flat `api` object for backward compatibility ──────────
 // All existing code that imports `api` from bridge/wails continues to work.
@@ -24,6 +25,7 @@ import { etlAPI } from './etl'
 import { localdbAPI } from './localdb'
 import { databaseAPI, httpAPI, terminalAPI, connectionAPI } from './database'
 import { meetingAPI } from './meeting'
+import { secretAPI } from './secret'
 
 function go() { return window.go.app.App }
 
@@ -91,6 +93,9 @@ export const api = {
     saveBlockHTTPConfig: httpAPI.saveBlockConfig,
     listPageHTTPBlocks: etlAPI.listPageHTTPBlocks,
 
+    // ── Named secrets ──────────────────────────────────────
+    ...secretAPI,
+
     // ── Meeting capture ────────────────────────────────────
     ...meetingAPI,
 
@@ -0,0 +1,16 @@
+// ─────────────────────────────────────────────────────────────
+// Named Secrets API — values referenced as ${secret:<name>}
+// ─────────────────────────────────────────────────────────────
+
+import type { NamedSecret } from '../wails'
+
+function go() { return window.go.app.App }
+
+export const secretAPI = {
+    listSecrets: (): Promise<NamedSecret[]> =>
+        go().ListSecrets(),
+    setSecret: (name: string, value: string): Promise<void> =>
+        go().SetSecret(name, value),
+    deleteSecret: (name: string): Promise<void> =>
+        go().DeleteSecret(name),
+}
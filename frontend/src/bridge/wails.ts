@@ -77,13 +77,20 @@ declare global {
           GetLocalDatabaseStats(dbID: string): Promise<LocalDBStats>
           // ETL plugin
           ListETLSources(): Promise<ETLSourceSpec[]>
+          ListETLDestinations(): Promise<ETLDestinationSpec[]>
           CreateETLJob(input: ETLJobInput): Promise<ETLSyncJob>
           GetETLJob(id: string): Promise<ETLSyncJob>
           ListETLJobs(): Promise<ETLSyncJob[]>
           UpdateETLJob(id: string, input: ETLJobInput): Promise<void>
+          SetETLJobEnabled(id: string, enabled: boolean): Promise<void>
           DeleteETLJob(id: string): Promise<void>
           RunETLJob(id: string): Promise<ETLSyncResult>
+          CancelETLJob(id: string): Promise<void>
+          GetETLWebhookURL(id: string): Promise<string>
+          ResetETLJobCursor(id: string): Promise<void>
           PreviewETLSource(sourceType: string, sourceConfigJSON: string): Promise<ETLPreviewResult>
+          QueryETLSQL(query: string, rows: Record<string, unknown>[]): Promise<ETLPreviewResult>
+          DebugETLJob(id: string, maxRows: number): Promise<ETLDebugResult>
           ListETLRunLogs(jobID: string): Promise<ETLRunLog[]>
           PickETLFile(): Promise<string>
           ListPageDatabaseBlocks(pageID: string): Promise<PageBlockRef[]>
@@ -91,6 +98,14 @@ declare global {
           ExecuteHTTPRequest(blockID: string, configJSON: string): Promise<HTTPResponse>
           SaveBlockHTTPConfig(blockID: string, config: string): Promise<void>
           ListPageHTTPBlocks(pageID: string): Promise<PageBlockRef[]>
+          ExportETLJobs(ids: string[], format: 'yaml' | 'json'): Promise<string>
+          ExportETLJobsToFile(ids: string[], format: 'yaml' | 'json'): Promise<string>
+          ReadETLPipelineFile(): Promise<string>
+          ImportETLJobs(content: string, opts: ETLPipelineImportOptions): Promise<ETLPipelineImportResult>
+          // Named secrets
+          ListSecrets(): Promise<NamedSecret[]>
+          SetSecret(name: string, value: string): Promise<void>
+          DeleteSecret(name: string): Promise<void>
           // Canvas entities (unified)
           CreateCanvasEntity(pageID: string, entityType: string, x: number, y: number, w: number, h: number): Promise<CanvasEntity>
           GetCanvasEntity(id: string): Promise<CanvasEntity>
@@ -382,6 +397,15 @@ export interface ETLSourceSpec {
   label: string
   icon: string
   configFields: ETLConfigField[]
+  stateful?: boolean // tracks its own incremental state (connector STATE)
+}
+
+export interface ETLDestinationSpec {
+  type: string
+  label: string
+  icon: string
+  configFields: ETLConfigField[]
+  modes: string[] // sync modes the destination can write
 }
 
 export interface ETLConfigField {
@@ -393,6 +417,7 @@ export interface ETLConfigField {
   default?: string
   help?: string
   placeholder?: string
+  fixed?: boolean // cannot be overridden by a webhook
 }
 
 export interface ETLJobInput {
@@ -401,13 +426,70 @@ export interface ETLJobInput {
   sourceConfig: Record<string, string>
   transforms: ETLTransformConfig[]
   targetDbId: string
+  destType?: string // registered destination type; '' = LocalDB (targetDbId)
+  destConfig?: Record<string, string>
   syncMode: string
   dedupeKey: string
+  cursorField?: string
+  mergeKeys?: string[]
+  deleteMissing?: boolean
+  assertions?: ETLAssertion[]
+  quarantineDbId?: string
+  retry?: ETLRetryPolicy
+  schemaPolicy?: ETLSchemaPolicy
   triggerType: string
   triggerConfig: string
   enabled: boolean
 }
 
+// What a run does when an output field changes type or disappears:
+// ignore (default) only records it, fail aborts the run, evolve retypes the
+// LocalDB column and converts existing values as the run commits.
+export type ETLSchemaPolicy = '' | 'ignore' | 'fail' | 'evolve'
+
+// A difference between the output schema of a run and of the last successful one.
+export interface ETLSchemaChange {
+  field: string
+  change: 'added' | 'removed' | 'type_changed'
+  oldType?: string
+  newType?: string
+}
+
+// Retries of scheduled runs (cron, file watch, after_job).
+export interface ETLRetryPolicy {
+  maxAttempts?: number       // attempts per run, including the first
+  backoffSeconds?: number    // delay before the first retry, doubled after each one
+  maxBackoffSeconds?: number
+  disableAfter?: number      // consecutive failed runs before the job is disabled
+}
+
+// Payload of the etl:job-failed event.
+export interface ETLJobFailedEvent {
+  jobId: string
+  jobName: string
+  error: string
+  attempts: number
+  consecutiveFailures: number
+  disabled: boolean
+}
+
+export interface ETLAssertion {
+  type: 'not_null' | 'unique' | 'in_set' | 'regex' | 'range' | 'row_count'
+  column?: string
+  values?: unknown[]
+  pattern?: string
+  min?: number
+  max?: number
+  policy?: 'fail' | 'warn' | 'quarantine'
+}
+
+export interface ETLAssertionResult {
+  assertion: string
+  policy: string
+  violations: number
+  example?: string
+}
+
 export interface ETLTransformConfig {
   type: string
   config: Record<string, string>
@@ -420,14 +502,52 @@ export interface ETLSyncJob {
   sourceConfig: Record<string, string>
   transforms: ETLTransformConfig[]
   targetDbId: string
+  destType?: string // registered destination type; '' = LocalDB (targetDbId)
+  destConfig?: Record<string, string>
   syncMode: string
   dedupeKey: string
+  cursorField?: string
+  cursorValue?: string
+  mergeKeys?: string[]
+  deleteMissing?: boolean
+  assertions?: ETLAssertion[]
+  quarantineDbId?: string
+  retry?: ETLRetryPolicy
+  schemaPolicy?: ETLSchemaPolicy
+  lastSchema?: ETLSchemaInfo // schema discovered by the last successful run
   triggerType: string
   triggerConfig: string
   enabled: boolean
   lastRunAt: string
   lastStatus: string
   lastError: string
+  consecutiveFailures?: number
+  createdAt: string
+  updatedAt: string
+}
+
+// Import of a YAML/JSON pipeline document (jobs matched by name).
+export interface ETLPipelineImportOptions {
+  dryRun: boolean    // validate only
+  overwrite: boolean // replace jobs with the same name instead of skipping them
+}
+
+export interface ETLPipelineImportResult {
+  dryRun: boolean
+  jobs: {
+    name: string
+    action: 'create' | 'update' | 'skip'
+    jobId?: string
+    conflict?: string
+    errors?: string[]
+    warnings?: string[]
+  }[]
+  secrets?: { name: string; job: string; field: string; set: boolean }[] // named secrets referenced; unset ones must be entered
+}
+
+// Named secret, referenced from configs as ${secret:<name>}. Values are write-only.
+export interface NamedSecret {
+  name: string
   createdAt: string
   updatedAt: string
 }
@@ -437,8 +557,35 @@ export interface ETLSyncResult {
   status: string
   rowsRead: number
   rowsWritten: number
+  rowsInserted?: number
+  rowsUpdated?: number
+  rowsDeleted?: number
   duration: number
   error?: string
+  cursor?: string
+  output?: string // source diagnostic output, e.g. connector stderr
+  files?: ETLIngestedFile[] // files read by a directory source
+  rowsQuarantined?: number
+  assertions?: ETLAssertionResult[]
+  schemaDrift?: ETLSchemaChange[]
+}
+
+// A file read by a CSV/JSON source in directory mode.
+export interface ETLIngestedFile {
+  path: string
+  checksum: string // hex SHA-256
+  rows: number
+  ingestedAt: string
+}
+
+// Payload of the etl:progress event.
+export interface ETLProgress {
+  jobId: string
+  stage: 'discover' | 'read' | 'aggregate' | 'sort' | 'commit'
+  batch: number
+  rowsRead: number
+  rowsWritten: number
+  elapsed: number // nanoseconds
 }
 
 export interface ETLPreviewResult {
@@ -447,6 +594,23 @@ export interface ETLPreviewResult {
   totalRows: number
 }
 
+export interface ETLDebugStage {
+  index: number // position in job.transforms; -1 for source, cursor and dedupe
+  type: string
+  recordsIn: number
+  recordsOut: number
+  sample: { data: Record<string, any> }[]
+  schema: ETLSchemaInfo
+  errors?: { row: number; error: string }[]
+  errorCount: number
+}
+
+export interface ETLDebugResult {
+  jobId: string
+  stages: ETLDebugStage[]
+  error?: string
+}
+
 export interface ETLRunLog {
   id: string
   jobId: string
@@ -455,7 +619,17 @@ export interface ETLRunLog {
   status: string
   rowsRead: number
   rowsWritten: number
+  rowsInserted?: number
+  rowsUpdated?: number
+  rowsDeleted?: number
   error?: string
+  output?: string        // source diagnostic output, e.g. connector stderr
+  rowsQuarantined?: number
+  assertions?: ETLAssertionResult[]
+  schemaDrift?: ETLSchemaChange[]
+  upstreamRunId?: string // after_job runs: the run that triggered this one
+  rootRunId?: string     // after_job runs: the first run of the chain
+  attempt?: number       // 1-based attempt within a retried run
 }
 
 export interface ETLSchemaInfo {
@@ -3,6 +3,7 @@ import { createPortal } from 'react-dom'
 import { IconPlus, IconX, IconChevronUp, IconChevronDown } from '@tabler/icons-react'
 import type { ColumnDef } from './types'
 import type { Row } from './pipeline'
+import { computeExpressionError } from '../shared/expr'
 import { Select } from '../shared/components/Select'
 import {
     type PipelineConfig, type Stage, type FilterCondition, type MetricDef,
@@ -63,6 +64,7 @@ export function NotebookEditor({ config, databases, dbColumns, executedRows, onC
             case 'default_value': stage = { type: 'default_value', field: '', defaultValue: '' }; break
             case 'type_cast': stage = { type: 'type_cast', field: '', castType: 'number' }; break
             case 'pivot': stage = { type: 'pivot', rowKeys: [], pivotColumn: '', valueColumns: [] }; break
+            case 'sql': stage = { type: 'sql', query: 'SELECT * FROM input' }; break
             default: return
         }
         onChange({ ...config, stages: [...config.stages, stage] })
@@ -287,7 +289,10 @@ function StageBody({ stage, dbOptions, dbColumns, availableColumns, colOptions,
                                 onChange({ ...stage, columns: next })
                             }} />
                             <span className="pl-kw">=</span>
-                            <input className="pl-input" placeholder="{price} * {quantity}" value={c.expression} onChange={e => {
+                            <input
+                                className={computeExpressionError(c.expression) ? 'pl-input pl-input-invalid' : 'pl-input'}
+                                title={computeExpressionError(c.expression) ?? undefined}
+                                placeholder="{price} * {quantity}" value={c.expression} onChange={e => {
                                 const next = [...stage.columns]; next[i] = { ...c, expression: e.target.value }
                                 onChange({ ...stage, columns: next })
                             }} />
@@ -596,6 +601,19 @@ function StageBody({ stage, dbOptions, dbColumns, availableColumns, colOptions,
                 </div>
             )
 
+        case 'sql':
+            return (
+                <textarea
+                    className="pl-input"
+                    style={{ width: '100%', resize: 'vertical', fontFamily: 'monospace', fontSize: 11 }}
+                    rows={4}
+                    value={stage.query}
+                    onChange={e => onChange({ ...stage, query: e.target.value })}
+                    placeholder="SELECT category, sum(sales) AS total FROM input GROUP BY category"
+                    title="Rows so far are in the table input; other local databases can be joined by name"
+                />
+            )
+
         default:
             return null
     }
@@ -646,6 +664,7 @@ function AddStageMenu({ onAdd }: { onAdd: (type: Stage['type']) => void }) {
         { type: 'group', label: 'Group', desc: 'Aggregate by categories' },
         { type: 'pivot', label: 'Pivot', desc: 'Turn row values into columns' },
         { type: 'percent', label: 'Percent', desc: 'Add % of total column' },
+        { type: 'sql', label: 'SQL', desc: 'Reshape rows with a SELECT query' },
         { type: 'sort', label: 'Sort', desc: 'Order rows' },
         { type: 'limit', label: 'Limit', desc: 'Cap row count' },
     ]
@@ -178,6 +178,8 @@ function ChartBlockRenderer({ block, ctx }: PluginRendererProps) {
                 for (const c of cols) map[c.id] = c.name
                 return map
             },
+            querySQL: (query, rows) => ctx!.rpc.call<{ records?: { data: Row }[] }>('QueryETLSQL', query, rows)
+                .then(res => (res?.records || []).map(r => r.data)),
         }
         executePipeline(pipeline, fetcher).then(rows => {
             setExecutedRows(rows)
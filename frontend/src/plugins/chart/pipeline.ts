@@ -1,4 +1,5 @@
 import type { ColumnDef } from './types'
+import { evaluateCompute } from '../shared/expr'
 
 // ── Types ──────────────────────────────────────────────────
 
@@ -119,6 +120,11 @@ export interface PivotStage {
     showTotal?: boolean   // add a Grand Total row at the bottom
 }
 
+export interface SQLStage {
+    type: 'sql'
+    query: string         // SELECT over the table "input"; other LocalDBs by name
+}
+
 export type Stage =
     | SourceStage
     | JoinStage
@@ -134,6 +140,7 @@ export type Stage =
     | DefaultValueStage
     | TypeCastStage
     | PivotStage
+    | SQLStage
 
 // ── Viz Config ─────────────────────────────────────────────
 
@@ -159,6 +166,7 @@ export function defaultPipelineConfig(): PipelineConfig {
 export interface DataFetcher {
     getRows(databaseId: string): Promise<{ dataJson: string }[]>
     getColumnMap(databaseId: string): Record<string, string> // uuid → name
+    querySQL?(query: string, rows: Row[]): Promise<Row[]>   // runs a sql stage on the backend
 }
 
 // ── Pipeline Executor ──────────────────────────────────────
@@ -211,6 +219,9 @@ export async function executePipeline(config: PipelineConfig, fetcher: DataFetch
             case 'pivot':
                 rows = executePivot(rows, stage)
                 break
+            case 'sql':
+                rows = await executeSQL(rows, stage)
+                break
         }
     }
 
@@ -314,7 +325,7 @@ function executeCompute(rows: Row[], stage: ComputeStage): Row[] {
         const next = { ...row }
         for (const col of stage.columns) {
             try {
-                next[col.name] = evaluateExpression(row, col.expression)
+                next[col.name] = evaluateCompute(row, col.expression)
             } catch {
                 next[col.name] = null
             }
@@ -323,29 +334,6 @@ function executeCompute(rows: Row[], stage: ComputeStage): Row[] {
     })
 }
 
-function evaluateExpression(row: Row, expr: string): unknown {
-    // Simple expression evaluator:
-    // - Column references: {column_name}
-    // - Basic math: +, -, *, /
-    // - String concat: {a} + " " + {b}
-
-    // Replace {column} with values
-    const resolved = expr.replace(/\{([^}]+)\}/g, (_match, colName: string) => {
-        const val = row[colName]
-        if (val === null || val === undefined) return '0'
-        if (typeof val === 'number') return String(val)
-        return JSON.stringify(String(val))
-    })
-
-    try {
-        // Safe eval using Function constructor (no access to globals)
-        const fn = new Function('return ' + resolved)
-        return fn()
-    } catch {
-        return resolved
-    }
-}
-
 function executeGroup(rows: Row[], stage: GroupStage): Row[] {
     if (stage.groupBy.length === 0 && stage.metrics.length === 0) return rows
 
@@ -641,6 +629,12 @@ function executePivot(rows: Row[], stage: PivotStage): Row[] {
     return result
 }
 
+async function executeSQL(rows: Row[], stage: SQLStage): Promise<Row[]> {
+    const query = (stage.query || '').trim()
+    if (!query || !_activeFetcher?.querySQL) return rows
+    return _activeFetcher.querySQL(query, rows)
+}
+
 // ── Helpers ────────────────────────────────────────────────
 
 /** Get available columns after executing stages up to a given index */
@@ -689,6 +683,7 @@ export function getColumnsAtStage(
                 break
             }
             // pivot: dynamic columns — can't track statically, but rowKey stays
+            // sql: the result's columns are only known once the query runs
         }
     }
 
@@ -711,6 +706,7 @@ export const STAGE_LABELS: Record<Stage['type'], string> = {
     default_value: 'Default Value',
     type_cast: 'Type Cast',
     pivot: 'Pivot',
+    sql: 'SQL',
 }
 
 export const FILTER_OPS: { value: FilterOp; label: string }[] = [
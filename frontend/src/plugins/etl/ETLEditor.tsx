@@ -5,7 +5,7 @@ import { Select } from '../shared/components/Select'
 import { ETLTransformStep } from './ETLTransformStep'
 import { CronBuilder } from './CronBuilder'
 import type { TransformStage } from './ETLPipeline'
-import type { SourceSpec, SyncJob, TransformConfig } from './index'
+import type { DestinationSpec, RetryPolicy, SourceSpec, SyncJob, TransformConfig } from './index'
 
 // ── Props ──────────────────────────────────────────────────
 
@@ -26,6 +26,7 @@ interface HTTPBlockOption {
 interface ETLEditorProps {
     existingJob: SyncJob | null
     sources: SourceSpec[]
+    destinations: DestinationSpec[]
     databases: LocalDatabase[]
     pageId: string
     onSave: (job: SyncJob) => void
@@ -42,28 +43,63 @@ const STEPS = [
 
 // ── Component ──────────────────────────────────────────────
 
-export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onCancel }: ETLEditorProps) {
+export function ETLEditor({ existingJob, sources, destinations, databases, pageId, onSave, onCancel }: ETLEditorProps) {
     const [step, setStep] = useState(1)
 
     const [name, setName] = useState(existingJob?.name || '')
     const [sourceType, setSourceType] = useState(existingJob?.sourceType || '')
     const [sourceConfig, setSourceConfig] = useState<Record<string, any>>(existingJob?.sourceConfig || {})
     const [targetDbId, setTargetDbId] = useState(existingJob?.targetDbId || '')
+    const [destType, setDestType] = useState(existingJob?.destType && existingJob.destType !== 'localdb' ? existingJob.destType : '')
+    const [destConfig, setDestConfig] = useState<Record<string, any>>(existingJob?.destConfig || {})
     const [syncMode, setSyncMode] = useState(existingJob?.syncMode || 'replace')
     const [dedupeKey, setDedupeKey] = useState(existingJob?.dedupeKey || '')
     const [triggerType, setTriggerType] = useState(existingJob?.triggerType || 'manual')
     const [triggerConfig, setTriggerConfig] = useState(existingJob?.triggerConfig || '')
+    const [retry, setRetry] = useState<RetryPolicy>(existingJob?.retry || {})
+    const [schemaPolicy, setSchemaPolicy] = useState(existingJob?.schemaPolicy || 'ignore')
     const [saving, setSaving] = useState(false)
     const [error, setError] = useState('')
     const [transforms, setTransforms] = useState<TransformStage[]>(
         (existingJob?.transforms || []).map((t: any) => ({ type: t.type, config: t.config || {} }))
     )
 
+    // Other jobs, for the after_job trigger
+    const [otherJobs, setOtherJobs] = useState<SyncJob[]>([])
+    useEffect(() => {
+        if (triggerType !== 'after_job') return
+        rpcCall<SyncJob[]>('ListETLJobs')
+            .then(jobs => setOtherJobs((jobs || []).filter(j => j.id !== existingJob?.id)))
+            .catch(() => setOtherJobs([]))
+    }, [triggerType, existingJob?.id])
+
+    // Webhook URL of a saved webhook job
+    const [webhookUrl, setWebhookUrl] = useState('')
+    useEffect(() => {
+        if (triggerType !== 'webhook' || existingJob?.triggerType !== 'webhook') {
+            setWebhookUrl('')
+            return
+        }
+        rpcCall<string>('GetETLWebhookURL', existingJob.id)
+            .then(setWebhookUrl)
+            .catch(() => setWebhookUrl(''))
+    }, [triggerType, existingJob?.id, existingJob?.triggerType])
+
     // Database blocks on this page
     const [dbBlocks, setDbBlocks] = useState<DatabaseBlockOption[]>([])
     const [httpBlocks, setHttpBlocks] = useState<HTTPBlockOption[]>([])
 
     const selectedSource = sources.find(s => s.type === sourceType)
+    const selectedDest = destinations.find(d => d.type === destType)
+
+    // Saved database connections, for the SQL destination
+    const [connections, setConnections] = useState<{ id: string; name: string }[]>([])
+    useEffect(() => {
+        if (!selectedDest?.configFields.some(f => f.type === 'db_connection')) return
+        rpcCall<{ id: string; name: string }[]>('ListDatabaseConnections')
+            .then(c => setConnections(c || []))
+            .catch(() => setConnections([]))
+    }, [selectedDest])
 
     useEffect(() => {
         if (sourceType === 'database' && pageId) {
@@ -106,8 +142,8 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
     }, [])
 
     const handleSave = useCallback(async () => {
-        if (!sourceType || !targetDbId) {
-            setError('Source type and target database are required')
+        if (!sourceType || (!destType && !targetDbId)) {
+            setError('Source type and target are required')
             return
         }
 
@@ -115,15 +151,19 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
         setError('')
         try {
             const input = {
-                name: name || `${selectedSource?.label || sourceType} → ${databases.find(d => d.id === targetDbId)?.name || 'DB'}`,
+                name: name || `${selectedSource?.label || sourceType} → ${selectedDest?.label || databases.find(d => d.id === targetDbId)?.name || 'DB'}`,
                 sourceType,
                 sourceConfig,
                 transforms: transforms as TransformConfig[],
-                targetDbId,
+                targetDbId: destType ? '' : targetDbId,
+                destType,
+                destConfig: destType ? destConfig : {},
                 syncMode,
                 dedupeKey,
                 triggerType,
-                triggerConfig: triggerType === 'file_watch' ? (sourceConfig.filePath || '') : triggerConfig,
+                triggerConfig: triggerType === 'file_watch' ? (sourceConfig.directory || sourceConfig.filePath || '') : triggerConfig,
+                retry,
+                schemaPolicy,
             }
 
             let savedJob: SyncJob
@@ -139,7 +179,7 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
         } finally {
             setSaving(false)
         }
-    }, [name, sourceType, sourceConfig, transforms, targetDbId, syncMode, dedupeKey, triggerType, triggerConfig, existingJob, selectedSource, databases, onSave])
+    }, [name, sourceType, sourceConfig, transforms, targetDbId, destType, destConfig, selectedDest, syncMode, dedupeKey, triggerType, triggerConfig, retry, schemaPolicy, existingJob, selectedSource, databases, onSave])
 
     // Options
     const dbOptions = databases.map(d => ({ value: d.id, label: d.name || d.id }))
@@ -150,11 +190,19 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
     const syncModeOptions = [
         { value: 'replace', label: 'Replace (full refresh)' },
         { value: 'append', label: 'Append (add new)' },
+        ...(selectedSource?.stateful ? [{ value: 'incremental', label: 'Incremental (connector state)' }] : []),
+    ].filter(o => !selectedDest || selectedDest.modes.includes(o.value))
+    const schemaPolicyOptions = [
+        { value: 'ignore', label: 'Ignore (log only)' },
+        { value: 'fail', label: 'Fail the run' },
+        ...(!destType ? [{ value: 'evolve', label: 'Evolve column types' }] : []),
     ]
-    const isFileSource = sourceType === 'csv_file' || sourceType === 'json_file'
+    const isFileSource = sourceType === 'csv_file' || sourceType === 'json_file' || sourceType === 'jsonl_file' || sourceType === 'xlsx_file'
     const triggerOptionsBase = [
         { value: 'manual', label: 'Manual' },
         { value: 'schedule', label: 'Schedule (cron)' },
+        { value: 'after_job', label: 'After another job' },
+        { value: 'webhook', label: 'Webhook' },
     ]
     const triggerOptionsFile = [
         ...triggerOptionsBase,
@@ -184,10 +232,12 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
             )
         }
 
-        if (field.type === 'select' || field.type === 'db_block' || field.type === 'http_block') {
+        if (field.type === 'select' || field.type === 'db_block' || field.type === 'http_block' || field.type === 'localdb') {
             let optionsToUse: { value: string; label: string }[]
             if (field.type === 'db_block') {
                 optionsToUse = dbBlockOptions
+            } else if (field.type === 'localdb') {
+                optionsToUse = dbOptions
             } else if (field.type === 'http_block') {
                 optionsToUse = httpBlocks.map(b => ({ value: b.blockId, label: b.label }))
             } else {
@@ -227,6 +277,44 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
         )
     }
 
+    // Render a destination config field
+    const renderDestField = (field: DestinationSpec['configFields'][0]) => {
+        const setValue = (v: string) => setDestConfig(prev => ({ ...prev, [field.key]: v }))
+        if (field.type === 'db_connection' || field.type === 'select') {
+            const options = field.type === 'db_connection'
+                ? connections.map(c => ({ value: c.id, label: c.name || c.id }))
+                : (field.options || []).map(o => ({ value: o, label: o }))
+            return (
+                <Select
+                    value={destConfig[field.key] || ''}
+                    options={options}
+                    placeholder={`Select ${field.label}…`}
+                    onChange={setValue}
+                />
+            )
+        }
+        if (field.type === 'textarea') {
+            return (
+                <textarea
+                    className="pl-input pl-input-full"
+                    value={destConfig[field.key] || ''}
+                    onChange={e => setValue(e.target.value)}
+                    placeholder={field.help || ''}
+                    rows={3}
+                    style={{ resize: 'vertical', fontFamily: 'monospace', fontSize: 11 }}
+                />
+            )
+        }
+        return (
+            <input
+                className="pl-input pl-input-full"
+                value={destConfig[field.key] ?? field.default ?? ''}
+                onChange={e => setValue(e.target.value)}
+                placeholder={field.help || ''}
+            />
+        )
+    }
+
     return (
         <div className="pl-editor">
             {/* Step indicator */}
@@ -347,13 +435,47 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
                             <span className="pl-stage-label">Target</span>
                         </div>
                         <div className="pl-stage-body">
-                            <Select
-                                value={targetDbId}
-                                options={dbOptions}
-                                placeholder="Select target database…"
-                                onChange={v => setTargetDbId(v)}
-                                className="pl-sel--full"
-                            />
+                            {destinations.length > 0 && (
+                                <div className="pl-chips" style={{ marginBottom: 6 }}>
+                                    <button
+                                        className={`pl-chip ${!destType ? 'active' : ''}`}
+                                        onClick={() => { setDestType(''); setDestConfig({}) }}
+                                    >
+                                        Local Database
+                                    </button>
+                                    {destinations.map(d => (
+                                        <button
+                                            key={d.type}
+                                            className={`pl-chip ${destType === d.type ? 'active' : ''}`}
+                                            onClick={() => {
+                                                setDestType(d.type)
+                                                setDestConfig({})
+                                                if (!d.modes.includes(syncMode)) setSyncMode(d.modes[0] || 'replace')
+                                            }}
+                                        >
+                                            {d.label}
+                                        </button>
+                                    ))}
+                                </div>
+                            )}
+                            {!selectedDest && (
+                                <Select
+                                    value={targetDbId}
+                                    options={dbOptions}
+                                    placeholder="Select target database…"
+                                    onChange={v => setTargetDbId(v)}
+                                    className="pl-sel--full"
+                                />
+                            )}
+                            {selectedDest?.configFields.map(field => (
+                                <div key={field.key} className="pl-field">
+                                    <label className="pl-label">
+                                        {field.label}
+                                        {field.required && <span style={{ color: 'var(--color-danger, #ef4444)', marginLeft: 2 }}>*</span>}
+                                    </label>
+                                    {renderDestField(field)}
+                                </div>
+                            ))}
                         </div>
                     </div>
 
@@ -381,6 +503,14 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
                                     />
                                 </div>
                             )}
+                            <div className="pl-field">
+                                <label className="pl-label">Schema Drift</label>
+                                <Select
+                                    value={schemaPolicy}
+                                    options={schemaPolicyOptions}
+                                    onChange={v => setSchemaPolicy(v)}
+                                />
+                            </div>
                             <div className="pl-field">
                                 <label className="pl-label">Trigger</label>
                                 <div className="pl-inline">
@@ -390,19 +520,88 @@ export function ETLEditor({ existingJob, sources, databases, pageId, onSave, onC
                                         onChange={v => {
                                             setTriggerType(v)
                                             if (v === 'file_watch') {
-                                                setTriggerConfig(sourceConfig.filePath || '')
+                                                setTriggerConfig(sourceConfig.directory || sourceConfig.filePath || '')
+                                            } else if (v === 'webhook' && existingJob?.triggerType === 'webhook') {
+                                                setTriggerConfig(existingJob.triggerConfig)
+                                            } else if (v !== triggerType) {
+                                                setTriggerConfig('')
                                             }
                                         }}
                                     />
                                     {triggerType === 'file_watch' && (
                                         <span style={{ fontSize: 10, color: 'var(--color-text-muted)' }}>
-                                            watching: {(sourceConfig.filePath || '').split('/').pop() || 'source file'}
+                                            watching: {(sourceConfig.directory || sourceConfig.filePath || '').split('/').pop() || 'source file'}
                                         </span>
                                     )}
                                 </div>
                                 {triggerType === 'schedule' && (
                                     <CronBuilder value={triggerConfig} onChange={setTriggerConfig} />
                                 )}
+                                {triggerType === 'after_job' && (
+                                    <div className="pl-inline" style={{ marginTop: 4 }}>
+                                        <span className="pl-kw">runs after</span>
+                                        <Select
+                                            value={triggerConfig}
+                                            options={otherJobs.map(j => ({ value: j.id, label: j.name || j.id }))}
+                                            placeholder="Upstream job…"
+                                            onChange={setTriggerConfig}
+                                        />
+                                    </div>
+                                )}
+                                {triggerType === 'webhook' && (
+                                    <div className="pl-inline" style={{ marginTop: 4 }}>
+                                        <span className="pl-kw">POST</span>
+                                        {webhookUrl ? (
+                                            <input
+                                                className="pl-input pl-input-full"
+                                                value={webhookUrl}
+                                                readOnly
+                                                onFocus={e => e.target.select()}
+                                                title="POST to this URL to run the job. A JSON body overrides source settings for that run; add ?wait=true to wait for the result."
+                                            />
+                                        ) : (
+                                            <span style={{ fontSize: 10, color: 'var(--color-text-muted)' }}>
+                                                the URL is generated when the job is saved
+                                            </span>
+                                        )}
+                                    </div>
+                                )}
+                                {triggerType !== 'manual' && (
+                                    <div className="pl-inline" style={{ marginTop: 4 }}>
+                                        <span className="pl-kw">retry</span>
+                                        <input
+                                            className="pl-input"
+                                            type="number"
+                                            style={{ width: 48, flex: 'none' }}
+                                            value={retry.maxAttempts || 1}
+                                            onChange={e => setRetry(r => ({ ...r, maxAttempts: Number(e.target.value) }))}
+                                            min={1}
+                                            max={10}
+                                            title="Attempts per run, including the first. Only network errors, timeouts and HTTP 429/5xx are retried."
+                                        />
+                                        <span className="pl-kw">attempts, backoff</span>
+                                        <input
+                                            className="pl-input"
+                                            type="number"
+                                            style={{ width: 56, flex: 'none' }}
+                                            value={retry.backoffSeconds || 30}
+                                            onChange={e => setRetry(r => ({ ...r, backoffSeconds: Number(e.target.value) }))}
+                                            min={1}
+                                            title="Seconds before the first retry; doubled after each one"
+                                        />
+                                        <span className="pl-kw">s, disable after</span>
+                                        <input
+                                            className="pl-input"
+                                            type="number"
+                                            style={{ width: 48, flex: 'none' }}
+                                            value={retry.disableAfter || 0}
+                                            onChange={e => setRetry(r => ({ ...r, disableAfter: Number(e.target.value) }))}
+                                            min={0}
+                                            title="Consecutive failed runs before the job is disabled (0 = never)"
+                                        />
+                                        <span className="pl-kw">failures</span>
+                                    </div>
+                                )}
                             </div>
                         </div>
                     </div>
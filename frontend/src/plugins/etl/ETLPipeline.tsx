@@ -3,10 +3,12 @@ import { createPortal } from 'react-dom'
 import { IconPlus, IconX, IconChevronUp, IconChevronDown } from '@tabler/icons-react'
 import { rpcCall } from '../sdk'
 import { Select } from '../shared/components/Select'
+import { computeExpressionError } from '../shared/expr'
+import type { LocalDatabase } from './types'
 
 // ── Types ──────────────────────────────────────────────────
 
-export type TransformType = 'filter' | 'rename' | 'select' | 'dedupe' | 'compute' | 'sort' | 'limit' | 'type_cast' | 'flatten' | 'string' | 'date_part' | 'default_value' | 'math'
+export type TransformType = 'filter' | 'rename' | 'select' | 'dedupe' | 'compute' | 'sort' | 'limit' | 'type_cast' | 'flatten' | 'string' | 'date_part' | 'default_value' | 'math' | 'group' | 'lookup' | 'sql'
 
 export interface TransformStage {
     type: TransformType
@@ -26,6 +28,34 @@ export const FILTER_OPS: { value: FilterOp; label: string }[] = [
     { value: 'is_not_empty', label: 'is not empty' },
 ]
 
+export type GroupAgg = 'count' | 'count_distinct' | 'sum' | 'avg' | 'min' | 'max' | 'median' | 'percentile'
+
+export interface GroupMetric {
+    column: string      // ignored for count
+    agg: GroupAgg
+    percentile?: number // 0–100, for percentile
+    as?: string         // output column name
+}
+
+export const GROUP_AGGS: { value: GroupAgg; label: string }[] = [
+    { value: 'count', label: 'Count' },
+    { value: 'count_distinct', label: 'Count distinct' },
+    { value: 'sum', label: 'Sum' },
+    { value: 'avg', label: 'Average' },
+    { value: 'min', label: 'Min' },
+    { value: 'max', label: 'Max' },
+    { value: 'median', label: 'Median' },
+    { value: 'percentile', label: 'Percentile' },
+]
+
+/** Output column name for a group metric — mirrors etl.GroupMetric.OutputName. */
+export function groupMetricName(m: GroupMetric): string {
+    if (m.as) return m.as
+    if (!m.column) return m.agg
+    if (m.agg === 'percentile') return `${m.column}_p${String(m.percentile ?? 50).replace('.', '_')}`
+    return `${m.column}_${m.agg}`
+}
+
 export const STAGE_LABELS: Record<TransformType, string> = {
     filter: 'Filter',
     rename: 'Rename',
@@ -40,6 +70,9 @@ export const STAGE_LABELS: Record<TransformType, string> = {
     date_part: 'Date Part',
     default_value: 'Default Value',
     math: 'Math',
+    group: 'Group',
+    lookup: 'Lookup',
+    sql: 'SQL',
 }
 
 export const STAGE_DESCS: Record<TransformType, string> = {
@@ -56,6 +89,22 @@ export const STAGE_DESCS: Record<TransformType, string> = {
     date_part: 'Extract part of a date',
     default_value: 'Fill empty values',
     math: 'Apply math functions',
+    group: 'Aggregate rows into summaries',
+    lookup: 'Join columns from a local database',
+    sql: 'Reshape rows with a SELECT query',
+}
+
+export interface LookupColumn {
+    column: string // LocalDB column name
+    as?: string    // output column name
+}
+
+/** Lookup columns may be stored as plain names or {column, as} objects. */
+export function normalizeLookupColumns(raw: unknown): LookupColumn[] {
+    if (!Array.isArray(raw)) return []
+    return raw
+        .map(c => (typeof c === 'string' ? { column: c } : c as LookupColumn))
+        .filter(c => c && c.column)
 }
 
 // ── Column Tracking ────────────────────────────────────────
@@ -108,6 +157,19 @@ export function getColumnsAtStage(
                 if (tgt && !cols.includes(tgt)) cols.push(tgt)
                 break
             }
+            case 'group': {
+                const metrics = (s.config.metrics as GroupMetric[]) || []
+                cols = [...((s.config.groupBy as string[]) || []), ...metrics.map(groupMetricName)]
+                break
+            }
+            case 'lookup': {
+                for (const c of normalizeLookupColumns(s.config.columns)) {
+                    const outName = c.as || c.column
+                    if (!cols.includes(outName)) cols.push(outName)
+                }
+                break
+            }
+            // sql: the result's columns are only known once the query runs
             // filter, dedupe, sort, limit, type_cast, default_value, math don't change column set
         }
     }
@@ -132,6 +194,9 @@ function defaultStage(type: TransformType): TransformStage {
         case 'date_part': return { type, config: { field: '', part: 'year', targetField: '' } }
         case 'default_value': return { type, config: { field: '', defaultValue: '' } }
         case 'math': return { type, config: { field: '', op: 'round' } }
+        case 'group': return { type, config: { groupBy: [], metrics: [{ column: '', agg: 'count' }] } }
+        case 'lookup': return { type, config: { databaseId: '', leftKey: '', rightKey: '', columns: [], joinType: 'left' } }
+        case 'sql': return { type, config: { query: 'SELECT * FROM input' } }
     }
 }
 
@@ -418,7 +483,8 @@ function TransformStageBody({ stage, availableCols, colOptions, onChange }: {
                             />
                             <span className="pl-kw">=</span>
                             <input
-                                className="pl-input"
+                                className={computeExpressionError(col.expression) ? 'pl-input pl-input-invalid' : 'pl-input'}
+                                title={computeExpressionError(col.expression) ?? undefined}
                                 style={{ flex: 1 }}
                                 value={col.expression}
                                 onChange={e => {
@@ -444,6 +510,96 @@ function TransformStageBody({ stage, availableCols, colOptions, onChange }: {
             )
         }
 
+        case 'group': {
+            const groupBy = (stage.config.groupBy || []) as string[]
+            const metrics = (stage.config.metrics || []) as GroupMetric[]
+            const updateMetric = (i: number, patch: Partial<GroupMetric>) => {
+                const next = [...metrics]
+                next[i] = { ...metrics[i], ...patch }
+                updateConfig({ metrics: next })
+            }
+            return (
+                <div className="pl-stage-body">
+                    <div className="pl-chips">
+                        <span className="pl-kw">by</span>
+                        {availableCols.map(c => {
+                            const active = groupBy.includes(c)
+                            return (
+                                <button
+                                    key={c}
+                                    className={`pl-chip ${active ? 'active' : ''}`}
+                                    onClick={() => updateConfig({ groupBy: active ? groupBy.filter(g => g !== c) : [...groupBy, c] })}
+                                >
+                                    {c}
+                                </button>
+                            )
+                        })}
+                    </div>
+                    {metrics.map((m, i) => (
+                        <div key={i} className="pl-inline" style={{ marginTop: 4 }}>
+                            <Select
+                                value={m.agg}
+                                options={GROUP_AGGS}
+                                onChange={v => updateMetric(i, { agg: v as GroupAgg })}
+                            />
+                            {m.agg === 'percentile' && (
+                                <input
+                                    className="pl-input"
+                                    type="number"
+                                    style={{ width: 56, flex: 'none' }}
+                                    value={m.percentile ?? 50}
+                                    onChange={e => updateMetric(i, { percentile: Number(e.target.value) })}
+                                    min={0}
+                                    max={100}
+                                />
+                            )}
+                            <span className="pl-kw">of</span>
+                            <Select
+                                value={m.column || ''}
+                                options={m.agg === 'count' ? [{ value: '', label: 'rows' }, ...colOptions] : colOptions}
+                                placeholder="Column…"
+                                onChange={v => updateMetric(i, { column: v })}
+                            />
+                            <span className="pl-kw">as</span>
+                            <input
+                                className="pl-input"
+                                style={{ flex: 1 }}
+                                value={m.as || ''}
+                                onChange={e => updateMetric(i, { as: e.target.value })}
+                                placeholder={groupMetricName({ ...m, as: '' })}
+                            />
+                            {metrics.length > 1 && (
+                                <button className="pl-stage-btn pl-stage-btn-rm" onClick={() => {
+                                    updateConfig({ metrics: metrics.filter((_, j) => j !== i) })
+                                }}><IconX size={10} /></button>
+                            )}
+                        </div>
+                    ))}
+                    <button className="pl-add-btn" style={{ alignSelf: 'flex-start', marginTop: 4 }} onClick={() => {
+                        updateConfig({ metrics: [...metrics, { column: '', agg: 'sum' }] })
+                    }}>
+                        <IconPlus size={10} /> Add Metric
+                    </button>
+                </div>
+            )
+        }
+
+        case 'lookup':
+            return <LookupStageBody config={stage.config} colOptions={colOptions} updateConfig={updateConfig} />
+
+        case 'sql':
+            return (
+                <textarea
+                    className="pl-input pl-input-full"
+                    value={stage.config.query || ''}
+                    onChange={e => updateConfig({ query: e.target.value })}
+                    placeholder="SELECT region, sum(amount) AS total FROM input GROUP BY region"
+                    title="Rows so far are in the table input; other local databases can be joined by name"
+                    rows={4}
+                    style={{ resize: 'vertical', fontFamily: 'monospace', fontSize: 11 }}
+                />
+            )
+
         case 'sort':
             return (
                 <div className="pl-inline">
@@ -785,6 +941,96 @@ function parseConcatParts(input: string, _cols: string[]): string[] {
     return parts
 }
 
+// ── Lookup Stage ───────────────────────────────────────────
+
+function LookupStageBody({ config, colOptions, updateConfig }: {
+    config: Record<string, any>
+    colOptions: { value: string; label: string }[]
+    updateConfig: (patch: Record<string, any>) => void
+}) {
+    const [databases, setDatabases] = useState<LocalDatabase[]>([])
+
+    useEffect(() => {
+        rpcCall<LocalDatabase[]>('ListLocalDatabases').then(setDatabases).catch(console.error)
+    }, [])
+
+    const db = databases.find(d => d.id === config.databaseId)
+    const dbCols: { value: string; label: string }[] = db
+        ? (JSON.parse(db.configJson || '{}').columns || []).map((c: { name: string }) => ({ value: c.name, label: c.name }))
+        : []
+    const columns = normalizeLookupColumns(config.columns)
+
+    return (
+        <div className="pl-stage-body">
+            <div className="pl-inline">
+                <Select
+                    value={config.joinType || 'left'}
+                    options={[
+                        { value: 'left', label: 'Left join' },
+                        { value: 'inner', label: 'Inner join' },
+                    ]}
+                    onChange={v => updateConfig({ joinType: v })}
+                />
+                <Select
+                    value={config.databaseId || ''}
+                    options={databases.map(d => ({ value: d.id, label: d.name }))}
+                    placeholder="Database…"
+                    onChange={v => updateConfig({ databaseId: v, rightKey: '', columns: [] })}
+                />
+            </div>
+            <div className="pl-inline" style={{ marginTop: 4 }}>
+                <span className="pl-kw">on</span>
+                <Select
+                    value={config.leftKey || ''}
+                    options={colOptions}
+                    placeholder="Column…"
+                    onChange={v => updateConfig({ leftKey: v })}
+                />
+                <span className="pl-kw">=</span>
+                <Select
+                    value={config.rightKey || ''}
+                    options={dbCols}
+                    placeholder="Lookup column…"
+                    onChange={v => updateConfig({ rightKey: v })}
+                />
+            </div>
+            {dbCols.length > 0 && (
+                <div className="pl-chips" style={{ marginTop: 4 }}>
+                    <span className="pl-kw">add</span>
+                    {dbCols.map(c => {
+                        const active = columns.some(lc => lc.column === c.value)
+                        return (
+                            <button
+                                key={c.value}
+                                className={`pl-chip ${active ? 'active' : ''}`}
+                                onClick={() => updateConfig({
+                                    columns: active
+                                        ? columns.filter(lc => lc.column !== c.value)
+                                        : [...columns, { column: c.value }],
+                                })}
+                            >
+                                {c.label}
+                            </button>
+                        )
+                    })}
+                </div>
+            )}
+            {(config.joinType || 'left') === 'left' && (
+                <div className="pl-inline" style={{ marginTop: 4 }}>
+                    <span className="pl-kw">if missing</span>
+                    <input
+                        className="pl-input"
+                        style={{ flex: 1 }}
+                        value={config.defaultValue ?? ''}
+                        onChange={e => updateConfig({ defaultValue: e.target.value === '' ? undefined : e.target.value })}
+                        placeholder="(empty)"
+                    />
+                </div>
+            )}
+        </div>
+    )
+}
+
 // ── Add Transform Menu ─────────────────────────────────────
 
 function AddTransformMenu({ onAdd }: { onAdd: (type: TransformType) => void }) {
@@ -798,7 +1044,7 @@ function AddTransformMenu({ onAdd }: { onAdd: (type: TransformType) => void }) {
         setPos({ top: rect.bottom + 2, left: rect.left })
     }, [open])
 
-    const types: TransformType[] = ['filter', 'select', 'rename', 'compute', 'string', 'date_part', 'type_cast', 'dedupe', 'sort', 'limit', 'flatten', 'default_value', 'math']
+    const types: TransformType[] = ['filter', 'select', 'rename', 'compute', 'string', 'date_part', 'type_cast', 'dedupe', 'sort', 'limit', 'flatten', 'default_value', 'math', 'group', 'lookup', 'sql']
 
     return (
         <div className="pl-add-wrap" ref={triggerRef}>
@@ -1,6 +1,8 @@
 import { useState, useEffect, useMemo } from 'react'
 import { rpcCall } from '../sdk'
-import { ETLPipeline, type TransformStage, getColumnsAtStage } from './ETLPipeline'
+import { evaluateCompute } from '../shared'
+import type { LocalDatabase } from './types'
+import { ETLPipeline, type TransformStage, type GroupMetric, getColumnsAtStage, groupMetricName, normalizeLookupColumns } from './ETLPipeline'
 
 // ── Types ──────────────────────────────────────────────────
 
@@ -17,7 +19,10 @@ interface ETLTransformStepProps {
 
 // ── Client-side Transform Preview ──────────────────────────
 
-function applyTransformsPreview(records: SampleRecord[], transforms: TransformStage[]): SampleRecord[] {
+/** LocalDB rows keyed by column name, per database ID — loaded for lookup stages. */
+type LookupTables = Record<string, Record<string, any>[]>
+
+function applyTransformsPreview(records: SampleRecord[], transforms: TransformStage[], lookupTables: LookupTables = {}): SampleRecord[] {
     let result = records.map(r => ({ data: { ...r.data } }))
 
     for (const t of transforms) {
@@ -68,12 +73,11 @@ function applyTransformsPreview(records: SampleRecord[], transforms: TransformSt
                     const d = { ...r.data }
                     for (const c of cols) {
                         if (!c.name || !c.expression) continue
-                        let expr = c.expression
-                        for (const [k, v] of Object.entries(d)) {
-                            expr = expr.replaceAll(`{${k}}`, String(v ?? ''))
+                        try {
+                            d[c.name] = evaluateCompute(d, c.expression)
+                        } catch {
+                            d[c.name] = null
                         }
-                        const num = Number(expr)
-                        d[c.name] = isNaN(num) ? expr : num
                     }
                     return { data: d }
                 })
@@ -296,12 +300,94 @@ function applyTransformsPreview(records: SampleRecord[], transforms: TransformSt
                 })
                 break
             }
+            case 'lookup': {
+                const { databaseId, leftKey, rightKey, joinType, defaultValue } = t.config
+                const table = lookupTables[databaseId]
+                if (!table || !leftKey || !rightKey) break
+                const columns = normalizeLookupColumns(t.config.columns)
+                const index = new Map<string, Record<string, any>>()
+                for (const row of table) {
+                    const k = row[rightKey]
+                    if (k != null && !index.has(String(k))) index.set(String(k), row)
+                }
+                result = result.flatMap(r => {
+                    const k = r.data[leftKey]
+                    const match = k != null ? index.get(String(k)) : undefined
+                    if (!match && joinType === 'inner') return []
+                    const d = { ...r.data }
+                    for (const c of columns) d[c.as || c.column] = match ? match[c.column] ?? null : defaultValue ?? null
+                    return [{ data: d }]
+                })
+                break
+            }
+            case 'group': {
+                const groupBy = (t.config.groupBy || []) as string[]
+                const metrics = (t.config.metrics || []) as GroupMetric[]
+                if (groupBy.length === 0 && metrics.length === 0) break
+                result = groupPreview(result, groupBy, metrics)
+                break
+            }
         }
     }
 
     return result
 }
 
+/** applyTransformsPreview, with sql stages run on the backend over the rows so far. */
+async function previewTransforms(records: SampleRecord[], transforms: TransformStage[], lookupTables: LookupTables): Promise<SampleRecord[]> {
+    let result = records
+    let start = 0
+    for (let i = 0; i < transforms.length; i++) {
+        if (transforms[i].type !== 'sql') continue
+        result = applyTransformsPreview(result, transforms.slice(start, i), lookupTables)
+        start = i + 1
+        const query = (transforms[i].config.query || '').trim()
+        if (!query) continue
+        const res = await rpcCall<{ records?: SampleRecord[] }>('QueryETLSQL', query, result.map(r => r.data))
+        result = res?.records || []
+    }
+    return applyTransformsPreview(result, transforms.slice(start), lookupTables)
+}
+
+/** Preview of etl.GroupTransform over the sample rows. */
+function groupPreview(records: SampleRecord[], groupBy: string[], metrics: GroupMetric[]): SampleRecord[] {
+    const groups = new Map<string, SampleRecord[]>()
+    for (const r of records) {
+        const key = JSON.stringify(groupBy.map(k => r.data[k] ?? null))
+        if (!groups.has(key)) groups.set(key, [])
+        groups.get(key)!.push(r)
+    }
+    if (groups.size === 0 && groupBy.length === 0) groups.set('[]', [])
+
+    return Array.from(groups.values()).map(rows => {
+        const d: Record<string, any> = {}
+        for (const k of groupBy) d[k] = rows[0]?.data[k] ?? null
+        for (const m of metrics) {
+            const present = m.column ? rows.map(r => r.data[m.column]).filter(v => v != null) : []
+            const nums = present.map(Number).filter(n => !isNaN(n)).sort((a, b) => a - b)
+            const pct = (p: number) => {
+                if (nums.length === 0) return null
+                const rank = p / 100 * (nums.length - 1)
+                const lo = Math.floor(rank), hi = Math.ceil(rank)
+                return nums[lo] + (nums[hi] - nums[lo]) * (rank - lo)
+            }
+            let v: any = null
+            switch (m.agg) {
+                case 'count': v = m.column ? present.length : rows.length; break
+                case 'count_distinct': v = new Set(present.map(String)).size; break
+                case 'sum': v = nums.reduce((a, b) => a + b, 0); break
+                case 'avg': v = nums.length ? nums.reduce((a, b) => a + b, 0) / nums.length : null; break
+                case 'min': v = nums.length ? nums[0] : null; break
+                case 'max': v = nums.length ? nums[nums.length - 1] : null; break
+                case 'median': v = pct(50); break
+                case 'percentile': v = pct(m.percentile ?? 50); break
+            }
+            d[groupMetricName(m)] = v
+        }
+        return { data: d }
+    })
+}
+
 function extractJsonPath(obj: Record<string, any>, path: string): any {
     const parts = path.split('.')
     let current: any = obj
@@ -349,19 +435,70 @@ export function ETLTransformStep({ sourceType, sourceConfig, transforms, onChang
             .finally(() => setLoading(false))
     }, [sourceType, JSON.stringify(sourceConfig)])
 
-    // Apply transforms to sample data (client-side preview)
-    const outputRecords = useMemo(
-        () => applyTransformsPreview(sampleRecords, transforms),
-        [sampleRecords, transforms]
-    )
+    // Load the LocalDB tables referenced by lookup stages
+    const [lookupTables, setLookupTables] = useState<LookupTables>({})
+    const lookupIds = transforms
+        .filter(t => t.type === 'lookup' && t.config.databaseId)
+        .map(t => t.config.databaseId as string)
+        .sort()
+        .join(',')
+    useEffect(() => {
+        if (!lookupIds) return
+        let cancelled = false
+        rpcCall<LocalDatabase[]>('ListLocalDatabases').then(async dbs => {
+            const tables: LookupTables = {}
+            for (const id of lookupIds.split(',')) {
+                const db = dbs.find(d => d.id === id)
+                if (!db) continue
+                const names: Record<string, string> = {}
+                for (const c of JSON.parse(db.configJson || '{}').columns || []) names[c.id] = c.name
+                const rows = await rpcCall<{ dataJson: string }[]>('ListLocalDBRows', id)
+                tables[id] = rows.map(r => {
+                    const byName: Record<string, any> = {}
+                    for (const [colId, v] of Object.entries(JSON.parse(r.dataJson || '{}'))) {
+                        if (names[colId]) byName[names[colId]] = v
+                    }
+                    return byName
+                })
+            }
+            if (!cancelled) setLookupTables(tables)
+        }).catch(console.error)
+        return () => { cancelled = true }
+    }, [lookupIds])
 
-    // Output columns
-    const outputColumns = useMemo(
-        () => transforms.length > 0
+    // Apply transforms to sample data (client-side preview; sql stages query the backend)
+    const hasSQL = transforms.some(t => t.type === 'sql')
+    const [outputRecords, setOutputRecords] = useState<SampleRecord[]>([])
+    const [previewError, setPreviewError] = useState('')
+    useEffect(() => {
+        let cancelled = false
+        previewTransforms(sampleRecords, transforms, lookupTables)
+            .then(records => {
+                if (cancelled) return
+                setOutputRecords(records)
+                setPreviewError('')
+            })
+            .catch(err => {
+                if (cancelled) return
+                setOutputRecords([])
+                setPreviewError(typeof err === 'string' ? err : (err?.message || 'Preview failed'))
+            })
+        return () => { cancelled = true }
+    }, [sampleRecords, transforms, lookupTables])
+
+    // Output columns; after a sql stage they come from the query result
+    const outputColumns = useMemo(() => {
+        if (hasSQL) {
+            const cols: string[] = []
+            for (const r of outputRecords) {
+                for (const k of Object.keys(r.data)) if (!cols.includes(k)) cols.push(k)
+            }
+            return cols
+        }
+        return transforms.length > 0
             ? getColumnsAtStage(sourceColumns, transforms, transforms.length - 1)
-            : sourceColumns,
-        [sourceColumns, transforms]
-    )
+            : sourceColumns
+    }, [sourceColumns, transforms, hasSQL, outputRecords])
 
     return (
         <div className="etl-transform-step">
@@ -399,7 +536,9 @@ export function ETLTransformStep({ sourceType, sourceConfig, transforms, onChang
                             {outputRecords.length}/{sampleRecords.length} rows · {outputColumns.length} cols
                         </span>
                     </div>
-                    {outputRecords.length === 0 ? (
+                    {previewError ? (
+                        <div className="etl-sample-empty" style={{ color: 'var(--color-danger, #ef4444)' }}>{previewError}</div>
+                    ) : outputRecords.length === 0 ? (
                         <div className="etl-sample-empty">All rows filtered out</div>
                     ) : (
                         <SampleTable columns={outputColumns} records={outputRecords} highlight />
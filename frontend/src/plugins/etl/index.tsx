@@ -26,6 +26,15 @@ export interface SourceSpec {
     label: string
     icon: string
     configFields: ConfigField[]
+    stateful?: boolean
+}
+
+export interface DestinationSpec {
+    type: string
+    label: string
+    icon: string
+    configFields: ConfigField[]
+    modes: string[]
 }
 
 export interface ConfigField {
@@ -46,18 +55,30 @@ export interface SyncJob {
     sourceConfig: Record<string, any>
     transforms: TransformConfig[]
     targetDbId: string
+    destType?: string // '' = LocalDB (targetDbId)
+    destConfig?: Record<string, any>
     syncMode: string
     dedupeKey: string
     triggerType: string
     triggerConfig: string
     enabled: boolean
+    retry?: RetryPolicy
+    schemaPolicy?: string // '' = ignore
     lastRunAt: string
     lastStatus: string
     lastError: string
+    consecutiveFailures?: number
     createdAt: string
     updatedAt: string
 }
 
+export interface RetryPolicy {
+    maxAttempts?: number
+    backoffSeconds?: number
+    maxBackoffSeconds?: number
+    disableAfter?: number
+}
+
 export interface TransformConfig {
     type: string
     config: Record<string, any>
@@ -72,6 +93,15 @@ export interface SyncResult {
     error?: string
 }
 
+export interface SyncProgress {
+    jobId: string
+    stage: string
+    batch: number
+    rowsRead: number
+    rowsWritten: number
+    elapsed: number // nanoseconds
+}
+
 export interface SyncRunLog {
     id: string
     jobId: string
@@ -81,6 +111,22 @@ export interface SyncRunLog {
     rowsRead: number
     rowsWritten: number
     error?: string
+    attempt?: number
+    output?: string
+    schemaDrift?: SchemaChange[]
+}
+
+export interface SchemaChange {
+    field: string
+    change: string // 'added' | 'removed' | 'type_changed'
+    oldType?: string
+    newType?: string
+}
+
+function describeSchemaChange(c: SchemaChange): string {
+    if (c.change === 'added') return `${c.field} added (${c.newType})`
+    if (c.change === 'removed') return `${c.field} removed (was ${c.oldType})`
+    return `${c.field} changed from ${c.oldType} to ${c.newType}`
 }
 
 // ── Block Renderer ─────────────────────────────────────────
@@ -91,12 +137,14 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
 
     const [job, setJob] = useState<SyncJob | null>(null)
     const [sources, setSources] = useState<SourceSpec[]>([])
+    const [destinations, setDestinations] = useState<DestinationSpec[]>([])
     const [databases, setDatabases] = useState<LocalDatabase[]>([])
     const [logs, setLogs] = useState<SyncRunLog[]>([])
     const [showEditor, setShowEditor] = useState(false)
     const [showHistory, setShowHistory] = useState(false)
     const [running, setRunning] = useState(false)
     const [lastResult, setLastResult] = useState<SyncResult | null>(null)
+    const [progress, setProgress] = useState<SyncProgress | null>(null)
     const [editingTitle, setEditingTitle] = useState(false)
     const [titleValue, setTitleValue] = useState('')
 
@@ -107,6 +155,7 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
     // Load data on mount.
     useEffect(() => {
         rpc.call<SourceSpec[]>('ListETLSources').then(setSources).catch(console.error)
+        rpc.call<DestinationSpec[]>('ListETLDestinations').then(setDestinations).catch(console.error)
         rpc.call<LocalDatabase[]>('ListLocalDatabases').then(setDatabases).catch(console.error)
         if (config.jobId) {
             rpc.call<SyncJob>('GetETLJob', config.jobId).then(setJob).catch(() => setJob(null))
@@ -114,6 +163,34 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
         }
     }, [config.jobId, rpc])
 
+    // Live progress of a run of this job, manual or scheduled. Reports stop
+    // when the run ends, so a quiet few seconds clears the indicator.
+    useEffect(() => {
+        if (!config.jobId) return
+        let stale: ReturnType<typeof setTimeout> | undefined
+        const unsub = ctx!.events.onBackend('etl:progress', (payload: any) => {
+            if (payload?.jobId !== config.jobId) return
+            setProgress(payload)
+            clearTimeout(stale)
+            stale = setTimeout(() => setProgress(null), 3000)
+        })
+        return () => { clearTimeout(stale); unsub() }
+    }, [config.jobId, ctx])
+
+    // A scheduled run of this job failed after its retries.
+    useEffect(() => {
+        if (!config.jobId) return
+        return ctx!.events.onBackend('etl:job-failed', (payload: any) => {
+            if (payload?.jobId !== config.jobId) return
+            rpc.call<SyncJob>('GetETLJob', config.jobId).then(setJob).catch(() => { })
+            rpc.call<SyncRunLog[]>('ListETLRunLogs', config.jobId).then(setLogs).catch(() => { })
+            const attempts = payload.attempts > 1 ? ` after ${payload.attempts} attempts` : ''
+            ctx!.ui.toast(payload.disabled
+                ? `${payload.jobName} disabled after ${payload.consecutiveFailures} consecutive failures`
+                : `${payload.jobName} failed${attempts}: ${payload.error}`, 'error')
+        })
+    }, [config.jobId, rpc, ctx])
+
     const handleSave = useCallback((savedJob: SyncJob) => {
         setJob(savedJob)
         setShowEditor(false)
@@ -124,6 +201,7 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
         if (!job) return
         setRunning(true)
         setLastResult(null)
+        setProgress(null)
         try {
             const result = await rpc.call<SyncResult>('RunETLJob', job.id)
             setLastResult(result)
@@ -139,9 +217,29 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
             // Notify other plugins
             ctx!.events.emit('etl:job-completed', { jobId: job.id, status: result.status })
         } catch (err: any) {
-            setLastResult({ jobId: job.id, status: 'error', rowsRead: 0, rowsWritten: 0, duration: 0, error: err.message })
+            const updated = await rpc.call<SyncJob>('GetETLJob', job.id).catch(() => null)
+            if (updated) setJob(updated)
+            rpc.call<SyncRunLog[]>('ListETLRunLogs', job.id).then(setLogs).catch(() => { })
+            const status = updated?.lastStatus === 'cancelled' ? 'cancelled' : 'error'
+            setLastResult({ jobId: job.id, status, rowsRead: 0, rowsWritten: 0, duration: 0, error: err.message })
         } finally {
             setRunning(false)
+            setProgress(null)
+        }
+    }, [job, rpc, ctx])
+
+    const handleCancel = useCallback(() => {
+        if (!job) return
+        rpc.call('CancelETLJob', job.id).catch((err: any) => ctx!.ui.toast(err?.message || 'Failed to cancel', 'error'))
+    }, [job, rpc, ctx])
+
+    const handleEnable = useCallback(async () => {
+        if (!job) return
+        try {
+            await rpc.call('SetETLJobEnabled', job.id, true)
+            setJob(await rpc.call<SyncJob>('GetETLJob', job.id))
+        } catch (err: any) {
+            ctx!.ui.toast(err?.message || 'Failed to enable job', 'error')
         }
     }, [job, rpc, ctx])
 
@@ -154,6 +252,7 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
 
     const sourceSpec = sources.find(s => s.type === job?.sourceType)
     const targetDB = databases.find(d => d.id === job?.targetDbId)
+    const targetDest = job?.destType && job.destType !== 'localdb' ? destinations.find(d => d.type === job.destType) : undefined
 
     return (
         <div className="etl-block" onMouseDown={e => e.stopPropagation()}>
@@ -182,12 +281,20 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
                     )}
                 </div>
                 <div className="etl-header-right">
-                    <button
-                        className={`chart-toolbar-btn ${running ? 'active' : ''}`}
-                        onClick={handleRun}
-                        disabled={running || !job}
-                        title="Run sync now"
-                    >{running ? '⟳' : '▶ Run'}</button>
+                    {running ? (
+                        <button
+                            className="chart-toolbar-btn active"
+                            onClick={handleCancel}
+                            title="Cancel this run"
+                        >■ Stop</button>
+                    ) : (
+                        <button
+                            className="chart-toolbar-btn"
+                            onClick={handleRun}
+                            disabled={!job}
+                            title="Run sync now"
+                        >▶ Run</button>
+                    )}
                     <button
                         className={`chart-toolbar-btn ${showHistory ? 'active' : ''}`}
                         onClick={() => { setShowHistory(!showHistory); setShowEditor(false) }}
@@ -204,6 +311,7 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
                 <ETLEditor
                     existingJob={job}
                     sources={sources}
+                    destinations={destinations}
                     databases={databases}
                     pageId={block.pageId}
                     onSave={handleSave}
@@ -219,15 +327,20 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
                     ) : logs.slice(0, 10).map(log => (
                         <div key={log.id} className="etl-history-row">
                             <span className={`etl-history-status ${log.status}`}>
-                                {log.status === 'success' ? '✓' : '✗'}
+                                {log.status === 'success' ? '✓' : log.status === 'cancelled' ? '■' : '✗'}
                             </span>
                             <span className="etl-history-stats">
                                 {log.rowsWritten} rows
+                                {(log.attempt ?? 1) > 1 && ` · attempt ${log.attempt}`}
                             </span>
                             <span className="etl-history-time">
                                 {new Date(log.startedAt).toLocaleString(undefined, { month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit' })}
                             </span>
                             {log.error && <span className="etl-history-error" title={log.error}>error</span>}
+                            {log.output && <span className="etl-history-output" title={log.output}>log</span>}
+                            {log.schemaDrift && log.schemaDrift.length > 0 && (
+                                <span className="etl-history-drift" title={log.schemaDrift.map(describeSchemaChange).join('\n')}>drift</span>
+                            )}
                         </div>
                     ))}
                 </div>
@@ -235,11 +348,18 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
 
             {/* Main content area — compact pipeline visualization */}
             <div className="etl-area">
-                {lastResult && (
+                {progress && (
+                    <div className="etl-toast running">
+                        ⟳ {progress.stage} · {progress.rowsRead} read · {progress.rowsWritten} written · {(progress.elapsed / 1e9).toFixed(0)}s
+                    </div>
+                )}
+                {lastResult && !progress && (
                     <div className={`etl-toast ${lastResult.status}`}>
                         {lastResult.status === 'success'
                             ? `✓ Synced ${lastResult.rowsWritten} rows (${(lastResult.duration / 1e6).toFixed(0)}ms)`
-                            : `✗ ${lastResult.error}`
+                            : lastResult.status === 'cancelled'
+                                ? '■ Run cancelled'
+                                : `✗ ${lastResult.error}`
                         }
                     </div>
                 )}
@@ -291,7 +411,7 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
                                     <line x1="2" y1="9" x2="14" y2="9" stroke="currentColor" strokeWidth="0.8" />
                                     <line x1="7" y1="2" x2="7" y2="14" stroke="currentColor" strokeWidth="0.8" />
                                 </svg>
-                                <span className="etl-flow-text">{targetDB?.name || 'Unknown'}</span>
+                                <span className="etl-flow-text">{targetDest ? targetDest.label : targetDB?.name || 'Unknown'}</span>
                             </div>
                         </div>
 
@@ -300,12 +420,19 @@ function ETLBlockRenderer({ block, ctx }: PluginRendererProps) {
                             <div className="etl-stats">
                                 <span className={`etl-stats-status etl-stat-${job.lastStatus}`}>
                                     {job.lastStatus === 'success' ? '●' : job.lastStatus === 'running' ? '◌' : '●'}
-                                    {' '}{job.lastStatus === 'success' ? 'Synced' : job.lastStatus === 'running' ? 'Running' : 'Error'}
+                                    {' '}{job.lastStatus === 'success' ? 'Synced' : job.lastStatus === 'running' ? 'Running' : job.lastStatus === 'skipped' ? 'Skipped' : job.lastStatus === 'cancelled' ? 'Cancelled' : 'Error'}
                                 </span>
                                 <span className="etl-stats-detail">
                                     {job.syncMode}
                                     {job.transforms && job.transforms.length > 0 && ` · ${job.transforms.length} transform${job.transforms.length > 1 ? 's' : ''}`}
                                 </span>
+                                {!job.enabled && job.triggerType !== 'manual' && (
+                                    <span className="etl-stats-detail" title={job.lastError}>
+                                        disabled{job.consecutiveFailures ? ` after ${job.consecutiveFailures} failures` : ''}
+                                        {' '}
+                                        <button className="chart-toolbar-btn" onClick={handleEnable}>Enable</button>
+                                    </span>
+                                )}
                                 {job.lastRunAt && job.lastRunAt !== '0001-01-01T00:00:00Z' && (
                                     <span className="etl-stats-time">
                                         {new Date(job.lastRunAt).toLocaleString(undefined, { day: 'numeric', month: 'short', hour: '2-digit', minute: '2-digit' })}
@@ -114,6 +114,10 @@ export function RequestEditor({ config, loading, onChange, onSend }: Props) {
         onChange({ ...config, ...partial })
     }, [config, onChange])
 
+    // Saved credentials live in the keychain and are never loaded back into the editor.
+    const secretPlaceholder = (label: string) =>
+        config.auth.secretRef ? `${label} (saved — type to replace)` : label
+
     const handleKeyDown = useCallback((e: React.KeyboardEvent) => {
         if (e.key === 'Enter' && (e.metaKey || e.ctrlKey)) {
             e.preventDefault()
@@ -213,12 +217,24 @@ export function RequestEditor({ config, loading, onChange, onSend }: Props) {
                                     onChange={() => updateConfig({ auth: { type: 'basic', username: config.auth.username || '', password: config.auth.password || '' } })} />
                                 Basic Auth
                             </label>
+                            <label className="http-auth-option">
+                                <input type="radio" name="auth" value="api_key"
+                                    checked={config.auth.type === 'api_key'}
+                                    onChange={() => updateConfig({ auth: { type: 'api_key', keyName: config.auth.keyName || 'X-Api-Key', keyIn: config.auth.keyIn || 'header' } })} />
+                                API Key
+                            </label>
+                            <label className="http-auth-option">
+                                <input type="radio" name="auth" value="oauth2"
+                                    checked={config.auth.type === 'oauth2'}
+                                    onChange={() => updateConfig({ auth: { type: 'oauth2', tokenUrl: config.auth.tokenUrl || '', clientId: config.auth.clientId || '' } })} />
+                                OAuth2
+                            </label>
                         </div>
                         {config.auth.type === 'bearer' && (
                             <input
                                 className="http-auth-input"
-                                type="text"
-                                placeholder="Token"
+                                type="password"
+                                placeholder={secretPlaceholder('Token')}
                                 value={config.auth.token || ''}
                                 onChange={e => updateConfig({ auth: { ...config.auth, token: e.target.value } })}
                             />
@@ -235,12 +251,70 @@ export function RequestEditor({ config, loading, onChange, onSend }: Props) {
                                 <input
                                     className="http-auth-input"
                                     type="password"
-                                    placeholder="Password"
+                                    placeholder={secretPlaceholder('Password')}
                                     value={config.auth.password || ''}
                                     onChange={e => updateConfig({ auth: { ...config.auth, password: e.target.value } })}
                                 />
                             </div>
                         )}
+                        {config.auth.type === 'api_key' && (
+                            <div className="http-auth-basic">
+                                <input
+                                    className="http-auth-input"
+                                    type="text"
+                                    placeholder="Key name"
+                                    value={config.auth.keyName || ''}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, keyName: e.target.value } })}
+                                />
+                                <select
+                                    className="http-auth-input"
+                                    value={config.auth.keyIn || 'header'}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, keyIn: e.target.value as 'header' | 'query' } })}
+                                >
+                                    <option value="header">Header</option>
+                                    <option value="query">Query param</option>
+                                </select>
+                                <input
+                                    className="http-auth-input"
+                                    type="password"
+                                    placeholder={secretPlaceholder('API key')}
+                                    value={config.auth.apiKey || ''}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, apiKey: e.target.value } })}
+                                />
+                            </div>
+                        )}
+                        {config.auth.type === 'oauth2' && (
+                            <div className="http-auth-basic">
+                                <input
+                                    className="http-auth-input"
+                                    type="text"
+                                    placeholder="Token URL"
+                                    value={config.auth.tokenUrl || ''}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, tokenUrl: e.target.value } })}
+                                />
+                                <input
+                                    className="http-auth-input"
+                                    type="text"
+                                    placeholder="Client ID"
+                                    value={config.auth.clientId || ''}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, clientId: e.target.value } })}
+                                />
+                                <input
+                                    className="http-auth-input"
+                                    type="password"
+                                    placeholder={secretPlaceholder('Client secret')}
+                                    value={config.auth.clientSecret || ''}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, clientSecret: e.target.value } })}
+                                />
+                                <input
+                                    className="http-auth-input"
+                                    type="text"
+                                    placeholder="Scopes (space-separated)"
+                                    value={config.auth.scopes || ''}
+                                    onChange={e => updateConfig({ auth: { ...config.auth, scopes: e.target.value } })}
+                                />
+                            </div>
+                        )}
                     </div>
                 )}
 
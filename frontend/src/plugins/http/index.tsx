@@ -14,12 +14,29 @@ export interface KeyValuePair {
     description?: string
 }
 
+// Credentials (token, password, apiKey, clientSecret) are sent to the backend,
+// which moves them to the system keychain and keeps only secretRef.
+export interface HTTPBlockAuth {
+    type: 'none' | 'bearer' | 'basic' | 'api_key' | 'oauth2'
+    token?: string
+    username?: string
+    password?: string
+    keyName?: string
+    keyIn?: 'header' | 'query'
+    apiKey?: string
+    tokenUrl?: string
+    clientId?: string
+    clientSecret?: string
+    scopes?: string
+    secretRef?: string
+}
+
 export interface HTTPBlockConfig {
     method: string
     url: string
     params: KeyValuePair[]
     headers: KeyValuePair[]
-    auth: { type: 'none' | 'bearer' | 'basic'; token?: string; username?: string; password?: string }
+    auth: HTTPBlockAuth
     body: { mode: 'none' | 'json' | 'raw'; content: string }
 }
 
@@ -91,6 +108,12 @@ function serializeContent(config: HTTPBlockConfig, response: HTTPResponseData |
     return JSON.stringify({ ...config, lastResponse: response })
 }
 
+// Block content without plaintext credentials — the backend keeps those in the keychain.
+function redactContent(config: HTTPBlockConfig, response: HTTPResponseData | null): string {
+    const { token, password, apiKey, clientSecret, ...auth } = config.auth
+    return serializeContent({ ...config, auth }, response)
+}
+
 // ── Main Renderer ──────────────────────────────────────────
 
 function HTTPRenderer({ block, isSelected, ctx }: PluginRendererProps) {
@@ -126,9 +149,8 @@ function HTTPRenderer({ block, isSelected, ctx }: PluginRendererProps) {
         configRef.current = newConfig
         if (saveTimerRef.current) clearTimeout(saveTimerRef.current)
         saveTimerRef.current = setTimeout(() => {
-            const json = serializeContent(newConfig, responseRef.current)
-            ctx?.rpc.call('SaveBlockHTTPConfig', block.id, json)
-            ctx?.storage.setContent(json)
+            ctx?.rpc.call('SaveBlockHTTPConfig', block.id, serializeContent(newConfig, responseRef.current))
+            ctx?.storage.setContent(redactContent(newConfig, responseRef.current))
         }, 500)
     }, [block.id, ctx])
 
@@ -149,11 +171,6 @@ function HTTPRenderer({ block, isSelected, ctx }: PluginRendererProps) {
             for (const h of c.headers) {
                 if (h.enabled && h.key) headersObj[h.key] = h.value
             }
-            if (c.auth.type === 'bearer' && c.auth.token) {
-                headersObj['Authorization'] = `Bearer ${c.auth.token}`
-            } else if (c.auth.type === 'basic' && c.auth.username) {
-                headersObj['Authorization'] = `Basic ${btoa(`${c.auth.username}:${c.auth.password || ''}`)}`
-            }
 
             const finalURL = buildURL(c.url, c.params)
 
@@ -162,13 +179,14 @@ function HTTPRenderer({ block, isSelected, ctx }: PluginRendererProps) {
                 url: finalURL,
                 headers: headersObj,
                 body: c.body.mode !== 'none' ? c.body.content : '',
+                auth: c.auth,
             }))
             setResponse(result)
             responseRef.current = result
 
             // Persist config + response together
-            const json = serializeContent(c, result)
-            await ctx!.rpc.call('SaveBlockHTTPConfig', block.id, json)
+            await ctx!.rpc.call('SaveBlockHTTPConfig', block.id, serializeContent(c, result))
+            const json = redactContent(c, result)
             ctx!.storage.setContent(json)
             lastContentRef.current = json
 
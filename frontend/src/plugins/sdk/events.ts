@@ -39,6 +39,10 @@ export interface WailsEventMap {
     'db:updated': { databaseId: string; jobId: string }
     // Cron/file-watch ETL job completed
     'etl:job-completed': string  // payload is jobId string
+    // ETL run progress, after each batch and about once a second
+    'etl:progress': { jobId: string; stage: string; batch: number; rowsRead: number; rowsWritten: number; elapsed: number }
+    // Scheduled ETL run failed after its last retry
+    'etl:job-failed': { jobId: string; jobName: string; error: string; attempts: number; consecutiveFailures: number; disabled: boolean }
     // Terminal PTY output (base64 encoded)
     'terminal:data': string
     // Meeting recording started
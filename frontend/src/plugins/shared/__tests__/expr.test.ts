@@ -0,0 +1,29 @@
+import { describe, it, expect } from 'vitest'
+import { compileExpression } from '../expr'
+
+const evaluate = (src: string, vars: Record<string, unknown> = {}) => compileExpression(src)(vars)
+
+describe('arithmetic', () => {
+    it('adds numeric text like - * and / do', () => {
+        expect(evaluate('"1" + "2"')).toBe(3)
+        expect(evaluate('"1" + 1')).toBe(2)
+        expect(evaluate('{price} + {qty}', { price: '12.5', qty: 6 })).toBe(18.5)
+        expect(evaluate('{price} * 2', { price: '12.5' })).toBe(25)
+    })
+
+    it('yields null for non-numeric operands', () => {
+        expect(evaluate('{name} + 1', { name: 'Ada' })).toBeNull()
+        expect(evaluate('{empty} + 1', { empty: null })).toBeNull()
+    })
+
+    it('concatenates with &', () => {
+        expect(evaluate('{name} & " " & {last}', { name: 'Ada', last: 'Lovelace' })).toBe('Ada Lovelace')
+    })
+
+    it('yields null for results that are not finite', () => {
+        expect(evaluate('1 / 0')).toBeNull()
+        expect(evaluate('1e308 * 10')).toBeNull()
+        expect(evaluate('1e308 + 1e308')).toBeNull()
+        expect(evaluate('"Infinity" + 1')).toBeNull()
+    })
+})
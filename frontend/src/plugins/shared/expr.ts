@@ -0,0 +1,601 @@
+// ═══════════════════════════════════════════════════════════
+// Compute Expressions — TypeScript port of internal/expr
+// ═══════════════════════════════════════════════════════════
+// Chart compute stages and the ETL transform preview run client-side, so
+// they use this port of the Go evaluator behind ETL compute transforms and
+// LocalDB formula columns. Keep the grammar, null rules and function set in
+// sync with internal/expr.
+
+export type Value = null | number | string | boolean | Date
+
+export class ExprError extends Error {
+    constructor(public pos: number, msg: string) {
+        super(`expression error at position ${pos + 1}: ${msg}`)
+    }
+}
+
+// ── Lexer ──────────────────────────────────────────────────
+
+type TokKind = 'eof' | 'num' | 'str' | 'field' | 'ident' | 'op' | '(' | ')' | ','
+
+interface Token {
+    kind: TokKind
+    text: string
+    num?: number
+    pos: number
+}
+
+const OPS = ['==', '!=', '<>', '<=', '>=', '&&', '||', '+', '-', '*', '/', '%', '&', '=', '<', '>', '!']
+const OP_ALIAS: Record<string, string> = { '==': '=', '<>': '!=', '&&': 'and', '||': 'or', '!': 'not' }
+
+const isDigit = (c: string) => c >= '0' && c <= '9'
+const isIdentStart = (c: string) => c === '_' || /\p{L}/u.test(c)
+
+function lex(src: string): Token[] {
+    const toks: Token[] = []
+    let i = 0
+    while (i < src.length) {
+        const c = src[i]
+        if (c === ' ' || c === '\t' || c === '\n' || c === '\r') {
+            i++
+        } else if (c === '{') {
+            const end = src.indexOf('}', i + 1)
+            if (end < 0) throw new ExprError(i, 'unterminated field reference')
+            const name = src.slice(i + 1, end).trim()
+            if (!name) throw new ExprError(i, 'empty field reference')
+            toks.push({ kind: 'field', text: name, pos: i })
+            i = end + 1
+        } else if (c === '"' || c === "'") {
+            let j = i + 1
+            let s = ''
+            for (; j < src.length && src[j] !== c; j++) {
+                if (src[j] === '\\' && j + 1 < src.length) {
+                    j++
+                    s += src[j] === 'n' ? '\n' : src[j] === 't' ? '\t' : src[j]
+                } else {
+                    s += src[j]
+                }
+            }
+            if (j >= src.length) throw new ExprError(i, 'unterminated string')
+            toks.push({ kind: 'str', text: s, pos: i })
+            i = j + 1
+        } else if (isDigit(c) || (c === '.' && isDigit(src[i + 1] ?? ''))) {
+            const m = /^(\d*\.?\d*)([eE][+-]?\d+)?/.exec(src.slice(i))!
+            const text = m[0]
+            const num = Number(text)
+            if (Number.isNaN(num)) throw new ExprError(i, `invalid number "${text}"`)
+            toks.push({ kind: 'num', text, num, pos: i })
+            i += text.length
+        } else if (isIdentStart(c)) {
+            let j = i
+            while (j < src.length && (isIdentStart(src[j]) || isDigit(src[j]))) j++
+            const word = src.slice(i, j)
+            const lower = word.toLowerCase()
+            if (lower === 'and' || lower === 'or' || lower === 'not') {
+                toks.push({ kind: 'op', text: lower, pos: i })
+            } else {
+                let k = j
+                while (src[k] === ' ' || src[k] === '\t') k++
+                toks.push({ kind: src[k] === '(' ? 'ident' : 'field', text: word, pos: i })
+            }
+            i = j
+        } else if (c === '(' || c === ')' || c === ',') {
+            toks.push({ kind: c, text: c, pos: i })
+            i++
+        } else {
+            const raw = OPS.find(op => src.startsWith(op, i))
+            if (!raw) throw new ExprError(i, `unexpected character "${c}"`)
+            toks.push({ kind: 'op', text: OP_ALIAS[raw] ?? raw, pos: i })
+            i += raw.length
+        }
+    }
+    toks.push({ kind: 'eof', text: '', pos: src.length })
+    return toks
+}
+
+// ── Parser ─────────────────────────────────────────────────
+
+type Vars = Record<string, unknown>
+type Node = (vars: Vars) => Value
+
+const COMPARISONS = ['=', '!=', '<', '<=', '>', '>=']
+
+class Parser {
+    private pos = 0
+    constructor(private toks: Token[]) {}
+
+    peek(): Token { return this.toks[this.pos] }
+    next(): Token {
+        const t = this.toks[this.pos]
+        if (t.kind !== 'eof') this.pos++
+        return t
+    }
+    isOp(...ops: string[]): boolean {
+        const t = this.peek()
+        return t.kind === 'op' && ops.includes(t.text)
+    }
+
+    parseOr(): Node {
+        let left = this.parseAnd()
+        while (this.isOp('or')) {
+            this.next()
+            const l = left, r = this.parseAnd()
+            left = v => truthy(l(v)) || truthy(r(v))
+        }
+        return left
+    }
+
+    parseAnd(): Node {
+        let left = this.parseNot()
+        while (this.isOp('and')) {
+            this.next()
+            const l = left, r = this.parseNot()
+            left = v => truthy(l(v)) && truthy(r(v))
+        }
+        return left
+    }
+
+    parseNot(): Node {
+        if (this.isOp('not')) {
+            this.next()
+            const operand = this.parseNot()
+            return v => !truthy(operand(v))
+        }
+        return this.parseComparison()
+    }
+
+    parseComparison(): Node {
+        const left = this.parseAdditive()
+        if (!this.isOp(...COMPARISONS)) return left
+        const op = this.next().text
+        const right = this.parseAdditive()
+        if (this.isOp(...COMPARISONS)) {
+            throw new ExprError(this.peek().pos, 'comparisons cannot be chained; combine them with and/or')
+        }
+        return v => binary(op, left(v), right(v))
+    }
+
+    parseAdditive(): Node {
+        let left = this.parseMultiplicative()
+        while (this.isOp('+', '-', '&')) {
+            const op = this.next().text
+            const l = left, r = this.parseMultiplicative()
+            left = v => binary(op, l(v), r(v))
+        }
+        return left
+    }
+
+    parseMultiplicative(): Node {
+        let left = this.parseUnary()
+        while (this.isOp('*', '/', '%')) {
+            const op = this.next().text
+            const l = left, r = this.parseUnary()
+            left = v => binary(op, l(v), r(v))
+        }
+        return left
+    }
+
+    parseUnary(): Node {
+        if (this.isOp('-', '+')) {
+            const op = this.next().text
+            const operand = this.parseUnary()
+            if (op === '+') return operand
+            return v => {
+                const n = toNumber(operand(v))
+                return n === null ? null : -n
+            }
+        }
+        return this.parsePrimary()
+    }
+
+    parsePrimary(): Node {
+        const t = this.next()
+        switch (t.kind) {
+            case 'num': { const n = t.num!; return () => n }
+            case 'str': { const s = t.text; return () => s }
+            case 'field': {
+                const lower = t.text.toLowerCase()
+                if (lower === 'true') return () => true
+                if (lower === 'false') return () => false
+                if (lower === 'null') return () => null
+                const name = t.text
+                return v => normalize(v[name])
+            }
+            case 'ident': return this.parseCall(t)
+            case '(': {
+                const inner = this.parseOr()
+                if (this.peek().kind !== ')') throw new ExprError(this.peek().pos, 'expected )')
+                this.next()
+                return inner
+            }
+            case 'eof': throw new ExprError(t.pos, 'unexpected end of expression')
+            default: throw new ExprError(t.pos, `unexpected "${t.text}"`)
+        }
+    }
+
+    parseCall(name: Token): Node {
+        const fn = FUNCTIONS[name.text.toLowerCase()]
+        if (!fn) throw new ExprError(name.pos, `unknown function "${name.text}"`)
+        this.next() // (
+
+        const args: Node[] = []
+        if (this.peek().kind !== ')') {
+            for (;;) {
+                args.push(this.parseOr())
+                if (this.peek().kind !== ',') break
+                this.next()
+            }
+        }
+        if (this.peek().kind !== ')') {
+            throw new ExprError(this.peek().pos, `expected , or ) in call to ${name.text}`)
+        }
+        this.next()
+
+        const [min, max] = fn.arity
+        if (args.length < min || (max >= 0 && args.length > max)) {
+            const want = max < 0 ? `at least ${min} argument(s)` : min === max ? `${min} argument(s)` : `${min} to ${max} arguments`
+            throw new ExprError(name.pos, `${name.text} expects ${want}, got ${args.length}`)
+        }
+        if (fn.lazy) {
+            const lazy = fn.lazy
+            return v => lazy(args, v)
+        }
+        const call = fn.call!
+        return v => call(args.map(a => a(v)))
+    }
+}
+
+// Compiled programs are cached by source; chart stages re-run on every render.
+const cache = new Map<string, Node>()
+
+/** Compiles an expression, throwing ExprError on syntax errors. */
+export function compileExpression(src: string): (vars: Vars) => Value {
+    let node = cache.get(src)
+    if (node) return node
+    if (!src.trim()) throw new ExprError(0, 'empty expression')
+    const p = new Parser(lex(src))
+    node = p.parseOr()
+    const t = p.peek()
+    if (t.kind !== 'eof') throw new ExprError(t.pos, `unexpected "${t.text}"`)
+    if (cache.size > 500) cache.clear()
+    cache.set(src, node)
+    return node
+}
+
+/** Returns the syntax error message for src, or null if it compiles. */
+export function validateExpression(src: string): string | null {
+    try {
+        compileExpression(src)
+        return null
+    } catch (e) {
+        return e instanceof Error ? e.message : String(e)
+    }
+}
+
+// ── Compute Columns ────────────────────────────────────────
+// Plain templates like "{first} {last}" that are not valid expressions still
+// substitute field values, matching etl.ComputeTransform.
+
+/** Evaluates a compute column expression against a row. Throws ExprError. */
+export function evaluateCompute(row: Vars, expr: string): unknown {
+    if (validateExpression(expr) !== null && isTemplate(expr)) {
+        return expr.replace(/\{([^}]+)\}/g, (_match, name: string) => String(row[name] ?? ''))
+    }
+    const value = compileExpression(expr)(row)
+    return value instanceof Date ? toText(value) : value
+}
+
+/** Returns why a compute expression is invalid, or null if it can run. */
+export function computeExpressionError(expr: string): string | null {
+    if (!expr.trim()) return null
+    const err = validateExpression(expr)
+    return err !== null && !isTemplate(expr) ? err : null
+}
+
+function isTemplate(expr: string): boolean {
+    if (!expr.includes('{')) return false
+    return !/[+\-*/%()<>=!&|"']/.test(expr.replace(/\{[^}]*\}/g, ''))
+}
+
+// ── Values ─────────────────────────────────────────────────
+// Null rules (same as Go):
+//   - arithmetic with a null or non-numeric operand, or a non-finite result, yields null
+//   - & and text functions treat null as ""
+//   - = is true only when both sides are null; ordering against null is false
+//   - and/or/not/if treat null as false
+
+function normalize(v: unknown): Value {
+    if (v === undefined || v === null) return null
+    if (typeof v === 'number') return Number.isFinite(v) ? v : null
+    if (typeof v === 'string' || typeof v === 'boolean' || v instanceof Date) return v
+    return JSON.stringify(v)
+}
+
+function toNumber(v: Value): number | null {
+    if (typeof v === 'number') return v
+    if (typeof v === 'boolean') return v ? 1 : 0
+    if (typeof v === 'string' && v.trim() !== '') {
+        const n = Number(v.trim())
+        return Number.isFinite(n) ? n : null
+    }
+    return null
+}
+
+const pad = (n: number, w = 2) => String(n).padStart(w, '0')
+
+export function toText(v: Value): string {
+    if (v === null) return ''
+    if (v instanceof Date) {
+        const date = `${v.getUTCFullYear()}-${pad(v.getUTCMonth() + 1)}-${pad(v.getUTCDate())}`
+        if (v.getUTCHours() === 0 && v.getUTCMinutes() === 0 && v.getUTCSeconds() === 0 && v.getUTCMilliseconds() === 0) return date
+        return v.toISOString().replace(/\.\d{3}Z$/, 'Z')
+    }
+    return String(v)
+}
+
+function truthy(v: Value): boolean {
+    if (v === null) return false
+    if (typeof v === 'boolean') return v
+    if (typeof v === 'number') return v !== 0
+    if (typeof v === 'string') return v !== '' && v.toLowerCase() !== 'false'
+    return true
+}
+
+function compare(l: Value, r: Value): number {
+    if (typeof l === 'boolean' && typeof r === 'boolean') return l === r ? 0 : l ? 1 : -1
+    if (typeof l === 'number' || typeof r === 'number') {
+        const a = toNumber(l), b = toNumber(r)
+        if (a !== null && b !== null) return a < b ? -1 : a > b ? 1 : 0
+    }
+    if (l instanceof Date || r instanceof Date) {
+        const a = toDate(l), b = toDate(r)
+        if (a && b) return Math.sign(a.getTime() - b.getTime())
+    }
+    const a = toText(l), b = toText(r)
+    return a < b ? -1 : a > b ? 1 : 0
+}
+
+function binary(op: string, l: Value, r: Value): Value {
+    switch (op) {
+        case '&': return toText(l) + toText(r)
+        case '+': case '-': case '*': case '/': case '%': {
+            const a = toNumber(l), b = toNumber(r)
+            if (a === null || b === null) return null
+            if (op === '+') return finite(a + b)
+            if (op === '-') return finite(a - b)
+            if (op === '*') return finite(a * b)
+            if (b === 0) return null
+            return finite(op === '/' ? a / b : a % b)
+        }
+        case '=': return l === null || r === null ? l === null && r === null : compare(l, r) === 0
+        case '!=': return l === null || r === null ? !(l === null && r === null) : compare(l, r) !== 0
+        default: {
+            if (l === null || r === null) return false
+            const c = compare(l, r)
+            return op === '<' ? c < 0 : op === '<=' ? c <= 0 : op === '>' ? c > 0 : c >= 0
+        }
+    }
+}
+
+// ── Dates ──────────────────────────────────────────────────
+// Dates are handled in UTC, matching Go's time.Parse of zone-less layouts.
+
+function toDate(v: Value): Date | null {
+    if (v instanceof Date) return v
+    if (typeof v !== 'string') return null
+    const s = v.trim()
+    let m = /^(\d{4})-(\d{2})-(\d{2})(?:[T ](\d{2}):(\d{2}):(\d{2})(\.\d+)?(Z|[+-]\d{2}:\d{2})?)?$/.exec(s)
+    if (m) {
+        if (m[8]) {
+            const d = new Date(s.replace(' ', 'T'))
+            return Number.isNaN(d.getTime()) ? null : d
+        }
+        const ms = m[7] ? Math.round(Number(m[7]) * 1000) : 0
+        const d = new Date(Date.UTC(+m[1], +m[2] - 1, +m[3], +(m[4] ?? 0), +(m[5] ?? 0), +(m[6] ?? 0), ms))
+        return d.getUTCDate() === +m[3] ? d : null
+    }
+    m = /^(\d{2})\/(\d{2})\/(\d{4})(?: (\d{2}):(\d{2}):(\d{2}))?$/.exec(s)
+    if (m) {
+        // DD/MM/YYYY first, then MM/DD/YYYY, like the Go layouts.
+        let [day, month] = [+m[1], +m[2]]
+        if (month > 12 && !m[4]) [day, month] = [month, day]
+        const d = new Date(Date.UTC(+m[3], month - 1, day, +(m[4] ?? 0), +(m[5] ?? 0), +(m[6] ?? 0)))
+        return d.getUTCMonth() === month - 1 ? d : null
+    }
+    return null
+}
+
+function addMonths(d: Date, n: number): Date {
+    const r = new Date(d.getTime())
+    r.setUTCMonth(r.getUTCMonth() + n)
+    return r
+}
+
+function monthsBetween(a: Date, b: Date): number {
+    let sign = 1
+    if (b < a) { [a, b] = [b, a]; sign = -1 }
+    let months = (b.getUTCFullYear() - a.getUTCFullYear()) * 12 + b.getUTCMonth() - a.getUTCMonth()
+    if (addMonths(a, months) > b) months--
+    return sign * months
+}
+
+const UNIT_MS: Record<string, number> = {
+    second: 1000, minute: 60_000, hour: 3_600_000, day: 86_400_000, week: 604_800_000,
+}
+
+function unit(v: Value): string {
+    return toText(v).toLowerCase().replace(/s$/, '')
+}
+
+function formatDate(d: Date, layout: string): string {
+    return layout.replace(/YYYY|MM|DD|HH|mm|ss/g, tok => {
+        switch (tok) {
+            case 'YYYY': return pad(d.getUTCFullYear(), 4)
+            case 'MM': return pad(d.getUTCMonth() + 1)
+            case 'DD': return pad(d.getUTCDate())
+            case 'HH': return pad(d.getUTCHours())
+            case 'mm': return pad(d.getUTCMinutes())
+            default: return pad(d.getUTCSeconds())
+        }
+    })
+}
+
+// ── Functions ──────────────────────────────────────────────
+
+interface Fn {
+    arity: [number, number] // max < 0 = variadic
+    call?: (args: Value[]) => Value
+    lazy?: (args: Node[], vars: Vars) => Value
+}
+
+const finite = (n: number): Value => (Number.isFinite(n) ? n : null)
+const runes = (s: string) => Array.from(s)
+
+const textFn = (f: (s: string) => string): Fn => ({ arity: [1, 1], call: ([v]) => (v === null ? null : f(toText(v))) })
+const mathFn = (f: (n: number) => number): Fn => ({
+    arity: [1, 1],
+    call: ([v]) => { const n = toNumber(v); return n === null ? null : finite(f(n)) },
+})
+const dateFn = (f: (d: Date) => number): Fn => ({
+    arity: [1, 1],
+    call: ([v]) => { const d = toDate(v); return d ? f(d) : null },
+})
+const predicate = (f: (s: string, sub: string) => boolean): Fn => ({ arity: [2, 2], call: ([a, b]) => f(toText(a), toText(b)) })
+const int = (v: Value): number | null => { const n = toNumber(v); return n === null ? null : Math.trunc(n) }
+const clamp = (n: number, lo: number, hi: number) => Math.min(Math.max(n, lo), hi)
+
+function extreme(args: Value[], dir: number): Value {
+    let best: Value = null
+    for (const v of args) {
+        if (v === null) continue
+        if (best === null || compare(v, best) * dir > 0) best = v
+    }
+    return best
+}
+
+const FUNCTIONS: Record<string, Fn> = {
+    // Logic
+    if: {
+        arity: [2, 3],
+        lazy: (a, v) => (truthy(a[0](v)) ? a[1](v) : a.length === 3 ? a[2](v) : null),
+    },
+    coalesce: {
+        arity: [1, -1],
+        lazy: (a, v) => {
+            for (const arg of a) {
+                const x = arg(v)
+                if (x !== null && x !== '') return x
+            }
+            return null
+        },
+    },
+    isnull: { arity: [1, 1], call: ([v]) => v === null || v === '' },
+
+    // Text
+    upper: textFn(s => s.toUpperCase()),
+    lower: textFn(s => s.toLowerCase()),
+    trim: textFn(s => s.trim()),
+    len: { arity: [1, 1], call: ([v]) => runes(toText(v)).length },
+    length: { arity: [1, 1], call: ([v]) => runes(toText(v)).length },
+    text: { arity: [1, 1], call: ([v]) => toText(v) },
+    concat: { arity: [1, -1], call: a => a.map(toText).join('') },
+    left: {
+        arity: [2, 2],
+        call: ([s, n]) => { const k = int(n); const r = runes(toText(s)); return k === null ? null : r.slice(0, clamp(k, 0, r.length)).join('') },
+    },
+    right: {
+        arity: [2, 2],
+        call: ([s, n]) => { const k = int(n); const r = runes(toText(s)); return k === null ? null : r.slice(r.length - clamp(k, 0, r.length)).join('') },
+    },
+    substr: {
+        arity: [2, 3],
+        call: a => {
+            const r = runes(toText(a[0]))
+            const start = int(a[1])
+            if (start === null) return null
+            const from = clamp(start - 1, 0, r.length)
+            let to = r.length
+            if (a.length === 3) {
+                const n = int(a[2])
+                if (n === null) return null
+                to = clamp(from + n, from, r.length)
+            }
+            return r.slice(from, to).join('')
+        },
+    },
+    replace: { arity: [3, 3], call: ([s, o, n]) => toText(s).split(toText(o)).join(toText(n)) },
+    contains: predicate((s, sub) => s.includes(sub)),
+    startswith: predicate((s, sub) => s.startsWith(sub)),
+    endswith: predicate((s, sub) => s.endsWith(sub)),
+    split_part: {
+        arity: [3, 3],
+        call: ([s, sep, n]) => {
+            const parts = toText(s).split(toText(sep))
+            const k = int(n)
+            return k === null || k < 1 || k > parts.length ? null : parts[k - 1]
+        },
+    },
+
+    // Math
+    abs: mathFn(Math.abs),
+    floor: mathFn(Math.floor),
+    ceil: mathFn(Math.ceil),
+    sqrt: mathFn(Math.sqrt),
+    round: {
+        arity: [1, 2],
+        call: a => {
+            const n = toNumber(a[0])
+            const digits = a.length === 2 ? int(a[1]) : 0
+            if (n === null || digits === null) return null
+            const p = 10 ** digits
+            return Math.round(n * p) / p
+        },
+    },
+    pow: {
+        arity: [2, 2],
+        call: ([x, y]) => { const a = toNumber(x), b = toNumber(y); return a === null || b === null ? null : finite(a ** b) },
+    },
+    min: { arity: [1, -1], call: a => extreme(a, -1) },
+    max: { arity: [1, -1], call: a => extreme(a, 1) },
+    number: { arity: [1, 1], call: ([v]) => toNumber(v) },
+
+    // Date
+    now: { arity: [0, 0], call: () => new Date() },
+    today: {
+        arity: [0, 0],
+        call: () => { const d = new Date(); return new Date(Date.UTC(d.getFullYear(), d.getMonth(), d.getDate())) },
+    },
+    date: { arity: [1, 1], call: ([v]) => toDate(v) },
+    year: dateFn(d => d.getUTCFullYear()),
+    month: dateFn(d => d.getUTCMonth() + 1),
+    day: dateFn(d => d.getUTCDate()),
+    hour: dateFn(d => d.getUTCHours()),
+    minute: dateFn(d => d.getUTCMinutes()),
+    weekday: dateFn(d => d.getUTCDay()),
+    date_add: {
+        arity: [3, 3],
+        call: ([dv, nv, uv]) => {
+            const d = toDate(dv), n = int(nv)
+            if (!d || n === null) return null
+            const u = unit(uv)
+            if (u === 'month') return addMonths(d, n)
+            if (u === 'year') return addMonths(d, 12 * n)
+            return UNIT_MS[u] ? new Date(d.getTime() + n * UNIT_MS[u]) : null
+        },
+    },
+    date_diff: {
+        arity: [3, 3],
+        call: ([av, bv, uv]) => {
+            const a = toDate(av), b = toDate(bv)
+            if (!a || !b) return null
+            const u = unit(uv)
+            if (u === 'month') return monthsBetween(b, a)
+            if (u === 'year') return Math.trunc(monthsBetween(b, a) / 12)
+            return UNIT_MS[u] ? Math.trunc((a.getTime() - b.getTime()) / UNIT_MS[u]) : null
+        },
+    },
+    format_date: {
+        arity: [2, 2],
+        call: ([dv, layout]) => { const d = toDate(dv); return d ? formatDate(d, toText(layout)) : null },
+    },
+}
@@ -10,3 +10,4 @@ export { useWheelCapture } from './hooks/useWheelCapture'
 export { useEditableTitle } from './hooks/useEditableTitle'
 export { useLoadingState } from './hooks/useLoadingState'
 export { Select } from './components/Select'
+export { evaluateCompute, computeExpressionError } from './expr'
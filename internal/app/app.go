@@ -9,6 +9,8 @@ import (
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 
+	"notes/internal/domain"
+	"notes/internal/httpauth"
 	"notes/internal/meeting"
 	mcpserver "notes/internal/mcp"
 	"notes/internal/neovim"
@@ -39,6 +41,9 @@ type App struct {
 	etl            *service.ETLService
 	localdb        *service.LocalDBService
 	database       *service.DatabaseService
+	httpAuth       *httpauth.Authenticator
+	secrets        *secret.Resolver
+	namedSecrets   *service.SecretService
 	window         *service.WindowSettingsService
 
 	// Meeting capture
@@ -108,13 +113,23 @@ func (a *App) Startup(ctx context.Context) {
 
 	// Secret store (macOS Keychain)
 	secretStore := secret.NewKeychainStore()
+	a.httpAuth = httpauth.New(secretStore)
+	a.secrets = secret.NewResolver(secretStore)
 
 	// ── Services ────────────────────────────────────────────
 	// App itself implements EventEmitter — emits Wails events to the frontend.
 	a.blocks = service.NewBlockService(blocksStore, dataDir, a)
+	a.blocks.SetDeleteHook(func(b domain.Block) {
+		if b.Type == domain.BlockTypeHTTP {
+			if err := a.forgetHTTPBlockAuth(b.ID); err != nil {
+				wailsRuntime.LogWarningf(a.ctx, "forget credential of http block %s: %v", b.ID, err)
+			}
+		}
+	})
 	a.localdb = service.NewLocalDBService(localDBStore)
 	a.database = service.NewDatabaseService(dbConnStore, secretStore, blocksStore)
 	a.etl = service.NewETLService(etlStore, localDBStore, a)
+	a.namedSecrets = service.NewSecretService(storage.NewNamedSecretStore(db), a.secrets)
 	a.notebooks = service.NewNotebookService(notebooksStore, a.blocks, connsStore, dataDir, a)
 	a.notebooks.SetCanvasStores(canvasEntityStore, canvasConnStore)
 	a.drawing = service.NewDrawingService(a.notebooks)
@@ -146,7 +161,10 @@ func (a *App) Startup(ctx context.Context) {
 	// ETL block resolver adapters (remain in app layer since they need ctx)
 	setupETLAdapters(a)
 
-	// Start ETL watchers (cron + file watch)
+	// Start ETL watchers (cron + file watch + webhooks)
+	a.etl.SetConnectionLister(a.database)
+	a.etl.SetSecretLister(a.namedSecrets)
+	a.etl.SetWebhookAddr(service.DefaultWebhookAddr)
 	a.etl.RestartWatchers(ctx)
 
 	// ── Terminal / Neovim ───────────────────────────────────
@@ -6,6 +6,8 @@ package app
 
 import (
 	"encoding/json"
+	"os"
+
 	"notes/internal/domain"
 	"notes/internal/etl"
 	"notes/internal/service"
@@ -17,6 +19,11 @@ func (a *App) ListETLSources() []etl.SourceSpec {
 	return a.etl.ListSources()
 }
 
+// ListETLDestinations returns the destination types a job can write to besides a LocalDB.
+func (a *App) ListETLDestinations() []etl.DestinationSpec {
+	return a.etl.ListDestinations()
+}
+
 func (a *App) CreateETLJob(input service.CreateETLJobInput) (*etl.SyncJob, error) {
 	return a.etl.CreateJob(a.ctx, input)
 }
@@ -33,6 +40,11 @@ func (a *App) UpdateETLJob(id string, input service.CreateETLJobInput) error {
 	return a.etl.UpdateJob(a.ctx, id, input)
 }
 
+// SetETLJobEnabled turns a job's trigger on or off.
+func (a *App) SetETLJobEnabled(id string, enabled bool) error {
+	return a.etl.SetJobEnabled(a.ctx, id, enabled)
+}
+
 func (a *App) DeleteETLJob(id string) error {
 	return a.etl.DeleteJob(a.ctx, id)
 }
@@ -41,10 +53,35 @@ func (a *App) RunETLJob(id string) (*etl.SyncResult, error) {
 	return a.etl.RunJob(a.ctx, id)
 }
 
+// GetETLWebhookURL returns the URL that triggers a webhook ETL job.
+func (a *App) GetETLWebhookURL(id string) (string, error) {
+	return a.etl.WebhookURL(id)
+}
+
+// CancelETLJob stops a running ETL job.
+func (a *App) CancelETLJob(id string) error {
+	return a.etl.CancelJob(id)
+}
+
+func (a *App) ResetETLJobCursor(id string) error {
+	return a.etl.ResetCursor(id)
+}
+
 func (a *App) PreviewETLSource(sourceType, sourceConfigJSON string) (*service.PreviewResult, error) {
 	return a.etl.PreviewSource(a.ctx, sourceType, sourceConfigJSON)
 }
 
+// QueryETLSQL runs a SELECT over rows loaded as the "input" table, for the
+// sql stage of chart pipelines.
+func (a *App) QueryETLSQL(query string, rows []map[string]any) (*service.PreviewResult, error) {
+	return a.etl.QuerySQL(a.ctx, query, rows)
+}
+
+// DebugETLJob runs a job on a sample and reports what each transform stage did.
+func (a *App) DebugETLJob(id string, maxRows int) (*etl.DebugResult, error) {
+	return a.etl.DebugJob(a.ctx, id, maxRows)
+}
+
 func (a *App) ListETLRunLogs(jobID string) ([]etl.SyncRunLog, error) {
 	return a.etl.ListRunLogs(jobID)
 }
@@ -57,7 +94,7 @@ func (a *App) PickETLFile() (string, error) {
 	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
 		Title: "Select ETL source file",
 		Filters: []wailsRuntime.FileFilter{
-			{DisplayName: "CSV / JSON", Pattern: "*.csv;*.json"},
+			{DisplayName: "CSV / JSON / Excel", Pattern: "*.csv;*.json;*.jsonl;*.ndjson;*.xlsx;*.gz;*.zst"},
 			{DisplayName: "All Files", Pattern: "*.*"},
 		},
 	})
@@ -67,6 +104,51 @@ func (a *App) PickETLFile() (string, error) {
 	return path, nil
 }
 
+// ExportETLJobs encodes jobs (all jobs if ids is empty) as a "yaml" or "json"
+// pipeline document.
+func (a *App) ExportETLJobs(ids []string, format string) (string, error) {
+	return a.etl.ExportPipelines(ids, format)
+}
+
+// ExportETLJobsToFile saves a pipeline document chosen with a save dialog.
+// It returns the path written, or "" if the dialog was cancelled.
+func (a *App) ExportETLJobsToFile(ids []string, format string) (string, error) {
+	doc, err := a.etl.ExportPipelines(ids, format)
+	if err != nil {
+		return "", err
+	}
+	path, err := wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:           "Export ETL jobs",
+		DefaultFilename: "pipelines." + format,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(doc), 0o644)
+}
+
+// ReadETLPipelineFile returns the content of a pipeline document chosen with
+// an open dialog, or "" if the dialog was cancelled.
+func (a *App) ReadETLPipelineFile() (string, error) {
+	path, err := wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "Import ETL jobs",
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "YAML / JSON", Pattern: "*.yaml;*.yml;*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	b, err := os.ReadFile(path)
+	return string(b), err
+}
+
+// ImportETLJobs validates a pipeline document and, unless opts.DryRun is
+// set, creates or updates its jobs.
+func (a *App) ImportETLJobs(content string, opts service.PipelineImportOptions) (*service.PipelineImportResult, error) {
+	return a.etl.ImportPipelines(a.ctx, []byte(content), opts)
+}
+
 // ListPageDatabaseBlocks and ListPageHTTPBlocks support the ETL UI
 // for selecting target / source blocks on a page.
 func (a *App) ListPageDatabaseBlocks(pageID string) ([]PageBlockRef, error) {
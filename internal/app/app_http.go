@@ -7,6 +7,9 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"notes/internal/httpauth"
+	"notes/internal/secret"
 )
 
 // ── HTTP Block ─────────────────────────────────────────────
@@ -19,6 +22,57 @@ type HTTPRequestConfig struct {
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers"`
 	Body    string            `json:"body"`
+	Auth    *httpBlockAuth    `json:"auth,omitempty"`
+}
+
+// httpBlockAuth is the auth section of an HTTP block as the frontend edits it.
+// Plaintext credentials are moved to the secret store when the block is saved
+// (see SaveBlockHTTPConfig); only secretRef stays in block content.
+type httpBlockAuth struct {
+	Type         string `json:"type"`
+	Token        string `json:"token,omitempty"`    // bearer
+	Username     string `json:"username,omitempty"` // basic
+	Password     string `json:"password,omitempty"` // basic
+	KeyName      string `json:"keyName,omitempty"`  // api_key
+	KeyIn        string `json:"keyIn,omitempty"`    // api_key: header | query
+	APIKey       string `json:"apiKey,omitempty"`   // api_key
+	TokenURL     string `json:"tokenUrl,omitempty"` // oauth2
+	ClientID     string `json:"clientId,omitempty"` // oauth2
+	ClientSecret string `json:"clientSecret,omitempty"`
+	Scopes       string `json:"scopes,omitempty"`
+	SecretRef    string `json:"secretRef,omitempty"`
+}
+
+// blockSecretRef is the secret store key for an HTTP block's credential.
+func blockSecretRef(blockID string) string { return "http-block:" + blockID }
+
+// config converts the block auth into an httpauth.Config. A block without a
+// plaintext credential or explicit reference falls back to its own store key.
+func (b httpBlockAuth) config(blockID string) httpauth.Config {
+	cfg := httpauth.Config{
+		Type:      b.Type,
+		Username:  b.Username,
+		KeyName:   b.KeyName,
+		KeyIn:     b.KeyIn,
+		TokenURL:  b.TokenURL,
+		ClientID:  b.ClientID,
+		Scopes:    b.Scopes,
+		SecretRef: b.SecretRef,
+	}
+	switch b.Type {
+	case httpauth.TypeBearer:
+		cfg.Secret = b.Token
+	case httpauth.TypeBasic:
+		cfg.Secret = b.Password
+	case httpauth.TypeAPIKey:
+		cfg.Secret = b.APIKey
+	case httpauth.TypeOAuth2:
+		cfg.Secret = b.ClientSecret
+	}
+	if cfg.Secret == "" && cfg.SecretRef == "" && blockID != "" {
+		cfg.SecretRef = blockSecretRef(blockID)
+	}
+	return cfg
 }
 
 // HTTPResponse is returned to the frontend after executing a request.
@@ -42,6 +96,15 @@ func (a *App) ExecuteHTTPRequest(blockID string, configJSON string) (*HTTPRespon
 	if cfg.URL == "" {
 		return nil, fmt.Errorf("url is required")
 	}
+	if err := a.expandHTTPSecrets(&cfg.URL, &cfg.Body); err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.Headers {
+		if err := a.expandHTTPSecrets(&v); err != nil {
+			return nil, err
+		}
+		cfg.Headers[k] = v
+	}
 	method := cfg.Method
 	if method == "" {
 		method = "GET"
@@ -52,7 +115,7 @@ func (a *App) ExecuteHTTPRequest(blockID string, configJSON string) (*HTTPRespon
 		bodyReader = strings.NewReader(cfg.Body)
 	}
 
-	req, err := http.NewRequest(method, cfg.URL, bodyReader)
+	req, err := http.NewRequestWithContext(a.ctx, method, cfg.URL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -64,15 +127,42 @@ func (a *App) ExecuteHTTPRequest(blockID string, configJSON string) (*HTTPRespon
 		}
 	}
 
+	var auth httpauth.Config
+	if cfg.Auth != nil {
+		auth = cfg.Auth.config(blockID)
+	}
+	if err := a.expandHTTPSecrets(&auth.Username, &auth.Secret); err != nil {
+		return nil, err
+	}
+	if auth.Enabled() {
+		if a.httpAuth == nil {
+			return nil, fmt.Errorf("http auth is not available")
+		}
+		if err := a.httpAuth.Apply(req.Context(), req, auth); err != nil {
+			return nil, fmt.Errorf("apply auth: %w", err)
+		}
+	}
+
 	client := &http.Client{Timeout: 30 * time.Second}
 
 	start := time.Now()
 	resp, err := client.Do(req)
+	// An expired or revoked OAuth2 token gets one retry with a fresh token.
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && auth.Type == httpauth.TypeOAuth2 {
+		resp.Body.Close()
+		a.httpAuth.Invalidate(auth)
+		if retry, rerr := http.NewRequestWithContext(req.Context(), method, cfg.URL, strings.NewReader(cfg.Body)); rerr == nil {
+			retry.Header = req.Header.Clone()
+			if err = a.httpAuth.Apply(retry.Context(), retry, auth); err == nil {
+				resp, err = client.Do(retry)
+			}
+		}
+	}
 	durationMs := time.Since(start).Milliseconds()
 	if err != nil {
 		return &HTTPResponse{
 			StatusCode: 0,
-			StatusText: err.Error(),
+			StatusText: a.redactSecrets(err.Error()),
 			DurationMs: durationMs,
 		}, nil
 	}
@@ -84,17 +174,18 @@ func (a *App) ExecuteHTTPRequest(blockID string, configJSON string) (*HTTPRespon
 		return nil, fmt.Errorf("read body: %w", err)
 	}
 
-	// Collect response headers.
+	// Collect response headers. An endpoint echoing the request must not
+	// reveal resolved secrets.
 	respHeaders := make(map[string]string)
 	for k := range resp.Header {
-		respHeaders[k] = resp.Header.Get(k)
+		respHeaders[k] = a.redactSecrets(resp.Header.Get(k))
 	}
 
 	return &HTTPResponse{
 		StatusCode:  resp.StatusCode,
 		StatusText:  resp.Status,
 		Headers:     respHeaders,
-		Body:        string(data),
+		Body:        a.redactSecrets(string(data)),
 		DurationMs:  durationMs,
 		ContentType: resp.Header.Get("Content-Type"),
 		SizeBytes:   len(data),
@@ -102,11 +193,95 @@ func (a *App) ExecuteHTTPRequest(blockID string, configJSON string) (*HTTPRespon
 }
 
 // SaveBlockHTTPConfig persists HTTP request config to a block.
+// Credentials in the auth section are moved to the secret store first.
 func (a *App) SaveBlockHTTPConfig(blockID string, config string) error {
 	b, err := a.blocks.GetBlock(blockID)
 	if err != nil {
 		return err
 	}
-	b.Content = config
+	sealed, err := a.sealHTTPBlockAuth(blockID, config)
+	if err != nil {
+		return err
+	}
+	b.Content = sealed
 	return a.blocks.UpdateBlock(b)
 }
+
+// sealHTTPBlockAuth stores any plaintext credential from the block's auth
+// section in the secret store and returns the content without it. A block
+// whose auth no longer uses its own store key has that credential deleted.
+func (a *App) sealHTTPBlockAuth(blockID, content string) (string, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &doc); err != nil || len(doc["auth"]) == 0 {
+		// Not a JSON object or no auth: store as-is.
+		return content, a.forgetHTTPBlockAuth(blockID)
+	}
+	var auth httpBlockAuth
+	if err := json.Unmarshal(doc["auth"], &auth); err != nil {
+		return content, nil
+	}
+
+	cfg := auth.config("")
+	if cfg.Secret == "" || secret.HasRefs(cfg.Secret) {
+		// ${secret:<name>} references are resolved per request.
+		if own := auth.config(blockID); !own.Enabled() || own.SecretRef != blockSecretRef(blockID) {
+			return content, a.forgetHTTPBlockAuth(blockID)
+		}
+		return content, nil
+	}
+	if a.httpAuth == nil {
+		return "", fmt.Errorf("http auth is not available")
+	}
+	if err := a.httpAuth.Seal(&cfg, blockSecretRef(blockID)); err != nil {
+		return "", err
+	}
+	if cfg.SecretRef != blockSecretRef(blockID) {
+		if err := a.forgetHTTPBlockAuth(blockID); err != nil {
+			return "", err
+		}
+	}
+	auth.Token, auth.Password, auth.APIKey, auth.ClientSecret = "", "", "", ""
+	auth.SecretRef = cfg.SecretRef
+
+	raw, _ := json.Marshal(auth)
+	doc["auth"] = raw
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// forgetHTTPBlockAuth deletes the credential stored under the block's own key.
+func (a *App) forgetHTTPBlockAuth(blockID string) error {
+	if a.httpAuth == nil {
+		return nil
+	}
+	return a.httpAuth.Forget(httpauth.Config{SecretRef: blockSecretRef(blockID)})
+}
+
+// expandHTTPSecrets resolves ${secret:<name>} references in the given fields.
+func (a *App) expandHTTPSecrets(fields ...*string) error {
+	for _, f := range fields {
+		if !secret.HasRefs(*f) {
+			continue
+		}
+		if a.secrets == nil {
+			return fmt.Errorf("secrets are not available")
+		}
+		expanded, err := a.secrets.Expand(*f)
+		if err != nil {
+			return err
+		}
+		*f = expanded
+	}
+	return nil
+}
+
+// redactSecrets masks resolved secret values in text shown to the user.
+func (a *App) redactSecrets(s string) string {
+	if a.secrets == nil {
+		return s
+	}
+	return a.secrets.Redact(s)
+}
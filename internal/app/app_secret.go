@@ -0,0 +1,24 @@
+package app
+
+// ─────────────────────────────────────────────────────────────
+// Secret Handlers — thin delegates to SecretService
+// ─────────────────────────────────────────────────────────────
+// Named secrets are referenced from configs as ${secret:<name>}. Values can
+// be set and deleted but are never returned to the frontend.
+
+import "notes/internal/domain"
+
+// ListSecrets returns the names of the stored secrets.
+func (a *App) ListSecrets() ([]domain.NamedSecret, error) {
+	return a.namedSecrets.ListSecrets()
+}
+
+// SetSecret creates or replaces a named secret.
+func (a *App) SetSecret(name, value string) error {
+	return a.namedSecrets.SetSecret(name, value)
+}
+
+// DeleteSecret removes a named secret.
+func (a *App) DeleteSecret(name string) error {
+	return a.namedSecrets.DeleteSecret(name)
+}
@@ -4,26 +4,37 @@ package app
 // ETL Adapter Bridge
 // ─────────────────────────────────────────────────────────────
 //
-// The ETL sources package uses interfaces (BlockResolver, DBProvider,
-// HTTPBlockResolver) to access app infrastructure without creating circular
-// deps. This file provides the concrete adapters that satisfy those interfaces
-// using the App's services.
+// The ETL sources and destinations packages use interfaces (BlockResolver,
+// DBProvider, HTTPBlockResolver, NotesReader, SQLProvider) to access app
+// infrastructure without creating circular deps. This file provides the concrete adapters that
+// satisfy those interfaces using the App's services.
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 
+	"notes/internal/domain"
+	"notes/internal/etl"
+	"notes/internal/etl/destinations"
 	"notes/internal/etl/sources"
+	"notes/internal/httpauth"
 )
 
 // ── Setup ──────────────────────────────────────────────────
 
-// setupETLAdapters wires the ETL source adapters using the App's services.
+// setupETLAdapters wires the ETL source and destination adapters using the App's services.
 func setupETLAdapters(a *App) {
 	sources.SetBlockResolver(&appBlockResolver{app: a})
 	sources.SetDBProvider(&appDBProvider{app: a})
 	sources.SetHTTPBlockResolver(&appHTTPBlockResolver{app: a})
+	sources.SetHTTPAuthenticator(a.httpAuth)
+	sources.SetNotesReader(&appNotesReader{app: a})
+	destinations.SetSQLProvider(&appDBProvider{app: a})
+	if a.secrets != nil {
+		etl.SetSecretResolver(a.secrets)
+	}
 }
 
 // ── Block Resolver ─────────────────────────────────────────
@@ -65,6 +76,37 @@ func (p *appDBProvider) FetchMoreETLRows(ctx context.Context, connID string, fet
 	return &sources.QueryPage{Columns: result.Columns, Rows: result.Rows, HasMore: result.HasMore}, nil
 }
 
+// ETLQueryDriver names the driver of a saved connection, or "" when it is not
+// a SQL database.
+func (p *appDBProvider) ETLQueryDriver(connID string) string {
+	_, driver, err := p.app.database.SQLHandle(connID)
+	if err != nil {
+		return ""
+	}
+	return driver
+}
+
+// OpenETLSQL returns the pooled handle of a saved SQL connection, for the SQL destination.
+func (p *appDBProvider) OpenETLSQL(ctx context.Context, connID string) (*sql.DB, string, error) {
+	return p.app.database.SQLHandle(connID)
+}
+
+// ── Notes Reader ───────────────────────────────────────────
+
+type appNotesReader struct{ app *App }
+
+func (r *appNotesReader) ListNotebooks() ([]domain.Notebook, error) {
+	return r.app.notebooks.ListNotebooks()
+}
+
+func (r *appNotesReader) ListPages(notebookID string) ([]domain.Page, error) {
+	return r.app.notebooks.ListPages(notebookID)
+}
+
+func (r *appNotesReader) ListBlocks(pageID string) ([]domain.Block, error) {
+	return r.app.blocks.ListBlocks(pageID)
+}
+
 // ── HTTP Block Resolver ────────────────────────────────────
 
 type appHTTPBlockResolver struct{ app *App }
@@ -122,6 +164,35 @@ func (r *appHTTPBlockResolver) GetHTTPBlockContent(blockID string) (url, method,
 		}
 	}
 
+	if err := r.app.expandHTTPSecrets(&cfg.URL, &bodyStr); err != nil {
+		return "", "", "", "", err
+	}
+	for k, v := range headers {
+		if err := r.app.expandHTTPSecrets(&v); err != nil {
+			return "", "", "", "", err
+		}
+		headers[k] = v
+	}
+
 	hdrs, _ := json.Marshal(headers)
 	return cfg.URL, cfg.Method, string(hdrs), bodyStr, nil
 }
+
+// GetHTTPBlockAuth returns the auth config saved on an HTTP block.
+func (r *appHTTPBlockResolver) GetHTTPBlockAuth(blockID string) (httpauth.Config, error) {
+	b, err := r.app.blocks.GetBlock(blockID)
+	if err != nil {
+		return httpauth.Config{}, fmt.Errorf("resolve http block %s: %w", blockID, err)
+	}
+	var cfg struct {
+		Auth httpBlockAuth `json:"auth"`
+	}
+	if err := json.Unmarshal([]byte(b.Content), &cfg); err != nil {
+		return httpauth.Config{}, fmt.Errorf("parse http block config: %w", err)
+	}
+	auth := cfg.Auth.config(blockID)
+	if err := r.app.expandHTTPSecrets(&auth.Username, &auth.Secret); err != nil {
+		return httpauth.Config{}, err
+	}
+	return auth, nil
+}
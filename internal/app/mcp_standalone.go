@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"notes/internal/httpauth"
 	mcpserver "notes/internal/mcp"
 	"notes/internal/plugins"
 	"notes/internal/secret"
@@ -61,8 +62,11 @@ func ServeMCP() {
 
 	// Wire ETL adapters so database source can resolve block references
 	setupETLAdapters(&App{
-		blocks:   blocksSvc,
-		database: databaseSvc,
+		blocks:    blocksSvc,
+		notebooks: notebooksSvc,
+		database:  databaseSvc,
+		httpAuth:  httpauth.New(secretStore),
+		secrets:   secret.NewResolver(secretStore),
 	})
 
 	// Create and serve MCP
@@ -2,6 +2,7 @@ package dbclient
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"notes/internal/domain"
@@ -71,6 +72,15 @@ type Connector interface {
 	Close() error
 }
 
+// SQLHandle is implemented by the SQL connectors (MySQL, Postgres, SQLite).
+// It exposes the pooled handle to callers that need parameterized statements
+// and transactions, such as the ETL SQL destination.
+type SQLHandle interface {
+	// SQLDB returns the database handle and its driver name
+	// ("mysql" | "postgres" | "sqlite").
+	SQLDB() (*sql.DB, string)
+}
+
 // NewConnector creates a Connector for the given database connection.
 // The password must be provided separately (from SecretStore).
 func NewConnector(conn *domain.DatabaseConnection, password string) (Connector, error) {
@@ -37,6 +37,9 @@ func newSQLConnector(driverName, dsn string) (*sqlConnector, error) {
 	return &sqlConnector{driverName: driverName, db: db}, nil
 }
 
+// SQLDB returns the pooled handle and driver name (see SQLHandle).
+func (c *sqlConnector) SQLDB() (*sql.DB, string) { return c.db, c.driverName }
+
 func (c *sqlConnector) TestConnection(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -60,4 +60,21 @@ type LocalDatabaseStore interface {
 	DeleteRow(id string) error
 	DeleteRowsByDatabase(databaseID string) error
 	ReorderRows(databaseID string, rowIDs []string) error
+
+	// Staged writes: rows staged under a stage ID stay invisible until
+	// CommitStage applies them, so a failed bulk write can be discarded.
+	StageRows(stageID string, rows []LocalDBRow) error
+	GetStagedRow(stageID, rowID string) (*LocalDBRow, error)
+	CommitStage(stageID string, commit LocalDBStageCommit) error
+	DiscardStage(stageID string) error
+}
+
+// LocalDBStageCommit describes how CommitStage applies a stage to a database.
+// Staged rows are inserted, or replace the data of the existing row with the
+// same ID.
+type LocalDBStageCommit struct {
+	DatabaseID   string
+	ConfigJSON   string   // new config; "" keeps the current one
+	ClearRows    bool     // delete every existing row first
+	DeleteRowIDs []string // existing rows to delete
 }
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// NamedSecret is a credential that configs reference as ${secret:<name>}.
+// The value lives in the secret store and is never part of this record.
+type NamedSecret struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
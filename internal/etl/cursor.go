@@ -0,0 +1,147 @@
+package etl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Incremental Cursor ─────────────────────────────────────
+// Incremental jobs declare a cursor field (e.g. "updated_at" or an
+// auto-increment id). The engine remembers the highest value seen per job
+// and hands it to the source on the next run so it can fetch only newer
+// records. Sources that cannot push the cursor down still work: the engine
+// drops records at or below the previous high-water mark.
+//
+// Pattern: Singer bookmarks / Airbyte incremental cursor.
+
+// Cursor is the incremental-sync position handed to a source.
+type Cursor struct {
+	Field string `json:"field"`
+	Value string `json:"value"` // "" on the first run
+}
+
+// cursorConfigKey is the reserved SourceConfig key carrying the cursor.
+const cursorConfigKey = "__cursor"
+
+// WithCursor returns a copy of cfg carrying the given cursor.
+// The original config is left untouched so it can be persisted as-is.
+func WithCursor(cfg SourceConfig, c Cursor) SourceConfig {
+	out := make(SourceConfig, len(cfg)+1)
+	for k, v := range cfg {
+		out[k] = v
+	}
+	out[cursorConfigKey] = c
+	return out
+}
+
+// CursorFromConfig returns the cursor the engine attached to cfg, if any.
+// Sources call this to push the cursor down to the upstream system.
+func CursorFromConfig(cfg SourceConfig) (Cursor, bool) {
+	c, ok := cfg[cursorConfigKey].(Cursor)
+	if !ok || c.Field == "" {
+		return Cursor{}, false
+	}
+	return c, true
+}
+
+// cursorTracker filters records against the previous high-water mark and
+// tracks the new one.
+type cursorTracker struct {
+	field string
+	prev  any // parsed previous value, nil on first run
+	max   any
+}
+
+func newCursorTracker(field, prev string) *cursorTracker {
+	t := &cursorTracker{field: field}
+	if prev != "" {
+		t.prev = parseCursorValue(prev)
+		t.max = t.prev
+	}
+	return t
+}
+
+// Accept reports whether r is newer than the previous cursor and, if so,
+// advances the high-water mark. Records without a cursor value are kept.
+func (t *cursorTracker) Accept(r Record) bool {
+	v, ok := r.Data[t.field]
+	if !ok || v == nil || fmt.Sprint(v) == "" {
+		return true
+	}
+	if t.prev != nil && compareCursor(v, t.prev) <= 0 {
+		return false
+	}
+	if t.max == nil || compareCursor(v, t.max) > 0 {
+		t.max = v
+	}
+	return true
+}
+
+// Value returns the high-water mark formatted for persistence.
+func (t *cursorTracker) Value() string {
+	return formatCursorValue(t.max)
+}
+
+// compareCursor orders cursor values: numerically when both sides are numbers,
+// chronologically when both parse as dates, lexically otherwise.
+func compareCursor(a, b any) int {
+	fa, aOk := toFloatSafe(a)
+	fb, bOk := toFloatSafe(b)
+	if aOk && bOk {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	ta, aOk := parseCursorTime(a)
+	tb, bOk := parseCursorTime(b)
+	if aOk && bOk {
+		return ta.Compare(tb)
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// parseCursorTime parses only date strings and time values; numbers are left
+// to the numeric comparison so ids are never mistaken for Unix timestamps.
+func parseCursorTime(v any) (time.Time, bool) {
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, true
+	case string:
+		for _, layout := range dateFormats {
+			if t, err := time.Parse(layout, tv); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseCursorValue restores a persisted cursor string to a comparable value.
+func parseCursorValue(s string) any {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// formatCursorValue renders a cursor value for persistence and for sources.
+func formatCursorValue(v any) string {
+	switch tv := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(tv, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(tv), 'f', -1, 32)
+	case time.Time:
+		return tv.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,332 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+
+	"notes/internal/expr"
+)
+
+// ── Debug Run ──────────────────────────────────────────────
+// Debug executes a job's source and transform chain on a sample without
+// writing anything, and reports what every stage did: how many records went
+// in and came out, a sample of its output, the schema after it, and the
+// records it could not process cleanly. It answers "which of my ten stages
+// dropped all the rows?" without a real run.
+//
+// Stages run one after another over the whole sample, which matches the
+// streaming engine: a group sees everything before it, and the stages after
+// it see its summaries. As in RunSync, sorting happens once at the end of the
+// chain, so the last stage's sample is in output order.
+
+const (
+	// DefaultDebugRows is the number of source records a debug run reads.
+	DefaultDebugRows = 200
+	// debugSampleSize is the number of output records kept per stage.
+	debugSampleSize = 5
+	// debugMaxErrors caps the record errors kept per stage.
+	debugMaxErrors = 20
+)
+
+// RecordChecker is implemented by transformers that can explain why a record
+// will not transform cleanly, e.g. a value that cannot be cast. Check is
+// called with the record before Transform; it is only used by debug runs.
+type RecordChecker interface {
+	Check(Record) error
+}
+
+// DebugResult is the outcome of a debug run.
+type DebugResult struct {
+	JobID  string       `json:"jobId"`
+	Stages []DebugStage `json:"stages"`
+	Error  string       `json:"error,omitempty"` // source or chain error; stages up to it are still reported
+}
+
+// DebugStage describes one pipeline stage. The first stage is always the
+// source; an incremental job adds a "cursor" stage and a dedupe key adds a
+// trailing "dedupe" stage.
+type DebugStage struct {
+	Index      int           `json:"index"` // position in SyncJob.Transforms, -1 for source/cursor/dedupe
+	Type       string        `json:"type"`
+	RecordsIn  int           `json:"recordsIn"`
+	RecordsOut int           `json:"recordsOut"`
+	Sample     []Record      `json:"sample"`
+	Schema     *Schema       `json:"schema"`
+	Errors     []RecordError `json:"errors,omitempty"`
+	ErrorCount int           `json:"errorCount"` // total, including errors beyond the kept ones
+}
+
+// RecordError is a problem with one record at one stage.
+type RecordError struct {
+	Row   int    `json:"row"` // 1-based source row; 0 for records produced by a group
+	Error string `json:"error"`
+}
+
+// debugRow is a record tagged with the source row it came from.
+type debugRow struct {
+	row int
+	rec Record
+}
+
+// Debug runs job's pipeline on up to maxRows source records (DefaultDebugRows
+// when maxRows <= 0). Errors building the chain are returned; source errors
+// are reported in DebugResult.Error alongside the stages that did run.
+func (e *Engine) Debug(ctx context.Context, job *SyncJob, maxRows int) (*DebugResult, error) {
+	if maxRows <= 0 {
+		maxRows = DefaultDebugRows
+	}
+	source, err := GetSource(job.SourceType)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := source.Discover(ctx, job.SourceCfg)
+	if err != nil {
+		return nil, fmt.Errorf("discover: %w", err)
+	}
+
+	// Build each configured stage on its own so results line up with
+	// job.Transforms, even for configs that compile to nothing.
+	stages := make([][]Transformer, len(job.Transforms))
+	grouped := false
+	for i, tc := range job.Transforms {
+		if tc.Type == "group" {
+			if grouped {
+				return nil, fmt.Errorf("transform %d: only one group transform is supported", i+1)
+			}
+			grouped = true
+		}
+		ts, err := buildTransformers([]TransformConfig{tc}, "", e.LookupStore)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d: %w", i+1, err)
+		}
+		stages[i] = ts
+	}
+
+	result := &DebugResult{JobID: job.ID}
+
+	// Source stage.
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	recCh, errCh := source.Read(readCtx, job.SourceCfg)
+	var rows []debugRow
+	for rec := range recCh {
+		rows = append(rows, debugRow{row: len(rows) + 1, rec: rec})
+		if len(rows) >= maxRows {
+			cancel()
+			break
+		}
+	}
+	go func() {
+		for range recCh {
+		}
+	}()
+	if err := <-errCh; err != nil && len(rows) < maxRows {
+		result.Error = fmt.Sprintf("read: %s", err)
+	}
+	src := DebugStage{Index: -1, Type: "source", RecordsIn: len(rows), RecordsOut: len(rows), Schema: schema}
+	src.Sample = sampleRows(rows)
+	result.Stages = append(result.Stages, src)
+
+	if job.SyncMode == SyncIncremental && job.CursorField != "" && !source.Spec().Stateful {
+		cursor := newCursorTracker(job.CursorField, job.CursorValue)
+		rows = result.addStage(-1, "cursor", rows, schema, job.Transforms[:0], func(r debugRow) ([]debugRow, error) {
+			if !cursor.Accept(r.rec) {
+				return nil, nil
+			}
+			return []debugRow{r}, nil
+		})
+	}
+
+	for i, tc := range job.Transforms {
+		ts := stages[i]
+		rows = result.addStage(i, tc.Type, rows, schema, job.Transforms[:i+1], func(r debugRow) ([]debugRow, error) {
+			var checkErr error
+			for _, t := range ts {
+				if c, ok := t.(RecordChecker); ok {
+					if err := c.Check(r.rec); err != nil {
+						checkErr = err
+					}
+				}
+			}
+			out, keep := ApplyTransformers(r.rec, ts)
+			if !keep {
+				return nil, checkErr
+			}
+			return []debugRow{{row: r.row, rec: out}}, checkErr
+		})
+		if batch, _ := findBatch(ts); batch != nil {
+			st := &result.Stages[len(result.Stages)-1]
+			output, derived, err := batch.Emit(ctx)
+			if err != nil {
+				st.ErrorCount++
+				st.Errors = append(st.Errors, RecordError{Error: err.Error()})
+			}
+			rows = rows[:0]
+			for _, rec := range output {
+				rows = append(rows, debugRow{rec: rec})
+			}
+			st.RecordsOut = len(rows)
+			st.Sample = sampleRows(rows)
+			st.Schema = stageSchema(schema, job.Transforms[:i+1], rows)
+			if derived != nil {
+				st.Schema = derived
+			}
+		}
+	}
+
+	if job.DedupeKey != "" {
+		dedupe := NewDedupeTransform(job.DedupeKey)
+		rows = result.addStage(-1, "dedupe", rows, schema, job.Transforms, func(r debugRow) ([]debugRow, error) {
+			if out, keep := dedupe.Transform(r.rec); keep {
+				return []debugRow{{row: r.row, rec: out}}, nil
+			}
+			return nil, nil
+		})
+	}
+
+	// Sorting is applied to the end of the chain, as RunSync does.
+	var all []Transformer
+	for _, ts := range stages {
+		all = append(all, ts...)
+	}
+	if st := findSort(all); st != nil && len(result.Stages) > 0 {
+		recs := make([]Record, len(rows))
+		for i, r := range rows {
+			recs[i] = r.rec
+		}
+		sortRecords(recs, st.Field, st.Direction)
+		sorted := make([]debugRow, len(recs))
+		for i, r := range recs {
+			sorted[i] = debugRow{rec: r}
+		}
+		result.Stages[len(result.Stages)-1].Sample = sampleRows(sorted)
+	}
+
+	return result, nil
+}
+
+// addStage runs fn over rows, appends the stage's report and returns its output.
+func (res *DebugResult) addStage(index int, typ string, rows []debugRow, source *Schema, upTo []TransformConfig, fn func(debugRow) ([]debugRow, error)) []debugRow {
+	st := DebugStage{Index: index, Type: typ, RecordsIn: len(rows)}
+	var out []debugRow
+	for _, r := range rows {
+		produced, err := fn(r)
+		if err != nil {
+			st.ErrorCount++
+			if len(st.Errors) < debugMaxErrors {
+				st.Errors = append(st.Errors, RecordError{Row: r.row, Error: err.Error()})
+			}
+		}
+		out = append(out, produced...)
+	}
+	st.RecordsOut = len(out)
+	st.Sample = sampleRows(out)
+	st.Schema = stageSchema(source, upTo, out)
+	res.Stages = append(res.Stages, st)
+	return out
+}
+
+// sampleRows copies the first few records, so later stages that modify
+// records in place do not change an earlier stage's sample.
+func sampleRows(rows []debugRow) []Record {
+	n := min(len(rows), debugSampleSize)
+	sample := make([]Record, n)
+	for i := range n {
+		data := make(map[string]any, len(rows[i].rec.Data))
+		for k, v := range rows[i].rec.Data {
+			data[k] = v
+		}
+		sample[i] = Record{Data: data}
+	}
+	return sample
+}
+
+// stageSchema derives the schema a destination would see after a stage.
+func stageSchema(source *Schema, upTo []TransformConfig, rows []debugRow) *Schema {
+	t := newSchemaTracker(source, upTo)
+	for _, r := range rows {
+		t.Observe([]Record{r.rec})
+	}
+	return t.Schema()
+}
+
+// ── Record Checks ─────────────────────────────────────────
+
+func (t *FilterTransform) Check(r Record) error {
+	v, ok := r.Data[t.Field]
+	if !ok {
+		return fmt.Errorf("field %q not found", t.Field)
+	}
+	if t.Op == "gt" || t.Op == "lt" {
+		if _, ok := toFloatSafe(v); !ok {
+			return fmt.Errorf("%s: %v is not a number", t.Field, v)
+		}
+	}
+	return nil
+}
+
+func (t *ComputeTransform) Check(r Record) error {
+	for _, col := range t.Columns {
+		prog, err := expr.Compile(col.Expression)
+		if err != nil {
+			continue // legacy template
+		}
+		for _, f := range prog.Fields() {
+			if _, ok := r.Data[f]; !ok {
+				return fmt.Errorf("%s: field %q not found", col.Name, f)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TypeCastTransform) Check(r Record) error {
+	v, ok := r.Data[t.Field]
+	if !ok || v == nil || v == "" {
+		return nil
+	}
+	switch t.CastType {
+	case "number":
+		if _, ok := toFloatSafe(v); !ok {
+			return fmt.Errorf("%s: cannot cast %v to number", t.Field, v)
+		}
+	case "date", "datetime":
+		if _, ok := tryParseTime(v); !ok {
+			return fmt.Errorf("%s: cannot cast %v to %s", t.Field, v, t.CastType)
+		}
+	}
+	return nil
+}
+
+func (t *DatePartTransform) Check(r Record) error {
+	v, ok := r.Data[t.Field]
+	if !ok || v == nil || v == "" {
+		return nil
+	}
+	if _, ok := tryParseTime(v); !ok {
+		return fmt.Errorf("%s: %v is not a date", t.Field, v)
+	}
+	return nil
+}
+
+func (t *MathTransform) Check(r Record) error {
+	v, ok := r.Data[t.Field]
+	if !ok || v == nil {
+		return nil
+	}
+	if _, ok := toFloatSafe(v); !ok {
+		return fmt.Errorf("%s: %v is not a number", t.Field, v)
+	}
+	return nil
+}
+
+func (t *LookupTransform) Check(r Record) error {
+	v := r.Data[t.LeftKey]
+	if v == nil {
+		return fmt.Errorf("%s is empty", t.LeftKey)
+	}
+	if t.index != nil && t.index[fmt.Sprint(v)] == nil {
+		return fmt.Errorf("no match for %s = %v", t.LeftKey, v)
+	}
+	return nil
+}
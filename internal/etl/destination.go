@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"time"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 
@@ -12,8 +14,10 @@ import (
 )
 
 // ── Destination ────────────────────────────────────────────
-// A Destination writes records into a target system.
-// For now, the only destination is LocalDB.
+// A Destination writes records into a target system. Jobs write to a LocalDB
+// by default, through the Engine's Dest. Other destination types (external
+// SQL databases, files, webhooks) are registered like sources and configured
+// per job; implementations live in etl/destinations/.
 //
 // Pattern: Singer target protocol.
 
@@ -23,11 +27,154 @@ type SyncMode string
 const (
 	SyncReplace SyncMode = "replace" // delete all existing rows, insert fresh
 	SyncAppend  SyncMode = "append"  // add rows without deleting existing
+	// SyncIncremental appends only records newer than the job's persisted cursor.
+	SyncIncremental SyncMode = "incremental"
+	// SyncMerge upserts records by key columns, optionally deleting rows missing from the source.
+	SyncMerge SyncMode = "merge"
 )
 
+// WriteOptions configures a destination write session.
+type WriteOptions struct {
+	Mode          SyncMode
+	MergeKeys     []string // merge mode: fields that identify a row
+	DeleteMissing bool     // merge mode: delete target rows not present in the source
+}
+
+// WriteStats counts the rows a session changed.
+type WriteStats struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Deleted  int `json:"deleted"`
+}
+
+// Written returns the number of rows inserted or updated.
+func (s WriteStats) Written() int { return s.Inserted + s.Updated }
+
+// Add accumulates other into s.
+func (s *WriteStats) Add(other WriteStats) {
+	s.Inserted += other.Inserted
+	s.Updated += other.Updated
+	s.Deleted += other.Deleted
+}
+
 // Destination writes records to a target system.
+// A run opens one WriteSession and streams its output through it in batches,
+// so the destination never needs the full record set in memory.
 type Destination interface {
-	Write(ctx context.Context, targetID string, schema *Schema, records []Record, mode SyncMode) (int, error)
+	Open(ctx context.Context, targetID string, opts WriteOptions) (WriteSession, error)
+}
+
+// WriteSession is a single run's write pass against a destination target.
+type WriteSession interface {
+	// Write writes one batch of records. schema describes every field seen so
+	// far in the run and may grow between batches.
+	Write(ctx context.Context, schema *Schema, records []Record) (WriteStats, error)
+
+	// Close finalizes the run and reports any rows changed while doing so
+	// (e.g. merge deletes). runErr is the error that ended the run (nil on
+	// success) so sessions can decide whether to commit or discard pending work.
+	Close(ctx context.Context, runErr error) (WriteStats, error)
+}
+
+// ── Destination Registry ───────────────────────────────────
+// Compile-time registration via init() in each destination file.
+
+// DestLocalDB is the destination type of jobs writing to a LocalDB, which is
+// also what an empty SyncJob.DestType means.
+const DestLocalDB = "localdb"
+
+// DestinationConfig is an opaque configuration map parsed per destination type.
+type DestinationConfig map[string]any
+
+// DestinationSpec describes a destination type: its label, icon, config
+// fields and the sync modes it can write.
+type DestinationSpec struct {
+	Type         string        `json:"type"`
+	Label        string        `json:"label"`
+	Icon         string        `json:"icon"` // Tabler icon name
+	ConfigFields []ConfigField `json:"configFields"`
+	Modes        []SyncMode    `json:"modes"`
+}
+
+// SupportsMode reports whether the destination can write in mode.
+func (s DestinationSpec) SupportsMode(mode SyncMode) bool {
+	for _, m := range s.Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// DestinationConnector is a registered destination type. Each run opens a
+// WriteSession with the job's destination config.
+type DestinationConnector interface {
+	// Spec returns metadata about this destination type.
+	Spec() DestinationSpec
+
+	// Open starts a write session against the configured target.
+	Open(ctx context.Context, cfg DestinationConfig, opts WriteOptions) (WriteSession, error)
+}
+
+var (
+	destRegistryMu sync.RWMutex
+	destRegistry   = map[string]DestinationConnector{}
+)
+
+// RegisterDestination registers a destination by its spec type.
+// Called from init() in each destination implementation file.
+func RegisterDestination(d DestinationConnector) {
+	destRegistryMu.Lock()
+	defer destRegistryMu.Unlock()
+	destRegistry[d.Spec().Type] = d
+}
+
+// GetDestination returns a registered destination by type, or an error if not found.
+// The destination resolves ${secret:name} references in its config (see secrets.go).
+func GetDestination(typ string) (DestinationConnector, error) {
+	destRegistryMu.RLock()
+	defer destRegistryMu.RUnlock()
+	d, ok := destRegistry[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown destination type: %q", typ)
+	}
+	return secretDestination{d}, nil
+}
+
+// ListDestinations returns the specs of all registered destinations.
+func ListDestinations() []DestinationSpec {
+	destRegistryMu.RLock()
+	defer destRegistryMu.RUnlock()
+	specs := make([]DestinationSpec, 0, len(destRegistry))
+	for _, d := range destRegistry {
+		specs = append(specs, d.Spec())
+	}
+	return specs
+}
+
+// ValidateDestination checks that a registered destination type supports
+// mode and has its required config. The LocalDB destination needs no config.
+func ValidateDestination(destType string, cfg DestinationConfig, mode SyncMode) error {
+	if destType == "" || destType == DestLocalDB {
+		return nil
+	}
+	d, err := GetDestination(destType)
+	if err != nil {
+		return err
+	}
+	spec := d.Spec()
+	if mode == "" {
+		mode = SyncReplace
+	}
+	if !spec.SupportsMode(mode) {
+		return fmt.Errorf("%s destination does not support %s mode", spec.Label, mode)
+	}
+	for _, f := range spec.ConfigFields {
+		if v, ok := cfg[f.Key]; f.Required && (!ok || v == nil || v == "") {
+			return fmt.Errorf("%s: %s is required", spec.Label, f.Label)
+		}
+	}
+	return nil
 }
 
 // ── LocalDB Destination ────────────────────────────────────
@@ -38,163 +185,468 @@ type LocalDBWriter struct {
 	Store domain.LocalDatabaseStore
 }
 
+// Open starts a write session against the LocalDB with the given ID.
+func (w *LocalDBWriter) Open(ctx context.Context, targetID string, opts WriteOptions) (WriteSession, error) {
+	if opts.Mode == SyncMerge && len(opts.MergeKeys) == 0 {
+		return nil, fmt.Errorf("merge mode requires at least one key column")
+	}
+	return &localDBSession{w: w, targetID: targetID, opts: opts}, nil
+}
+
+// Write is a one-shot helper that writes all records in a single batch.
 func (w *LocalDBWriter) Write(ctx context.Context, targetID string, schema *Schema, records []Record, mode SyncMode) (int, error) {
-	if len(records) == 0 {
-		return 0, nil
+	sess, err := w.Open(ctx, targetID, WriteOptions{Mode: mode})
+	if err != nil {
+		return 0, err
+	}
+	stats, err := sess.Write(ctx, schema, records)
+	if _, cerr := sess.Close(ctx, err); err == nil {
+		err = cerr
 	}
+	return stats.Written(), err
+}
 
-	// On replace mode, delete all existing rows first.
-	if mode == SyncReplace {
-		if err := w.Store.DeleteRowsByDatabase(targetID); err != nil {
-			return 0, fmt.Errorf("clear target: %w", err)
-		}
-		// Reset columns to exactly match the output schema.
-		if err := w.resetColumns(targetID, schema); err != nil {
-			return 0, fmt.Errorf("reset columns: %w", err)
-		}
-	} else {
-		// Append mode: add any missing columns.
-		if err := w.ensureColumns(targetID, schema); err != nil {
-			return 0, fmt.Errorf("ensure columns: %w", err)
-		}
+// localDBSession tracks per-run state while batches are written to a LocalDB.
+// Rows are staged rather than written, and the column changes are kept in
+// memory: Close applies both in one store transaction when the run succeeds
+// and discards them when it fails, so a failed run leaves the target as it
+// was. A run that produces no records never touches the target.
+type localDBSession struct {
+	w        *LocalDBWriter
+	targetID string
+	opts     WriteOptions
+
+	started   bool
+	stageID   string
+	config    map[string]any    // target config with this run's column changes
+	colMap    map[string]string // column name → column ID
+	sortOrder int
+
+	// merge mode state
+	index   map[string]string   // merge key → existing row ID
+	unkeyed []string            // existing rows with a null merge key
+	seen    map[string]struct{} // merge keys present in this run
+	staged  map[string]struct{} // IDs of rows staged by earlier batches
+}
+
+func (s *localDBSession) Write(ctx context.Context, schema *Schema, records []Record) (WriteStats, error) {
+	var stats WriteStats
+	if len(records) == 0 {
+		return stats, nil
 	}
 
-	// Resolve column name → column ID mapping.
-	colMap, err := w.columnNameToID(targetID)
-	if err != nil {
-		return 0, fmt.Errorf("resolve columns: %w", err)
+	if err := s.prepare(schema); err != nil {
+		return stats, err
 	}
 
-	// Bulk insert records.
-	written := 0
+	batch := &stagedBatch{byID: make(map[string]int)}
 	for i, rec := range records {
 		select {
 		case <-ctx.Done():
-			return written, ctx.Err()
+			return stats, ctx.Err()
 		default:
 		}
 
 		// Map field names to column IDs.
 		rowData := make(map[string]any, len(rec.Data))
 		for k, v := range rec.Data {
-			if colID, ok := colMap[k]; ok {
+			if colID, ok := s.colMap[k]; ok {
 				rowData[colID] = v
 			}
 		}
 
-		dataJSON, _ := json.Marshal(rowData)
-		row := &domain.LocalDBRow{
-			ID:         uuid.New().String(),
-			DatabaseID: targetID,
-			DataJSON:   string(dataJSON),
-			SortOrder:  i + 1,
+		if s.opts.Mode == SyncMerge {
+			outcome, err := s.upsert(batch, rowData)
+			if err != nil {
+				return stats, fmt.Errorf("merge row %d: %w", i, err)
+			}
+			switch outcome {
+			case mergeInserted:
+				stats.Inserted++
+			case mergeUpdated:
+				stats.Updated++
+			}
+			continue
+		}
+
+		if _, err := s.insert(batch, rowData); err != nil {
+			return stats, fmt.Errorf("create row %d: %w", i, err)
 		}
-		if err := w.Store.CreateRow(row); err != nil {
-			return written, fmt.Errorf("create row %d: %w", i, err)
+		stats.Inserted++
+	}
+
+	if err := s.w.Store.StageRows(s.stageID, batch.rows); err != nil {
+		return WriteStats{}, fmt.Errorf("stage rows: %w", err)
+	}
+	if s.staged != nil {
+		for id := range batch.byID {
+			s.staged[id] = struct{}{}
 		}
-		written++
 	}
+	return stats, nil
+}
 
-	return written, nil
+// stagedBatch collects the rows one Write stages, indexed by row ID so a
+// merge key repeated within the batch updates the row staged for it.
+type stagedBatch struct {
+	rows []domain.LocalDBRow
+	byID map[string]int
 }
 
-// resetColumns replaces all columns in the LocalDB config to exactly match the schema.
-// Used in replace mode so the target structure matches the transformed output.
-func (w *LocalDBWriter) resetColumns(dbID string, schema *Schema) error {
-	db, err := w.Store.GetDatabase(dbID)
+func (b *stagedBatch) put(row domain.LocalDBRow) {
+	if i, ok := b.byID[row.ID]; ok {
+		b.rows[i] = row
+		return
+	}
+	b.byID[row.ID] = len(b.rows)
+	b.rows = append(b.rows, row)
+}
+
+// insert stages a new row and returns its ID.
+func (s *localDBSession) insert(batch *stagedBatch, rowData map[string]any) (string, error) {
+	dataJSON, err := json.Marshal(rowData)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("encode row: %w", err)
+	}
+	s.sortOrder++
+	row := domain.LocalDBRow{
+		ID:         uuid.New().String(),
+		DatabaseID: s.targetID,
+		DataJSON:   string(dataJSON),
+		SortOrder:  s.sortOrder,
 	}
+	batch.put(row)
+	return row.ID, nil
+}
+
+// mergeOutcome is what upsert did with a record.
+type mergeOutcome int
 
-	// Parse existing config to preserve non-column fields (e.g. activeView).
-	var raw map[string]any
-	if err := json.Unmarshal([]byte(db.ConfigJSON), &raw); err != nil {
-		raw = make(map[string]any)
+const (
+	mergeInserted  mergeOutcome = iota // no row had the key: a new one was staged
+	mergeUpdated                       // the row with the key changed
+	mergeUnchanged                     // the row with the key already held the data
+)
+
+// upsert stages an update of the row matching rowData's merge key, or a new
+// row. Columns missing from the incoming record keep their existing values,
+// and a row the record would not change is left alone.
+func (s *localDBSession) upsert(batch *stagedBatch, rowData map[string]any) (mergeOutcome, error) {
+	key, err := s.mergeKey(rowData)
+	if err != nil {
+		return 0, err
 	}
+	s.seen[key] = struct{}{}
 
-	// Build new columns from schema.
-	cols := make([]map[string]any, 0, len(schema.Fields))
-	for _, f := range schema.Fields {
-		cols = append(cols, map[string]any{
-			"id":    uuid.New().String(),
-			"name":  f.Name,
-			"type":  mapFieldType(f.Type),
-			"width": 150,
-		})
+	rowID, ok := s.index[key]
+	if !ok {
+		id, err := s.insert(batch, rowData)
+		if err != nil {
+			return 0, err
+		}
+		s.index[key] = id
+		return mergeInserted, nil
+	}
+
+	row, err := s.currentRow(batch, rowID)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := decodeRowData(row.DataJSON)
+	if err != nil {
+		return 0, fmt.Errorf("decode row %s: %w", rowID, err)
+	}
+	before, err := json.Marshal(existing)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range rowData {
+		existing[k] = v
+	}
+	after, err := json.Marshal(existing)
+	if err != nil {
+		return 0, fmt.Errorf("encode row: %w", err)
 	}
-	raw["columns"] = cols
+	if string(after) == string(before) {
+		return mergeUnchanged, nil
+	}
+	row.DataJSON = string(after)
+	batch.put(*row)
+	return mergeUpdated, nil
+}
+
+// decodeRowData decodes a stored row, keeping numbers as written so they
+// re-encode exactly.
+func decodeRowData(dataJSON string) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(dataJSON))
+	dec.UseNumber()
+	data := make(map[string]any)
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// currentRow returns the latest version of a row: staged by this batch or an
+// earlier one, or else as stored in the target.
+func (s *localDBSession) currentRow(batch *stagedBatch, rowID string) (*domain.LocalDBRow, error) {
+	if i, ok := batch.byID[rowID]; ok {
+		row := batch.rows[i]
+		return &row, nil
+	}
+	if _, ok := s.staged[rowID]; ok {
+		return s.w.Store.GetStagedRow(s.stageID, rowID)
+	}
+	return s.w.Store.GetRow(rowID)
+}
 
-	configBytes, _ := json.Marshal(raw)
-	db.ConfigJSON = string(configBytes)
-	db.UpdatedAt = time.Now()
-	return w.Store.UpdateDatabase(db)
+// mergeKey builds the composite key for a row keyed by column ID. Each part
+// is the key value's JSON encoding, as the row stores it, so a record matches
+// the row it was written as whatever Go type its source produced. A key
+// column that is missing or null cannot match anything and is an error.
+func (s *localDBSession) mergeKey(rowData map[string]any) (string, error) {
+	parts := make([]string, len(s.opts.MergeKeys))
+	for i, name := range s.opts.MergeKeys {
+		v, ok := rowData[s.colMap[name]]
+		if !ok || v == nil {
+			return "", fmt.Errorf("merge key %q is null", name)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("merge key %q: %w", name, err)
+		}
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, "\x1f"), nil
 }
 
-// ensureColumns adds any missing columns to the LocalDB config.
-func (w *LocalDBWriter) ensureColumns(dbID string, schema *Schema) error {
-	db, err := w.Store.GetDatabase(dbID)
+// buildIndex loads the merge key of every existing row. Rows with a null key
+// are set aside: no record can match them.
+func (s *localDBSession) buildIndex() error {
+	rows, err := s.w.Store.ListRows(s.targetID)
 	if err != nil {
 		return err
 	}
+	s.index = make(map[string]string, len(rows))
+	s.seen = make(map[string]struct{})
+	s.staged = make(map[string]struct{})
+	for _, r := range rows {
+		if r.SortOrder > s.sortOrder {
+			s.sortOrder = r.SortOrder
+		}
+		data, err := decodeRowData(r.DataJSON)
+		if err != nil {
+			continue
+		}
+		key, err := s.mergeKey(data)
+		if err != nil {
+			s.unkeyed = append(s.unkeyed, r.ID)
+			continue
+		}
+		s.index[key] = r.ID
+	}
+	return nil
+}
 
-	var cfg struct {
-		Columns    []map[string]any `json:"columns"`
-		ActiveView string           `json:"activeView"`
+// Close commits the staged rows and column changes when the run succeeded,
+// deleting rows missing from the source in merge mode, and discards them
+// otherwise. Failed runs never delete: a partial read would look like
+// missing rows.
+func (s *localDBSession) Close(ctx context.Context, runErr error) (WriteStats, error) {
+	var stats WriteStats
+	if !s.started {
+		return stats, nil
+	}
+	if runErr != nil {
+		return stats, s.w.Store.DiscardStage(s.stageID)
+	}
+
+	commit := domain.LocalDBStageCommit{
+		DatabaseID: s.targetID,
+		ClearRows:  s.opts.Mode == SyncReplace,
+	}
+	configBytes, err := json.Marshal(s.config)
+	if err != nil {
+		s.w.Store.DiscardStage(s.stageID)
+		return stats, fmt.Errorf("encode config: %w", err)
+	}
+	commit.ConfigJSON = string(configBytes)
+	commit.DeleteRowIDs = s.missingRowIDs()
+	if err := s.w.Store.CommitStage(s.stageID, commit); err != nil {
+		s.w.Store.DiscardStage(s.stageID)
+		return stats, fmt.Errorf("commit: %w", err)
+	}
+	stats.Deleted = len(commit.DeleteRowIDs)
+	return stats, nil
+}
+
+// missingRowIDs lists the existing rows a merge with DeleteMissing removes:
+// those whose key no record had, and those without a key, which cannot be in
+// the source.
+func (s *localDBSession) missingRowIDs() []string {
+	if s.opts.Mode != SyncMerge || !s.opts.DeleteMissing {
+		return nil
+	}
+	ids := slices.Clone(s.unkeyed)
+	for key, rowID := range s.index {
+		if _, ok := s.seen[key]; !ok {
+			ids = append(ids, rowID)
+		}
 	}
-	if err := json.Unmarshal([]byte(db.ConfigJSON), &cfg); err != nil {
-		return fmt.Errorf("parse config: %w", err)
+	return ids
+}
+
+// prepare readies the run for a batch: the first batch loads the target's
+// config and resets its columns (replace mode), adds missing columns (append
+// modes), or also indexes existing rows by key (merge mode); later batches
+// only add columns for fields that have not been seen yet.
+func (s *localDBSession) prepare(schema *Schema) error {
+	if !s.started {
+		db, err := s.w.Store.GetDatabase(s.targetID)
+		if err != nil {
+			return err
+		}
+		// Keep non-column fields (e.g. activeView) as they are.
+		if err := json.Unmarshal([]byte(db.ConfigJSON), &s.config); err != nil || s.config == nil {
+			if s.opts.Mode != SyncReplace {
+				return fmt.Errorf("parse config: %w", err)
+			}
+			s.config = make(map[string]any)
+		}
+		s.started = true
+		s.stageID = uuid.New().String()
+
+		if s.opts.Mode == SyncReplace {
+			// Reset columns to exactly match the output schema.
+			s.config["columns"] = []any{}
+		}
+		s.addColumns(schema)
+		if s.opts.Mode == SyncMerge {
+			for _, k := range s.opts.MergeKeys {
+				if _, ok := s.colMap[k]; !ok {
+					return fmt.Errorf("merge key %q is not a column in the output", k)
+				}
+			}
+			if err := s.buildIndex(); err != nil {
+				return fmt.Errorf("index existing rows: %w", err)
+			}
+		}
+		return nil
 	}
 
-	// Build set of existing column names.
-	existing := make(map[string]bool)
-	for _, col := range cfg.Columns {
-		if name, ok := col["name"].(string); ok {
-			existing[name] = true
+	for _, f := range schema.Fields {
+		if _, ok := s.colMap[f.Name]; !ok {
+			s.addColumns(schema)
+			return nil
 		}
 	}
+	return nil
+}
 
-	// Add missing fields from schema.
+// addColumns adds a column for every schema field the config lacks and
+// refreshes the column name → column ID mapping.
+func (s *localDBSession) addColumns(schema *Schema) {
+	cols, _ := s.config["columns"].([]any)
+	s.colMap = make(map[string]string, len(cols))
+	for _, col := range cols {
+		m, _ := col.(map[string]any)
+		name, _ := m["name"].(string)
+		id, _ := m["id"].(string)
+		if name != "" && id != "" {
+			s.colMap[name] = id
+		}
+	}
 	for _, f := range schema.Fields {
-		if existing[f.Name] {
+		if _, ok := s.colMap[f.Name]; ok {
 			continue
 		}
-		cfg.Columns = append(cfg.Columns, map[string]any{
-			"id":    uuid.New().String(),
+		id := uuid.New().String()
+		cols = append(cols, map[string]any{
+			"id":    id,
 			"name":  f.Name,
 			"type":  mapFieldType(f.Type),
 			"width": 150,
 		})
+		s.colMap[f.Name] = id
 	}
-
-	configBytes, _ := json.Marshal(cfg)
-	db.ConfigJSON = string(configBytes)
-	db.UpdatedAt = time.Now()
-	return w.Store.UpdateDatabase(db)
+	s.config["columns"] = cols
 }
 
-// columnNameToID builds a mapping from column name to column ID.
-func (w *LocalDBWriter) columnNameToID(dbID string) (map[string]string, error) {
-	db, err := w.Store.GetDatabase(dbID)
-	if err != nil {
-		return nil, err
+// Evolve changes the type of the columns named after fields whose type
+// changed and stages the target's existing rows with the values in those
+// columns converted, so the new types are committed, or discarded, together
+// with the run's rows. It implements SchemaEvolver.
+func (s *localDBSession) Evolve(changes []SchemaChange) error {
+	// Replace mode recreates the columns from the output schema and drops the
+	// old rows anyway.
+	if !s.started || s.opts.Mode == SyncReplace {
+		return nil
 	}
-
-	var cfg struct {
-		Columns []map[string]any `json:"columns"`
+	cols, _ := s.config["columns"].([]any)
+	retyped := make(map[string]string) // column ID → new field type
+	for _, c := range changes {
+		if c.Change != FieldTypeChanged {
+			continue
+		}
+		for _, col := range cols {
+			m, ok := col.(map[string]any)
+			if !ok || m["name"] != c.Field {
+				continue
+			}
+			if id, _ := m["id"].(string); id != "" {
+				m["type"] = mapFieldType(c.NewType)
+				retyped[id] = c.NewType
+			}
+		}
 	}
-	if err := json.Unmarshal([]byte(db.ConfigJSON), &cfg); err != nil {
-		return nil, err
+	if len(retyped) == 0 {
+		return nil
 	}
 
-	m := make(map[string]string)
-	for _, col := range cfg.Columns {
-		name, _ := col["name"].(string)
-		id, _ := col["id"].(string)
-		if name != "" && id != "" {
-			m[name] = id
+	rows, err := s.w.Store.ListRows(s.targetID)
+	if err != nil {
+		return err
+	}
+	deleted := make(map[string]bool)
+	for _, id := range s.missingRowIDs() {
+		deleted[id] = true
+	}
+	batch := &stagedBatch{byID: make(map[string]int)}
+	for _, r := range rows {
+		if deleted[r.ID] {
+			continue
 		}
+		row := &r
+		if _, ok := s.staged[r.ID]; ok {
+			if row, err = s.w.Store.GetStagedRow(s.stageID, r.ID); err != nil {
+				return err
+			}
+		}
+		var data map[string]any
+		if err := json.Unmarshal([]byte(row.DataJSON), &data); err != nil {
+			continue
+		}
+		changed := false
+		for id, typ := range retyped {
+			if v, ok := data[id]; ok {
+				data[id] = coerceValue(v, typ)
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		dataJSON, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("encode row %s: %w", row.ID, err)
+		}
+		row.DataJSON = string(dataJSON)
+		batch.put(*row)
+	}
+	if err := s.w.Store.StageRows(s.stageID, batch.rows); err != nil {
+		return fmt.Errorf("stage rows: %w", err)
 	}
-	return m, nil
+	return nil
 }
 
 // mapFieldType converts ETL field types to LocalDB column types.
@@ -0,0 +1,221 @@
+package destinations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"notes/internal/etl"
+)
+
+var testSchema = &etl.Schema{Fields: []etl.Field{{Name: "id", Type: "number"}, {Name: "name", Type: "text"}}}
+
+func recs(data ...map[string]any) []etl.Record {
+	out := make([]etl.Record, len(data))
+	for i, d := range data {
+		out[i] = etl.Record{Data: d}
+	}
+	return out
+}
+
+// runDest opens a session on a registered destination, writes the batches
+// and closes it with runErr.
+func runDest(t *testing.T, typ string, cfg etl.DestinationConfig, mode etl.SyncMode, runErr error, schema *etl.Schema, batches ...[]etl.Record) (int, error) {
+	t.Helper()
+	ctx := context.Background()
+	d, err := etl.GetDestination(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, err := d.Open(ctx, cfg, etl.WriteOptions{Mode: mode})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	written := 0
+	for _, b := range batches {
+		stats, err := sess.Write(ctx, schema, b)
+		written += stats.Inserted
+		if err != nil {
+			sess.Close(ctx, err)
+			return written, err
+		}
+	}
+	stats, err := sess.Close(ctx, runErr)
+	return written + stats.Inserted, err
+}
+
+// fakeSQL serves one SQLite database for every connection.
+type fakeSQL struct{ db *sql.DB }
+
+func (f fakeSQL) OpenETLSQL(ctx context.Context, connID string) (*sql.DB, string, error) {
+	return f.db, "sqlite", nil
+}
+
+func TestSQLDestination(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "out.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	SetSQLProvider(fakeSQL{db})
+	t.Cleanup(func() { SetSQLProvider(nil) })
+	cfg := etl.DestinationConfig{"connectionId": "c1", "table": "people"}
+
+	count := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM people`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Auto-created table, written in several statements.
+	var many []etl.Record
+	for i := range 1000 {
+		many = append(many, etl.Record{Data: map[string]any{"id": float64(i), "name": "n"}})
+	}
+	if n, err := runDest(t, "sql", cfg, etl.SyncReplace, nil, testSchema, many); err != nil || n != 1000 {
+		t.Fatalf("replace: n=%d err=%v", n, err)
+	}
+	if count() != 1000 {
+		t.Fatalf("rows = %d", count())
+	}
+
+	// Replace clears the table; a new field adds a column.
+	wide := &etl.Schema{Fields: append(testSchema.Fields, etl.Field{Name: "tags", Type: "text"})}
+	if _, err := runDest(t, "sql", cfg, etl.SyncReplace, nil, wide, recs(map[string]any{"id": 1.0, "name": "ada", "tags": []any{"x"}})); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	var name, tags string
+	if err := db.QueryRow(`SELECT name, tags FROM people`).Scan(&name, &tags); err != nil || name != "ada" || tags != `["x"]` {
+		t.Errorf("row = %q %q err=%v", name, tags, err)
+	}
+
+	// A failed run rolls back its writes.
+	if _, err := runDest(t, "sql", cfg, etl.SyncAppend, errors.New("boom"), testSchema, recs(map[string]any{"id": 2.0, "name": "bob"})); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if count() != 1 {
+		t.Errorf("rows after failed append = %d, want 1", count())
+	}
+
+	// A failed replace keeps the rows; the column its first batch added is
+	// created ahead of the transaction and stays.
+	wider := &etl.Schema{Fields: append(wide.Fields, etl.Field{Name: "score", Type: "number"})}
+	if _, err := runDest(t, "sql", cfg, etl.SyncReplace, errors.New("boom"), wider, recs(map[string]any{"id": 3.0, "score": 1.0})); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	var score sql.NullFloat64
+	if err := db.QueryRow(`SELECT name, score FROM people`).Scan(&name, &score); err != nil || name != "ada" || score.Valid {
+		t.Errorf("row after failed replace = %q %v err=%v", name, score, err)
+	}
+	if _, err := runDest(t, "sql", cfg, etl.SyncAppend, nil, testSchema, recs(map[string]any{"id": 2.0, "name": "bob"})); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if count() != 2 {
+		t.Errorf("rows after append = %d, want 2", count())
+	}
+}
+
+func TestFileDestinations(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "out.csv")
+	cfg := etl.DestinationConfig{"filePath": csvPath}
+
+	if _, err := runDest(t, "csv_file", cfg, etl.SyncReplace, nil, testSchema,
+		recs(map[string]any{"id": 1.0, "name": "ada"}), recs(map[string]any{"id": 2.5, "name": "b,c"})); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	want := "id,name\n1,ada\n2.5,\"b,c\"\n"
+	if b, _ := os.ReadFile(csvPath); string(b) != want {
+		t.Errorf("csv = %q, want %q", b, want)
+	}
+
+	// Appends follow the existing header; a failed append is cut back off.
+	other := &etl.Schema{Fields: []etl.Field{{Name: "name", Type: "text"}, {Name: "extra", Type: "text"}}}
+	if _, err := runDest(t, "csv_file", cfg, etl.SyncAppend, nil, other, recs(map[string]any{"name": "cy", "extra": "x"})); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if _, err := runDest(t, "csv_file", cfg, etl.SyncAppend, errors.New("boom"), other, recs(map[string]any{"name": "dee"})); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	want += ",cy\n"
+	if b, _ := os.ReadFile(csvPath); string(b) != want {
+		t.Errorf("csv = %q, want %q", b, want)
+	}
+
+	// A failed replace keeps the previous file and leaves no temp file.
+	if _, err := runDest(t, "csv_file", cfg, etl.SyncReplace, errors.New("boom"), testSchema, recs(map[string]any{"id": 9.0})); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if b, _ := os.ReadFile(csvPath); string(b) != want {
+		t.Errorf("csv after failed replace = %q", b)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("dir has %d entries, want 1", len(entries))
+	}
+
+	jsonlPath := filepath.Join(dir, "out.jsonl")
+	jcfg := etl.DestinationConfig{"filePath": jsonlPath}
+	for range 2 {
+		if _, err := runDest(t, "jsonl_file", jcfg, etl.SyncAppend, nil, testSchema, recs(map[string]any{"id": 1.0, "nested": map[string]any{"a": true}})); err != nil {
+			t.Fatalf("jsonl: %v", err)
+		}
+	}
+	if b, _ := os.ReadFile(jsonlPath); string(b) != strings.Repeat(`{"id":1,"nested":{"a":true}}`+"\n", 2) {
+		t.Errorf("jsonl = %q", b)
+	}
+}
+
+func TestHTTPDestination(t *testing.T) {
+	var batches [][]map[string]any
+	var auth string
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		var batch []map[string]any
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Errorf("body: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer srv.Close()
+
+	cfg := etl.DestinationConfig{"url": srv.URL, "batchSize": "2", "headers": `{"Authorization": "Bearer t"}`}
+	var data []map[string]any
+	for i := range 5 {
+		data = append(data, map[string]any{"id": float64(i)})
+	}
+	n, err := runDest(t, "http", cfg, etl.SyncAppend, nil, testSchema, recs(data[:3]...), recs(data[3:]...))
+	if err != nil || n != 5 {
+		t.Fatalf("n=%d err=%v", n, err)
+	}
+	if len(batches) != 3 || len(batches[0]) != 2 || len(batches[2]) != 1 || auth != "Bearer t" {
+		t.Errorf("batches = %v, auth = %q", batches, auth)
+	}
+
+	fail = true
+	_, err = runDest(t, "http", cfg, etl.SyncAppend, nil, testSchema, recs(data...))
+	if err == nil || !strings.Contains(err.Error(), "429") || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("err = %v, want HTTP 429 with body", err)
+	}
+
+	if err := etl.ValidateDestination("http", cfg, etl.SyncReplace); err == nil {
+		t.Error("replace mode should be rejected for http")
+	}
+}
@@ -0,0 +1,261 @@
+package destinations
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"notes/internal/etl"
+)
+
+// ── File Destinations ──────────────────────────────────────
+// Write records to a local CSV or JSON Lines file. In replace mode the output
+// goes to a temporary file next to the target, renamed over it when the run
+// succeeds. In append mode records are added to the end of the file, and a
+// failed run truncates the file back to its original size.
+
+func init() {
+	etl.RegisterDestination(&fileDestination{format: "csv"})
+	etl.RegisterDestination(&fileDestination{format: "jsonl"})
+}
+
+// fileDestination handles both file formats.
+type fileDestination struct {
+	format string // "csv" | "jsonl"
+}
+
+func (d *fileDestination) Spec() etl.DestinationSpec {
+	spec := etl.DestinationSpec{
+		Type:  "csv_file",
+		Label: "CSV File",
+		Icon:  "IconFileTypeCsv",
+		ConfigFields: []etl.ConfigField{
+			{Key: "filePath", Label: "File Path", Type: "string", Required: true, Help: "Output file, created if it does not exist"},
+		},
+		Modes: []etl.SyncMode{etl.SyncReplace, etl.SyncAppend, etl.SyncIncremental},
+	}
+	if d.format == "jsonl" {
+		spec.Type, spec.Label, spec.Icon = "jsonl_file", "JSON Lines File", "IconBraces"
+	}
+	return spec
+}
+
+func (d *fileDestination) Open(ctx context.Context, cfg etl.DestinationConfig, opts etl.WriteOptions) (etl.WriteSession, error) {
+	path := strings.TrimSpace(cfgString(cfg, "filePath", ""))
+	if path == "" {
+		return nil, fmt.Errorf("filePath is required")
+	}
+	if st, err := os.Stat(filepath.Dir(path)); err != nil || !st.IsDir() {
+		return nil, fmt.Errorf("output directory %s does not exist", filepath.Dir(path))
+	}
+	return &fileSession{format: d.format, path: path, replace: opts.Mode == etl.SyncReplace}, nil
+}
+
+// fileSession opens the output on the first batch, so that a run that fails
+// before producing records leaves the file untouched.
+type fileSession struct {
+	format  string
+	path    string
+	replace bool
+
+	f       *os.File
+	w       *bufio.Writer
+	csv     *csv.Writer
+	header  []string // CSV columns, fixed by the first batch or the existing file
+	origLen int64    // size of the file before an append, for rollback
+}
+
+func (s *fileSession) Write(ctx context.Context, schema *etl.Schema, records []etl.Record) (etl.WriteStats, error) {
+	var stats etl.WriteStats
+	if len(records) == 0 {
+		return stats, nil
+	}
+	if s.f == nil {
+		if err := s.open(schema); err != nil {
+			return stats, err
+		}
+	}
+	for _, rec := range records {
+		if err := s.writeRecord(rec); err != nil {
+			return stats, fmt.Errorf("write %s: %w", s.path, err)
+		}
+		stats.Inserted++
+	}
+	return stats, nil
+}
+
+// open creates the temporary file (replace) or opens the target for
+// appending, reading the header of an existing CSV file.
+func (s *fileSession) open(schema *etl.Schema) error {
+	var err error
+	if s.replace {
+		s.f, err = os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*.tmp")
+		if err != nil {
+			return fmt.Errorf("create temp file: %w", err)
+		}
+	} else {
+		s.f, err = os.OpenFile(s.path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", s.path, err)
+		}
+		if s.origLen, err = s.f.Seek(0, io.SeekEnd); err != nil {
+			return fmt.Errorf("seek %s: %w", s.path, err)
+		}
+		if s.format == "csv" && s.origLen > 0 {
+			if err := s.readHeader(); err != nil {
+				return err
+			}
+		}
+	}
+	s.w = bufio.NewWriter(s.f)
+
+	if s.format == "csv" {
+		s.csv = csv.NewWriter(s.w)
+		if s.header == nil {
+			s.header = make([]string, len(schema.Fields))
+			for i, f := range schema.Fields {
+				s.header[i] = f.Name
+			}
+			if err := s.csv.Write(s.header); err != nil {
+				return fmt.Errorf("write header: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// readHeader takes the columns of an existing CSV file, so appended rows line
+// up with it. A file not ending in a newline gets one first.
+func (s *fileSession) readHeader() error {
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek %s: %w", s.path, err)
+	}
+	header, err := csv.NewReader(bufio.NewReader(s.f)).Read()
+	if err != nil {
+		return fmt.Errorf("read header of %s: %w", s.path, err)
+	}
+	s.header = header
+
+	last := make([]byte, 1)
+	if _, err := s.f.ReadAt(last, s.origLen-1); err != nil {
+		return fmt.Errorf("read %s: %w", s.path, err)
+	}
+	if _, err := s.f.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("seek %s: %w", s.path, err)
+	}
+	if last[0] != '\n' {
+		if _, err := s.f.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("write %s: %w", s.path, err)
+		}
+	}
+	return nil
+}
+
+// writeRecord writes one line. CSV rows follow the header; fields missing
+// from it are dropped.
+func (s *fileSession) writeRecord(rec etl.Record) error {
+	if s.format == "jsonl" {
+		b, err := json.Marshal(rec.Data)
+		if err != nil {
+			return err
+		}
+		if _, err := s.w.Write(b); err != nil {
+			return err
+		}
+		return s.w.WriteByte('\n')
+	}
+	row := make([]string, len(s.header))
+	for i, name := range s.header {
+		row[i] = csvValue(rec.Data[name])
+	}
+	return s.csv.Write(row)
+}
+
+// Close flushes the output and publishes it, or discards it if the run failed.
+func (s *fileSession) Close(ctx context.Context, runErr error) (etl.WriteStats, error) {
+	if s.f == nil {
+		// Nothing written: a successful replace still empties the target.
+		if runErr == nil && s.replace {
+			if err := os.WriteFile(s.path, nil, 0o644); err != nil {
+				return etl.WriteStats{}, fmt.Errorf("write %s: %w", s.path, err)
+			}
+		}
+		return etl.WriteStats{}, nil
+	}
+	if runErr != nil {
+		s.discard()
+		return etl.WriteStats{}, nil
+	}
+	if err := s.flush(); err != nil {
+		s.discard()
+		return etl.WriteStats{}, fmt.Errorf("write %s: %w", s.path, err)
+	}
+	if s.replace {
+		tmp := s.f.Name()
+		if err := s.f.Close(); err != nil {
+			os.Remove(tmp)
+			return etl.WriteStats{}, fmt.Errorf("write %s: %w", s.path, err)
+		}
+		if err := os.Rename(tmp, s.path); err != nil {
+			os.Remove(tmp)
+			return etl.WriteStats{}, fmt.Errorf("replace %s: %w", s.path, err)
+		}
+		return etl.WriteStats{}, nil
+	}
+	if err := s.f.Close(); err != nil {
+		return etl.WriteStats{}, fmt.Errorf("write %s: %w", s.path, err)
+	}
+	return etl.WriteStats{}, nil
+}
+
+func (s *fileSession) flush() error {
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+// discard removes the temporary file, or cuts an appended file back to its
+// original length.
+func (s *fileSession) discard() {
+	if s.replace {
+		s.f.Close()
+		os.Remove(s.f.Name())
+		return
+	}
+	s.f.Truncate(s.origLen)
+	s.f.Close()
+}
+
+// csvValue renders a record value as a CSV cell.
+func csvValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case int:
+		return strconv.Itoa(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
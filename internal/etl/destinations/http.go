@@ -0,0 +1,156 @@
+package destinations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── HTTP Destination ───────────────────────────────────────
+// POSTs records to a webhook or API endpoint as JSON arrays of up to
+// batchSize records. Requests that were sent cannot be taken back, so a
+// failed run may have delivered part of its output; the final partial batch
+// is only sent when the run succeeds.
+
+// httpClient is shared by all HTTP destination sessions.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// defaultHTTPBatchSize is the number of records per request.
+const defaultHTTPBatchSize = 100
+
+type httpDestination struct{}
+
+func init() { etl.RegisterDestination(&httpDestination{}) }
+
+func (d *httpDestination) Spec() etl.DestinationSpec {
+	return etl.DestinationSpec{
+		Type:  "http",
+		Label: "HTTP POST",
+		Icon:  "IconWebhook",
+		ConfigFields: []etl.ConfigField{
+			{Key: "url", Label: "URL", Type: "string", Required: true, Help: "Endpoint receiving a JSON array of records per request"},
+			{Key: "headers", Label: "Headers", Type: "textarea", Required: false, Sensitive: true, Help: "JSON object of headers (e.g., {\"Authorization\": \"Bearer ${secret:webhook-token}\"})"},
+			{Key: "batchSize", Label: "Batch Size", Type: "string", Required: false, Default: strconv.Itoa(defaultHTTPBatchSize), Help: "Records per request"},
+		},
+		Modes: []etl.SyncMode{etl.SyncAppend, etl.SyncIncremental},
+	}
+}
+
+func (d *httpDestination) Open(ctx context.Context, cfg etl.DestinationConfig, opts etl.WriteOptions) (etl.WriteSession, error) {
+	url := strings.TrimSpace(cfgString(cfg, "url", ""))
+	if url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("url must start with http:// or https://")
+	}
+	headers := map[string]string{}
+	if h := strings.TrimSpace(cfgString(cfg, "headers", "")); h != "" {
+		if err := json.Unmarshal([]byte(h), &headers); err != nil {
+			return nil, fmt.Errorf("invalid headers JSON: %w", err)
+		}
+	}
+	size := cfgInt(cfg, "batchSize", defaultHTTPBatchSize)
+	if size <= 0 {
+		return nil, fmt.Errorf("batch size must be positive")
+	}
+	return &httpSession{url: url, headers: headers, size: size}, nil
+}
+
+// httpSession buffers records and sends them in batchSize requests.
+type httpSession struct {
+	url     string
+	headers map[string]string
+	size    int
+
+	buf []map[string]any
+}
+
+func (s *httpSession) Write(ctx context.Context, schema *etl.Schema, records []etl.Record) (etl.WriteStats, error) {
+	var stats etl.WriteStats
+	for _, rec := range records {
+		s.buf = append(s.buf, rec.Data)
+		if len(s.buf) >= s.size {
+			n, err := s.flush(ctx)
+			stats.Inserted += n
+			if err != nil {
+				return stats, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+// Close sends the last partial batch of a successful run.
+func (s *httpSession) Close(ctx context.Context, runErr error) (etl.WriteStats, error) {
+	if runErr != nil {
+		return etl.WriteStats{}, nil
+	}
+	n, err := s.flush(ctx)
+	return etl.WriteStats{Inserted: n}, err
+}
+
+// flush POSTs the buffered records and reports how many were delivered.
+func (s *httpSession) flush(ctx context.Context) (int, error) {
+	if len(s.buf) == 0 {
+		return 0, nil
+	}
+	body, err := json.Marshal(s.buf)
+	if err != nil {
+		return 0, fmt.Errorf("encode records: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("post: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	n := len(s.buf)
+	s.buf = s.buf[:0]
+	return n, nil
+}
+
+// cfgString reads a string config value, returning def if missing or empty.
+func cfgString(cfg etl.DestinationConfig, key, def string) string {
+	if v, ok := cfg[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// cfgInt reads an integer config value given either as a number or a string.
+func cfgInt(cfg etl.DestinationConfig, key string, def int) int {
+	switch v := cfg[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
+}
@@ -0,0 +1,306 @@
+package destinations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── SQL Destination ────────────────────────────────────────
+// Writes records into a table of an external Postgres, MySQL or SQLite
+// database, through a saved database connection. The table is created on the
+// first batch if it does not exist, and columns are added as new fields show
+// up. The first batch's table and columns are created before the run's
+// transaction starts, and stay even if the run fails; the rows are written
+// inside the transaction, so a failed run leaves the table's rows as they
+// were. Postgres and SQLite add the columns of later batches inside the
+// transaction too. MySQL commits DDL implicitly: there, a column first seen
+// after the first batch commits the rows written before it.
+
+// SQLProvider gives access to the database handle of a saved connection.
+// The app layer implements this and injects it at startup.
+type SQLProvider interface {
+	// OpenETLSQL returns the handle and driver name ("postgres" | "mysql" | "sqlite").
+	OpenETLSQL(ctx context.Context, connID string) (*sql.DB, string, error)
+}
+
+var sqlProvider SQLProvider
+
+// SetSQLProvider is called by the app at startup.
+func SetSQLProvider(p SQLProvider) { sqlProvider = p }
+
+// maxSQLParams bounds the placeholders in one INSERT, below every driver's limit.
+const maxSQLParams = 900
+
+type sqlDestination struct{}
+
+func init() { etl.RegisterDestination(&sqlDestination{}) }
+
+func (d *sqlDestination) Spec() etl.DestinationSpec {
+	return etl.DestinationSpec{
+		Type:  "sql",
+		Label: "SQL Database",
+		Icon:  "IconDatabaseExport",
+		ConfigFields: []etl.ConfigField{
+			{Key: "connectionId", Label: "Connection", Type: "db_connection", Required: true, Help: "Postgres, MySQL or SQLite connection"},
+			{Key: "table", Label: "Table", Type: "string", Required: true, Help: "Created if it does not exist (e.g., 'orders' or 'analytics.orders')"},
+		},
+		Modes: []etl.SyncMode{etl.SyncReplace, etl.SyncAppend, etl.SyncIncremental},
+	}
+}
+
+func (d *sqlDestination) Open(ctx context.Context, cfg etl.DestinationConfig, opts etl.WriteOptions) (etl.WriteSession, error) {
+	connID := cfgString(cfg, "connectionId", "")
+	table := strings.TrimSpace(cfgString(cfg, "table", ""))
+	if connID == "" || table == "" {
+		return nil, fmt.Errorf("connectionId and table are required")
+	}
+	if sqlProvider == nil {
+		return nil, fmt.Errorf("sql provider not initialized")
+	}
+	db, driver, err := sqlProvider.OpenETLSQL(ctx, connID)
+	if err != nil {
+		return nil, err
+	}
+	dialect, err := newSQLDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlSession{db: db, dialect: dialect, table: table, opts: opts}, nil
+}
+
+// sqlSession writes a run's batches inside one transaction, started by the
+// first non-empty batch so an empty run leaves the table untouched.
+type sqlSession struct {
+	db      *sql.DB
+	dialect sqlDialect
+	table   string
+	opts    etl.WriteOptions
+
+	tx      *sql.Tx
+	columns map[string]string // lower-case name → column name in the table
+}
+
+func (s *sqlSession) Write(ctx context.Context, schema *etl.Schema, records []etl.Record) (etl.WriteStats, error) {
+	var stats etl.WriteStats
+	if len(records) == 0 {
+		return stats, nil
+	}
+	if s.tx == nil {
+		if err := s.begin(ctx, schema); err != nil {
+			return stats, err
+		}
+	} else if err := s.addColumns(ctx, s.tx, schema); err != nil {
+		return stats, err
+	}
+
+	fields := schema.Fields
+	if len(fields) == 0 {
+		return stats, nil
+	}
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = s.dialect.quote(s.columns[strings.ToLower(f.Name)])
+	}
+
+	perStmt := max(1, maxSQLParams/len(fields))
+	for start := 0; start < len(records); start += perStmt {
+		chunk := records[start:min(start+perStmt, len(records))]
+		var q strings.Builder
+		fmt.Fprintf(&q, "INSERT INTO %s (%s) VALUES ", s.dialect.quoteTable(s.table), strings.Join(names, ", "))
+		args := make([]any, 0, len(chunk)*len(fields))
+		for i, rec := range chunk {
+			if i > 0 {
+				q.WriteString(", ")
+			}
+			q.WriteByte('(')
+			for j, f := range fields {
+				if j > 0 {
+					q.WriteString(", ")
+				}
+				args = append(args, s.dialect.value(rec.Data[f.Name], f.Type))
+				q.WriteString(s.dialect.placeholder(len(args)))
+			}
+			q.WriteByte(')')
+		}
+		if _, err := s.tx.ExecContext(ctx, q.String(), args...); err != nil {
+			return stats, fmt.Errorf("insert into %s: %w", s.table, err)
+		}
+		stats.Inserted += len(chunk)
+	}
+	return stats, nil
+}
+
+// sqlExecer is a database or a transaction.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// begin creates the table and its columns if needed, then opens the run's
+// transaction and clears the table in replace mode. The DDL runs first and
+// outside the transaction: on MySQL it would commit the DELETE with it.
+func (s *sqlSession) begin(ctx context.Context, schema *etl.Schema) error {
+	cols := make([]string, 0, len(schema.Fields))
+	for _, f := range schema.Fields {
+		cols = append(cols, s.dialect.quote(f.Name)+" "+s.dialect.columnType(f.Type))
+	}
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", s.dialect.quoteTable(s.table), strings.Join(cols, ", "))
+	if _, err := s.db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("create table %s: %w", s.table, err)
+	}
+	if err := s.loadColumns(ctx, s.db); err != nil {
+		return err
+	}
+	if err := s.addColumns(ctx, s.db, schema); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	s.tx = tx
+	if s.opts.Mode == etl.SyncReplace {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+s.dialect.quoteTable(s.table)); err != nil {
+			return fmt.Errorf("clear table %s: %w", s.table, err)
+		}
+	}
+	return nil
+}
+
+// loadColumns reads the table's column names from an empty result set.
+func (s *sqlSession) loadColumns(ctx context.Context, db sqlExecer) error {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+s.dialect.quoteTable(s.table)+" WHERE 1=0")
+	if err != nil {
+		return fmt.Errorf("read columns of %s: %w", s.table, err)
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("read columns of %s: %w", s.table, err)
+	}
+	s.columns = make(map[string]string, len(names))
+	for _, n := range names {
+		s.columns[strings.ToLower(n)] = n
+	}
+	return nil
+}
+
+// addColumns adds a column for every schema field the table lacks.
+func (s *sqlSession) addColumns(ctx context.Context, db sqlExecer, schema *etl.Schema) error {
+	for _, f := range schema.Fields {
+		if _, ok := s.columns[strings.ToLower(f.Name)]; ok {
+			continue
+		}
+		alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", s.dialect.quoteTable(s.table), s.dialect.quote(f.Name), s.dialect.columnType(f.Type))
+		if _, err := db.ExecContext(ctx, alter); err != nil {
+			return fmt.Errorf("add column %s: %w", f.Name, err)
+		}
+		s.columns[strings.ToLower(f.Name)] = f.Name
+	}
+	return nil
+}
+
+// Close commits the run's transaction, or rolls it back if the run failed.
+func (s *sqlSession) Close(ctx context.Context, runErr error) (etl.WriteStats, error) {
+	if s.tx == nil {
+		return etl.WriteStats{}, nil
+	}
+	if runErr != nil {
+		return etl.WriteStats{}, s.tx.Rollback()
+	}
+	if err := s.tx.Commit(); err != nil {
+		return etl.WriteStats{}, fmt.Errorf("commit: %w", err)
+	}
+	return etl.WriteStats{}, nil
+}
+
+// ── Dialects ───────────────────────────────────────────────
+
+// sqlDialect holds the per-driver SQL syntax.
+type sqlDialect struct {
+	driver string
+}
+
+func newSQLDialect(driver string) (sqlDialect, error) {
+	switch driver {
+	case "postgres", "mysql", "sqlite":
+		return sqlDialect{driver: driver}, nil
+	}
+	return sqlDialect{}, fmt.Errorf("unsupported sql driver %q", driver)
+}
+
+func (d sqlDialect) quote(ident string) string {
+	if d.driver == "mysql" {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// quoteTable quotes a table name, keeping a schema prefix ("analytics.orders").
+func (d sqlDialect) quoteTable(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = d.quote(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// value converts a record value for the driver: nested values as JSON, and
+// datetime strings as times so the driver formats them for its column type.
+// SQLite keeps datetimes as RFC 3339 text.
+func (d sqlDialect) value(v any, fieldType string) any {
+	switch t := v.(type) {
+	case string:
+		if fieldType == "datetime" && d.driver != "sqlite" {
+			if ts, err := time.Parse(time.RFC3339, t); err == nil {
+				return ts
+			}
+		}
+		return t
+	case nil, float64, bool, int, int64, time.Time:
+		return t
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func (d sqlDialect) placeholder(n int) string {
+	if d.driver == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// columnType maps a schema field type to a column type.
+func (d sqlDialect) columnType(fieldType string) string {
+	switch fieldType {
+	case "number":
+		switch d.driver {
+		case "postgres":
+			return "DOUBLE PRECISION"
+		case "mysql":
+			return "DOUBLE"
+		}
+		return "REAL"
+	case "boolean":
+		return "BOOLEAN"
+	case "date":
+		return "DATE"
+	case "datetime":
+		switch d.driver {
+		case "postgres":
+			return "TIMESTAMPTZ"
+		case "mysql":
+			return "DATETIME"
+		}
+		return "TEXT"
+	}
+	return "TEXT"
+}
@@ -0,0 +1,175 @@
+package etl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ── Schema Drift ───────────────────────────────────────────
+// Each run compares the schema of its output, as the transforms shaped it,
+// with the output schema of the job's last successful run. Fields that appear
+// are handled as before (new columns are added); fields that change type or
+// disappear are drift, and the job's SchemaPolicy decides what the run does
+// about it before the destination commits. Every change is reported in the
+// run log.
+
+// SchemaPolicy is a job's response to schema drift.
+type SchemaPolicy string
+
+const (
+	// SchemaIgnore records the drift and writes as usual. It is the default.
+	SchemaIgnore SchemaPolicy = "ignore"
+	// SchemaFail fails the run, so the destination discards what it wrote.
+	SchemaFail SchemaPolicy = "fail"
+	// SchemaEvolve changes the type of LocalDB columns named after fields
+	// whose type changed, coercing the values already stored, when the run
+	// commits.
+	SchemaEvolve SchemaPolicy = "evolve"
+)
+
+// Validate rejects unknown policies; "" means SchemaIgnore.
+func (p SchemaPolicy) Validate() error {
+	switch p {
+	case "", SchemaIgnore, SchemaFail, SchemaEvolve:
+		return nil
+	}
+	return fmt.Errorf("unknown schema policy %q (want ignore, fail or evolve)", p)
+}
+
+// Schema change kinds.
+const (
+	FieldAdded       = "added"
+	FieldRemoved     = "removed"
+	FieldTypeChanged = "type_changed"
+)
+
+// SchemaChange is one difference between two output schemas.
+type SchemaChange struct {
+	Field   string `json:"field"`
+	Change  string `json:"change"` // FieldAdded | FieldRemoved | FieldTypeChanged
+	OldType string `json:"oldType,omitempty"`
+	NewType string `json:"newType,omitempty"`
+}
+
+func (c SchemaChange) String() string {
+	switch c.Change {
+	case FieldAdded:
+		return fmt.Sprintf("%s added (%s)", c.Field, c.NewType)
+	case FieldRemoved:
+		return fmt.Sprintf("%s removed (was %s)", c.Field, c.OldType)
+	}
+	return fmt.Sprintf("%s changed from %s to %s", c.Field, c.OldType, c.NewType)
+}
+
+// DiffSchemas lists the changes from prev to cur, in field order: changed and
+// added fields of cur, then removed fields of prev. Without a previous schema,
+// or when the run produced no fields, there is nothing to compare.
+func DiffSchemas(prev, cur *Schema) []SchemaChange {
+	if prev == nil || cur == nil || len(prev.Fields) == 0 || len(cur.Fields) == 0 {
+		return nil
+	}
+	old := make(map[string]string, len(prev.Fields))
+	for _, f := range prev.Fields {
+		old[f.Name] = f.Type
+	}
+	var changes []SchemaChange
+	seen := make(map[string]bool, len(cur.Fields))
+	for _, f := range cur.Fields {
+		seen[f.Name] = true
+		switch typ, ok := old[f.Name]; {
+		case !ok:
+			changes = append(changes, SchemaChange{Field: f.Name, Change: FieldAdded, NewType: f.Type})
+		case typ != f.Type:
+			changes = append(changes, SchemaChange{Field: f.Name, Change: FieldTypeChanged, OldType: typ, NewType: f.Type})
+		}
+	}
+	for _, f := range prev.Fields {
+		if !seen[f.Name] {
+			changes = append(changes, SchemaChange{Field: f.Name, Change: FieldRemoved, OldType: f.Type})
+		}
+	}
+	return changes
+}
+
+// breakingChanges returns the changes a policy acts on: removed fields and
+// type changes.
+func breakingChanges(changes []SchemaChange) []SchemaChange {
+	var out []SchemaChange
+	for _, c := range changes {
+		if c.Change != FieldAdded {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// driftError describes breaking changes for a run failed by SchemaFail.
+func driftError(changes []SchemaChange) error {
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = c.String()
+	}
+	return fmt.Errorf("schema drift: %s", strings.Join(parts, "; "))
+}
+
+// SchemaEvolver is implemented by write sessions that can change the type of
+// existing columns as part of what they commit (see localDBSession.Evolve).
+type SchemaEvolver interface {
+	Evolve(changes []SchemaChange) error
+}
+
+// coerceValue converts a stored value to an ETL field type. Values that do
+// not convert are kept as they are: a wrongly typed value can still be fixed
+// by hand, a lost one cannot.
+func coerceValue(v any, typ string) any {
+	if v == nil {
+		return nil
+	}
+	switch typ {
+	case "number":
+		if f, ok := toFloatSafe(v); ok {
+			return f
+		}
+		if b, ok := v.(bool); ok {
+			if b {
+				return 1.0
+			}
+			return 0.0
+		}
+		return v
+	case "boolean":
+		switch t := v.(type) {
+		case bool:
+			return t
+		case float64:
+			return t != 0
+		case string:
+			switch strings.ToLower(strings.TrimSpace(t)) {
+			case "true", "yes", "1":
+				return true
+			case "false", "no", "0", "":
+				return false
+			}
+		}
+		return v
+	case "date":
+		if t, ok := tryParseTime(v); ok {
+			return t.Format("2006-01-02")
+		}
+		return v
+	case "datetime":
+		if t, ok := tryParseTime(v); ok {
+			return t.Format(time.RFC3339)
+		}
+		return v
+	}
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
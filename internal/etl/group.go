@@ -0,0 +1,300 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ── Group Transform ────────────────────────────────────────
+// GroupTransform collapses the stream into one summary record per distinct
+// combination of group-by values. Like SortTransform it is a batch
+// transform: Transform absorbs every record (returning keep=false), and the
+// engine emits Results once the source is exhausted, running them through
+// the transforms configured after the group.
+//
+// Memory is bounded by the number of groups, except for count_distinct,
+// median and percentile, which keep each group's distinct or numeric values.
+
+// Aggregations supported by GroupMetric.
+const (
+	AggCount         = "count"
+	AggCountDistinct = "count_distinct"
+	AggSum           = "sum"
+	AggAvg           = "avg"
+	AggMin           = "min"
+	AggMax           = "max"
+	AggMedian        = "median"
+	AggPercentile    = "percentile"
+)
+
+// GroupMetric is one aggregate column in a group's summary record.
+type GroupMetric struct {
+	Column     string  // source field; empty for count = number of rows
+	Agg        string  // one of the Agg* constants
+	Percentile float64 // 0–100, for AggPercentile
+	As         string  // output name (default: column_agg, or "count")
+}
+
+// OutputName returns the summary field the metric is written to.
+func (m GroupMetric) OutputName() string {
+	if m.As != "" {
+		return m.As
+	}
+	if m.Column == "" {
+		return m.Agg
+	}
+	if m.Agg == AggPercentile {
+		return fmt.Sprintf("%s_p%s", m.Column, formatPercentile(m.Percentile))
+	}
+	return m.Column + "_" + m.Agg
+}
+
+// numeric reports whether the metric always produces a number.
+func (m GroupMetric) numeric() bool {
+	return m.Agg != AggMin && m.Agg != AggMax
+}
+
+func (m GroupMetric) validate() error {
+	switch m.Agg {
+	case AggCount:
+	case AggCountDistinct, AggSum, AggAvg, AggMin, AggMax, AggMedian:
+		if m.Column == "" {
+			return fmt.Errorf("%s requires a column", m.Agg)
+		}
+	case AggPercentile:
+		if m.Column == "" {
+			return fmt.Errorf("percentile requires a column")
+		}
+		if m.Percentile < 0 || m.Percentile > 100 {
+			return fmt.Errorf("percentile must be between 0 and 100, got %v", m.Percentile)
+		}
+	default:
+		return fmt.Errorf("unknown aggregation %q", m.Agg)
+	}
+	return nil
+}
+
+// GroupTransform aggregates records by Keys.
+type GroupTransform struct {
+	Keys    []string
+	Metrics []GroupMetric
+
+	groups map[string]*groupState
+	order  []*groupState // first-seen order, so output is deterministic
+}
+
+type groupState struct {
+	keys    map[string]any
+	rows    int
+	metrics []metricState
+}
+
+type metricState struct {
+	n        int // non-null values seen
+	sum      float64
+	numeric  int // numeric values seen (sum/avg)
+	min, max any
+	values   []float64       // median / percentile
+	distinct map[string]bool // count_distinct
+}
+
+// Transform absorbs the record into its group and drops it from the stream.
+func (t *GroupTransform) Transform(r Record) (Record, bool) {
+	if t.groups == nil {
+		t.groups = make(map[string]*groupState)
+	}
+
+	parts := make([]string, len(t.Keys))
+	for i, k := range t.Keys {
+		if v := r.Data[k]; v != nil {
+			parts[i] = fmt.Sprint(v)
+		} else {
+			parts[i] = "\x00" // null groups apart from ""
+		}
+	}
+	id := strings.Join(parts, "\x1f")
+
+	g, ok := t.groups[id]
+	if !ok {
+		g = &groupState{keys: make(map[string]any, len(t.Keys)), metrics: make([]metricState, len(t.Metrics))}
+		for _, k := range t.Keys {
+			g.keys[k] = r.Data[k]
+		}
+		t.groups[id] = g
+		t.order = append(t.order, g)
+	}
+
+	g.rows++
+	for i, m := range t.Metrics {
+		if m.Column == "" {
+			continue
+		}
+		v := r.Data[m.Column]
+		if v == nil {
+			continue
+		}
+		s := &g.metrics[i]
+		s.n++
+		switch m.Agg {
+		case AggCountDistinct:
+			if s.distinct == nil {
+				s.distinct = make(map[string]bool)
+			}
+			s.distinct[fmt.Sprint(v)] = true
+		case AggSum, AggAvg:
+			if f, ok := toFloatSafe(v); ok {
+				s.sum += f
+				s.numeric++
+			}
+		case AggMin:
+			if s.min == nil || compareValues(v, s.min) < 0 {
+				s.min = v
+			}
+		case AggMax:
+			if s.max == nil || compareValues(v, s.max) > 0 {
+				s.max = v
+			}
+		case AggMedian, AggPercentile:
+			if f, ok := toFloatSafe(v); ok {
+				s.values = append(s.values, f)
+			}
+		}
+	}
+	return r, false
+}
+
+// Results returns one summary record per group, in first-seen order.
+// With no group-by keys and no input, a single summary row is still emitted
+// (count 0), matching SQL aggregates without GROUP BY.
+func (t *GroupTransform) Results() []Record {
+	order := t.order
+	if len(order) == 0 && len(t.Keys) == 0 {
+		order = []*groupState{{metrics: make([]metricState, len(t.Metrics))}}
+	}
+
+	out := make([]Record, 0, len(order))
+	for _, g := range order {
+		data := make(map[string]any, len(t.Keys)+len(t.Metrics))
+		for _, k := range t.Keys {
+			data[k] = g.keys[k]
+		}
+		for i, m := range t.Metrics {
+			data[m.OutputName()] = g.metrics[i].result(m, g.rows)
+		}
+		out = append(out, Record{Data: data})
+	}
+	return out
+}
+
+func (s *metricState) result(m GroupMetric, rows int) any {
+	switch m.Agg {
+	case AggCount:
+		if m.Column == "" {
+			return float64(rows)
+		}
+		return float64(s.n)
+	case AggCountDistinct:
+		return float64(len(s.distinct))
+	case AggSum:
+		return s.sum
+	case AggAvg:
+		if s.numeric == 0 {
+			return nil
+		}
+		return s.sum / float64(s.numeric)
+	case AggMin:
+		return s.min
+	case AggMax:
+		return s.max
+	case AggMedian:
+		return percentile(s.values, 50)
+	case AggPercentile:
+		return percentile(s.values, m.Percentile)
+	}
+	return nil
+}
+
+// percentile interpolates linearly between the closest ranks (the
+// PERCENTILE.INC definition used by spreadsheets). It sorts values in place.
+func percentile(values []float64, p float64) any {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+	rank := p / 100 * float64(len(values)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return values[lo] + (values[hi]-values[lo])*(rank-float64(lo))
+}
+
+func formatPercentile(p float64) string {
+	return strings.ReplaceAll(fmt.Sprint(p), ".", "_")
+}
+
+// parseGroupConfig builds a GroupTransform from its declarative config:
+//
+//	{groupBy: ["region"], metrics: [{column, agg, as?, percentile?}]}
+func parseGroupConfig(cfg map[string]any) (*GroupTransform, error) {
+	t := &GroupTransform{}
+	if keys, ok := cfg["groupBy"].([]any); ok {
+		for _, k := range keys {
+			if s := fmt.Sprint(k); s != "" {
+				t.Keys = append(t.Keys, s)
+			}
+		}
+	}
+	if metrics, ok := cfg["metrics"].([]any); ok {
+		for _, raw := range metrics {
+			mm, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			m := GroupMetric{
+				Column: strVal(mm["column"]),
+				Agg:    strVal(mm["agg"]),
+				As:     strVal(mm["as"]),
+			}
+			if p, ok := toFloatSafe(mm["percentile"]); ok {
+				m.Percentile = p
+			}
+			if err := m.validate(); err != nil {
+				return nil, err
+			}
+			t.Metrics = append(t.Metrics, m)
+		}
+	}
+	if len(t.Keys) == 0 && len(t.Metrics) == 0 {
+		return nil, fmt.Errorf("group requires group-by columns or metrics")
+	}
+	return t, nil
+}
+
+// ── Batch Transforms ──────────────────────────────────────
+
+// batchTransform is a transform that absorbs the stream and emits records of
+// its own once the source is exhausted: group and sql.
+type batchTransform interface {
+	Transformer
+	// Emit returns the transform's output and, when the transform derives
+	// one, the output's schema.
+	Emit(ctx context.Context) ([]Record, *Schema, error)
+}
+
+// Emit returns the summary records. It implements batchTransform.
+func (t *GroupTransform) Emit(context.Context) ([]Record, *Schema, error) {
+	return t.Results(), nil, nil
+}
+
+// findBatch returns the first batch transform in the chain and the
+// transformers that follow it, which run on its output.
+func findBatch(ts []Transformer) (batchTransform, []Transformer) {
+	for i, t := range ts {
+		if bt, ok := t.(batchTransform); ok {
+			return bt, ts[i+1:]
+		}
+	}
+	return nil, nil
+}
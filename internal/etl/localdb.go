@@ -0,0 +1,164 @@
+package etl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"notes/internal/domain"
+	"notes/internal/expr"
+)
+
+// ── LocalDB Tables ─────────────────────────────────────────
+// LocalDB rows store their values by column ID, and the database's ConfigJSON
+// names the columns. The localdb source and the lookup and sql transforms all
+// read a LocalDB as records keyed by column name through LocalDBTable, which
+// also computes formula columns, so every reader sees the values the table
+// view shows.
+//
+// Formula columns hold an expression (see package expr) evaluated on read.
+// Other columns are referenced by name ({Price} * {Qty}) or by ID. Formulas
+// are evaluated in column order, so a formula may use an earlier one.
+
+// LocalDBColumn is a column definition from a LocalDatabase's ConfigJSON.
+type LocalDBColumn struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Formula string `json:"formula,omitempty"`
+}
+
+// ParseLocalDBColumns returns the column definitions in a LocalDatabase's
+// ConfigJSON.
+func ParseLocalDBColumns(configJSON string) ([]LocalDBColumn, error) {
+	var cfg struct {
+		Columns []LocalDBColumn `json:"columns"`
+	}
+	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+		return nil, err
+	}
+	return cfg.Columns, nil
+}
+
+// IsFormula reports whether the column is computed from a formula.
+func (c LocalDBColumn) IsFormula() bool {
+	return c.Type == string(domain.ColTypeFormula) && strings.TrimSpace(c.Formula) != ""
+}
+
+// FieldType maps the column's type to a schema field type; it is the inverse
+// of the destination's column mapping.
+func (c LocalDBColumn) FieldType() string {
+	switch c.Type {
+	case "number":
+		return "number"
+	case "checkbox":
+		return "boolean"
+	case "date":
+		return "date"
+	case "datetime":
+		return "datetime"
+	}
+	return "text"
+}
+
+// LocalDBTable maps the rows of one LocalDatabase to records.
+type LocalDBTable struct {
+	DB      *domain.LocalDatabase
+	Columns []LocalDBColumn // named columns, in config order
+
+	names    map[string]string // column ID → name
+	all      []LocalDBColumn   // every column, for formula references
+	formulas []localDBFormula
+}
+
+type localDBFormula struct {
+	col  LocalDBColumn
+	prog *expr.Program
+}
+
+// NewLocalDBTable parses db's column definitions and compiles its formulas.
+// Columns without an ID or a name cannot be addressed and are left out of
+// Columns.
+func NewLocalDBTable(db *domain.LocalDatabase) (*LocalDBTable, error) {
+	cols, err := ParseLocalDBColumns(db.ConfigJSON)
+	if err != nil {
+		return nil, fmt.Errorf("parse config of %q: %w", db.Name, err)
+	}
+	t := &LocalDBTable{DB: db, names: make(map[string]string, len(cols)), all: cols}
+	for _, c := range cols {
+		if c.IsFormula() {
+			// Formulas saved before validation existed may not compile; skip them.
+			if prog, err := expr.Compile(c.Formula); err == nil {
+				t.formulas = append(t.formulas, localDBFormula{c, prog})
+			}
+		}
+		if c.ID == "" || c.Name == "" {
+			continue
+		}
+		t.Columns = append(t.Columns, c)
+		t.names[c.ID] = c.Name
+	}
+	return t, nil
+}
+
+// HasFormulas reports whether the table has formula columns to evaluate.
+func (t *LocalDBTable) HasFormulas() bool { return len(t.formulas) > 0 }
+
+// EvalFormulas fills the formula column values into data, a row's values
+// keyed by column ID.
+func (t *LocalDBTable) EvalFormulas(data map[string]any) {
+	if len(t.formulas) == 0 {
+		return
+	}
+	vars := make(map[string]any, 2*len(t.all))
+	for _, c := range t.all {
+		vars[c.ID] = data[c.ID]
+		if c.Name != "" {
+			vars[c.Name] = data[c.ID]
+		}
+	}
+	for _, f := range t.formulas {
+		v := f.prog.Eval(vars)
+		if tv, ok := v.(time.Time); ok {
+			v = expr.ToString(tv)
+		}
+		data[f.col.ID] = v
+		vars[f.col.ID] = v
+		if f.col.Name != "" {
+			vars[f.col.Name] = v
+		}
+	}
+}
+
+// HasColumn reports whether the table has a column called name.
+func (t *LocalDBTable) HasColumn(name string) bool {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Record converts a row to a record keyed by column name, with its formula
+// columns evaluated. Columns deleted from the config leave orphaned IDs
+// behind; their values are dropped. ok is false for a row whose data does not
+// parse.
+func (t *LocalDBTable) Record(row domain.LocalDBRow) (rec Record, ok bool) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(row.DataJSON), &data); err != nil {
+		return Record{}, false
+	}
+	if data == nil {
+		data = make(map[string]any)
+	}
+	t.EvalFormulas(data)
+	byName := make(map[string]any, len(t.names))
+	for id, v := range data {
+		if name, ok := t.names[id]; ok {
+			byName[name] = v
+		}
+	}
+	return Record{Data: byName}, true
+}
@@ -0,0 +1,148 @@
+package etl
+
+import (
+	"fmt"
+
+	"notes/internal/domain"
+)
+
+// ── Lookup Transform ───────────────────────────────────────
+// LookupTransform enriches streaming records with columns from an existing
+// LocalDB, e.g. mapping customer_id to the customer's name. The lookup table
+// is loaded once when the chain is built and indexed on its key column, so
+// each record costs a single map access.
+//
+// Join semantics:
+//   - left (default): records without a match are kept, with the looked-up
+//     columns set to Default
+//   - inner: records without a match are dropped
+
+// LookupColumn is a LocalDB column copied onto matching records.
+type LookupColumn struct {
+	Column string // LocalDB column name
+	As     string // output field (default: Column)
+}
+
+func (c LookupColumn) outputName() string {
+	if c.As != "" {
+		return c.As
+	}
+	return c.Column
+}
+
+// LookupTransform joins LocalDB columns onto records by key.
+type LookupTransform struct {
+	DatabaseID string
+	LeftKey    string // record field
+	RightKey   string // LocalDB column name
+	Columns    []LookupColumn
+	JoinType   string // "left" | "inner"
+	Default    any    // value for looked-up columns on a miss (left join)
+
+	index map[string]map[string]any // key → LocalDB row (by column name)
+}
+
+func (t *LookupTransform) Transform(r Record) (Record, bool) {
+	var row map[string]any
+	if v := r.Data[t.LeftKey]; v != nil {
+		row = t.index[fmt.Sprint(v)]
+	}
+	if row == nil {
+		if t.JoinType == "inner" {
+			return r, false
+		}
+		for _, c := range t.Columns {
+			r.Data[c.outputName()] = t.Default
+		}
+		return r, true
+	}
+	for _, c := range t.Columns {
+		r.Data[c.outputName()] = row[c.Column]
+	}
+	return r, true
+}
+
+// Load reads the lookup LocalDB and indexes its rows on RightKey. When
+// several rows share a key, the first one (in row order) wins.
+func (t *LookupTransform) Load(store domain.LocalDatabaseStore) error {
+	db, err := store.GetDatabase(t.DatabaseID)
+	if err != nil {
+		return fmt.Errorf("database %s: %w", t.DatabaseID, err)
+	}
+
+	table, err := NewLocalDBTable(db)
+	if err != nil {
+		return err
+	}
+	if !table.HasColumn(t.RightKey) {
+		return fmt.Errorf("%q has no column %q", db.Name, t.RightKey)
+	}
+	for _, c := range t.Columns {
+		if !table.HasColumn(c.Column) {
+			return fmt.Errorf("%q has no column %q", db.Name, c.Column)
+		}
+	}
+
+	rows, err := store.ListRows(t.DatabaseID)
+	if err != nil {
+		return fmt.Errorf("list rows of %q: %w", db.Name, err)
+	}
+	t.index = make(map[string]map[string]any, len(rows))
+	for _, row := range rows {
+		rec, ok := table.Record(row)
+		if !ok {
+			continue
+		}
+		key := rec.Data[t.RightKey]
+		if key == nil {
+			continue
+		}
+		k := fmt.Sprint(key)
+		if _, dup := t.index[k]; !dup {
+			t.index[k] = rec.Data
+		}
+	}
+	return nil
+}
+
+// parseLookupConfig builds a LookupTransform from its declarative config:
+//
+//	{databaseId, leftKey, rightKey, columns: ["name" | {column, as}],
+//	 joinType?: "left"|"inner", defaultValue?}
+func parseLookupConfig(cfg map[string]any) (*LookupTransform, error) {
+	t := &LookupTransform{
+		DatabaseID: strVal(cfg["databaseId"]),
+		LeftKey:    strVal(cfg["leftKey"]),
+		RightKey:   strVal(cfg["rightKey"]),
+		JoinType:   strVal(cfg["joinType"]),
+		Default:    cfg["defaultValue"],
+	}
+	if t.DatabaseID == "" || t.LeftKey == "" || t.RightKey == "" {
+		return nil, fmt.Errorf("databaseId, leftKey and rightKey are required")
+	}
+	switch t.JoinType {
+	case "":
+		t.JoinType = "left"
+	case "left", "inner":
+	default:
+		return nil, fmt.Errorf("unknown join type %q", t.JoinType)
+	}
+
+	cols, _ := cfg["columns"].([]any)
+	for _, c := range cols {
+		switch cv := c.(type) {
+		case string:
+			if cv != "" {
+				t.Columns = append(t.Columns, LookupColumn{Column: cv})
+			}
+		case map[string]any:
+			if col := strVal(cv["column"]); col != "" {
+				t.Columns = append(t.Columns, LookupColumn{Column: col, As: strVal(cv["as"])})
+			}
+		}
+	}
+	if len(t.Columns) == 0 && t.JoinType == "left" {
+		return nil, fmt.Errorf("select at least one column to look up")
+	}
+	return t, nil
+}
@@ -0,0 +1,102 @@
+package etl
+
+import (
+	"sync"
+	"time"
+)
+
+// ── Progress ───────────────────────────────────────────────
+// A run reports progress after every batch written and, between batches, on
+// a timer, so a slow source or a sort that buffers everything still shows
+// signs of life. Each report carries the stage the run is in.
+
+// Run stages reported in Progress.Stage.
+const (
+	StageDiscover  = "discover"  // reading the source schema
+	StageRead      = "read"      // streaming records through the chain
+	StageAggregate = "aggregate" // emitting group summaries or sql results
+	StageSort      = "sort"      // writing sorted records
+	StageCommit    = "commit"    // finalizing the destination
+)
+
+// DefaultProgressInterval is how often a run reports progress between batches.
+const DefaultProgressInterval = time.Second
+
+// progressReporter tracks a run's progress and calls the engine's
+// OnProgress hook. Calls are serialized, and none happen after stop returns.
+type progressReporter struct {
+	fn    func(Progress)
+	start time.Time
+
+	mu   sync.Mutex
+	p    Progress
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newProgressReporter starts reporting for jobID every interval. A nil fn
+// disables reporting.
+func newProgressReporter(jobID string, fn func(Progress), interval time.Duration) *progressReporter {
+	r := &progressReporter{fn: fn, start: time.Now(), p: Progress{JobID: jobID}, done: make(chan struct{})}
+	if fn == nil {
+		return r
+	}
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				r.mu.Lock()
+				r.report()
+				r.mu.Unlock()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// Stage records the stage the run entered.
+func (r *progressReporter) Stage(stage string) {
+	r.mu.Lock()
+	r.p.Stage = stage
+	r.mu.Unlock()
+}
+
+// Read records the number of source records read so far.
+func (r *progressReporter) Read(n int) {
+	r.mu.Lock()
+	r.p.RowsRead = n
+	r.mu.Unlock()
+}
+
+// Flush records a written batch and reports it.
+func (r *progressReporter) Flush(batch, written int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.p.Batch = batch
+	r.p.RowsWritten = written
+	r.report()
+}
+
+// report calls fn with the current progress; r.mu must be held.
+func (r *progressReporter) report() {
+	if r.fn == nil {
+		return
+	}
+	r.p.Elapsed = time.Since(r.start)
+	r.fn(r.p)
+}
+
+// stop ends the periodic reports.
+func (r *progressReporter) stop() {
+	close(r.done)
+	r.wg.Wait()
+}
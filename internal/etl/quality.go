@@ -0,0 +1,292 @@
+package etl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ── Data-Quality Assertions ────────────────────────────────
+// Assertions are declarative checks on a job's output. Record assertions
+// (not_null, unique, in_set, regex, range) run on every record after the
+// transform chain, just before it would be written; row_count runs once on
+// the number of records that made it through, after they were written but
+// before the destination commits them.
+//
+// Each assertion has a policy:
+//   - fail (default): the first violation fails the run, so the destination
+//     discards what the run wrote (an HTTP destination cannot take back the
+//     requests it already sent)
+//   - warn: the record is written anyway and the violation is counted
+//   - quarantine: the record is written to the job's quarantine LocalDB
+//     instead of the target, with a _violations column describing why
+
+// Assertion types.
+const (
+	AssertNotNull  = "not_null"
+	AssertUnique   = "unique"
+	AssertInSet    = "in_set"
+	AssertRegex    = "regex"
+	AssertRange    = "range"
+	AssertRowCount = "row_count"
+)
+
+// Assertion policies.
+const (
+	PolicyFail       = "fail"
+	PolicyWarn       = "warn"
+	PolicyQuarantine = "quarantine"
+)
+
+// Assertion is one data-quality check on a job's output.
+type Assertion struct {
+	Type    string   `json:"type"`
+	Column  string   `json:"column,omitempty"`  // every type except row_count
+	Values  []any    `json:"values,omitempty"`  // in_set
+	Pattern string   `json:"pattern,omitempty"` // regex
+	Min     *float64 `json:"min,omitempty"`     // range, row_count
+	Max     *float64 `json:"max,omitempty"`     // range, row_count
+	Policy  string   `json:"policy,omitempty"`  // fail | warn | quarantine
+}
+
+// String describes the assertion, e.g. "not_null(email)" or "range(age 0..120)".
+func (a Assertion) String() string {
+	switch a.Type {
+	case AssertInSet:
+		vals := make([]string, len(a.Values))
+		for i, v := range a.Values {
+			vals[i] = fmt.Sprint(v)
+		}
+		return fmt.Sprintf("in_set(%s: %s)", a.Column, strings.Join(vals, ", "))
+	case AssertRegex:
+		return fmt.Sprintf("regex(%s ~ %s)", a.Column, a.Pattern)
+	case AssertRange:
+		return fmt.Sprintf("range(%s %s)", a.Column, a.bounds())
+	case AssertRowCount:
+		return fmt.Sprintf("row_count(%s)", a.bounds())
+	}
+	return fmt.Sprintf("%s(%s)", a.Type, a.Column)
+}
+
+func (a Assertion) bounds() string {
+	var lo, hi string
+	if a.Min != nil {
+		lo = fmt.Sprint(*a.Min)
+	}
+	if a.Max != nil {
+		hi = fmt.Sprint(*a.Max)
+	}
+	return lo + ".." + hi
+}
+
+func (a Assertion) policy() string {
+	if a.Policy == "" {
+		return PolicyFail
+	}
+	return a.Policy
+}
+
+func (a Assertion) inRange(f float64) bool {
+	return (a.Min == nil || f >= *a.Min) && (a.Max == nil || f <= *a.Max)
+}
+
+// AssertionResult summarizes one assertion's violations in a run.
+type AssertionResult struct {
+	Assertion  string `json:"assertion"` // Assertion.String()
+	Policy     string `json:"policy"`
+	Violations int    `json:"violations"`
+	Example    string `json:"example,omitempty"` // first offending value
+}
+
+// ValidateAssertions checks assertion definitions when a job is saved.
+// Quarantine policies need a quarantine database.
+func ValidateAssertions(assertions []Assertion, quarantineDBID string) error {
+	_, err := newQualityChecker(assertions, quarantineDBID)
+	return err
+}
+
+// qualityChecker evaluates a job's assertions over one run.
+type qualityChecker struct {
+	assertions []Assertion
+	results    []AssertionResult
+	patterns   []*regexp.Regexp  // regex, parallel to assertions
+	sets       []map[string]bool // in_set
+	seen       []map[string]bool // unique
+}
+
+func newQualityChecker(assertions []Assertion, quarantineDBID string) (*qualityChecker, error) {
+	q := &qualityChecker{
+		assertions: assertions,
+		results:    make([]AssertionResult, len(assertions)),
+		patterns:   make([]*regexp.Regexp, len(assertions)),
+		sets:       make([]map[string]bool, len(assertions)),
+		seen:       make([]map[string]bool, len(assertions)),
+	}
+	for i, a := range assertions {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("assertion %d (%s): %s", i+1, a.Type, fmt.Sprintf(format, args...))
+		}
+		switch a.policy() {
+		case PolicyFail, PolicyWarn:
+		case PolicyQuarantine:
+			if a.Type == AssertRowCount {
+				return nil, fail("row_count cannot quarantine records")
+			}
+			if quarantineDBID == "" {
+				return nil, fail("quarantine policy requires a quarantine database")
+			}
+		default:
+			return nil, fail("unknown policy %q", a.Policy)
+		}
+		if a.Type != AssertRowCount && a.Column == "" {
+			return nil, fail("column is required")
+		}
+		switch a.Type {
+		case AssertNotNull:
+		case AssertUnique:
+			q.seen[i] = make(map[string]bool)
+		case AssertInSet:
+			if len(a.Values) == 0 {
+				return nil, fail("values are required")
+			}
+			q.sets[i] = make(map[string]bool, len(a.Values))
+			for _, v := range a.Values {
+				q.sets[i][fmt.Sprint(v)] = true
+			}
+		case AssertRegex:
+			re, err := regexp.Compile(a.Pattern)
+			if err != nil {
+				return nil, fail("invalid pattern: %v", err)
+			}
+			q.patterns[i] = re
+		case AssertRange, AssertRowCount:
+			if a.Min == nil && a.Max == nil {
+				return nil, fail("min or max is required")
+			}
+		default:
+			return nil, fmt.Errorf("assertion %d: unknown type %q", i+1, a.Type)
+		}
+		q.results[i] = AssertionResult{Assertion: a.String(), Policy: a.policy()}
+	}
+	return q, nil
+}
+
+// Check evaluates the record assertions. It returns an error for a violated
+// fail assertion, and otherwise the descriptions of violated quarantine
+// assertions (nil when the record should be written).
+func (q *qualityChecker) Check(r Record) (quarantine []string, err error) {
+	for i, a := range q.assertions {
+		if a.Type == AssertRowCount {
+			continue
+		}
+		v := r.Data[a.Column]
+		if q.passes(i, a, v) {
+			continue
+		}
+		res := &q.results[i]
+		res.Violations++
+		if res.Example == "" {
+			res.Example = fmt.Sprint(v)
+		}
+		switch a.policy() {
+		case PolicyFail:
+			return nil, fmt.Errorf("assertion %s failed: %v", a, v)
+		case PolicyQuarantine:
+			quarantine = append(quarantine, a.String())
+		}
+	}
+	return quarantine, nil
+}
+
+// passes reports whether v satisfies assertion i. Only not_null rejects a
+// null value; the other checks leave nulls to it.
+func (q *qualityChecker) passes(i int, a Assertion, v any) bool {
+	if v == nil || v == "" {
+		return a.Type != AssertNotNull
+	}
+	switch a.Type {
+	case AssertUnique:
+		k := fmt.Sprint(v)
+		if q.seen[i][k] {
+			return false
+		}
+		q.seen[i][k] = true
+	case AssertInSet:
+		return q.sets[i][fmt.Sprint(v)]
+	case AssertRegex:
+		return q.patterns[i].MatchString(fmt.Sprint(v))
+	case AssertRange:
+		f, isNum := toFloatSafe(v)
+		return isNum && a.inRange(f)
+	}
+	return true
+}
+
+// CheckRowCount evaluates row_count assertions against the number of records
+// that passed the record assertions. It returns an error for a violated fail
+// assertion.
+func (q *qualityChecker) CheckRowCount(n int) error {
+	for i, a := range q.assertions {
+		if a.Type != AssertRowCount || a.inRange(float64(n)) {
+			continue
+		}
+		q.results[i].Violations++
+		q.results[i].Example = fmt.Sprint(n)
+		if a.policy() == PolicyFail {
+			return fmt.Errorf("assertion %s failed: %d rows", a, n)
+		}
+	}
+	return nil
+}
+
+// Results returns the per-assertion summary.
+func (q *qualityChecker) Results() []AssertionResult {
+	return q.results
+}
+
+// quarantineWriter sends records that violate a quarantine assertion to the
+// job's quarantine LocalDB. The session is opened on first use, so runs
+// without violations never touch it.
+type quarantineWriter struct {
+	ctx      context.Context
+	dest     Destination
+	targetID string
+	size     int
+	schema   *schemaTracker
+
+	w     *batchWriter
+	count int
+}
+
+// Add queues r with a _violations column listing the assertions it broke.
+func (q *quarantineWriter) Add(r Record, violations []string) error {
+	if q.w == nil {
+		sess, err := q.dest.Open(q.ctx, q.targetID, WriteOptions{Mode: SyncAppend})
+		if err != nil {
+			return err
+		}
+		q.w = &batchWriter{ctx: q.ctx, sess: sess, size: q.size, schema: q.schema}
+	}
+	r.Data["_violations"] = strings.Join(violations, "; ")
+	q.count++
+	return q.w.Add(r)
+}
+
+// Close flushes and commits the session when runErr is nil. Otherwise the
+// quarantined records are discarded, as the target's are.
+func (q *quarantineWriter) Close(runErr error) error {
+	if q.w == nil {
+		return nil
+	}
+	var flushErr error
+	if runErr == nil {
+		flushErr = q.w.Flush()
+		runErr = flushErr
+	}
+	_, err := q.w.sess.Close(q.ctx, runErr)
+	if flushErr != nil {
+		return flushErr
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package etl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ── Retry Policy ───────────────────────────────────────────
+// Scheduled runs (cron, file watch, after_job) are retried when they fail
+// with a transient error: a network failure, a timeout, or an HTTP 429/5xx.
+// Errors that another attempt cannot fix, like a bad config or a failed
+// assertion, end the run at once. The delay before each retry doubles,
+// starting at BackoffSeconds and capped at MaxBackoffSeconds.
+//
+// A run that still fails after its last attempt counts as one consecutive
+// failure; DisableAfter such failures in a row disable the job.
+
+const (
+	// DefaultBackoff is the delay before the first retry.
+	DefaultBackoff = 30 * time.Second
+	// DefaultMaxBackoff caps the delay between retries.
+	DefaultMaxBackoff = 10 * time.Minute
+	// maxRetryAttempts bounds MaxAttempts so a misconfigured job cannot
+	// retry for hours.
+	maxRetryAttempts = 10
+)
+
+// RetryPolicy configures how a job's scheduled runs are retried.
+type RetryPolicy struct {
+	MaxAttempts       int `json:"maxAttempts,omitempty"`       // attempts per run, including the first (0 or 1 = no retry)
+	BackoffSeconds    int `json:"backoffSeconds,omitempty"`    // delay before the first retry (default 30)
+	MaxBackoffSeconds int `json:"maxBackoffSeconds,omitempty"` // cap on the delay (default 600)
+	DisableAfter      int `json:"disableAfter,omitempty"`      // consecutive failed runs before the job is disabled (0 = never)
+}
+
+// Validate checks the policy when a job is saved.
+func (p RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0 || p.BackoffSeconds < 0 || p.MaxBackoffSeconds < 0 || p.DisableAfter < 0:
+		return fmt.Errorf("retry: values cannot be negative")
+	case p.MaxAttempts > maxRetryAttempts:
+		return fmt.Errorf("retry: at most %d attempts are allowed", maxRetryAttempts)
+	}
+	return nil
+}
+
+// Attempts returns the number of attempts a run may make.
+func (p RetryPolicy) Attempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+// Backoff returns the delay after failed attempt n (1-based).
+func (p RetryPolicy) Backoff(n int) time.Duration {
+	base, ceiling := DefaultBackoff, DefaultMaxBackoff
+	if p.BackoffSeconds > 0 {
+		base = time.Duration(p.BackoffSeconds) * time.Second
+	}
+	if p.MaxBackoffSeconds > 0 {
+		ceiling = time.Duration(p.MaxBackoffSeconds) * time.Second
+	}
+	d := base
+	for i := 1; i < n && d < ceiling; i++ {
+		d *= 2
+	}
+	return min(d, ceiling)
+}
+
+// StatusError is an HTTP response with an error status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.StatusCode, e.Body)
+}
+
+// transientMessages are error fragments from drivers that do not expose
+// typed errors, e.g. database connections proxied through dbclient.
+var transientMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"no such host",
+	"i/o timeout",
+	"timeout",
+	"too many connections",
+	"database is locked",
+	"temporarily unavailable",
+}
+
+// IsRetryable reports whether err is transient, so another attempt of the
+// same run may succeed.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == 429 || se.StatusCode >= 500
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	// Checked by type: syscall.Errno also implements net.Error.
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package etl
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// ── Secret References ──────────────────────────────────────
+// Source and destination config values may contain ${secret:name}
+// references. Sources returned by GetSource see them expanded in Discover and
+// Read, and destinations returned by GetDestination in Open, while the saved
+// config keeps the reference. Errors coming back from a connector, and any
+// text the service shows to the user, pass through RedactSecrets so resolved
+// values are never echoed.
+
+// SecretResolver expands and masks secret references.
+type SecretResolver interface {
+	// Expand replaces the secret references in s with their values.
+	Expand(s string) (string, error)
+	// Redact replaces secret values in s with their references.
+	Redact(s string) string
+}
+
+var (
+	secretsMu sync.RWMutex
+	secrets   SecretResolver
+)
+
+// SetSecretResolver is called by the app at startup.
+func SetSecretResolver(r SecretResolver) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = r
+}
+
+func secretResolver() SecretResolver {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	return secrets
+}
+
+// ExpandSecrets returns s with its secret references resolved. Without a
+// resolver s is returned as is.
+func ExpandSecrets(s string) (string, error) {
+	r := secretResolver()
+	if r == nil || !strings.Contains(s, "${secret:") {
+		return s, nil
+	}
+	return r.Expand(s)
+}
+
+// RedactSecrets masks resolved secret values in s.
+func RedactSecrets(s string) string {
+	r := secretResolver()
+	if r == nil || s == "" {
+		return s
+	}
+	return r.Redact(s)
+}
+
+// RedactRecords masks resolved secret values in the string fields of records,
+// in place. Nested maps and lists are walked.
+func RedactRecords(records []Record) {
+	if secretResolver() == nil {
+		return
+	}
+	for _, rec := range records {
+		for k, v := range rec.Data {
+			rec.Data[k] = redactValue(v)
+		}
+	}
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case string:
+		return RedactSecrets(t)
+	case map[string]any:
+		for k, x := range t {
+			t[k] = redactValue(x)
+		}
+	case []any:
+		for i, x := range t {
+			t[i] = redactValue(x)
+		}
+	}
+	return v
+}
+
+// RedactError masks resolved secret values in err's message, keeping err
+// reachable through errors.Is and errors.As.
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := RedactSecrets(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// expandConfig copies cfg with the secret references in its string values
+// resolved. Other values, such as the SourceRun, are shared.
+func expandConfig[M ~map[string]any](cfg M) (M, error) {
+	out := make(M, len(cfg))
+	for k, v := range cfg {
+		if s, ok := v.(string); ok {
+			expanded, err := ExpandSecrets(s)
+			if err != nil {
+				return nil, err
+			}
+			v = expanded
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// secretSource resolves secret references for the source it wraps.
+type secretSource struct {
+	Source
+}
+
+func (s secretSource) Discover(ctx context.Context, cfg SourceConfig) (*Schema, error) {
+	cfg, err := expandConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.Source.Discover(ctx, cfg)
+	return schema, RedactError(err)
+}
+
+func (s secretSource) Read(ctx context.Context, cfg SourceConfig) (<-chan Record, <-chan error) {
+	cfg, err := expandConfig(cfg)
+	if err != nil {
+		recCh := make(chan Record)
+		errCh := make(chan error, 1)
+		close(recCh)
+		errCh <- err
+		return recCh, errCh
+	}
+	recCh, srcErrCh := s.Source.Read(ctx, cfg)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		if err := <-srcErrCh; err != nil {
+			errCh <- RedactError(err)
+		}
+	}()
+	return recCh, errCh
+}
+
+// secretDestination resolves secret references for the destination it wraps.
+type secretDestination struct {
+	DestinationConnector
+}
+
+func (d secretDestination) Open(ctx context.Context, cfg DestinationConfig, opts WriteOptions) (WriteSession, error) {
+	cfg, err := expandConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := d.DestinationConnector.Open(ctx, cfg, opts)
+	if err != nil {
+		return nil, RedactError(err)
+	}
+	return secretSession{sess}, nil
+}
+
+// secretSession masks resolved secret values in the errors of the session it
+// wraps.
+type secretSession struct {
+	WriteSession
+}
+
+func (s secretSession) Write(ctx context.Context, schema *Schema, records []Record) (WriteStats, error) {
+	stats, err := s.WriteSession.Write(ctx, schema, records)
+	return stats, RedactError(err)
+}
+
+func (s secretSession) Close(ctx context.Context, runErr error) (WriteStats, error) {
+	stats, err := s.WriteSession.Close(ctx, runErr)
+	return stats, RedactError(err)
+}
@@ -25,6 +25,13 @@ type ConfigField struct {
 	Options  []string `json:"options,omitempty"` // for "select" type
 	Default  string   `json:"default,omitempty"`
 	Help     string   `json:"help,omitempty"`
+	// Sensitive fields hold a JSON object that may carry credentials, such as
+	// request headers; exports replace its credential-like values with
+	// secret references.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Overridable fields may be set for a single run, e.g. by a webhook body.
+	// Locations of credentials, endpoints and commands never are.
+	Overridable bool `json:"overridable,omitempty"`
 }
 
 // SourceSpec describes a source type: its label, icon, and required config fields.
@@ -33,6 +40,7 @@ type SourceSpec struct {
 	Label        string        `json:"label"`
 	Icon         string        `json:"icon"` // Tabler icon name
 	ConfigFields []ConfigField `json:"configFields"`
+	Stateful     bool          `json:"stateful,omitempty"` // tracks its own incremental state (see SourceRun)
 }
 
 // Source is the interface every data source must implement.
@@ -66,6 +74,7 @@ func RegisterSource(s Source) {
 }
 
 // GetSource returns a registered source by type, or an error if not found.
+// The source resolves ${secret:name} references in its config (see secrets.go).
 func GetSource(typ string) (Source, error) {
 	registryMu.RLock()
 	defer registryMu.RUnlock()
@@ -73,7 +82,7 @@ func GetSource(typ string) (Source, error) {
 	if !ok {
 		return nil, fmt.Errorf("unknown source type: %q", typ)
 	}
-	return s, nil
+	return secretSource{s}, nil
 }
 
 // ListSources returns the specs of all registered sources.
@@ -3,8 +3,9 @@ package sources
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 
@@ -12,7 +13,8 @@ import (
 )
 
 // ── CSV File Source ─────────────────────────────────────────
-// Reads records from a local CSV file.
+// Reads records from a local CSV file, or from every CSV file in a
+// directory (see directory.go). Rows are streamed one at a time.
 
 type csvFileSource struct{}
 
@@ -23,19 +25,31 @@ func (s *csvFileSource) Spec() etl.SourceSpec {
 		Type:  "csv_file",
 		Label: "CSV File",
 		Icon:  "IconFileTypeCsv",
-		ConfigFields: []etl.ConfigField{
-			{Key: "filePath", Label: "File Path", Type: "file", Required: true, Help: "Absolute path to the CSV file"},
-			{Key: "delimiter", Label: "Delimiter", Type: "string", Required: false, Default: ",", Help: "Column delimiter (default: comma)"},
-			{Key: "hasHeader", Label: "Has Header", Type: "select", Required: false, Options: []string{"true", "false"}, Default: "true", Help: "Whether the first row contains column names"},
-		},
+		ConfigFields: append([]etl.ConfigField{
+			{Key: "filePath", Label: "File Path", Type: "file", Required: false, Overridable: true, Help: "Absolute path to the CSV file (.gz and .zst are decompressed)"},
+			{Key: "delimiter", Label: "Delimiter", Type: "string", Required: false, Overridable: true, Default: ",", Help: "Column delimiter (default: comma)"},
+			{Key: "hasHeader", Label: "Has Header", Type: "select", Required: false, Overridable: true, Options: []string{"true", "false"}, Default: "true", Help: "Whether the first row contains column names"},
+		}, directoryFields("*.csv")...),
 	}
 }
 
 func (s *csvFileSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
-	headers, _, err := readCSVFile(cfg)
+	path, err := firstSourceFile(cfg, "*.csv")
 	if err != nil {
 		return nil, err
 	}
+	if path == "" {
+		return &etl.Schema{}, nil
+	}
+	f, err := openSourceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	headers, err := readCSV(f, cfg, func(etl.Record) error { return errStopRead })
+	if err != nil && !errors.Is(err, errStopRead) {
+		return nil, err
+	}
 
 	schema := &etl.Schema{Fields: make([]etl.Field, len(headers))}
 	for i, h := range headers {
@@ -45,50 +59,16 @@ func (s *csvFileSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*et
 }
 
 func (s *csvFileSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
-	out := make(chan etl.Record, 100)
-	errCh := make(chan error, 1)
-
-	go func() {
-		defer close(out)
-		defer close(errCh)
-
-		headers, rows, err := readCSVFile(cfg)
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		for _, row := range rows {
-			data := make(map[string]any, len(headers))
-			for j, h := range headers {
-				if j < len(row) {
-					data[h] = inferCSVValue(row[j])
-				}
-			}
-			select {
-			case out <- etl.Record{Data: data}:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
-	return out, errCh
+	return readSourceFilesChan(ctx, cfg, "*.csv", func(r io.Reader, emit func(etl.Record) error) error {
+		_, err := readCSV(r, cfg, emit)
+		return err
+	})
 }
 
-func readCSVFile(cfg etl.SourceConfig) ([]string, [][]string, error) {
-	filePath, _ := cfg["filePath"].(string)
-	if filePath == "" {
-		return nil, nil, fmt.Errorf("filePath is required")
-	}
-
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("open file: %w", err)
-	}
-	defer f.Close()
-
-	reader := csv.NewReader(f)
+// readCSV streams the rows of a CSV file into emit and returns its header.
+// The header is known before the first row is emitted.
+func readCSV(r io.Reader, cfg etl.SourceConfig, emit func(etl.Record) error) ([]string, error) {
+	reader := csv.NewReader(r)
 
 	// Configure delimiter.
 	if delim, ok := cfg["delimiter"].(string); ok && len(delim) > 0 {
@@ -97,12 +77,12 @@ func readCSVFile(cfg etl.SourceConfig) ([]string, [][]string, error) {
 	reader.LazyQuotes = true
 	reader.TrimLeadingSpace = true
 
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, nil, fmt.Errorf("parse csv: %w", err)
+	first, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("empty csv file")
 	}
-	if len(records) == 0 {
-		return nil, nil, fmt.Errorf("empty csv file")
+	if err != nil {
+		return nil, fmt.Errorf("parse csv: %w", err)
 	}
 
 	// Check if first row is header.
@@ -112,20 +92,38 @@ func readCSVFile(cfg etl.SourceConfig) ([]string, [][]string, error) {
 	}
 
 	var headers []string
-	var rows [][]string
+	row := first
 	if hasHeader {
-		headers = records[0]
-		rows = records[1:]
+		headers = append([]string(nil), first...)
+		row = nil
 	} else {
 		// Generate column names: col_1, col_2, ...
-		headers = make([]string, len(records[0]))
+		headers = make([]string, len(first))
 		for i := range headers {
 			headers[i] = fmt.Sprintf("col_%d", i+1)
 		}
-		rows = records
 	}
 
-	return headers, rows, nil
+	for {
+		if row != nil {
+			data := make(map[string]any, len(headers))
+			for j, h := range headers {
+				if j < len(row) {
+					data[h] = inferCSVValue(row[j])
+				}
+			}
+			if err := emit(etl.Record{Data: data}); err != nil {
+				return headers, err
+			}
+		}
+		row, err = reader.Read()
+		if err == io.EOF {
+			return headers, nil
+		}
+		if err != nil {
+			return headers, fmt.Errorf("parse csv: %w", err)
+		}
+	}
 }
 
 // inferCSVValue tries to parse a string as a number or bool.
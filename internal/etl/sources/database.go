@@ -3,6 +3,10 @@ package sources
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"notes/internal/etl"
 )
@@ -25,6 +29,13 @@ type DBProvider interface {
 	FetchMoreETLRows(ctx context.Context, connID string, fetchSize int) (*QueryPage, error)
 }
 
+// DBDriverProvider is optionally implemented by a DBProvider to name the
+// driver behind a connection ("mysql", "postgres", "sqlite", ...), so cursors
+// pushed into its queries are written in a form the database compares correctly.
+type DBDriverProvider interface {
+	ETLQueryDriver(connID string) string
+}
+
 var dbProvider DBProvider
 
 // SetDBProvider is called by the app at startup.
@@ -114,6 +125,14 @@ func (s *databaseSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan
 			return
 		}
 
+		if c, ok := etl.CursorFromConfig(cfg); ok {
+			var driver string
+			if dp, ok := dbProvider.(DBDriverProvider); ok {
+				driver = dp.ETLQueryDriver(connID)
+			}
+			query = applyCursor(query, c, driver)
+		}
+
 		page, err := dbProvider.ExecuteETLQuery(ctx, connID, query, 500)
 		if err != nil {
 			errCh <- fmt.Errorf("execute: %w", err)
@@ -155,3 +174,41 @@ func emitPage(ctx context.Context, out chan<- etl.Record, page *QueryPage) bool
 	}
 	return true
 }
+
+// identRe matches column names that are safe to splice into SQL unquoted.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// applyCursor pushes an incremental cursor down into a SQL query by wrapping it
+// in a filtered subquery. Non-SQL queries (e.g. MongoDB) and unusual column
+// names are returned unchanged — the engine still filters their records.
+func applyCursor(query string, c etl.Cursor, driver string) string {
+	if c.Value == "" || !identRe.MatchString(c.Field) {
+		return query
+	}
+	q := strings.TrimRight(strings.TrimSpace(query), ";")
+	upper := strings.ToUpper(q)
+	if !strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH") {
+		return query
+	}
+
+	literal := c.Value
+	if _, err := strconv.ParseFloat(c.Value, 64); err != nil {
+		literal = "'" + strings.ReplaceAll(cursorLiteral(c.Value, driver), "'", "''") + "'"
+	}
+	return fmt.Sprintf("SELECT * FROM (%s) AS _etl_incr WHERE %s > %s", q, c.Field, literal)
+}
+
+// cursorLiteral writes a time cursor the way the driver stores datetimes.
+// SQLite compares them as text and MySQL does not read RFC 3339's zone
+// suffix, so both get "YYYY-MM-DD HH:MM:SS" in the cursor's own zone; other
+// values and drivers keep the cursor as saved.
+func cursorLiteral(value, driver string) string {
+	if driver != "sqlite" && driver != "mysql" {
+		return value
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.Format("2006-01-02 15:04:05.999999999")
+}
@@ -0,0 +1,106 @@
+package sources
+
+import (
+	"database/sql"
+	"testing"
+
+	"notes/internal/etl"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestApplyCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		cursor etl.Cursor
+		driver string
+		want   string
+	}{
+		{
+			name:   "numeric",
+			query:  "SELECT * FROM orders;",
+			cursor: etl.Cursor{Field: "id", Value: "42"},
+			want:   "SELECT * FROM (SELECT * FROM orders) AS _etl_incr WHERE id > 42",
+		},
+		{
+			name:   "quoted literal",
+			query:  "select id, updated_at from t",
+			cursor: etl.Cursor{Field: "updated_at", Value: "2024-01-01 o'clock"},
+			want:   "SELECT * FROM (select id, updated_at from t) AS _etl_incr WHERE updated_at > '2024-01-01 o''clock'",
+		},
+		{
+			name:   "time cursor on postgres",
+			query:  "SELECT * FROM t",
+			cursor: etl.Cursor{Field: "updated_at", Value: "2024-01-02T03:04:05Z"},
+			driver: "postgres",
+			want:   "SELECT * FROM (SELECT * FROM t) AS _etl_incr WHERE updated_at > '2024-01-02T03:04:05Z'",
+		},
+		{
+			name:   "time cursor on mysql",
+			query:  "SELECT * FROM t",
+			cursor: etl.Cursor{Field: "updated_at", Value: "2024-01-02T03:04:05.25Z"},
+			driver: "mysql",
+			want:   "SELECT * FROM (SELECT * FROM t) AS _etl_incr WHERE updated_at > '2024-01-02 03:04:05.25'",
+		},
+		{
+			name:   "first run",
+			query:  "SELECT * FROM orders",
+			cursor: etl.Cursor{Field: "id"},
+			want:   "SELECT * FROM orders",
+		},
+		{
+			name:   "non-sql query",
+			query:  `db.orders.find({})`,
+			cursor: etl.Cursor{Field: "id", Value: "1"},
+			want:   `db.orders.find({})`,
+		},
+		{
+			name:   "unsafe column",
+			query:  "SELECT * FROM orders",
+			cursor: etl.Cursor{Field: "id; DROP TABLE x", Value: "1"},
+			want:   "SELECT * FROM orders",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyCursor(tt.query, tt.cursor, tt.driver); got != tt.want {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A DATETIME column read back from SQLite becomes an RFC 3339 cursor, but the
+// column itself holds "YYYY-MM-DD HH:MM:SS" text; the pushed-down filter must
+// still keep the rows after the cursor.
+func TestApplyCursor_SQLiteDatetime(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE t (id INTEGER, updated_at DATETIME);
+		INSERT INTO t VALUES (1, '2024-01-02 03:04:05'), (2, '2024-01-02 03:04:06'), (3, '2024-01-02 10:00:00')`); err != nil {
+		t.Fatal(err)
+	}
+
+	c := etl.Cursor{Field: "updated_at", Value: "2024-01-02T03:04:05Z"}
+	rows, err := db.Query(applyCursor("SELECT id, updated_at FROM t ORDER BY id", c, "sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		var at any
+		if err := rows.Scan(&id, &at); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("ids = %v, want [2 3]", ids)
+	}
+}
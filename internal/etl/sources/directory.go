@@ -0,0 +1,200 @@
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── Directory Ingestion ────────────────────────────────────
+// The file sources (CSV, JSON, JSON Lines) read either one filePath or every
+// file in a directory matching a glob, e.g. a folder of daily exports. Files are read
+// in name order. Unless the job is a full refresh, a file is read only once:
+// the engine remembers its path and checksum after a successful run, so a
+// file is read again only if its contents change. A failed run commits none
+// of its rows to a LocalDB, SQL or file destination, so the files it read
+// are read again in full by the next run (an HTTP destination may already
+// have received some of them). Processed files can be moved to an archive
+// folder once the run has been committed.
+
+// directoryFields are the config fields shared by the file sources.
+func directoryFields(defaultPattern string) []etl.ConfigField {
+	return []etl.ConfigField{
+		{Key: "directory", Label: "Directory", Type: "string", Required: false, Help: "Read every matching file in this folder instead of a single file"},
+		{Key: "pattern", Label: "File Pattern", Type: "string", Required: false, Overridable: true, Default: defaultPattern, Help: "Glob matched against file names in the directory"},
+		{Key: "archiveDir", Label: "Archive Folder", Type: "string", Required: false, Help: "Move processed files here after a successful run (directory mode)"},
+	}
+}
+
+// sourceFiles returns the files a file source reads: its filePath, or the
+// files in its directory matching the pattern.
+func sourceFiles(cfg etl.SourceConfig, defaultPattern string) ([]string, error) {
+	dir := cfgString(cfg, "directory", "")
+	if dir == "" {
+		filePath := cfgString(cfg, "filePath", "")
+		if filePath == "" {
+			return nil, fmt.Errorf("filePath or directory is required")
+		}
+		return []string{filePath}, nil
+	}
+
+	pattern := cfgString(cfg, "pattern", defaultPattern)
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, e.Name()); ok {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// firstSourceFile returns the file Discover infers the schema from, or "" for
+// a directory with no matching files: a watched folder is often empty between
+// drops, and the run then reads nothing.
+func firstSourceFile(cfg etl.SourceConfig, defaultPattern string) (string, error) {
+	files, err := sourceFiles(cfg, defaultPattern)
+	if err != nil || len(files) == 0 {
+		return "", err
+	}
+	return files[0], nil
+}
+
+// readSourceFiles streams each file through read into out. In directory mode
+// files ingested by earlier runs are skipped, and every file read is reported
+// to the run and archived after the commit.
+func readSourceFiles(ctx context.Context, cfg etl.SourceConfig, defaultPattern string, out chan<- etl.Record, read recordReader) error {
+	files, err := sourceFiles(cfg, defaultPattern)
+	if err != nil {
+		return err
+	}
+	run, ok := etl.SourceRunFromConfig(cfg)
+	if !ok {
+		run = &etl.SourceRun{}
+	}
+	dirMode := cfgString(cfg, "directory", "") != ""
+	archiveDir := cfgString(cfg, "archiveDir", "")
+
+	for _, path := range files {
+		var sum string
+		if dirMode {
+			if sum, err = fileChecksum(path); err != nil {
+				return err
+			}
+			if run.Ingested != nil {
+				done, err := run.Ingested(path, sum)
+				if err != nil {
+					return fmt.Errorf("file history: %w", err)
+				}
+				if done {
+					continue
+				}
+			}
+		}
+
+		rows := 0
+		err := readFile(path, read, func(rec etl.Record) error {
+			select {
+			case out <- rec:
+				rows++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			if dirMode {
+				return fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			return err
+		}
+
+		if !dirMode {
+			continue
+		}
+		run.AddFile(etl.IngestedFile{Path: path, Checksum: sum, Rows: rows, IngestedAt: time.Now()})
+		if archiveDir != "" {
+			run.OnCommit(func() error { return archiveFile(path, archiveDir) })
+		}
+	}
+	return nil
+}
+
+// fileChecksum returns the hex SHA-256 of a file's contents.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// archiveFile moves path into dir, adding a timestamp to the name if a file
+// with the same name was archived before.
+func archiveFile(path, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("archive %s: %w", filepath.Base(path), err)
+	}
+	name := filepath.Base(path)
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		ext := filepath.Ext(name)
+		dest = filepath.Join(dir, fmt.Sprintf("%s.%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102-150405.000"), ext))
+	}
+	if err := os.Rename(path, dest); err != nil {
+		// Rename fails across filesystems; fall back to copy and delete.
+		if cerr := copyFile(path, dest); cerr != nil {
+			return fmt.Errorf("archive %s: %w", name, errors.Join(err, cerr))
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("archive %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	return out.Close()
+}
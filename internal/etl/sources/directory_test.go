@@ -0,0 +1,80 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"notes/internal/etl"
+)
+
+func TestCSVSource_Read_Directory(t *testing.T) {
+	src, _ := etl.GetSource("csv_file")
+	dir := t.TempDir()
+	a := writeFile(t, dir, "2024-01-01.csv", "id\n1\n2\n")
+	writeFile(t, dir, "2024-01-02.csv", "id\n3\n")
+	writeFile(t, dir, "notes.txt", "ignored")
+
+	sumA, err := fileChecksum(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := &etl.SourceRun{Ingested: func(path, checksum string) (bool, error) {
+		return path == a && checksum == sumA, nil
+	}}
+	cfg := etl.WithSourceRun(etl.SourceConfig{"directory": dir}, run)
+
+	recCh, errCh := src.Read(context.Background(), cfg)
+	var records []etl.Record
+	for r := range recCh {
+		records = append(records, r)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(records) != 1 || records[0].Data["id"] != 3.0 {
+		t.Fatalf("records = %v, want only the new file", records)
+	}
+	files := run.Files()
+	if len(files) != 1 || filepath.Base(files[0].Path) != "2024-01-02.csv" || files[0].Rows != 1 || files[0].Checksum == "" {
+		t.Errorf("files = %+v", files)
+	}
+
+	// Discover uses the first matching file, ingested or not.
+	schema, err := src.Discover(context.Background(), etl.SourceConfig{"directory": dir})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(schema.Fields) != 1 || schema.Fields[0].Name != "id" {
+		t.Errorf("schema = %+v", schema.Fields)
+	}
+	// No matching file is an empty folder, not an error.
+	schema, err = src.Discover(context.Background(), etl.SourceConfig{"directory": dir, "pattern": "*.tsv"})
+	if err != nil || len(schema.Fields) != 0 {
+		t.Errorf("schema = %+v, err = %v; want no fields", schema, err)
+	}
+}
+
+func TestArchiveFile(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "done")
+
+	first := writeFile(t, dir, "export.json", "[]")
+	if err := archiveFile(first, archive); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	// A later file with the same name must not overwrite the archived one.
+	second := writeFile(t, dir, "export.json", "[{}]")
+	if err := archiveFile(second, archive); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+
+	entries, _ := os.ReadDir(archive)
+	if len(entries) != 2 {
+		t.Fatalf("archived = %d files, want 2", len(entries))
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("source file should be moved, stat err = %v", err)
+	}
+}
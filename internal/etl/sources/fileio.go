@@ -0,0 +1,125 @@
+package sources
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"notes/internal/etl"
+)
+
+// ── File Streaming ─────────────────────────────────────────
+// File sources decode their files record by record, so memory stays bounded
+// by the record channel however large the file is. Files ending in .gz or
+// .zst are decompressed on the fly.
+
+// fileBufferSize is the read buffer in front of each file.
+const fileBufferSize = 256 << 10
+
+// errStopRead ends a file read early without an error.
+var errStopRead = errors.New("stop reading")
+
+// recordReader decodes the records of one file, calling emit for each.
+type recordReader func(r io.Reader, emit func(etl.Record) error) error
+
+// openSourceFile opens path for reading, decompressing it by extension.
+func openSourceFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	br := bufio.NewReaderSize(f, fileBufferSize)
+
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".gz"):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("open gzip: %w", err)
+		}
+		return &fileReader{Reader: zr, closers: []io.Closer{zr, f}}, nil
+	case strings.HasSuffix(lower, ".zst"), strings.HasSuffix(lower, ".zstd"):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("open zstd: %w", err)
+		}
+		return &fileReader{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), f}}, nil
+	}
+	return &fileReader{Reader: br, closers: []io.Closer{f}}, nil
+}
+
+// fileReader closes a decompressor together with its file.
+type fileReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *fileReader) Close() error {
+	var errs []error
+	for _, c := range r.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// readFile streams the records of one file into emit.
+func readFile(path string, read recordReader, emit func(etl.Record) error) error {
+	f, err := openSourceFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return read(f, emit)
+}
+
+// discoverSampleSize is the number of records a source infers its schema
+// from.
+const discoverSampleSize = 100
+
+// sampleSourceFile returns up to n records from the first file of a file
+// source, for schema discovery.
+func sampleSourceFile(cfg etl.SourceConfig, defaultPattern string, n int, read recordReader) ([]etl.Record, error) {
+	path, err := firstSourceFile(cfg, defaultPattern)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	var sample []etl.Record
+	err = readFile(path, read, func(rec etl.Record) error {
+		sample = append(sample, rec)
+		if len(sample) >= n {
+			return errStopRead
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopRead) {
+		return nil, err
+	}
+	return sample, nil
+}
+
+// readSourceFilesChan runs readSourceFiles in a goroutine, returning the
+// channels of Source.Read.
+func readSourceFilesChan(ctx context.Context, cfg etl.SourceConfig, defaultPattern string, read recordReader) (<-chan etl.Record, <-chan error) {
+	out := make(chan etl.Record, 100)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		if err := readSourceFiles(ctx, cfg, defaultPattern, out, read); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return out, errCh
+}
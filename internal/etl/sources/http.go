@@ -6,11 +6,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+
 	"notes/internal/etl"
+	"notes/internal/httpauth"
+	"notes/internal/secret"
 )
 
 // ── HTTP Source ─────────────────────────────────────────────
@@ -27,6 +33,17 @@ var httpBlockResolver HTTPBlockResolver
 // SetHTTPBlockResolver is called by the app at startup.
 func SetHTTPBlockResolver(r HTTPBlockResolver) { httpBlockResolver = r }
 
+// HTTPBlockAuthResolver is optionally implemented by the HTTPBlockResolver to
+// expose a block's auth config; it is used when the job sets no auth itself.
+type HTTPBlockAuthResolver interface {
+	GetHTTPBlockAuth(blockID string) (httpauth.Config, error)
+}
+
+var httpAuthenticator *httpauth.Authenticator
+
+// SetHTTPAuthenticator is called by the app at startup.
+func SetHTTPAuthenticator(a *httpauth.Authenticator) { httpAuthenticator = a }
+
 type httpSource struct{}
 
 func init() { etl.RegisterSource(&httpSource{}) }
@@ -40,16 +57,38 @@ func (s *httpSource) Spec() etl.SourceSpec {
 			{Key: "blockId", Label: "HTTP Block", Type: "http_block", Required: false, Help: "Select an HTTP block from this page"},
 			{Key: "url", Label: "URL", Type: "string", Required: false, Help: "Full URL to fetch (e.g., https://api.github.com/users/me/repos)"},
 			{Key: "method", Label: "Method", Type: "select", Required: false, Options: []string{"GET", "POST"}, Default: "GET"},
-			{Key: "headers", Label: "Headers", Type: "textarea", Required: false, Help: "JSON object of headers (e.g., {\"Authorization\": \"Bearer xxx\"})"},
+			{Key: "headers", Label: "Headers", Type: "textarea", Required: false, Sensitive: true, Help: "JSON object of headers (e.g., {\"Authorization\": \"Bearer ${secret:github-token}\"})"},
 			{Key: "body", Label: "Body", Type: "textarea", Required: false, Help: "Request body (for POST)"},
-			{Key: "dataPath", Label: "Data Path", Type: "string", Required: false, Help: "Dot-separated path to the array in the response (e.g., 'data.items')"},
+			{Key: "dataPath", Label: "Data Path", Type: "string", Required: false, Overridable: true, Help: "Dot-separated path to the array in the response (e.g., 'data.items')"},
+			{Key: "authType", Label: "Auth", Type: "select", Required: false, Options: []string{"none", "bearer", "basic", "api_key", "oauth2"}, Default: "none"},
+			{Key: "authUsername", Label: "Auth Username", Type: "string", Required: false, Help: "Basic auth: username"},
+			{Key: "authKeyName", Label: "API Key Name", Type: "string", Required: false, Help: "API key auth: header or query parameter name (e.g., 'X-Api-Key')"},
+			{Key: "authKeyIn", Label: "API Key Location", Type: "select", Required: false, Options: []string{"header", "query"}, Default: "header"},
+			{Key: "authTokenUrl", Label: "Token URL", Type: "string", Required: false, Help: "OAuth2 client credentials: token endpoint"},
+			{Key: "authClientId", Label: "Client ID", Type: "string", Required: false, Help: "OAuth2 client credentials: client id"},
+			{Key: "authScopes", Label: "Scopes", Type: "string", Required: false, Help: "OAuth2 client credentials: space-separated scopes"},
+			{Key: "authSecret", Label: "Credential", Type: "password", Required: false, Help: "Token, password, API key or client secret, or a ${secret:name} reference. Stored in the system keychain, not in the job"},
+			{Key: "cursorParam", Label: "Cursor Parameter", Type: "string", Required: false, Help: "Incremental sync: query parameter that receives the last cursor value (e.g., 'since')"},
+			{Key: "pagination", Label: "Pagination", Type: "select", Required: false, Options: []string{"none", "page", "offset", "cursor", "link"}, Default: "none", Help: "How to request further pages"},
+			{Key: "pageParam", Label: "Page Parameter", Type: "string", Required: false, Help: "Page mode: page number parameter (default 'page')"},
+			{Key: "startPage", Label: "Start Page", Type: "string", Required: false, Overridable: true, Help: "Page mode: first page number (default 1)"},
+			{Key: "offsetParam", Label: "Offset Parameter", Type: "string", Required: false, Help: "Offset mode: offset parameter (default 'offset')"},
+			{Key: "limitParam", Label: "Limit Parameter", Type: "string", Required: false, Help: "Page/offset mode: page size parameter (default 'limit' for offset mode)"},
+			{Key: "pageSize", Label: "Page Size", Type: "string", Required: false, Overridable: true, Help: "Page/offset mode: records per page; a shorter page ends pagination"},
+			{Key: "nextCursorPath", Label: "Next Cursor Path", Type: "string", Required: false, Help: "Cursor mode: dot-separated path to the next-page token or URL in the response (e.g., 'meta.next_cursor')"},
+			{Key: "nextCursorParam", Label: "Next Cursor Parameter", Type: "string", Required: false, Help: "Cursor mode: query parameter that receives the token (default 'cursor')"},
+			{Key: "maxPages", Label: "Max Pages", Type: "string", Required: false, Overridable: true, Default: "100", Help: "Safety limit on the number of pages fetched; a read with more pages fails"},
 		},
 	}
 }
 
 func (s *httpSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
-	// Fetch a small sample to discover schema.
-	records, err := fetchHTTP(ctx, cfg)
+	// Fetch only the first page to discover schema.
+	var records []etl.Record
+	err := fetchHTTP(ctx, cfg, 1, func(page []etl.Record) error {
+		records = page
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -64,97 +103,470 @@ func (s *httpSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl
 		defer close(out)
 		defer close(errCh)
 
-		records, err := fetchHTTP(ctx, cfg)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		for _, rec := range records {
-			select {
-			case out <- rec:
-			case <-ctx.Done():
-				return
+		// Each page is forwarded as soon as it arrives.
+		err := fetchHTTP(ctx, cfg, 0, func(page []etl.Record) error {
+			for _, rec := range page {
+				select {
+				case out <- rec:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
 			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			errCh <- err
 		}
 	}()
 
 	return out, errCh
 }
 
-func fetchHTTP(ctx context.Context, cfg etl.SourceConfig) ([]etl.Record, error) {
+// httpRequest is the resolved request template shared by every page.
+type httpRequest struct {
+	url      string
+	method   string
+	headers  map[string]string
+	body     string
+	dataPath string
+	auth     httpauth.Config
+}
+
+func resolveHTTPRequest(cfg etl.SourceConfig) (*httpRequest, error) {
+	req := &httpRequest{}
+	req.url, _ = cfg["url"].(string)
+	req.method, _ = cfg["method"].(string)
+	req.body, _ = cfg["body"].(string)
+	req.dataPath, _ = cfg["dataPath"].(string)
+	req.auth = HTTPAuthFromConfig(cfg)
+	headersStr, _ := cfg["headers"].(string)
+
 	// Resolve from HTTP block reference if blockId is set.
 	if blockID, ok := cfg["blockId"].(string); ok && blockID != "" && httpBlockResolver != nil {
 		bURL, bMethod, bHeaders, bBody, err := httpBlockResolver.GetHTTPBlockContent(blockID)
 		if err != nil {
 			return nil, fmt.Errorf("resolve http block: %w", err)
 		}
-		// Override config with block values (keep dataPath from ETL config).
-		cfg["url"] = bURL
-		cfg["method"] = bMethod
-		cfg["headers"] = bHeaders
-		cfg["body"] = bBody
+		// Block values win (dataPath stays from the ETL config).
+		req.url, req.method, headersStr, req.body = bURL, bMethod, bHeaders, bBody
+
+		if ar, ok := httpBlockResolver.(HTTPBlockAuthResolver); ok && !req.auth.Enabled() {
+			auth, err := ar.GetHTTPBlockAuth(blockID)
+			if err != nil {
+				return nil, fmt.Errorf("resolve http block auth: %w", err)
+			}
+			req.auth = auth
+		}
+	}
+	if req.auth.Enabled() && httpAuthenticator == nil {
+		return nil, fmt.Errorf("http auth is not available")
 	}
 
-	url, _ := cfg["url"].(string)
-	if url == "" {
+	if req.url == "" {
 		return nil, fmt.Errorf("url is required")
 	}
-
-	method, _ := cfg["method"].(string)
-	if method == "" {
-		method = "GET"
+	if req.method == "" {
+		req.method = "GET"
+	}
+	if headersStr != "" {
+		json.Unmarshal([]byte(headersStr), &req.headers)
 	}
 
-	var bodyReader io.Reader
-	if body, ok := cfg["body"].(string); ok && body != "" {
-		bodyReader = strings.NewReader(body)
+	// Incremental sync: pass the last high-water mark as a query parameter.
+	if c, ok := etl.CursorFromConfig(cfg); ok && c.Value != "" {
+		if param, _ := cfg["cursorParam"].(string); param != "" {
+			u, err := setQueryParam(req.url, param, c.Value)
+			if err != nil {
+				return nil, err
+			}
+			req.url = u
+		}
 	}
+	return req, nil
+}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+// fetchHTTP requests the configured endpoint, following pagination, and hands
+// each page's records to emit. maxPages > 0 overrides the configured limit and
+// stops quietly once it is reached.
+func fetchHTTP(ctx context.Context, cfg etl.SourceConfig, maxPages int, emit func([]etl.Record) error) error {
+	req, err := resolveHTTPRequest(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return err
+	}
+	p, err := newPaginator(cfg, req.url)
+	if err != nil {
+		return err
+	}
+	// A caller's limit (Discover) just stops early; the configured one must
+	// not silently cut the data short.
+	truncate := maxPages > 0
+	if truncate {
+		p.maxPages = maxPages
 	}
 
-	// Parse headers.
-	if headersStr, ok := cfg["headers"].(string); ok && headersStr != "" {
-		var headers map[string]string
-		if err := json.Unmarshal([]byte(headersStr), &headers); err == nil {
-			for k, v := range headers {
-				req.Header.Set(k, v)
+	client := &http.Client{Timeout: 30 * time.Second}
+	pageURL := p.first()
+	for page := 1; pageURL != ""; page++ {
+		records, resp, raw, err := fetchPage(ctx, client, req, pageURL)
+		if err != nil {
+			if page > 1 {
+				return fmt.Errorf("page %d: %w", page, err)
 			}
+			return err
+		}
+		if page > p.maxPages {
+			// The page past the limit only tells whether the data ends there.
+			if len(records) > 0 {
+				return fmt.Errorf("more than %d pages: raise Max Pages to read them all", p.maxPages)
+			}
+			return nil
+		}
+		if err := emit(records); err != nil {
+			return err
+		}
+		if page == p.maxPages && truncate {
+			break
+		}
+		pageURL, err = p.next(pageURL, len(records), resp, raw)
+		if err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	resp, err := client.Do(req)
+// fetchPage performs a single request and returns its records together with
+// the response headers and parsed body the paginator needs.
+func fetchPage(ctx context.Context, client *http.Client, r *httpRequest, url string) ([]etl.Record, http.Header, any, error) {
+	resp, err := doHTTP(ctx, client, r, url)
+	// An expired or revoked OAuth2 token gets one retry with a fresh token.
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && r.auth.Type == httpauth.TypeOAuth2 {
+		resp.Body.Close()
+		httpAuthenticator.Invalidate(r.auth)
+		resp, err = doHTTP(ctx, client, r, url)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return nil, nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("http %d: %s", resp.StatusCode, string(body))
+		return nil, nil, nil, &etl.StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, nil, nil, fmt.Errorf("read body: %w", err)
 	}
 
 	// Parse JSON response.
 	var raw any
 	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("parse json: %w", err)
+		return nil, nil, nil, fmt.Errorf("parse json: %w", err)
 	}
 
 	// Navigate to dataPath if specified.
-	dataPath, _ := cfg["dataPath"].(string)
-	if dataPath != "" {
-		raw = navigatePath(raw, dataPath)
+	items := raw
+	if r.dataPath != "" {
+		items = navigatePath(raw, r.dataPath)
+	}
+
+	return toRecords(items), resp.Header, raw, nil
+}
+
+// doHTTP sends one authenticated request.
+func doHTTP(ctx context.Context, client *http.Client, r *httpRequest, url string) (*http.Response, error) {
+	var bodyReader io.Reader
+	if r.body != "" {
+		bodyReader = strings.NewReader(r.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, url, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	// Credentials go only to the configured origin: a next-page URL taken
+	// from a response may point anywhere.
+	if sameOrigin(r.url, url) {
+		for k, v := range r.headers {
+			req.Header.Set(k, v)
+		}
+		if r.auth.Enabled() {
+			if err := httpAuthenticator.Apply(ctx, req, r.auth); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	return resp, nil
+}
+
+// sameOrigin reports whether two URLs have the same scheme and host.
+func sameOrigin(a, b string) bool {
+	ua, err := neturl.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := neturl.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// setQueryParam returns rawURL with the query parameter key set to value.
+func setQueryParam(rawURL, key, value string) (string, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parse url: %w", err)
 	}
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// ── Auth ───────────────────────────────────────────────────
+// Auth settings are flat config keys so the generic source form can render
+// them. The credential is entered as authSecret and moved to the SecretStore
+// by SealHTTPAuth when the job is saved; only authSecretRef is persisted.
+
+// HTTPAuthFromConfig builds the auth config from an HTTP source config.
+func HTTPAuthFromConfig(cfg etl.SourceConfig) httpauth.Config {
+	return httpauth.Config{
+		Type:      cfgString(cfg, "authType", httpauth.TypeNone),
+		Username:  cfgString(cfg, "authUsername", ""),
+		KeyName:   cfgString(cfg, "authKeyName", ""),
+		KeyIn:     cfgString(cfg, "authKeyIn", ""),
+		TokenURL:  cfgString(cfg, "authTokenUrl", ""),
+		ClientID:  cfgString(cfg, "authClientId", ""),
+		Scopes:    cfgString(cfg, "authScopes", ""),
+		SecretRef: cfgString(cfg, "authSecretRef", ""),
+		Secret:    cfgString(cfg, "authSecret", ""),
+	}
+}
+
+// SealHTTPAuth validates the auth settings in cfg and moves a plaintext
+// authSecret into the SecretStore, replacing it with authSecretRef. A
+// ${secret:<name>} reference is left in place; it is resolved on each run.
+func SealHTTPAuth(cfg etl.SourceConfig) error {
+	auth := HTTPAuthFromConfig(cfg)
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+	if auth.Secret == "" || secret.HasRefs(auth.Secret) {
+		return nil
+	}
+	if httpAuthenticator == nil {
+		return fmt.Errorf("http auth is not available")
+	}
+	if err := httpAuthenticator.Seal(&auth, "http-auth:"+uuid.New().String()); err != nil {
+		return err
+	}
+	delete(cfg, "authSecret")
+	cfg["authSecretRef"] = auth.SecretRef
+	return nil
+}
+
+// ForgetHTTPAuth deletes the credential referenced by cfg, if any.
+func ForgetHTTPAuth(cfg etl.SourceConfig) error {
+	auth := HTTPAuthFromConfig(cfg)
+	if auth.SecretRef == "" || httpAuthenticator == nil {
+		return nil
+	}
+	return httpAuthenticator.Forget(auth)
+}
+
+// ── Pagination ─────────────────────────────────────────────
+// Supported strategies:
+//   page   — ?page=N, incremented until an empty (or short) page
+//   offset — ?offset=N&limit=M, advanced by the records received
+//   cursor — next-page token (or full URL) read from a JSON path in the body
+//   link   — RFC 5988 `Link: <...>; rel="next"` response header
+// A read fails when the data runs past maxPages pages rather than stopping
+// short. Credentials are sent only to the configured origin, so a next-page
+// URL on another host gets neither the auth nor the configured headers.
+
+const defaultMaxPages = 100
+
+type paginator struct {
+	mode     string
+	baseURL  string
+	maxPages int
+	pageSize int
+
+	pageParam   string
+	page        int
+	offsetParam string
+	limitParam  string
+	offset      int
+	cursorPath  string
+	cursorParam string
+}
+
+func newPaginator(cfg etl.SourceConfig, baseURL string) (*paginator, error) {
+	p := &paginator{
+		baseURL:     baseURL,
+		maxPages:    cfgInt(cfg, "maxPages", defaultMaxPages),
+		pageSize:    cfgInt(cfg, "pageSize", 0),
+		pageParam:   cfgString(cfg, "pageParam", "page"),
+		page:        cfgInt(cfg, "startPage", 1),
+		offsetParam: cfgString(cfg, "offsetParam", "offset"),
+		limitParam:  cfgString(cfg, "limitParam", ""),
+		cursorPath:  cfgString(cfg, "nextCursorPath", ""),
+		cursorParam: cfgString(cfg, "nextCursorParam", "cursor"),
+	}
+	p.mode, _ = cfg["pagination"].(string)
+	switch p.mode {
+	case "", "none":
+		p.maxPages = 1
+	case "page", "link":
+	case "offset":
+		if p.limitParam == "" {
+			p.limitParam = "limit"
+		}
+	case "cursor":
+		if p.cursorPath == "" {
+			return nil, fmt.Errorf("cursor pagination requires nextCursorPath")
+		}
+	default:
+		return nil, fmt.Errorf("unknown pagination %q", p.mode)
+	}
+	if p.maxPages <= 0 {
+		p.maxPages = defaultMaxPages
+	}
+	return p, nil
+}
+
+// first returns the URL of the first page.
+func (p *paginator) first() string {
+	u := p.baseURL
+	switch p.mode {
+	case "page":
+		u = p.withParams(u, p.pageParam, strconv.Itoa(p.page))
+	case "offset":
+		u = p.withParams(u, p.offsetParam, strconv.Itoa(p.offset))
+	}
+	return u
+}
 
-	return toRecords(raw), nil
+// next returns the URL of the page after current, or "" when there is none.
+func (p *paginator) next(current string, count int, header http.Header, body any) (string, error) {
+	switch p.mode {
+	case "page":
+		if count == 0 || (p.pageSize > 0 && count < p.pageSize) {
+			return "", nil
+		}
+		p.page++
+		return p.withParams(p.baseURL, p.pageParam, strconv.Itoa(p.page)), nil
+
+	case "offset":
+		if count == 0 || (p.pageSize > 0 && count < p.pageSize) {
+			return "", nil
+		}
+		p.offset += count
+		return p.withParams(p.baseURL, p.offsetParam, strconv.Itoa(p.offset)), nil
+
+	case "cursor":
+		token := navigatePath(body, p.cursorPath)
+		if token == nil || fmt.Sprint(token) == "" {
+			return "", nil
+		}
+		tok := fmt.Sprint(token)
+		if f, ok := token.(float64); ok {
+			tok = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		// Some APIs return the whole next URL instead of a token.
+		if strings.HasPrefix(tok, "http://") || strings.HasPrefix(tok, "https://") {
+			return tok, nil
+		}
+		return setQueryParam(p.baseURL, p.cursorParam, tok)
+
+	case "link":
+		next := parseLinkNext(header.Values("Link"))
+		if next == "" {
+			return "", nil
+		}
+		// Relative links resolve against the page that returned them.
+		base, err := neturl.Parse(current)
+		if err != nil {
+			return "", fmt.Errorf("parse url: %w", err)
+		}
+		ref, err := neturl.Parse(next)
+		if err != nil {
+			return "", fmt.Errorf("parse link header: %w", err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+	return "", nil
+}
+
+// withParams sets the strategy's position parameter plus the page size, if any.
+func (p *paginator) withParams(rawURL, key, value string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	q.Set(key, value)
+	if p.limitParam != "" && p.pageSize > 0 {
+		q.Set(p.limitParam, strconv.Itoa(p.pageSize))
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// parseLinkNext extracts the rel="next" target from RFC 5988 Link headers.
+func parseLinkNext(values []string) string {
+	for _, v := range values {
+		for _, link := range strings.Split(v, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// cfgString reads a string config value, falling back to def when empty.
+func cfgString(cfg etl.SourceConfig, key, def string) string {
+	if v, ok := cfg[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// cfgInt reads an integer config value given either as a number or a string.
+func cfgInt(cfg etl.SourceConfig, key string, def int) int {
+	switch v := cfg[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
 }
 
 // navigatePath walks a dot-separated path into nested maps/slices.
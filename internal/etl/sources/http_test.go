@@ -0,0 +1,307 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"notes/internal/etl"
+	"notes/internal/httpauth"
+)
+
+// pagedServer serves ids 1..total in pages of size, addressed by the given
+// strategy, and counts the requests it receives.
+func pagedServer(t *testing.T, total, size int, handler func(w http.ResponseWriter, r *http.Request, ids []int)) (*httptest.Server, *int) {
+	t.Helper()
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		handler(w, r, pageIDs(total, size, r))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// pageIDs slices ids for the request's page/offset/cursor parameter.
+func pageIDs(total, size int, r *http.Request) []int {
+	start := 0
+	q := r.URL.Query()
+	if p := q.Get("page"); p != "" {
+		n, _ := strconv.Atoi(p)
+		start = (n - 1) * size
+	}
+	if o := q.Get("offset"); o != "" {
+		start, _ = strconv.Atoi(o)
+	}
+	if c := q.Get("cursor"); c != "" {
+		start, _ = strconv.Atoi(c)
+	}
+	var ids []int
+	for i := start; i < start+size && i < total; i++ {
+		ids = append(ids, i+1)
+	}
+	return ids
+}
+
+func writeItems(w http.ResponseWriter, ids []int, extra map[string]any) {
+	items := make([]map[string]any, len(ids))
+	for i, id := range ids {
+		items[i] = map[string]any{"id": id}
+	}
+	body := map[string]any{"items": items}
+	for k, v := range extra {
+		body[k] = v
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func readHTTP(t *testing.T, cfg etl.SourceConfig) []etl.Record {
+	t.Helper()
+	src, _ := etl.GetSource("http")
+	recCh, errCh := src.Read(context.Background(), cfg)
+	var records []etl.Record
+	for r := range recCh {
+		records = append(records, r)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return records
+}
+
+func TestHTTPSource_NoPagination(t *testing.T) {
+	srv, requests := pagedServer(t, 10, 3, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		writeItems(w, ids, nil)
+	})
+
+	records := readHTTP(t, etl.SourceConfig{"url": srv.URL, "dataPath": "items"})
+	if len(records) != 3 || *requests != 1 {
+		t.Errorf("records = %d, requests = %d; want 3, 1", len(records), *requests)
+	}
+}
+
+func TestHTTPSource_PagePagination(t *testing.T) {
+	srv, requests := pagedServer(t, 7, 3, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		writeItems(w, ids, nil)
+	})
+
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "page", "pageSize": "3",
+	})
+	if len(records) != 7 {
+		t.Fatalf("records = %d, want 7", len(records))
+	}
+	// Third page is short (1 record), so no fourth request is made.
+	if *requests != 3 {
+		t.Errorf("requests = %d, want 3", *requests)
+	}
+	if records[6].Data["id"] != 7.0 {
+		t.Errorf("last id = %v", records[6].Data["id"])
+	}
+}
+
+func TestHTTPSource_OffsetPagination(t *testing.T) {
+	srv, _ := pagedServer(t, 5, 2, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		if r.URL.Query().Get("limit") != "2" {
+			t.Errorf("limit = %q", r.URL.Query().Get("limit"))
+		}
+		writeItems(w, ids, nil)
+	})
+
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "offset", "pageSize": "2",
+	})
+	if len(records) != 5 {
+		t.Errorf("records = %d, want 5", len(records))
+	}
+}
+
+func TestHTTPSource_CursorPagination(t *testing.T) {
+	srv, _ := pagedServer(t, 5, 2, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		var next any
+		if len(ids) > 0 && ids[len(ids)-1] < 5 {
+			next = strconv.Itoa(ids[len(ids)-1])
+		}
+		writeItems(w, ids, map[string]any{"meta": map[string]any{"next": next}})
+	})
+
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "cursor", "nextCursorPath": "meta.next",
+	})
+	if len(records) != 5 {
+		t.Errorf("records = %d, want 5", len(records))
+	}
+}
+
+func TestHTTPSource_LinkPagination(t *testing.T) {
+	srv, _ := pagedServer(t, 5, 2, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page*2 < 5 {
+			w.Header().Set("Link", fmt.Sprintf(`</items?page=%d>; rel="next", </items?page=3>; rel="last"`, page+1))
+		}
+		writeItems(w, ids, nil)
+	})
+
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL + "/items?page=1", "dataPath": "items", "pagination": "link",
+	})
+	if len(records) != 5 {
+		t.Errorf("records = %d, want 5", len(records))
+	}
+}
+
+func TestHTTPSource_MaxPages(t *testing.T) {
+	srv, requests := pagedServer(t, 100, 2, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		writeItems(w, ids, nil)
+	})
+
+	src, _ := etl.GetSource("http")
+	recCh, errCh := src.Read(context.Background(), etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "page", "maxPages": "3",
+	})
+	for range recCh {
+	}
+	if err := <-errCh; err == nil {
+		t.Error("expected an error for data past maxPages")
+	}
+	if *requests != 4 {
+		t.Errorf("requests = %d, want 4", *requests)
+	}
+
+	// Data that ends exactly at the limit reads in full.
+	srv, _ = pagedServer(t, 6, 2, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		writeItems(w, ids, nil)
+	})
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "page", "maxPages": "3",
+	})
+	if len(records) != 6 {
+		t.Errorf("records = %d, want 6", len(records))
+	}
+}
+
+func TestHTTPSource_DiscoverFetchesFirstPageOnly(t *testing.T) {
+	srv, requests := pagedServer(t, 10, 2, func(w http.ResponseWriter, r *http.Request, ids []int) {
+		writeItems(w, ids, nil)
+	})
+
+	src, _ := etl.GetSource("http")
+	schema, err := src.Discover(context.Background(), etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "page",
+	})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(schema.Fields) != 1 || *requests != 1 {
+		t.Errorf("fields = %d, requests = %d; want 1, 1", len(schema.Fields), *requests)
+	}
+}
+
+func TestParseLinkNext(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{`<https://api.example.com/items?page=2>; rel="next"`, "https://api.example.com/items?page=2"},
+		{`<https://x/1>; rel="prev", <https://x/3>; rel="next"`, "https://x/3"},
+		{`<https://x/3>; rel="next last"`, "https://x/3"},
+		{`<https://x/9>; rel="last"`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := parseLinkNext([]string{tt.header}); got != tt.want {
+			t.Errorf("parseLinkNext(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// memSecrets is a simple in-memory secret store for testing.
+type memSecrets map[string][]byte
+
+func (m memSecrets) Set(key string, value []byte) error { m[key] = value; return nil }
+func (m memSecrets) Get(key string) ([]byte, error)     { return m[key], nil }
+func (m memSecrets) Delete(key string) error            { delete(m, key); return nil }
+
+func TestHTTPSource_Auth(t *testing.T) {
+	SetHTTPAuthenticator(httpauth.New(memSecrets{"ref": []byte("s3cret")}))
+	t.Cleanup(func() { SetHTTPAuthenticator(nil) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		writeItems(w, []int{1}, nil)
+	}))
+	defer srv.Close()
+
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items",
+		"authType": "api_key", "authKeyName": "api_key", "authKeyIn": "query", "authSecretRef": "ref",
+	})
+	if len(records) != 1 {
+		t.Errorf("records = %d, want 1", len(records))
+	}
+}
+
+func TestHTTPSource_CrossOriginNextPage(t *testing.T) {
+	SetHTTPAuthenticator(httpauth.New(memSecrets{"ref": []byte("s3cret")}))
+	t.Cleanup(func() { SetHTTPAuthenticator(nil) })
+
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, h := range []string{"Authorization", "X-Team"} {
+			if v := r.Header.Get(h); v != "" {
+				leaked = append(leaked, h+": "+v)
+			}
+		}
+		writeItems(w, []int{2}, nil)
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Link", "<"+other.URL+`/items?page=2>; rel="next"`)
+		writeItems(w, []int{1}, nil)
+	}))
+	defer srv.Close()
+
+	records := readHTTP(t, etl.SourceConfig{
+		"url": srv.URL, "dataPath": "items", "pagination": "link",
+		"headers": `{"X-Team": "core"}`, "authType": "bearer", "authSecretRef": "ref",
+	})
+	if len(records) != 2 {
+		t.Errorf("records = %d, want 2", len(records))
+	}
+	if len(leaked) > 0 {
+		t.Errorf("other origin got %v", leaked)
+	}
+}
+
+func TestSealHTTPAuth(t *testing.T) {
+	secrets := memSecrets{}
+	SetHTTPAuthenticator(httpauth.New(secrets))
+	t.Cleanup(func() { SetHTTPAuthenticator(nil) })
+
+	cfg := etl.SourceConfig{"authType": "basic", "authUsername": "ada", "authSecret": "pw"}
+	if err := SealHTTPAuth(cfg); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	ref, _ := cfg["authSecretRef"].(string)
+	if _, ok := cfg["authSecret"]; ok || string(secrets[ref]) != "pw" {
+		t.Errorf("cfg = %v, stored = %q", cfg, secrets[ref])
+	}
+
+	if err := SealHTTPAuth(etl.SourceConfig{"authType": "basic"}); err == nil {
+		t.Error("expected validation error for basic auth without username")
+	}
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	"notes/internal/etl"
 )
 
 // ── JSON File Source ────────────────────────────────────────
-// Reads records from a local JSON file.
+// Reads records from a local JSON file, or from every JSON file in a
+// directory (see directory.go). The array at dataPath is decoded one element
+// at a time, so large files are streamed.
 
 type jsonFileSource struct{}
 
@@ -22,15 +24,15 @@ func (s *jsonFileSource) Spec() etl.SourceSpec {
 		Type:  "json_file",
 		Label: "JSON File",
 		Icon:  "IconFileTypeJs",
-		ConfigFields: []etl.ConfigField{
-			{Key: "filePath", Label: "File Path", Type: "file", Required: true, Help: "Absolute path to the JSON file"},
-			{Key: "dataPath", Label: "Data Path", Type: "string", Required: false, Help: "Dot-separated path to the array (e.g., 'data.items'). Leave empty if root is an array."},
-		},
+		ConfigFields: append([]etl.ConfigField{
+			{Key: "filePath", Label: "File Path", Type: "file", Required: false, Overridable: true, Help: "Absolute path to the JSON file (.gz and .zst are decompressed)"},
+			{Key: "dataPath", Label: "Data Path", Type: "string", Required: false, Overridable: true, Help: "Dot-separated path to the array (e.g., 'data.items'). Leave empty if root is an array."},
+		}, directoryFields("*.json")...),
 	}
 }
 
 func (s *jsonFileSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
-	records, err := readJSONFile(cfg)
+	records, err := sampleSourceFile(cfg, "*.json", discoverSampleSize, jsonReader(cfg))
 	if err != nil {
 		return nil, err
 	}
@@ -38,59 +40,97 @@ func (s *jsonFileSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*e
 }
 
 func (s *jsonFileSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
-	out := make(chan etl.Record, 100)
-	errCh := make(chan error, 1)
+	return readSourceFilesChan(ctx, cfg, "*.json", jsonReader(cfg))
+}
 
-	go func() {
-		defer close(out)
-		defer close(errCh)
+// jsonReader streams the records of a JSON document: each object in the
+// array at dataPath (or the root), or the object found there.
+func jsonReader(cfg etl.SourceConfig) recordReader {
+	dataPath := cfgString(cfg, "dataPath", "")
+	return func(r io.Reader, emit func(etl.Record) error) error {
+		dec := json.NewDecoder(r)
+		if dataPath != "" {
+			for _, part := range strings.Split(dataPath, ".") {
+				if err := seekJSONKey(dec, part); err != nil {
+					return err
+				}
+			}
+		}
 
-		records, err := readJSONFile(cfg)
+		tok, err := dec.Token()
 		if err != nil {
-			errCh <- err
-			return
+			return fmt.Errorf("parse json: %w", err)
 		}
-		for _, rec := range records {
-			select {
-			case out <- rec:
-			case <-ctx.Done():
-				return
+		switch tok {
+		case json.Delim('['):
+			for dec.More() {
+				var item any
+				if err := dec.Decode(&item); err != nil {
+					return fmt.Errorf("parse json: %w", err)
+				}
+				if m, ok := item.(map[string]any); ok {
+					if err := emit(etl.Record{Data: flattenMap(m)}); err != nil {
+						return err
+					}
+				}
 			}
+			return nil
+		case json.Delim('{'):
+			// Single object → single record.
+			m, err := decodeJSONObjectRest(dec)
+			if err != nil {
+				return fmt.Errorf("parse json: %w", err)
+			}
+			return emit(etl.Record{Data: flattenMap(m)})
 		}
-	}()
-
-	return out, errCh
-}
-
-func readJSONFile(cfg etl.SourceConfig) ([]etl.Record, error) {
-	filePath, _ := cfg["filePath"].(string)
-	if filePath == "" {
-		return nil, fmt.Errorf("filePath is required")
+		return nil // a scalar holds no records
 	}
+}
 
-	data, err := os.ReadFile(filePath)
+// seekJSONKey advances dec past the key of the object it is about to read,
+// leaving it at the key's value.
+func seekJSONKey(dec *json.Decoder, key string) error {
+	tok, err := dec.Token()
 	if err != nil {
-		return nil, fmt.Errorf("read file: %w", err)
+		return fmt.Errorf("parse json: %w", err)
 	}
-
-	var raw any
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("parse json: %w", err)
+	if tok != json.Delim('{') {
+		return fmt.Errorf("invalid data path: %q not found", key)
 	}
-
-	// Navigate to dataPath if specified.
-	if dataPath, ok := cfg["dataPath"].(string); ok && dataPath != "" {
-		parts := strings.Split(dataPath, ".")
-		current := raw
-		for _, part := range parts {
-			if m, ok := current.(map[string]any); ok {
-				current = m[part]
-			} else {
-				return nil, fmt.Errorf("invalid data path: %q not found", part)
-			}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("parse json: %w", err)
+		}
+		if tok == key {
+			return nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return fmt.Errorf("parse json: %w", err)
 		}
-		raw = current
 	}
+	return fmt.Errorf("invalid data path: %q not found", key)
+}
 
-	return toRecords(raw), nil
+// decodeJSONObjectRest decodes the members of an object whose opening brace
+// dec has already consumed.
+func decodeJSONObjectRest(dec *json.Decoder) (map[string]any, error) {
+	m := make(map[string]any)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	if _, err := dec.Token(); err != nil { // closing brace
+		return nil, err
+	}
+	return m, nil
 }
@@ -0,0 +1,63 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"notes/internal/etl"
+)
+
+// ── JSON Lines File Source ──────────────────────────────────
+// Reads records from a JSON Lines (NDJSON) file, one object per line, or
+// from every such file in a directory (see directory.go). Lines are decoded
+// one at a time, so multi-gigabyte log exports stream through.
+
+type jsonlFileSource struct{}
+
+func init() { etl.RegisterSource(&jsonlFileSource{}) }
+
+func (s *jsonlFileSource) Spec() etl.SourceSpec {
+	return etl.SourceSpec{
+		Type:  "jsonl_file",
+		Label: "JSON Lines File",
+		Icon:  "IconFileTypeJs",
+		ConfigFields: append([]etl.ConfigField{
+			{Key: "filePath", Label: "File Path", Type: "file", Required: false, Overridable: true, Help: "Absolute path to the .jsonl/.ndjson file (.gz and .zst are decompressed)"},
+		}, directoryFields("*.jsonl")...),
+	}
+}
+
+func (s *jsonlFileSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
+	records, err := sampleSourceFile(cfg, "*.jsonl", discoverSampleSize, readJSONLines)
+	if err != nil {
+		return nil, err
+	}
+	return inferSchema(records), nil
+}
+
+func (s *jsonlFileSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
+	return readSourceFilesChan(ctx, cfg, "*.jsonl", readJSONLines)
+}
+
+// readJSONLines streams a sequence of JSON values, emitting each object as a
+// record. Values other than objects are skipped.
+func readJSONLines(r io.Reader, emit func(etl.Record) error) error {
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var v any
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse jsonl: value %d: %w", n, err)
+		}
+		if m, ok := v.(map[string]any); ok {
+			if err := emit(etl.Record{Data: flattenMap(m)}); err != nil {
+				return err
+			}
+		}
+	}
+}
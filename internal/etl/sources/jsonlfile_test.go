@@ -0,0 +1,125 @@
+package sources
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"notes/internal/etl"
+)
+
+func TestJSONLSource_Read(t *testing.T) {
+	src, _ := etl.GetSource("jsonl_file")
+	path := writeFile(t, t.TempDir(), "events.jsonl",
+		"{\"id\":1,\"meta\":{\"a\":1}}\n\n{\"id\":2}\n[1,2]\n{\"id\":3}")
+
+	recCh, errCh := src.Read(context.Background(), etl.SourceConfig{"filePath": path})
+	var records []etl.Record
+	for r := range recCh {
+		records = append(records, r)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3 (blank line and array skipped)", len(records))
+	}
+	if records[0].Data["meta"] != `{"a":1}` || records[2].Data["id"] != 3.0 {
+		t.Errorf("records = %v", records)
+	}
+
+	schema, err := src.Discover(context.Background(), etl.SourceConfig{"filePath": path})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(schema.Fields) != 2 {
+		t.Errorf("fields = %v", schema.Fields)
+	}
+}
+
+func TestJSONLSource_Read_InvalidLine(t *testing.T) {
+	src, _ := etl.GetSource("jsonl_file")
+	path := writeFile(t, t.TempDir(), "bad.jsonl", "{\"id\":1}\n{oops\n")
+
+	recCh, errCh := src.Read(context.Background(), etl.SourceConfig{"filePath": path})
+	for range recCh {
+	}
+	if err := <-errCh; err == nil || !strings.Contains(err.Error(), "value 2") {
+		t.Fatalf("err = %v, want parse error for value 2", err)
+	}
+}
+
+func TestFileSources_Compressed(t *testing.T) {
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("id,name\n1,alice\n2,bob\n"))
+	zw.Close()
+	csvPath := writeFile(t, dir, "export.csv.gz", gz.String())
+
+	enc, _ := zstd.NewWriter(nil)
+	zst := enc.EncodeAll([]byte(`[{"id":1},{"id":2}]`), nil)
+	jsonPath := writeFile(t, dir, "export.json.zst", string(zst))
+
+	for _, tc := range []struct {
+		source, path string
+	}{
+		{"csv_file", csvPath},
+		{"json_file", jsonPath},
+	} {
+		src, _ := etl.GetSource(tc.source)
+		recCh, errCh := src.Read(context.Background(), etl.SourceConfig{"filePath": tc.path})
+		var records []etl.Record
+		for r := range recCh {
+			records = append(records, r)
+		}
+		if err := <-errCh; err != nil {
+			t.Fatalf("%s: read: %v", tc.source, err)
+		}
+		if len(records) != 2 || records[1].Data["id"] != 2.0 {
+			t.Errorf("%s: records = %v", tc.source, records)
+		}
+	}
+}
+
+func TestFileSources_Streaming(t *testing.T) {
+	// A large file is not read past what the consumer takes.
+	var b strings.Builder
+	for i := range 50000 {
+		fmt.Fprintf(&b, "{\"id\":%d}\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "big.jsonl")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := &etl.Engine{}
+	records, _, err := engine.Preview(context.Background(), "jsonl_file", etl.SourceConfig{"filePath": path}, 10)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if len(records) != 10 {
+		t.Errorf("records = %d, want 10", len(records))
+	}
+
+	// Cancelling the read stops the source without an error.
+	ctx, cancel := context.WithCancel(context.Background())
+	src, _ := etl.GetSource("json_file")
+	arr := writeFile(t, t.TempDir(), "big.json", "["+strings.TrimSuffix(strings.ReplaceAll(b.String(), "\n", ","), ",")+"]")
+	recCh, errCh := src.Read(ctx, etl.SourceConfig{"filePath": arr})
+	<-recCh
+	cancel()
+	for range recCh {
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("err = %v, want nil after cancel", err)
+	}
+}
@@ -0,0 +1,106 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+
+	"notes/internal/domain"
+	"notes/internal/etl"
+)
+
+// ── LocalDB Source ─────────────────────────────────────────
+// Reads the rows of a LocalDatabase, so one LocalDB can feed another through
+// the transform chain (derived tables, summaries). Rows store their values by
+// column ID; the source maps them back to column names using the database's
+// ConfigJSON. All rows are loaded before the first record is emitted, so a job
+// may safely read from and write to the same LocalDB.
+
+// LocalDBReader provides read access to LocalDB tables.
+// The ETL service injects its store at construction.
+type LocalDBReader interface {
+	GetDatabase(id string) (*domain.LocalDatabase, error)
+	ListRows(databaseID string) ([]domain.LocalDBRow, error)
+}
+
+var localDBReader LocalDBReader
+
+// SetLocalDBReader sets the store the localdb source reads from.
+func SetLocalDBReader(r LocalDBReader) { localDBReader = r }
+
+type localDBSource struct{}
+
+func init() { etl.RegisterSource(&localDBSource{}) }
+
+func (s *localDBSource) Spec() etl.SourceSpec {
+	return etl.SourceSpec{
+		Type:  "localdb",
+		Label: "Local Database",
+		Icon:  "IconTable",
+		ConfigFields: []etl.ConfigField{
+			{Key: "databaseId", Label: "Database", Type: "localdb", Required: true, Help: "LocalDB table to read rows from"},
+		},
+	}
+}
+
+// loadLocalDB returns the configured database as a table.
+func loadLocalDB(cfg etl.SourceConfig) (*etl.LocalDBTable, error) {
+	dbID := cfgString(cfg, "databaseId", "")
+	if dbID == "" {
+		return nil, fmt.Errorf("databaseId is required")
+	}
+	if localDBReader == nil {
+		return nil, fmt.Errorf("local database reader not initialized")
+	}
+	db, err := localDBReader.GetDatabase(dbID)
+	if err != nil {
+		return nil, fmt.Errorf("database %s: %w", dbID, err)
+	}
+	return etl.NewLocalDBTable(db)
+}
+
+func (s *localDBSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
+	table, err := loadLocalDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	schema := &etl.Schema{Fields: make([]etl.Field, len(table.Columns))}
+	for i, c := range table.Columns {
+		schema.Fields[i] = etl.Field{Name: c.Name, Type: c.FieldType()}
+	}
+	return schema, nil
+}
+
+func (s *localDBSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
+	out := make(chan etl.Record, 100)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		table, err := loadLocalDB(cfg)
+		if err != nil {
+			errCh <- err
+			return
+		}
+
+		rows, err := localDBReader.ListRows(table.DB.ID)
+		if err != nil {
+			errCh <- fmt.Errorf("list rows of %q: %w", table.DB.Name, err)
+			return
+		}
+		for _, row := range rows {
+			rec, ok := table.Record(row)
+			if !ok {
+				continue
+			}
+			select {
+			case out <- rec:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, errCh
+}
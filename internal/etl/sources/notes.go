@@ -0,0 +1,393 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"notes/internal/domain"
+	"notes/internal/etl"
+)
+
+// ── Notes Source ───────────────────────────────────────────
+// Reads structured data out of the notebooks themselves. Every markdown block
+// and board document is scanned for one kind of record:
+//   - tasks: "- [ ]" / "- [x]" list items, with their checked state
+//   - frontmatter: the YAML front-matter properties, one record per document
+//   - tables: the rows of markdown tables, keyed by the header row
+//
+// Each record carries the notebook, page and block it came from. Documents can
+// be limited to one notebook (by name or ID) and to those carrying a tag,
+// either a #tag in the text or an entry in the front-matter "tags".
+
+// NotesReader provides read access to notebooks, pages and blocks.
+// The app layer implements this and injects it at startup.
+type NotesReader interface {
+	ListNotebooks() ([]domain.Notebook, error)
+	ListPages(notebookID string) ([]domain.Page, error)
+	ListBlocks(pageID string) ([]domain.Block, error)
+}
+
+var notesReader NotesReader
+
+// SetNotesReader is called by the app at startup.
+func SetNotesReader(r NotesReader) { notesReader = r }
+
+type notesSource struct{}
+
+func init() { etl.RegisterSource(&notesSource{}) }
+
+func (s *notesSource) Spec() etl.SourceSpec {
+	return etl.SourceSpec{
+		Type:  "notes",
+		Label: "Notes",
+		Icon:  "IconNotebook",
+		ConfigFields: []etl.ConfigField{
+			{Key: "kind", Label: "Records", Type: "select", Required: true, Overridable: true, Options: []string{"tasks", "frontmatter", "tables"}, Default: "tasks", Help: "What to extract from each document"},
+			{Key: "notebook", Label: "Notebook", Type: "string", Required: false, Overridable: true, Help: "Notebook name or ID. Leave empty to scan every notebook."},
+			{Key: "tag", Label: "Tag", Type: "string", Required: false, Overridable: true, Help: "Only documents with this #tag or front-matter tag"},
+		},
+	}
+}
+
+func (s *notesSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
+	var records []etl.Record
+	err := scanNotes(ctx, cfg, func(rec etl.Record) error {
+		records = append(records, rec)
+		if len(records) >= discoverSampleSize {
+			return errStopRead
+		}
+		return nil
+	})
+	if err != nil && err != errStopRead {
+		return nil, err
+	}
+	return inferSchema(records), nil
+}
+
+func (s *notesSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
+	out := make(chan etl.Record, 100)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		err := scanNotes(ctx, cfg, func(rec etl.Record) error {
+			select {
+			case out <- rec:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			errCh <- err
+		}
+	}()
+
+	return out, errCh
+}
+
+// noteDoc is one scanned document: a markdown block or a board page.
+type noteDoc struct {
+	notebook, page, pageID, blockID string
+	text                            string
+}
+
+// context returns the fields identifying where a record came from.
+func (d noteDoc) context() map[string]any {
+	return map[string]any{"notebook": d.notebook, "page": d.page, "pageId": d.pageID, "blockId": d.blockID}
+}
+
+// scanNotes walks the configured notebooks and emits the records of every
+// matching document.
+func scanNotes(ctx context.Context, cfg etl.SourceConfig, emit func(etl.Record) error) error {
+	if notesReader == nil {
+		return fmt.Errorf("notes reader not initialized")
+	}
+	kind := cfgString(cfg, "kind", "tasks")
+	var extract func(noteDoc, string, map[string]any, func(etl.Record) error) error
+	switch kind {
+	case "tasks":
+		extract = extractTasks
+	case "frontmatter":
+		extract = extractFrontMatter
+	case "tables":
+		extract = extractTables
+	default:
+		return fmt.Errorf("unknown record kind %q", kind)
+	}
+	notebook := strings.TrimSpace(cfgString(cfg, "notebook", ""))
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(cfgString(cfg, "tag", "")), "#"))
+
+	notebooks, err := notesReader.ListNotebooks()
+	if err != nil {
+		return fmt.Errorf("list notebooks: %w", err)
+	}
+	found := notebook == ""
+	for _, nb := range notebooks {
+		if notebook != "" && nb.ID != notebook && !strings.EqualFold(nb.Name, notebook) {
+			continue
+		}
+		found = true
+
+		pages, err := notesReader.ListPages(nb.ID)
+		if err != nil {
+			return fmt.Errorf("list pages of %q: %w", nb.Name, err)
+		}
+		for _, p := range pages {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			docs := []noteDoc{{notebook: nb.Name, page: p.Name, pageID: p.ID, text: p.BoardContent}}
+			blocks, err := notesReader.ListBlocks(p.ID)
+			if err != nil {
+				return fmt.Errorf("list blocks of %q: %w", p.Name, err)
+			}
+			for _, b := range blocks {
+				if b.Type == domain.BlockTypeMarkdown {
+					docs = append(docs, noteDoc{notebook: nb.Name, page: p.Name, pageID: p.ID, blockID: b.ID, text: b.Content})
+				}
+			}
+
+			for _, d := range docs {
+				if strings.TrimSpace(d.text) == "" {
+					continue
+				}
+				body, props := splitFrontMatter(d.text)
+				if tag != "" && !hasNoteTag(body, props, tag) {
+					continue
+				}
+				if err := extract(d, body, props, emit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("notebook %q not found", notebook)
+	}
+	return nil
+}
+
+// ── Front-matter and Tags ──────────────────────────────────
+
+// splitFrontMatter separates a leading "---" YAML block from the body. Text
+// without valid front-matter is returned whole with nil properties.
+func splitFrontMatter(text string) (string, map[string]any) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	if !strings.HasPrefix(text, "---\n") && !strings.HasPrefix(text, "---\r\n") {
+		return text, nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimRight(lines[i], "\r\n"); l == "---" || l == "..." {
+			var props map[string]any
+			if err := yaml.Unmarshal([]byte(strings.Join(lines[1:i], "")), &props); err != nil {
+				return text, nil
+			}
+			return strings.Join(lines[i+1:], ""), props
+		}
+	}
+	return text, nil
+}
+
+// hashtagRe matches #tags in text; headings ("# Title") have a space.
+var hashtagRe = regexp.MustCompile(`(?:^|[\s(])#([\p{L}\p{N}_/-]+)`)
+
+// hasNoteTag reports whether a document carries tag (lower case, no "#").
+func hasNoteTag(body string, props map[string]any, tag string) bool {
+	switch tags := props["tags"].(type) {
+	case []any:
+		for _, t := range tags {
+			if strings.EqualFold(strings.TrimPrefix(fmt.Sprint(t), "#"), tag) {
+				return true
+			}
+		}
+	case string:
+		for _, t := range strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if strings.EqualFold(strings.TrimPrefix(t, "#"), tag) {
+				return true
+			}
+		}
+	}
+	for _, m := range hashtagRe.FindAllStringSubmatch(body, -1) {
+		if strings.ToLower(m[1]) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// noteValue converts a YAML value to a record value: numbers as float64,
+// dates as the typecast transform formats them, lists and maps as JSON.
+func noteValue(v any) any {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case float64, string, bool, nil:
+		return t
+	case time.Time:
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+	return flattenMap(map[string]any{"v": v})["v"]
+}
+
+// ── Extractors ─────────────────────────────────────────────
+
+func extractFrontMatter(d noteDoc, _ string, props map[string]any, emit func(etl.Record) error) error {
+	if len(props) == 0 {
+		return nil
+	}
+	data := d.context()
+	for k, v := range props {
+		if _, ok := data[k]; !ok { // the context fields win over properties
+			data[k] = noteValue(v)
+		}
+	}
+	return emit(etl.Record{Data: data})
+}
+
+var (
+	taskRe    = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	headingRe = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+)
+
+// noteLines calls fn for each line outside fenced code blocks, with the
+// nearest heading above it.
+func noteLines(body string, fn func(line, section string) error) error {
+	fence, section := "", ""
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			section = m[1]
+		}
+		if err := fn(line, section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTasks(d noteDoc, body string, _ map[string]any, emit func(etl.Record) error) error {
+	return noteLines(body, func(line, section string) error {
+		m := taskRe.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		data := d.context()
+		data["text"] = strings.TrimSpace(m[2])
+		data["checked"] = m[1] != " "
+		data["section"] = section
+		return emit(etl.Record{Data: data})
+	})
+}
+
+// extractTables emits the rows of each markdown table, numbering the tables
+// of a document from 1. Numeric cells become numbers.
+func extractTables(d noteDoc, body string, _ map[string]any, emit func(etl.Record) error) error {
+	var header, pending []string
+	table := 0
+	return noteLines(body, func(line, _ string) error {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "|") {
+			header, pending = nil, nil
+			return nil
+		}
+		cells := splitTableRow(trimmed)
+		switch {
+		case header != nil:
+			data := d.context()
+			data["table"] = float64(table)
+			for i, name := range header {
+				var v any
+				if i < len(cells) {
+					v = tableCellValue(cells[i])
+				}
+				data[name] = v
+			}
+			return emit(etl.Record{Data: data})
+		case pending != nil && isTableSeparator(cells):
+			header = make([]string, len(pending))
+			seen := make(map[string]int)
+			for i, name := range pending {
+				if name == "" {
+					name = fmt.Sprintf("col_%d", i+1)
+				}
+				if seen[name]++; seen[name] > 1 {
+					name = fmt.Sprintf("%s_%d", name, seen[name])
+				}
+				header[i] = name
+			}
+			table++
+		default:
+			pending = cells
+		}
+		return nil
+	})
+}
+
+// splitTableRow splits "| a | b\|c |" into its trimmed cells.
+func splitTableRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	var cells []string
+	var cur strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+var tableSepRe = regexp.MustCompile(`^:?-+:?$`)
+
+func isTableSeparator(cells []string) bool {
+	for _, c := range cells {
+		if !tableSepRe.MatchString(c) {
+			return false
+		}
+	}
+	return len(cells) > 0
+}
+
+func tableCellValue(s string) any {
+	if s == "" {
+		return nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
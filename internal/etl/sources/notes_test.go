@@ -0,0 +1,135 @@
+package sources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"notes/internal/domain"
+	"notes/internal/etl"
+)
+
+// fakeNotes is an in-memory NotesReader.
+type fakeNotes struct {
+	notebooks []domain.Notebook
+	pages     map[string][]domain.Page  // notebook ID → pages
+	blocks    map[string][]domain.Block // page ID → blocks
+}
+
+func (f *fakeNotes) ListNotebooks() ([]domain.Notebook, error)  { return f.notebooks, nil }
+func (f *fakeNotes) ListPages(id string) ([]domain.Page, error) { return f.pages[id], nil }
+func (f *fakeNotes) ListBlocks(id string) ([]domain.Block, error) {
+	return f.blocks[id], nil
+}
+
+func setupNotes(t *testing.T) {
+	t.Helper()
+	SetNotesReader(&fakeNotes{
+		notebooks: []domain.Notebook{{ID: "nb-work", Name: "Work"}, {ID: "nb-home", Name: "Home"}},
+		pages: map[string][]domain.Page{
+			"nb-work": {{ID: "p1", Name: "Sprint", BoardContent: "## Todo\n\n- [ ] write report #ops\n- [x] ship release\n\n```\n- [ ] not a task\n```\n"}},
+			"nb-home": {{ID: "p2", Name: "Garden"}},
+		},
+		blocks: map[string][]domain.Block{
+			"p1": {
+				{ID: "b1", Type: domain.BlockTypeMarkdown, Content: "---\nstatus: active\npriority: 2\ntags: [ops, q3]\ndue: 2024-05-01\n---\n# Notes\n\n| item | cost |\n|------|-----:|\n| disk | 120 |\n| ram \\| ecc | 80.5 |\n\ntext\n\n| a |\n|---|\n| x |\n"},
+				{ID: "b2", Type: domain.BlockTypeCode, Content: "- [ ] in code block"},
+			},
+			"p2": {
+				{ID: "b3", Type: domain.BlockTypeMarkdown, Content: "* [X] water plants\n"},
+			},
+		},
+	})
+	t.Cleanup(func() { SetNotesReader(nil) })
+}
+
+func readNotes(t *testing.T, cfg etl.SourceConfig) []etl.Record {
+	t.Helper()
+	src, _ := etl.GetSource("notes")
+	recCh, errCh := src.Read(context.Background(), cfg)
+	var records []etl.Record
+	for r := range recCh {
+		records = append(records, r)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return records
+}
+
+func TestNotesSource_Tasks(t *testing.T) {
+	setupNotes(t)
+
+	records := readNotes(t, etl.SourceConfig{"kind": "tasks"})
+	if len(records) != 3 {
+		t.Fatalf("tasks = %d, want 3: %v", len(records), records)
+	}
+	first := records[0].Data
+	if first["text"] != "write report #ops" || first["checked"] != false || first["page"] != "Sprint" ||
+		first["notebook"] != "Work" || first["blockId"] != "" || first["section"] != "Todo" {
+		t.Errorf("first = %v", first)
+	}
+	if records[2].Data["checked"] != true || records[2].Data["blockId"] != "b3" {
+		t.Errorf("last = %v", records[2].Data)
+	}
+
+	// Filtered by notebook name and by tag.
+	if got := readNotes(t, etl.SourceConfig{"kind": "tasks", "notebook": "home"}); len(got) != 1 {
+		t.Errorf("home tasks = %v", got)
+	}
+	if got := readNotes(t, etl.SourceConfig{"kind": "tasks", "tag": "#ops"}); len(got) != 2 {
+		t.Errorf("#ops tasks = %v", got)
+	}
+
+	src, _ := etl.GetSource("notes")
+	recCh, errCh := src.Read(context.Background(), etl.SourceConfig{"notebook": "Missing"})
+	for range recCh {
+	}
+	if err := <-errCh; err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want notebook not found", err)
+	}
+}
+
+func TestNotesSource_FrontMatterAndTables(t *testing.T) {
+	setupNotes(t)
+
+	records := readNotes(t, etl.SourceConfig{"kind": "frontmatter"})
+	if len(records) != 1 {
+		t.Fatalf("frontmatter records = %v", records)
+	}
+	fm := records[0].Data
+	if fm["status"] != "active" || fm["priority"] != 2.0 || fm["tags"] != `["ops","q3"]` || fm["due"] != "2024-05-01" || fm["blockId"] != "b1" {
+		t.Errorf("frontmatter = %v", fm)
+	}
+	// A front-matter tag matches the tag filter.
+	if got := readNotes(t, etl.SourceConfig{"kind": "frontmatter", "tag": "q3"}); len(got) != 1 {
+		t.Errorf("q3 = %v", got)
+	}
+
+	records = readNotes(t, etl.SourceConfig{"kind": "tables"})
+	if len(records) != 3 {
+		t.Fatalf("table rows = %d, want 3: %v", len(records), records)
+	}
+	if records[0].Data["item"] != "disk" || records[0].Data["cost"] != 120.0 || records[0].Data["table"] != 1.0 {
+		t.Errorf("row 1 = %v", records[0].Data)
+	}
+	if records[1].Data["item"] != "ram | ecc" || records[1].Data["cost"] != 80.5 {
+		t.Errorf("row 2 = %v", records[1].Data)
+	}
+	if records[2].Data["a"] != "x" || records[2].Data["table"] != 2.0 {
+		t.Errorf("row 3 = %v", records[2].Data)
+	}
+
+	src, _ := etl.GetSource("notes")
+	schema, err := src.Discover(context.Background(), etl.SourceConfig{"kind": "tables"})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	types := map[string]string{}
+	for _, f := range schema.Fields {
+		types[f.Name] = f.Type
+	}
+	if types["cost"] != "number" || types["item"] != "text" {
+		t.Errorf("schema = %v", schema.Fields)
+	}
+}
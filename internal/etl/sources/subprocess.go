@@ -0,0 +1,541 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── Subprocess Source ───────────────────────────────────────
+// Runs an external connector that speaks the Singer tap or Airbyte source
+// message protocol, so a new source does not need a rebuild of the app:
+//
+//	<command> [args] --config config.json [--catalog catalog.json] [--state state.json]
+//
+// The connector writes one JSON message per line to stdout:
+//
+//	RECORD  a row: "record" in Singer, "record.data" in Airbyte
+//	STATE   the connector's bookmark, handed back on the next incremental run
+//	LOG     a log line (Airbyte), kept with stderr in the run output
+//
+// Other message types are ignored. The config and catalog are written to
+// temp files that only live as long as the process.
+//
+// Discover takes the stream's schema from the configured catalog. Without
+// one it runs the connector in discover mode, which lists the streams without
+// reading data: `read` in the arguments becomes `discover` for an Airbyte
+// source, and a Singer tap gets --discover. A connector that prints no
+// catalog gets a schema inferred from the records it printed instead, which
+// is empty when there are none.
+
+// maxConnectorLine bounds a single stdout message, and the discover output.
+const maxConnectorLine = 16 << 20
+
+// errStopConnector ends a connector run early without an error.
+var errStopConnector = errors.New("stop connector")
+
+type subprocessSource struct{}
+
+func init() { etl.RegisterSource(&subprocessSource{}) }
+
+func (s *subprocessSource) Spec() etl.SourceSpec {
+	return etl.SourceSpec{
+		Type:     "subprocess",
+		Label:    "Connector (Singer / Airbyte)",
+		Icon:     "IconPlugConnected",
+		Stateful: true,
+		ConfigFields: []etl.ConfigField{
+			{Key: "command", Label: "Executable", Type: "file", Required: true, Help: "Path to the connector executable (e.g., a Singer tap)"},
+			{Key: "args", Label: "Arguments", Type: "string", Required: false, Help: "Arguments before --config, separated by spaces (e.g., 'read' for an Airbyte source)"},
+			{Key: "config", Label: "Config", Type: "textarea", Required: false, Sensitive: true, Help: "JSON object passed to the connector with --config"},
+			{Key: "catalog", Label: "Catalog", Type: "textarea", Required: false, Help: "JSON catalog passed with --catalog (required by Airbyte sources); its stream schema is used as the discovered schema"},
+			{Key: "stream", Label: "Stream", Type: "string", Required: false, Overridable: true, Help: "Only read records of this stream. Leave empty for all streams."},
+		},
+	}
+}
+
+func (s *subprocessSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
+	stream := cfgString(cfg, "stream", "")
+	if schema, ok := catalogSchema(json.RawMessage(cfgString(cfg, "catalog", "")), stream); ok {
+		return jsonSchemaFields(schema), nil
+	}
+
+	var out []byte
+	err := execConnector(ctx, cfg, &etl.SourceRun{}, true, func(stdout io.Reader) error {
+		var err error
+		out, err = io.ReadAll(io.LimitReader(stdout, maxConnectorLine))
+		if err == nil && len(out) == maxConnectorLine {
+			// Far more than a catalog: the connector is reading data.
+			return errStopConnector
+		}
+		return err
+	})
+	// A connector without a discover mode may reject the flag; the read
+	// reports any real failure.
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	catalog := parseCatalog(out)
+	if schema, ok := catalogSchema(catalog, stream); ok {
+		return jsonSchemaFields(schema), nil
+	}
+	if stream != "" && catalogHasStreams(catalog) {
+		return nil, fmt.Errorf("connector catalog has no stream %q", stream)
+	}
+	// No catalog: infer the schema from any records the connector printed.
+	return inferSchema(outputRecords(out, stream)), nil
+}
+
+func (s *subprocessSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
+	out := make(chan etl.Record, 100)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		run, ok := etl.SourceRunFromConfig(cfg)
+		if !ok {
+			run = &etl.SourceRun{}
+		}
+		stream := cfgString(cfg, "stream", "")
+		state := newConnectorState(run.PrevState)
+		err := runConnector(ctx, cfg, run, func(m *connectorMessage) error {
+			switch m.Type {
+			case "RECORD":
+				rec, ok := m.record(stream)
+				if !ok {
+					return nil
+				}
+				select {
+				case out <- rec:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			case "STATE":
+				if state.add(m) {
+					run.SaveState(state.encode())
+				}
+			case "LOG":
+				if m.Log != nil {
+					fmt.Fprintf(run, "[%s] %s\n", m.Log.Level, m.Log.Message)
+				}
+			case "TRACE":
+				if m.Trace != nil && m.Trace.Error != nil {
+					fmt.Fprintf(run, "[ERROR] %s\n", m.Trace.Error.Message)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
+	return out, errCh
+}
+
+// runConnector reads with the connector configured in cfg and calls handle
+// for every message it writes to stdout. stderr and non-protocol output go to
+// run.
+func runConnector(ctx context.Context, cfg etl.SourceConfig, run *etl.SourceRun, handle func(*connectorMessage) error) error {
+	return execConnector(ctx, cfg, run, false, func(stdout io.Reader) error {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64<<10), maxConnectorLine)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var m connectorMessage
+			if err := json.Unmarshal(line, &m); err != nil || m.Type == "" {
+				// Not a protocol message; keep it with the diagnostics.
+				fmt.Fprintf(run, "%s\n", line)
+				continue
+			}
+			m.Type = strings.ToUpper(m.Type)
+			if err := handle(&m); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("read %s output: %w", filepath.Base(cfgString(cfg, "command", "")), err)
+		}
+		return nil
+	})
+}
+
+// execConnector starts the connector configured in cfg, in discover mode or
+// to read, and hands its stdout to consume. stderr goes to run. The process is
+// killed when consume fails.
+func execConnector(ctx context.Context, cfg etl.SourceConfig, run *etl.SourceRun, discover bool, consume func(io.Reader) error) error {
+	command := cfgString(cfg, "command", "")
+	if command == "" {
+		return fmt.Errorf("command is required")
+	}
+	name := filepath.Base(command)
+
+	dir, err := os.MkdirTemp("", "etl-connector-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	args, err := connectorArgs(cfg, dir, discover, run.PrevState)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stderr = run
+	cmd.WaitDelay = 5 * time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", name, err)
+	}
+
+	consumeErr := consume(stdout)
+	if consumeErr != nil {
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+
+	switch {
+	case errors.Is(consumeErr, errStopConnector):
+		return nil
+	case consumeErr != nil && ctx.Err() == nil:
+		return consumeErr
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case waitErr != nil:
+		if last := lastLine(run.Output()); last != "" {
+			return fmt.Errorf("%s: %w: %s", name, waitErr, last)
+		}
+		return fmt.Errorf("%s: %w", name, waitErr)
+	}
+	return nil
+}
+
+// connectorArgs writes the connector's input files to dir and returns its
+// arguments. Discover mode passes only the config.
+func connectorArgs(cfg etl.SourceConfig, dir string, discover bool, state string) ([]string, error) {
+	args := strings.Fields(cfgString(cfg, "args", ""))
+	files := []struct{ flag, key, value string }{
+		{"--config", "config", cfgString(cfg, "config", "{}")},
+	}
+	airbyte := slices.Index(args, "read")
+	if discover {
+		if airbyte >= 0 {
+			args[airbyte] = "discover"
+		}
+	} else {
+		files = append(files,
+			struct{ flag, key, value string }{"--catalog", "catalog", cfgString(cfg, "catalog", "")},
+			struct{ flag, key, value string }{"--state", "state", state},
+		)
+	}
+	for _, f := range files {
+		if strings.TrimSpace(f.value) == "" {
+			continue
+		}
+		if !json.Valid([]byte(f.value)) {
+			return nil, fmt.Errorf("%s must be valid JSON", f.key)
+		}
+		path := filepath.Join(dir, f.key+".json")
+		if err := os.WriteFile(path, []byte(f.value), 0o600); err != nil {
+			return nil, err
+		}
+		args = append(args, f.flag, path)
+	}
+	if discover && airbyte < 0 {
+		args = append(args, "--discover")
+	}
+	return args, nil
+}
+
+// lastLine returns the last non-empty line of s.
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
+
+// ── Connector Messages ─────────────────────────────────────
+
+// connectorMessage is one stdout line of a Singer or Airbyte connector.
+type connectorMessage struct {
+	Type   string          `json:"type"`
+	Stream string          `json:"stream"` // Singer
+	Schema json.RawMessage `json:"schema"` // Singer SCHEMA
+	Record json.RawMessage `json:"record"`
+	Value  json.RawMessage `json:"value"` // Singer STATE
+	State  json.RawMessage `json:"state"` // Airbyte STATE
+	Log    *struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	} `json:"log"`
+	Trace *struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"trace"`
+}
+
+// record returns the row of a RECORD message if it belongs to stream ("" for
+// any stream).
+func (m *connectorMessage) record(stream string) (etl.Record, bool) {
+	var raw map[string]any
+	if err := json.Unmarshal(m.Record, &raw); err != nil || raw == nil {
+		return etl.Record{}, false
+	}
+	recStream, data := m.Stream, raw
+	// Airbyte nests the row and its stream inside the record.
+	if m.Stream == "" {
+		if d, ok := raw["data"].(map[string]any); ok {
+			recStream, _ = raw["stream"].(string)
+			data = d
+		}
+	}
+	if stream != "" && recStream != stream {
+		return etl.Record{}, false
+	}
+	return etl.Record{Data: flattenMap(data)}, true
+}
+
+// parseCatalog finds the catalog in a connector's discover output: a CATALOG
+// message from an Airbyte source, or the whole output of a Singer tap.
+func parseCatalog(out []byte) json.RawMessage {
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		var m struct {
+			Type    string          `json:"type"`
+			Catalog json.RawMessage `json:"catalog"`
+		}
+		if json.Unmarshal(bytes.TrimSpace(line), &m) == nil && strings.EqualFold(m.Type, "CATALOG") {
+			return m.Catalog
+		}
+	}
+	return bytes.TrimSpace(out)
+}
+
+// catalogHasStreams reports whether catalog lists any stream.
+func catalogHasStreams(catalog json.RawMessage) bool {
+	var c struct {
+		Streams []json.RawMessage `json:"streams"`
+	}
+	return json.Unmarshal(catalog, &c) == nil && len(c.Streams) > 0
+}
+
+// outputRecords returns up to discoverSampleSize records of stream from a
+// connector's output.
+func outputRecords(out []byte, stream string) []etl.Record {
+	var records []etl.Record
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		var m connectorMessage
+		if json.Unmarshal(bytes.TrimSpace(line), &m) != nil || !strings.EqualFold(m.Type, "RECORD") {
+			continue
+		}
+		if rec, ok := m.record(stream); ok {
+			if records = append(records, rec); len(records) >= discoverSampleSize {
+				break
+			}
+		}
+	}
+	return records
+}
+
+// catalogSchema returns the JSON schema of stream ("" for the first) in a
+// Singer catalog, an Airbyte catalog or an Airbyte configured catalog.
+func catalogSchema(catalog json.RawMessage, stream string) (json.RawMessage, bool) {
+	var c struct {
+		Streams []struct {
+			Name        string          `json:"name"`          // Airbyte
+			JSONSchema  json.RawMessage `json:"json_schema"`   // Airbyte
+			Stream      json.RawMessage `json:"stream"`        // Singer: name; configured catalog: the stream
+			TapStreamID string          `json:"tap_stream_id"` // Singer
+			Schema      json.RawMessage `json:"schema"`        // Singer
+		} `json:"streams"`
+	}
+	if json.Unmarshal(catalog, &c) != nil {
+		return nil, false
+	}
+	for _, st := range c.Streams {
+		names, schema := []string{st.Name, st.TapStreamID}, st.Schema
+		if len(schema) == 0 {
+			schema = st.JSONSchema
+		}
+		var name string
+		var configured struct {
+			Name       string          `json:"name"`
+			JSONSchema json.RawMessage `json:"json_schema"`
+		}
+		if json.Unmarshal(st.Stream, &name) == nil {
+			names = append(names, name)
+		} else if json.Unmarshal(st.Stream, &configured) == nil {
+			names = append(names, configured.Name)
+			schema = configured.JSONSchema
+		}
+		if len(schema) > 0 && (stream == "" || slices.Contains(names, stream)) {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+// jsonSchemaFields maps a stream's JSON schema to a Schema, keeping the
+// property order.
+func jsonSchemaFields(raw json.RawMessage) *etl.Schema {
+	var s struct {
+		Properties json.RawMessage `json:"properties"`
+	}
+	json.Unmarshal(raw, &s)
+	var props map[string]struct {
+		Type   any    `json:"type"`
+		Format string `json:"format"`
+	}
+	json.Unmarshal(s.Properties, &props)
+
+	schema := &etl.Schema{}
+	for _, name := range objectKeys(s.Properties) {
+		p := props[name]
+		typ := "text"
+		types, _ := p.Type.([]any)
+		if t, ok := p.Type.(string); ok {
+			types = []any{t}
+		}
+		for _, t := range types {
+			switch t {
+			case "integer", "number":
+				typ = "number"
+			case "boolean":
+				typ = "boolean"
+			case "string":
+				if p.Format == "date-time" || p.Format == "date" {
+					typ = "datetime"
+				}
+			}
+		}
+		schema.Fields = append(schema.Fields, etl.Field{Name: name, Type: typ})
+	}
+	return schema
+}
+
+// objectKeys returns the keys of a JSON object in document order.
+func objectKeys(raw json.RawMessage) []string {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		keys = append(keys, t.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+// ── Connector State ────────────────────────────────────────
+
+// connectorState merges STATE messages into the state file handed to the
+// next run. Singer and legacy Airbyte connectors send their whole state each
+// time; Airbyte per-stream connectors send one stream at a time, so the last
+// state of every stream is kept and passed back as an array.
+type connectorState struct {
+	whole   json.RawMessage
+	streams map[string]json.RawMessage
+	order   []string
+}
+
+// airbyteState is the part of an Airbyte state message needed to merge it.
+type airbyteState struct {
+	Type   string          `json:"type"` // "STREAM" | "GLOBAL" | "LEGACY"
+	Data   json.RawMessage `json:"data"`
+	Stream *struct {
+		Descriptor struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"stream_descriptor"`
+	} `json:"stream"`
+}
+
+func newConnectorState(prev string) *connectorState {
+	c := &connectorState{streams: make(map[string]json.RawMessage)}
+	var msgs []json.RawMessage
+	if err := json.Unmarshal([]byte(prev), &msgs); err == nil {
+		for _, m := range msgs {
+			c.addAirbyte(m)
+		}
+	} else if prev != "" {
+		c.whole = json.RawMessage(prev)
+	}
+	return c
+}
+
+// add merges a STATE message and reports whether it carried a state.
+func (c *connectorState) add(m *connectorMessage) bool {
+	switch {
+	case len(m.Value) > 0:
+		c.whole = m.Value
+	case len(m.State) > 0:
+		c.addAirbyte(m.State)
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *connectorState) addAirbyte(raw json.RawMessage) {
+	var st airbyteState
+	json.Unmarshal(raw, &st)
+	switch {
+	case st.Type == "STREAM" && st.Stream != nil:
+		c.setStream(st.Stream.Descriptor.Namespace+"."+st.Stream.Descriptor.Name, raw)
+	case st.Type == "GLOBAL":
+		c.setStream("", raw)
+	case len(st.Data) > 0:
+		c.whole = st.Data
+	}
+}
+
+func (c *connectorState) setStream(key string, raw json.RawMessage) {
+	if _, ok := c.streams[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.streams[key] = raw
+}
+
+// encode renders the state for persistence and for the --state file.
+func (c *connectorState) encode() string {
+	if len(c.streams) == 0 {
+		return string(c.whole)
+	}
+	msgs := make([]json.RawMessage, 0, len(c.order))
+	for _, k := range c.order {
+		msgs = append(msgs, c.streams[k])
+	}
+	b, _ := json.Marshal(msgs)
+	return string(b)
+}
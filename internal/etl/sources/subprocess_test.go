@@ -0,0 +1,261 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"notes/internal/etl"
+)
+
+// TestConnectorHelper is not a real test: it is the connector process the
+// subprocess source tests run, re-executing the test binary. Its config picks
+// the protocol ("singer" or "airbyte") and the number of records; it resumes
+// after the id in its state. In discover mode it prints its catalog.
+func TestConnectorHelper(t *testing.T) {
+	if os.Getenv("ETL_CONNECTOR_HELPER") != "1" {
+		return
+	}
+	var cfg struct {
+		Protocol string `json:"protocol"`
+		Count    int    `json:"count"`
+		Fail     bool   `json:"fail"`
+		// NoDiscover makes discover mode read like a connector without one.
+		NoDiscover bool `json:"noDiscover"`
+	}
+	var last int
+	var discover bool
+	for i, a := range os.Args {
+		if a == "--discover" || a == "discover" {
+			discover = true
+		}
+		if i+1 >= len(os.Args) {
+			break
+		}
+		data, _ := os.ReadFile(os.Args[i+1])
+		switch a {
+		case "--config":
+			json.Unmarshal(data, &cfg)
+		case "--state":
+			if cfg.Protocol == "airbyte" {
+				var msgs []struct {
+					Stream struct {
+						State struct{ Last int } `json:"stream_state"`
+					} `json:"stream"`
+				}
+				json.Unmarshal(data, &msgs)
+				last = msgs[0].Stream.State.Last
+			} else {
+				var st struct{ Last int }
+				json.Unmarshal(data, &st)
+				last = st.Last
+			}
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "connector starting")
+	if cfg.Fail {
+		fmt.Fprintln(os.Stderr, "invalid api key")
+		os.Exit(1)
+	}
+	emit := func(v any) {
+		b, _ := json.Marshal(v)
+		fmt.Println(string(b))
+	}
+	if discover && !cfg.NoDiscover {
+		schema := map[string]any{"properties": map[string]any{
+			"id":    map[string]any{"type": "integer"},
+			"email": map[string]any{"type": "string"},
+		}}
+		if cfg.Protocol == "airbyte" {
+			emit(map[string]any{"type": "CATALOG", "catalog": map[string]any{"streams": []any{
+				map[string]any{"name": "users", "json_schema": schema},
+			}}})
+		} else {
+			b, _ := json.MarshalIndent(map[string]any{"streams": []any{
+				map[string]any{"tap_stream_id": "other", "stream": "other", "schema": map[string]any{}},
+				map[string]any{"tap_stream_id": "users", "stream": "users", "schema": schema},
+			}}, "", "  ")
+			fmt.Println(string(b))
+		}
+		os.Exit(0)
+	}
+	if cfg.Protocol == "airbyte" {
+		emit(map[string]any{"type": "LOG", "log": map[string]any{"level": "INFO", "message": "syncing users"}})
+		for id := last + 1; id <= last+cfg.Count; id++ {
+			emit(map[string]any{"type": "RECORD", "record": map[string]any{
+				"stream": "users", "emitted_at": 0, "data": map[string]any{"id": id, "tags": []string{"a"}},
+			}})
+			emit(map[string]any{"type": "STATE", "state": map[string]any{"type": "STREAM", "stream": map[string]any{
+				"stream_descriptor": map[string]any{"name": "users"}, "stream_state": map[string]any{"last": id},
+			}}})
+		}
+		os.Exit(0)
+	}
+	emit(map[string]any{"type": "SCHEMA", "stream": "users", "schema": map[string]any{"properties": map[string]any{
+		"id":      map[string]any{"type": []string{"null", "integer"}},
+		"name":    map[string]any{"type": "string"},
+		"updated": map[string]any{"type": "string", "format": "date-time"},
+	}}})
+	fmt.Println("not a message")
+	for id := last + 1; id <= last+cfg.Count; id++ {
+		emit(map[string]any{"type": "RECORD", "stream": "users", "record": map[string]any{"id": id, "name": fmt.Sprint("user", id)}})
+		emit(map[string]any{"type": "RECORD", "stream": "other", "record": map[string]any{"x": id}})
+	}
+	emit(map[string]any{"type": "STATE", "value": map[string]any{"last": last + cfg.Count}})
+	os.Exit(0)
+}
+
+func connectorConfig(t *testing.T, config string) etl.SourceConfig {
+	t.Setenv("ETL_CONNECTOR_HELPER", "1")
+	return etl.SourceConfig{
+		"command": os.Args[0],
+		"args":    "-test.run=^TestConnectorHelper$ --",
+		"config":  config,
+		"stream":  "users",
+	}
+}
+
+func readAll(t *testing.T, cfg etl.SourceConfig) ([]etl.Record, error) {
+	t.Helper()
+	src, _ := etl.GetSource("subprocess")
+	recCh, errCh := src.Read(context.Background(), cfg)
+	var records []etl.Record
+	for r := range recCh {
+		records = append(records, r)
+	}
+	return records, <-errCh
+}
+
+func TestSubprocessSource_Discover(t *testing.T) {
+	src, _ := etl.GetSource("subprocess")
+	for _, protocol := range []string{"singer", "airbyte"} {
+		cfg := connectorConfig(t, `{"protocol":"`+protocol+`","count":2}`)
+		if protocol == "airbyte" {
+			cfg["args"] = cfg["args"].(string) + " read"
+		}
+		schema, err := src.Discover(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("%s discover: %v", protocol, err)
+		}
+		// The catalog's fields, not the SCHEMA message a read sends.
+		got := fmt.Sprint(schema.Fields)
+		if want := "[{email text} {id number}]"; got != want {
+			t.Errorf("%s fields = %s, want %s", protocol, got, want)
+		}
+	}
+
+	// A configured catalog is used without running the connector.
+	cfg := connectorConfig(t, `{"fail":true}`)
+	cfg["catalog"] = `{"streams":[{"stream":{"name":"users","json_schema":{"properties":{"name":{"type":"string"}}}},"sync_mode":"full_refresh"}]}`
+	schema, err := src.Discover(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("discover from catalog: %v", err)
+	}
+	if got := fmt.Sprint(schema.Fields); got != "[{name text}]" {
+		t.Errorf("fields = %s, want [{name text}]", got)
+	}
+
+	// Without a catalog the schema comes from the records the connector
+	// printed, and is empty when it failed without any.
+	cfg = connectorConfig(t, `{"protocol":"singer","count":2,"noDiscover":true}`)
+	if schema, err = src.Discover(context.Background(), cfg); err != nil {
+		t.Fatalf("discover without catalog: %v", err)
+	}
+	slices.SortFunc(schema.Fields, func(a, b etl.Field) int { return strings.Compare(a.Name, b.Name) })
+	if got := fmt.Sprint(schema.Fields); got != "[{id number} {name text}]" {
+		t.Errorf("fields = %s, want [{id number} {name text}]", got)
+	}
+	cfg = connectorConfig(t, `{"fail":true}`)
+	if schema, err = src.Discover(context.Background(), cfg); err != nil || len(schema.Fields) != 0 {
+		t.Errorf("schema = %v, err = %v; want no fields", schema, err)
+	}
+
+	cfg = connectorConfig(t, `{"protocol":"singer"}`)
+	cfg["stream"] = "missing"
+	if _, err := src.Discover(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), `no stream "missing"`) {
+		t.Errorf("err = %v, want missing stream", err)
+	}
+}
+
+func TestSubprocessSource_Read_Singer(t *testing.T) {
+	cfg := connectorConfig(t, `{"protocol":"singer","count":3}`)
+	run := &etl.SourceRun{}
+
+	records, err := readAll(t, etl.WithSourceRun(cfg, run))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3 (other stream filtered out)", len(records))
+	}
+	if records[2].Data["name"] != "user3" {
+		t.Errorf("record = %v", records[2].Data)
+	}
+	if state, _ := run.State(); state != `{"last":3}` {
+		t.Errorf("state = %q", state)
+	}
+	if out := run.Output(); !strings.Contains(out, "connector starting") || !strings.Contains(out, "not a message") {
+		t.Errorf("output = %q", out)
+	}
+
+	// The next run resumes from the saved state.
+	next := &etl.SourceRun{PrevState: `{"last":3}`}
+	records, err = readAll(t, etl.WithSourceRun(cfg, next))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(records) != 3 || records[0].Data["id"] != 4.0 {
+		t.Errorf("resumed records = %v", records)
+	}
+}
+
+func TestSubprocessSource_Read_Airbyte(t *testing.T) {
+	cfg := connectorConfig(t, `{"protocol":"airbyte","count":2}`)
+	run := &etl.SourceRun{}
+
+	records, err := readAll(t, etl.WithSourceRun(cfg, run))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(records) != 2 || records[1].Data["id"] != 2.0 || records[1].Data["tags"] != `["a"]` {
+		t.Fatalf("records = %v", records)
+	}
+	state, _ := run.State()
+	if !strings.HasPrefix(state, "[") || !strings.Contains(state, `"last":2`) {
+		t.Errorf("state = %q, want the per-stream state array", state)
+	}
+	if !strings.Contains(run.Output(), "[INFO] syncing users") {
+		t.Errorf("output = %q", run.Output())
+	}
+
+	records, err = readAll(t, etl.WithSourceRun(cfg, &etl.SourceRun{PrevState: state}))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(records) != 2 || records[0].Data["id"] != 3.0 {
+		t.Errorf("resumed records = %v", records)
+	}
+}
+
+func TestSubprocessSource_Read_Failure(t *testing.T) {
+	cfg := connectorConfig(t, `{"fail":true}`)
+	run := &etl.SourceRun{}
+
+	_, err := readAll(t, etl.WithSourceRun(cfg, run))
+	if err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Fatalf("err = %v, want the last stderr line", err)
+	}
+	if !strings.Contains(run.Output(), "connector starting") {
+		t.Errorf("output = %q", run.Output())
+	}
+
+	cfg["config"] = "{not json"
+	if _, err := readAll(t, cfg); err == nil || !strings.Contains(err.Error(), "valid JSON") {
+		t.Errorf("err = %v, want invalid config", err)
+	}
+}
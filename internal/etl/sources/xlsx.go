@@ -0,0 +1,622 @@
+package sources
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── Excel (.xlsx) Source ────────────────────────────────────
+// Reads one sheet of an Excel workbook. The workbook is read with the
+// standard library: an .xlsx file is a zip of XML parts, and the sheet is
+// decoded row by row so large sheets stream. Cells are typed from the file:
+// numbers, booleans, text, and dates (numbers with a date format). Formula
+// cells yield the value Excel cached when the file was last saved.
+
+type xlsxSource struct{}
+
+func init() { etl.RegisterSource(&xlsxSource{}) }
+
+func (s *xlsxSource) Spec() etl.SourceSpec {
+	return etl.SourceSpec{
+		Type:  "xlsx_file",
+		Label: "Excel Spreadsheet",
+		Icon:  "IconFileSpreadsheet",
+		ConfigFields: []etl.ConfigField{
+			{Key: "filePath", Label: "File Path", Type: "file", Required: true, Overridable: true, Help: "Absolute path to the .xlsx file"},
+			{Key: "sheet", Label: "Sheet", Type: "string", Required: false, Overridable: true, Help: "Sheet name. Leave empty for the first sheet."},
+			{Key: "range", Label: "Cell Range", Type: "string", Required: false, Overridable: true, Help: "Cells to read (e.g., 'B3:F200' or 'A:D'). Leave empty for the whole sheet."},
+			{Key: "headerRow", Label: "Header Row", Type: "string", Required: false, Overridable: true, Help: "Row number holding the column names (default: first row of the range, 0 = no header)"},
+		},
+	}
+}
+
+func (s *xlsxSource) Discover(ctx context.Context, cfg etl.SourceConfig) (*etl.Schema, error) {
+	var headers []string
+	kinds := make(map[string]string) // column → type, "" once mixed
+	n := 0
+	err := readXLSX(cfg, func(h []string) { headers = h }, func(row []xlsxCell) error {
+		for i, c := range row {
+			if c.value == nil {
+				continue
+			}
+			prev, seen := kinds[headers[i]]
+			switch {
+			case !seen:
+				kinds[headers[i]] = c.kind
+			case prev == c.kind:
+			case isDateKind(prev) && isDateKind(c.kind):
+				kinds[headers[i]] = "datetime"
+			default:
+				kinds[headers[i]] = "text"
+			}
+		}
+		if n++; n >= discoverSampleSize {
+			return errStopRead
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopRead) {
+		return nil, err
+	}
+
+	schema := &etl.Schema{Fields: make([]etl.Field, len(headers))}
+	for i, h := range headers {
+		typ := kinds[h]
+		if typ == "" {
+			typ = "text"
+		}
+		schema.Fields[i] = etl.Field{Name: h, Type: typ}
+	}
+	return schema, nil
+}
+
+func (s *xlsxSource) Read(ctx context.Context, cfg etl.SourceConfig) (<-chan etl.Record, <-chan error) {
+	out := make(chan etl.Record, 100)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		defer close(errCh)
+
+		var headers []string
+		err := readXLSX(cfg, func(h []string) { headers = h }, func(row []xlsxCell) error {
+			data := make(map[string]any, len(headers))
+			for i, c := range row {
+				data[headers[i]] = c.value
+			}
+			select {
+			case out <- etl.Record{Data: data}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			errCh <- err
+		}
+	}()
+
+	return out, errCh
+}
+
+// readXLSX reads the configured sheet and range, calling header once with
+// the column names and then row for every non-empty data row. Each row has
+// one cell per column.
+func readXLSX(cfg etl.SourceConfig, header func([]string), row func([]xlsxCell) error) error {
+	filePath := cfgString(cfg, "filePath", "")
+	if filePath == "" {
+		return fmt.Errorf("filePath is required")
+	}
+	rng, err := parseCellRange(cfgString(cfg, "range", ""))
+	if err != nil {
+		return err
+	}
+	headerRow := rng.minRow
+	if h := strings.TrimSpace(cfgString(cfg, "headerRow", "")); h != "" {
+		if headerRow, err = strconv.Atoi(h); err != nil || headerRow < 0 {
+			return fmt.Errorf("invalid header row %q", h)
+		}
+	}
+
+	book, err := openXLSX(filePath)
+	if err != nil {
+		return err
+	}
+	defer book.Close()
+	sheetPath, err := book.sheetPath(cfgString(cfg, "sheet", ""))
+	if err != nil {
+		return err
+	}
+
+	var headers []string
+	setHeaders := func(names []string) {
+		seen := make(map[string]int)
+		headers = make([]string, len(names))
+		for i, name := range names {
+			if name == "" {
+				name = fmt.Sprintf("col_%d", i+1)
+			}
+			if seen[name]++; seen[name] > 1 {
+				name = fmt.Sprintf("%s_%d", name, seen[name])
+			}
+			headers[i] = name
+		}
+		header(headers)
+	}
+
+	return book.readSheet(sheetPath, func(rowNum int, cells map[int]xlsxCell) error {
+		// The header row is found before the range applies: it may lie above
+		// the range's rows (headerRow 3 with range A4:E5).
+		if headers == nil && headerRow > 0 && rowNum < headerRow {
+			return nil
+		}
+		isHeader := headers == nil && headerRow > 0
+		if !isHeader && (rowNum < rng.minRow || (rng.maxRow > 0 && rowNum > rng.maxRow)) {
+			return nil
+		}
+
+		// The columns come from the range, or from the header or first row.
+		minCol, maxCol := rng.minCol, rng.maxCol
+		if maxCol == 0 {
+			maxCol = len(headers) + minCol - 1
+			if headers == nil {
+				for c := range cells {
+					maxCol = max(maxCol, c)
+				}
+			}
+		}
+
+		if isHeader {
+			names := make([]string, maxCol-minCol+1)
+			for i := range names {
+				if c, ok := cells[minCol+i]; ok && c.value != nil {
+					names[i] = strings.TrimSpace(fmt.Sprint(c.value))
+				}
+			}
+			setHeaders(names)
+			return nil
+		}
+		if headers == nil {
+			setHeaders(make([]string, maxCol-minCol+1))
+		}
+
+		out := make([]xlsxCell, len(headers))
+		empty := true
+		for i := range out {
+			if c, ok := cells[minCol+i]; ok && c.value != nil {
+				out[i] = c
+				empty = false
+			}
+		}
+		if empty {
+			return nil
+		}
+		return row(out)
+	})
+}
+
+// ── Cell References ────────────────────────────────────────
+
+// cellRange is a rectangle of cells; zero bounds are open.
+type cellRange struct {
+	minCol, maxCol int // 1-based, maxCol 0 = no limit
+	minRow, maxRow int // 1-based, maxRow 0 = no limit
+}
+
+// parseCellRange parses "B3:F200", "A:D" or "" (the whole sheet).
+func parseCellRange(s string) (cellRange, error) {
+	r := cellRange{minCol: 1, minRow: 1}
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return r, nil
+	}
+	from, to, ok := strings.Cut(s, ":")
+	if !ok {
+		return r, fmt.Errorf("invalid cell range %q: want e.g. A1:D100", s)
+	}
+	c1, r1, err1 := parseCellRef(from)
+	c2, r2, err2 := parseCellRef(to)
+	if err1 != nil || err2 != nil || c1 == 0 || c2 < c1 || (r2 > 0 && r2 < r1) {
+		return r, fmt.Errorf("invalid cell range %q: want e.g. A1:D100", s)
+	}
+	r.minCol, r.maxCol = c1, c2
+	r.minRow, r.maxRow = max(r1, 1), r2
+	return r, nil
+}
+
+// parseCellRef splits a reference like "AB12" into a 1-based column and
+// row. Either part may be missing and is then 0.
+func parseCellRef(ref string) (col, row int, err error) {
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i < len(ref) {
+		if row, err = strconv.Atoi(ref[i:]); err != nil || row < 1 {
+			return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+		}
+	}
+	return col, row, nil
+}
+
+// ── Workbook ───────────────────────────────────────────────
+
+// xlsxCell is a typed cell value; kind is its schema type.
+type xlsxCell struct {
+	value any
+	kind  string // "text" | "number" | "boolean" | "date" | "datetime"
+}
+
+// xlsxBook is an open workbook with the parts every sheet needs.
+type xlsxBook struct {
+	zr         *zip.ReadCloser
+	sheets     []xlsxSheetRef
+	strings    []string
+	dateStyles []bool // by cell style index: the number format is a date
+	date1904   bool
+}
+
+type xlsxSheetRef struct {
+	name, path string
+}
+
+func openXLSX(filePath string) (*xlsxBook, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+	b := &xlsxBook{zr: zr}
+	if err := b.load(); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+	return b, nil
+}
+
+func (b *xlsxBook) Close() error { return b.zr.Close() }
+
+func (b *xlsxBook) load() error {
+	var wb struct {
+		Pr struct {
+			Date1904 bool `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := b.decodePart("xl/workbook.xml", &wb); err != nil {
+		return err
+	}
+	b.date1904 = wb.Pr.Date1904
+
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := b.decodePart("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return err
+	}
+	targets := make(map[string]string, len(rels.Rels))
+	for _, r := range rels.Rels {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	for _, s := range wb.Sheets {
+		b.sheets = append(b.sheets, xlsxSheetRef{name: s.Name, path: targets[s.RID]})
+	}
+
+	// Shared strings and styles are optional parts.
+	if f := b.part("xl/sharedStrings.xml"); f != nil {
+		if err := b.loadStrings(f); err != nil {
+			return err
+		}
+	}
+	if b.part("xl/styles.xml") != nil {
+		var styles struct {
+			NumFmts []struct {
+				ID   int    `xml:"numFmtId,attr"`
+				Code string `xml:"formatCode,attr"`
+			} `xml:"numFmts>numFmt"`
+			CellXfs []struct {
+				NumFmtID int `xml:"numFmtId,attr"`
+			} `xml:"cellXfs>xf"`
+		}
+		if err := b.decodePart("xl/styles.xml", &styles); err != nil {
+			return err
+		}
+		custom := make(map[int]string, len(styles.NumFmts))
+		for _, f := range styles.NumFmts {
+			custom[f.ID] = f.Code
+		}
+		b.dateStyles = make([]bool, len(styles.CellXfs))
+		for i, xf := range styles.CellXfs {
+			b.dateStyles[i] = isDateFormat(xf.NumFmtID, custom[xf.NumFmtID])
+		}
+	}
+	return nil
+}
+
+func (b *xlsxBook) part(name string) *zip.File {
+	for _, f := range b.zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (b *xlsxBook) decodePart(name string, v any) error {
+	f := b.part(name)
+	if f == nil {
+		return fmt.Errorf("missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// loadStrings reads the shared string table. Rich text runs are joined;
+// phonetic hints are skipped.
+func (b *xlsxBook) loadStrings(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(rc)
+	var cur strings.Builder
+	inText, inPhonetic := false, false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse shared strings: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = !inPhonetic
+			case "rPh":
+				inPhonetic = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				b.strings = append(b.strings, cur.String())
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic = false
+			}
+		case xml.CharData:
+			if inText {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+// sheetPath returns the part holding the named sheet, or the first sheet.
+func (b *xlsxBook) sheetPath(name string) (string, error) {
+	if len(b.sheets) == 0 {
+		return "", fmt.Errorf("workbook has no sheets")
+	}
+	if name == "" {
+		return b.sheets[0].path, nil
+	}
+	names := make([]string, len(b.sheets))
+	for i, s := range b.sheets {
+		if strings.EqualFold(s.name, name) {
+			return s.path, nil
+		}
+		names[i] = s.name
+	}
+	return "", fmt.Errorf("sheet %q not found (sheets: %s)", name, strings.Join(names, ", "))
+}
+
+// readSheet streams a sheet's rows, calling fn with the row number and its
+// cells by 1-based column.
+func (b *xlsxBook) readSheet(sheetPath string, fn func(row int, cells map[int]xlsxCell) error) error {
+	f := b.part(sheetPath)
+	if f == nil {
+		return fmt.Errorf("missing sheet part %s", sheetPath)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(rc)
+	var (
+		rowNum, col int
+		cells       map[int]xlsxCell
+		cellType    string
+		cellStyle   int
+		text        strings.Builder
+		inValue     bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse sheet: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				rowNum++
+				if r := xmlAttr(t, "r"); r != "" {
+					rowNum, _ = strconv.Atoi(r)
+				}
+				cells = make(map[int]xlsxCell)
+				col = 0
+			case "c":
+				col++
+				if ref := xmlAttr(t, "r"); ref != "" {
+					if c, _, err := parseCellRef(ref); err == nil && c > 0 {
+						col = c
+					}
+				}
+				cellType = xmlAttr(t, "t")
+				cellStyle, _ = strconv.Atoi(xmlAttr(t, "s"))
+				text.Reset()
+			case "v", "t":
+				// <t> holds inline strings; formulas (<f>) are skipped.
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				if cell, ok := b.cellValue(cellType, cellStyle, text.String()); ok {
+					cells[col] = cell
+				}
+			case "row":
+				if err := fn(rowNum, cells); err != nil {
+					return err
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// cellValue types a cell's raw text by its type attribute and style.
+func (b *xlsxBook) cellValue(typ string, style int, raw string) (xlsxCell, bool) {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(raw)
+		if err != nil || i < 0 || i >= len(b.strings) {
+			return xlsxCell{}, false
+		}
+		return xlsxCell{value: b.strings[i], kind: "text"}, true
+	case "str", "inlineStr", "e":
+		return xlsxCell{value: raw, kind: "text"}, raw != ""
+	case "b":
+		return xlsxCell{value: raw == "1", kind: "boolean"}, raw != ""
+	case "d":
+		if t, ok := tryParseISO(raw); ok {
+			return excelTime(t), true
+		}
+		return xlsxCell{value: raw, kind: "text"}, raw != ""
+	}
+	if raw == "" {
+		return xlsxCell{}, false
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return xlsxCell{value: raw, kind: "text"}, true
+	}
+	if style >= 0 && style < len(b.dateStyles) && b.dateStyles[style] {
+		return excelTime(excelSerialTime(f, b.date1904)), true
+	}
+	return xlsxCell{value: f, kind: "number"}, true
+}
+
+// excelSerialTime converts an Excel serial day number to a time.
+func excelSerialTime(serial float64, date1904 bool) time.Time {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	ms := math.Round((serial - days) * 86400 * 1000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond)
+}
+
+// excelTime renders a date cell the way the typecast transform does: a date
+// when there is no time of day, RFC 3339 otherwise.
+func excelTime(t time.Time) xlsxCell {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return xlsxCell{value: t.Format("2006-01-02"), kind: "date"}
+	}
+	return xlsxCell{value: t.Format(time.RFC3339), kind: "datetime"}
+}
+
+func isDateKind(kind string) bool { return kind == "date" || kind == "datetime" }
+
+func tryParseISO(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isDateFormat reports whether a number format displays a date: one of the
+// built-in date formats, or a custom format with day, year or hour tokens.
+func isDateFormat(id int, code string) bool {
+	switch {
+	case id >= 14 && id <= 22, id >= 45 && id <= 47:
+		return true
+	case code == "":
+		return false
+	}
+	// Ignore quoted literals, escaped characters and [colour]/[$-locale] tags.
+	var b strings.Builder
+	quoted, bracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '\\':
+			i++
+		case c == '[':
+			bracket = true
+		case c == ']':
+			bracket = false
+		case !bracket:
+			b.WriteByte(c)
+		}
+	}
+	return strings.ContainsAny(strings.ToLower(b.String()), "ydh")
+}
@@ -0,0 +1,156 @@
+package sources
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"notes/internal/etl"
+)
+
+// writeXLSX builds a minimal workbook with the given sheets (name → sheetData
+// XML). Style 1 is a built-in date format, style 2 a custom datetime format.
+func writeXLSX(t *testing.T, dir string, sheets map[string]string, order ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, "book.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	add := func(name, content string) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+
+	var sheetXML, relXML strings.Builder
+	for i, name := range order {
+		id := string(rune('1' + i))
+		sheetXML.WriteString(`<sheet name="` + name + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		relXML.WriteString(`<Relationship Id="rId` + id + `" Target="worksheets/sheet` + id + `.xml"/>`)
+		add("xl/worksheets/sheet"+id+".xml", `<?xml version="1.0"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+sheets[name]+`</sheetData></worksheet>`)
+	}
+	add("xl/workbook.xml", `<?xml version="1.0"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`+sheetXML.String()+`</sheets></workbook>`)
+	add("xl/_rels/workbook.xml.rels", `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+relXML.String()+`</Relationships>`)
+	add("xl/sharedStrings.xml", `<?xml version="1.0"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>id</t></si><si><t>name</t></si><si><t>joined</t></si><si><t>active</t></si><si><t>score</t></si><si><r><t>Ali</t></r><r><rPr><b/></rPr><t>ce</t></r></si><si><t>Bob</t></si></sst>`)
+	add("xl/styles.xml", `<?xml version="1.0"?><styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd hh:mm"/></numFmts><cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/></cellXfs></styleSheet>`)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return path
+}
+
+// peopleSheet has a title row, a header on row 3 and two data rows:
+// shared/rich strings, a date, a datetime, a boolean and a formula.
+const peopleSheet = `<row r="1"><c r="A1" t="inlineStr"><is><t>People export</t></is></c></row>` +
+	`<row r="3"><c r="A3" t="s"><v>0</v></c><c r="B3" t="s"><v>1</v></c><c r="C3" t="s"><v>2</v></c><c r="D3" t="s"><v>3</v></c><c r="E3" t="s"><v>4</v></c></row>` +
+	`<row r="4"><c r="A4"><v>1</v></c><c r="B4" t="s"><v>5</v></c><c r="C4" s="1"><v>45292</v></c><c r="D4" t="b"><v>1</v></c><c r="E4"><f>A4*10.5</f><v>10.5</v></c></row>` +
+	`<row r="5"><c r="A5"><v>2</v></c><c r="B5" t="s"><v>6</v></c><c r="C5" s="2"><v>45292.5</v></c><c r="D5" t="b"><v>0</v></c><c r="E5" t="str"><f>"n/a"</f><v>n/a</v></c></row>` +
+	`<row r="7"><c r="A7"/></row>`
+
+func readAllXLSX(t *testing.T, cfg etl.SourceConfig) []etl.Record {
+	t.Helper()
+	src, _ := etl.GetSource("xlsx_file")
+	recCh, errCh := src.Read(context.Background(), cfg)
+	var records []etl.Record
+	for r := range recCh {
+		records = append(records, r)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return records
+}
+
+func TestXLSXSource_Read(t *testing.T) {
+	path := writeXLSX(t, t.TempDir(), map[string]string{"People": peopleSheet}, "People")
+	records := readAllXLSX(t, etl.SourceConfig{"filePath": path, "headerRow": "3"})
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2 (title and empty row skipped): %v", len(records), records)
+	}
+
+	first := records[0].Data
+	if first["id"] != 1.0 || first["name"] != "Alice" || first["joined"] != "2024-01-01" || first["active"] != true || first["score"] != 10.5 {
+		t.Errorf("first = %v", first)
+	}
+	second := records[1].Data
+	if second["joined"] != "2024-01-01T12:00:00Z" || second["active"] != false || second["score"] != "n/a" {
+		t.Errorf("second = %v", second)
+	}
+}
+
+func TestXLSXSource_SheetAndRange(t *testing.T) {
+	other := `<row r="2"><c r="B2" t="inlineStr"><is><t>x</t></is></c><c r="C2" t="inlineStr"><is><t>y</t></is></c><c r="D2"><v>99</v></c></row>` +
+		`<row r="3"><c r="B3"><v>1</v></c><c r="C3"><v>2</v></c><c r="D3"><v>3</v></c></row>` +
+		`<row r="4"><c r="B4"><v>4</v></c><c r="C4"><v>5</v></c></row>`
+	path := writeXLSX(t, t.TempDir(), map[string]string{"People": peopleSheet, "Grid": other}, "People", "Grid")
+
+	records := readAllXLSX(t, etl.SourceConfig{"filePath": path, "sheet": "grid", "range": "B2:C3"})
+	if len(records) != 1 || records[0].Data["x"] != 1.0 || records[0].Data["y"] != 2.0 || len(records[0].Data) != 2 {
+		t.Errorf("records = %v, want one row of x,y", records)
+	}
+
+	// The header row lies above the range, which selects the data rows.
+	records = readAllXLSX(t, etl.SourceConfig{"filePath": path, "headerRow": "3", "range": "A4:E5"})
+	if len(records) != 2 || records[0].Data["id"] != 1.0 || records[1].Data["name"] == nil || len(records[1].Data) != 5 {
+		t.Errorf("records = %v, want two rows with the row 3 headers", records)
+	}
+
+	// No header: columns are numbered from the start of the range.
+	records = readAllXLSX(t, etl.SourceConfig{"filePath": path, "sheet": "Grid", "range": "C3:D", "headerRow": "0"})
+	if len(records) != 2 || records[0].Data["col_2"] != 3.0 || records[1].Data["col_1"] != 5.0 || records[1].Data["col_2"] != nil {
+		t.Errorf("records = %v", records)
+	}
+
+	src, _ := etl.GetSource("xlsx_file")
+	recCh, errCh := src.Read(context.Background(), etl.SourceConfig{"filePath": path, "sheet": "Missing"})
+	for range recCh {
+	}
+	if err := <-errCh; err == nil || !strings.Contains(err.Error(), "People, Grid") {
+		t.Errorf("err = %v, want sheet not found listing sheets", err)
+	}
+}
+
+func TestXLSXSource_Discover(t *testing.T) {
+	path := writeXLSX(t, t.TempDir(), map[string]string{"People": peopleSheet}, "People")
+	src, _ := etl.GetSource("xlsx_file")
+	schema, err := src.Discover(context.Background(), etl.SourceConfig{"filePath": path, "headerRow": "3"})
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	want := map[string]string{"id": "number", "name": "text", "joined": "datetime", "active": "boolean", "score": "text"}
+	if len(schema.Fields) != len(want) {
+		t.Fatalf("fields = %v", schema.Fields)
+	}
+	for _, f := range schema.Fields {
+		if want[f.Name] != f.Type {
+			t.Errorf("field %s = %s, want %s", f.Name, f.Type, want[f.Name])
+		}
+	}
+
+	// Preview goes through the same reader.
+	engine := &etl.Engine{}
+	records, _, err := engine.Preview(context.Background(), "xlsx_file", etl.SourceConfig{"filePath": path, "headerRow": "3"}, 1)
+	if err != nil || len(records) != 1 {
+		t.Errorf("preview = %v, %v", records, err)
+	}
+}
+
+func TestParseCellRange(t *testing.T) {
+	r, err := parseCellRange("b3:AA20")
+	if err != nil || r != (cellRange{minCol: 2, maxCol: 27, minRow: 3, maxRow: 20}) {
+		t.Errorf("range = %+v, %v", r, err)
+	}
+	for _, bad := range []string{"A1", "D1:A5", "A5:B2", "1:2"} {
+		if _, err := parseCellRange(bad); err == nil {
+			t.Errorf("parseCellRange(%q) succeeded", bad)
+		}
+	}
+}
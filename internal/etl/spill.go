@@ -0,0 +1,238 @@
+package etl
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ── External Sort ──────────────────────────────────────────
+// SortTransform needs every record before it can emit the first one.
+// To keep memory bounded, the engine buffers up to a fixed number of records,
+// sorts them, and spills each full buffer to a temp file as JSON lines.
+// Once the input is exhausted, the spilled runs are k-way merged back into a
+// single sorted stream.
+//
+// Spilled values go through a JSON round-trip, so integers come back as
+// float64 and time.Time values as RFC 3339 strings.
+
+// externalSorter sorts an unbounded record stream with bounded memory.
+type externalSorter struct {
+	field     string
+	direction string
+	maxBuffer int    // records held in memory before spilling
+	dir       string // temp directory for spill files ("" = os.TempDir())
+
+	buf  []Record
+	runs []string // spill file paths, in input order
+}
+
+func newExternalSorter(st *SortTransform, maxBuffer int, dir string) *externalSorter {
+	return &externalSorter{
+		field:     st.Field,
+		direction: st.Direction,
+		maxBuffer: maxBuffer,
+		dir:       dir,
+	}
+}
+
+// Add buffers a record, spilling the buffer to disk once it is full.
+func (s *externalSorter) Add(r Record) error {
+	s.buf = append(s.buf, r)
+	if len(s.buf) >= s.maxBuffer {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill sorts the in-memory buffer and writes it to a new temp file.
+func (s *externalSorter) spill() error {
+	sortRecords(s.buf, s.field, s.direction)
+
+	f, err := os.CreateTemp(s.dir, "etl-sort-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("create spill file: %w", err)
+	}
+	s.runs = append(s.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range s.buf {
+		if err := enc.Encode(r.Data); err != nil {
+			f.Close()
+			return fmt.Errorf("write spill file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write spill file: %w", err)
+	}
+	s.buf = s.buf[:0]
+	return f.Close()
+}
+
+// Drain emits all records in sorted order.
+func (s *externalSorter) Drain(emit func(Record) error) error {
+	sortRecords(s.buf, s.field, s.direction)
+
+	// Fast path: everything fit in memory.
+	if len(s.runs) == 0 {
+		for _, r := range s.buf {
+			if err := emit(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Open a cursor per spilled run, plus one for the in-memory remainder.
+	// Cursors are indexed in input order so ties keep the sort stable.
+	cursors := make([]runCursor, 0, len(s.runs)+1)
+	defer func() {
+		for _, c := range cursors {
+			c.close()
+		}
+	}()
+	for _, path := range s.runs {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("open spill file: %w", err)
+		}
+		cursors = append(cursors, &fileCursor{f: f, dec: json.NewDecoder(bufio.NewReader(f))})
+	}
+	cursors = append(cursors, &sliceCursor{records: s.buf})
+
+	dir := 1
+	if s.direction == "desc" {
+		dir = -1
+	}
+	h := &mergeHeap{field: s.field, dir: dir}
+	for i, c := range cursors {
+		r, ok, err := c.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.items = append(h.items, mergeItem{rec: r, run: i})
+		}
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		top := h.items[0]
+		if err := emit(top.rec); err != nil {
+			return err
+		}
+		r, ok, err := cursors[top.run].next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.items[0].rec = r
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// Cleanup removes all spill files.
+func (s *externalSorter) Cleanup() {
+	for _, path := range s.runs {
+		os.Remove(path)
+	}
+	s.runs = nil
+	s.buf = nil
+}
+
+// ── Merge Cursors ─────────────────────────────────────────
+
+type runCursor interface {
+	next() (Record, bool, error)
+	close()
+}
+
+type fileCursor struct {
+	f   *os.File
+	dec *json.Decoder
+}
+
+func (c *fileCursor) next() (Record, bool, error) {
+	var data map[string]any
+	if err := c.dec.Decode(&data); err != nil {
+		if err == io.EOF {
+			return Record{}, false, nil
+		}
+		return Record{}, false, fmt.Errorf("read spill file: %w", err)
+	}
+	return Record{Data: data}, true, nil
+}
+
+func (c *fileCursor) close() { c.f.Close() }
+
+type sliceCursor struct {
+	records []Record
+	pos     int
+}
+
+func (c *sliceCursor) next() (Record, bool, error) {
+	if c.pos >= len(c.records) {
+		return Record{}, false, nil
+	}
+	r := c.records[c.pos]
+	c.pos++
+	return r, true, nil
+}
+
+func (c *sliceCursor) close() {}
+
+type mergeItem struct {
+	rec Record
+	run int
+}
+
+// mergeHeap orders the head record of each run by the sort field,
+// falling back to run order for ties.
+type mergeHeap struct {
+	items []mergeItem
+	field string
+	dir   int
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	c := compareValues(h.items[i].rec.Data[h.field], h.items[j].rec.Data[h.field]) * h.dir
+	if c != 0 {
+		return c < 0
+	}
+	return h.items[i].run < h.items[j].run
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x any) { h.items = append(h.items, x.(mergeItem)) }
+
+func (h *mergeHeap) Pop() any {
+	old := h.items
+	n := len(old)
+	it := old[n-1]
+	h.items = old[:n-1]
+	return it
+}
+
+// sortRecords sorts records in place by field (stable).
+func sortRecords(records []Record, field, direction string) {
+	dir := 1
+	if direction == "desc" {
+		dir = -1
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return compareValues(records[i].Data[field], records[j].Data[field])*dir < 0
+	})
+}
@@ -0,0 +1,560 @@
+package etl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"notes/internal/domain"
+)
+
+// ── SQL Transform ──────────────────────────────────────────
+// SQLTransform reshapes the stream with a user-written SELECT. Like
+// GroupTransform it is a batch transform: Transform absorbs every record, and
+// once the source is exhausted the records are loaded into a table named
+// "input" of a private in-memory SQLite database, the query runs, and its rows
+// are emitted through the transforms configured after it.
+//
+// LocalDBs named in the query (by their name, quoted if it is not a plain
+// identifier) are loaded as tables as well, with their column names, so the
+// query can join or union them with the input.
+//
+// Column types survive the round trip through declared types: numbers are
+// REAL, booleans BOOLEAN, LocalDB date columns DATE/DATETIME and everything
+// else TEXT (nested values as JSON). The output schema takes the declared
+// type of columns selected as is, and infers computed columns from values.
+//
+// With no input records the query still runs, over an input table with a
+// column for every name in the query, so aggregates and queries over LocalDBs
+// only emit their rows; a query that returns none emits nothing. Memory is
+// bounded by the size of the input plus the referenced LocalDBs.
+
+// SQLInputTable is the table the transform's input is loaded into.
+const SQLInputTable = "input"
+
+// LocalDBCatalog lists LocalDBs so queries can reference them by name. The
+// LocalDB store passed as Engine.LookupStore implements it.
+type LocalDBCatalog interface {
+	ListDatabases() ([]domain.LocalDatabase, error)
+}
+
+// SQLTransform runs Query over the buffered stream.
+type SQLTransform struct {
+	Query string
+
+	store   domain.LocalDatabaseStore
+	records []Record
+}
+
+// Transform absorbs the record and drops it from the stream.
+func (t *SQLTransform) Transform(r Record) (Record, bool) {
+	t.records = append(t.records, r)
+	return r, false
+}
+
+// Emit runs the query over the absorbed records.
+func (t *SQLTransform) Emit(ctx context.Context) ([]Record, *Schema, error) {
+	records := t.records
+	t.records = nil
+	return RunSQL(ctx, t.Query, records, t.store)
+}
+
+// parseSQLConfig builds a SQLTransform from its declarative config:
+//
+//	{query: "SELECT region, sum(amount) AS total FROM input GROUP BY region"}
+func parseSQLConfig(cfg map[string]any, store domain.LocalDatabaseStore) (*SQLTransform, error) {
+	query := strings.TrimSpace(strVal(cfg["query"]))
+	if err := validateSQLQuery(query); err != nil {
+		return nil, err
+	}
+	return &SQLTransform{Query: query, store: store}, nil
+}
+
+// validateSQLQuery accepts exactly one read-only statement: a SELECT, a
+// VALUES or a WITH clause followed by either. The query's tokens are scanned
+// outside literals, quoted identifiers and comments, so trailing statements
+// and writes behind a WITH clause are rejected before anything runs. RunSQL
+// additionally runs the query with query_only set.
+func validateSQLQuery(query string) error {
+	tokens, err := sqlTokens(query)
+	if err != nil {
+		return err
+	}
+	// Trailing semicolons end the statement; anything after one is another.
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("query is required")
+	}
+	for _, t := range tokens {
+		switch t.text {
+		case ";":
+			return fmt.Errorf("query must be a single statement")
+		case "attach", "detach", "pragma":
+			return fmt.Errorf("query must not use %s", strings.ToUpper(t.text))
+		}
+	}
+
+	switch tokens[0].text {
+	case "select", "values":
+		return nil
+	case "with":
+		// The statement a WITH clause belongs to is its first keyword
+		// outside the parenthesized common table expressions.
+		for _, t := range tokens[1:] {
+			if t.depth > 0 {
+				continue
+			}
+			switch t.text {
+			case "select", "values":
+				return nil
+			case "insert", "update", "delete", "replace":
+				return fmt.Errorf("query must be a SELECT statement")
+			}
+		}
+	}
+	return fmt.Errorf("query must be a SELECT statement")
+}
+
+// sqlToken is a lowercased keyword or identifier, a punctuation character,
+// or "?" standing for a literal or quoted identifier; depth is its
+// parenthesis nesting level.
+type sqlToken struct {
+	text  string
+	depth int
+}
+
+// sqlTokens splits query into tokens, skipping comments.
+func sqlTokens(query string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	depth := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for {
+				k := strings.IndexByte(query[j:], closing)
+				if k < 0 {
+					return nil, fmt.Errorf("unterminated %c", c)
+				}
+				j += k + 1
+				// A doubled quote is an escaped one.
+				if closing != ']' && j < len(query) && query[j] == closing {
+					j++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, sqlToken{text: "?", depth: depth})
+			i = j
+		case isSQLWordByte(c):
+			j := i
+			for j < len(query) && isSQLWordByte(query[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: strings.ToLower(query[i:j]), depth: depth})
+			i = j
+		default:
+			if c == ')' {
+				depth--
+			}
+			tokens = append(tokens, sqlToken{text: string(c), depth: depth})
+			if c == '(' {
+				depth++
+			}
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// isSQLWordByte reports whether c can be part of a keyword, identifier or
+// number. Bytes of multi-byte UTF-8 characters count as identifier bytes.
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// RunSQL loads records into the "input" table of an in-memory SQLite
+// database, together with the LocalDBs the query names (when store can list
+// them), runs query and returns its rows as records with the derived schema.
+func RunSQL(ctx context.Context, query string, records []Record, store domain.LocalDatabaseStore) ([]Record, *Schema, error) {
+	if err := validateSQLQuery(query); err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	defer db.Close()
+	// Every connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+
+	inputCols := recordColumns(records)
+	if len(records) == 0 {
+		inputCols = queryNameColumns(query)
+	}
+	if err := loadSQLTable(ctx, db, SQLInputTable, inputCols, records); err != nil {
+		return nil, nil, err
+	}
+	if err := loadReferencedLocalDBs(ctx, db, query, store); err != nil {
+		return nil, nil, err
+	}
+	// From here on the database cannot change, whatever the query does.
+	if _, err := db.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, nil, fmt.Errorf("query_only: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+	out, schema, err := scanSQLRows(rows)
+	if len(records) == 0 && len(out) == 0 {
+		// The placeholder columns of the empty input are not a schema.
+		return nil, nil, err
+	}
+	return out, schema, err
+}
+
+// sqlNameRe matches a quoted identifier, a string literal (to skip it) or a
+// bare word.
+var sqlNameRe = regexp.MustCompile(`"((?:[^"]|"")*)"|` + "`([^`]*)`" + `|\[([^\]]*)\]|'(?:[^']|'')*'|([\pL_][\pL\pN_$]*)`)
+
+// queryNameColumns returns an untyped column for every identifier in query,
+// the input table's columns when there are no records to take them from.
+// Untyped columns take the type of their values in the output.
+func queryNameColumns(query string) []sqlColumn {
+	var cols []sqlColumn
+	seen := make(map[string]bool)
+	for _, m := range sqlNameRe.FindAllStringSubmatch(query, -1) {
+		name := m[1] + m[2] + m[3] + m[4]
+		if m[1] != "" {
+			name = strings.ReplaceAll(name, `""`, `"`)
+		}
+		if key := strings.ToLower(name); name != "" && !seen[key] {
+			seen[key] = true
+			cols = append(cols, sqlColumn{name: name})
+		}
+	}
+	return cols
+}
+
+// sqlColumn is a column of a table loaded into the query database.
+type sqlColumn struct {
+	name     string
+	declType string // TEXT | REAL | BOOLEAN | DATE | DATETIME, "" for untyped
+}
+
+// sqlDeclType maps a schema field type to the column type declared in SQLite.
+func sqlDeclType(fieldType string) string {
+	switch fieldType {
+	case "number":
+		return "REAL"
+	case "boolean":
+		return "BOOLEAN"
+	case "date":
+		return "DATE"
+	case "datetime":
+		return "DATETIME"
+	}
+	return "TEXT"
+}
+
+// sqlFieldType maps a declared SQLite column type back to a schema field type;
+// "" when the column has no declared type (an expression).
+func sqlFieldType(declType string) string {
+	switch strings.ToUpper(declType) {
+	case "":
+		return ""
+	case "REAL", "INTEGER", "NUMERIC", "INT", "FLOAT", "DOUBLE":
+		return "number"
+	case "BOOLEAN", "BOOL":
+		return "boolean"
+	case "DATE":
+		return "date"
+	case "DATETIME", "TIMESTAMP":
+		return "datetime"
+	}
+	return "text"
+}
+
+// recordColumns lists the fields of records in first-seen order, each
+// record's new fields by name, typed by their first non-null value.
+func recordColumns(records []Record) []sqlColumn {
+	var cols []sqlColumn
+	index := make(map[string]int)
+	for _, r := range records {
+		// Record fields have no order of their own; sorting them keeps the
+		// columns of SELECT * the same from run to run.
+		for _, k := range slices.Sorted(maps.Keys(r.Data)) {
+			v := r.Data[k]
+			i, seen := index[k]
+			if !seen {
+				i = len(cols)
+				index[k] = i
+				cols = append(cols, sqlColumn{name: k})
+			}
+			if cols[i].declType == "" && v != nil {
+				cols[i].declType = sqlDeclType(valueFieldType(v))
+			}
+		}
+	}
+	for i := range cols {
+		if cols[i].declType == "" {
+			cols[i].declType = "TEXT"
+		}
+	}
+	return cols
+}
+
+// valueFieldType infers a schema field type from a Go value.
+func valueFieldType(v any) string {
+	switch v.(type) {
+	case float64, float32, int, int64, int32:
+		return "number"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "datetime"
+	}
+	return "text"
+}
+
+// loadSQLTable creates table with cols and inserts records into it.
+func loadSQLTable(ctx context.Context, db *sql.DB, table string, cols []sqlColumn, records []Record) error {
+	defs := make([]string, len(cols))
+	marks := make([]string, len(cols))
+	for i, c := range cols {
+		defs[i] = quoteSQLIdent(c.name) + " " + c.declType
+		marks[i] = "?"
+	}
+	if len(cols) == 0 {
+		// A table needs a column; a LocalDB without any is still queryable.
+		defs = []string{`"_" TEXT`}
+	}
+	create := fmt.Sprintf("CREATE TABLE %s (%s)", quoteSQLIdent(table), strings.Join(defs, ", "))
+	if _, err := db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("create table %q: %w", table, err)
+	}
+	if len(cols) == 0 || len(records) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteSQLIdent(table), strings.Join(marks, ", ")))
+	if err != nil {
+		return fmt.Errorf("load table %q: %w", table, err)
+	}
+	defer stmt.Close()
+	args := make([]any, len(cols))
+	for _, r := range records {
+		for i, c := range cols {
+			args[i] = sqlArg(r.Data[c.name])
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("load table %q: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// sqlArg converts a record value to a SQLite parameter. Nested values are
+// stored as JSON, so the json_* functions can reach into them.
+func sqlArg(v any) any {
+	switch t := v.(type) {
+	case nil, string, float64, int, int64, bool:
+		return t
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case map[string]any, []any:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// loadReferencedLocalDBs loads the LocalDBs whose names occur in query as
+// tables named after them. Names are matched case-insensitively, as SQLite
+// matches identifiers; a LocalDB named "input" is shadowed by the input.
+func loadReferencedLocalDBs(ctx context.Context, db *sql.DB, query string, store domain.LocalDatabaseStore) error {
+	catalog, ok := store.(LocalDBCatalog)
+	if !ok {
+		return nil
+	}
+	dbs, err := catalog.ListDatabases()
+	if err != nil {
+		return fmt.Errorf("list databases: %w", err)
+	}
+	loaded := map[string]bool{SQLInputTable: true}
+	for _, ldb := range dbs {
+		key := strings.ToLower(ldb.Name)
+		if ldb.Name == "" || loaded[key] || !referencesTable(query, ldb.Name) {
+			continue
+		}
+		loaded[key] = true
+		cols, records, err := readLocalDB(store, ldb.ID)
+		if err != nil {
+			return err
+		}
+		if err := loadSQLTable(ctx, db, ldb.Name, cols, records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// referencesTable reports whether name occurs in query as a whole word.
+func referencesTable(query, name string) bool {
+	re, err := regexp.Compile(`(?i)(^|[^\w])` + regexp.QuoteMeta(name) + `($|[^\w])`)
+	return err == nil && re.MatchString(query)
+}
+
+// readLocalDB returns the columns and rows of a LocalDB, keyed by column name.
+func readLocalDB(store domain.LocalDatabaseStore, dbID string) ([]sqlColumn, []Record, error) {
+	ldb, err := store.GetDatabase(dbID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("database %s: %w", dbID, err)
+	}
+	table, err := NewLocalDBTable(ldb)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := store.ListRows(dbID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list rows of %q: %w", ldb.Name, err)
+	}
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		if rec, ok := table.Record(row); ok {
+			records = append(records, rec)
+		}
+	}
+
+	// A formula column has no type of its own: it takes the type of its
+	// values, as input columns do.
+	inferred := make(map[string]string)
+	for _, c := range recordColumns(records) {
+		inferred[c.name] = c.declType
+	}
+	cols := make([]sqlColumn, len(table.Columns))
+	for i, c := range table.Columns {
+		cols[i] = sqlColumn{name: c.Name, declType: sqlDeclType(c.FieldType())}
+		if c.IsFormula() && inferred[c.Name] != "" {
+			cols[i].declType = inferred[c.Name]
+		}
+	}
+	return cols, records, nil
+}
+
+// scanSQLRows converts query results to records and derives their schema.
+func scanSQLRows(rows *sql.Rows) ([]Record, *Schema, error) {
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	schema := &Schema{Fields: make([]Field, len(colTypes))}
+	for i, ct := range colTypes {
+		schema.Fields[i] = Field{Name: ct.Name(), Type: sqlFieldType(ct.DatabaseTypeName())}
+	}
+
+	var records []Record
+	values := make([]any, len(colTypes))
+	ptrs := make([]any, len(colTypes))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+		data := make(map[string]any, len(values))
+		for i, f := range schema.Fields {
+			data[f.Name] = sqlValue(values[i], f.Type)
+		}
+		records = append(records, Record{Data: data})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("query: %w", err)
+	}
+
+	// Computed columns take the type of their values: number when every
+	// non-null value is numeric, text otherwise.
+	for i, f := range schema.Fields {
+		if f.Type != "" {
+			continue
+		}
+		numbers, others := 0, 0
+		for _, r := range records {
+			switch r.Data[f.Name].(type) {
+			case nil:
+			case float64:
+				numbers++
+			default:
+				others++
+			}
+		}
+		schema.Fields[i].Type = "text"
+		if numbers > 0 && others == 0 {
+			schema.Fields[i].Type = "number"
+		}
+	}
+	return records, schema, nil
+}
+
+// sqlValue converts a scanned SQLite value to a record value of the column's
+// field type; typ is "" for computed columns.
+func sqlValue(v any, typ string) any {
+	switch t := v.(type) {
+	case int64:
+		if typ == "boolean" {
+			return t != 0
+		}
+		return float64(t)
+	case []byte:
+		return string(t)
+	case time.Time:
+		if typ == "date" {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+	return v
+}
+
+// quoteSQLIdent quotes an SQLite identifier.
+func quoteSQLIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,143 @@
+package etl
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ── Source Run State ───────────────────────────────────────
+// A stateful source tracks its own incremental position instead of relying
+// on a cursor field: it is handed the state its previous successful run saved
+// and saves a new one as it goes, like a Singer tap's STATE message. The
+// engine persists the last saved state as the job's cursor value once the run
+// has been committed, so a failed run resumes from the previous state.
+//
+// The same handle collects a source's diagnostic output (e.g. a connector
+// process's stderr), which ends up in the run log, and the files a directory
+// source ingested, which the engine hands back in the result so they are
+// skipped by later runs. Work that must wait until the destination has
+// committed, like archiving those files, is registered with OnCommit.
+
+// MaxSourceOutput bounds the diagnostic output kept per run; older output is
+// dropped first.
+const MaxSourceOutput = 64 << 10
+
+// sourceRunConfigKey is the reserved SourceConfig key carrying the SourceRun.
+const sourceRunConfigKey = "__run"
+
+// SourceRun is the per-run handle shared by the engine and a source.
+type SourceRun struct {
+	// PrevState is the state saved by the previous successful run, "" on the
+	// first run or when the job is not incremental.
+	PrevState string
+
+	// Ingested reports whether a directory source already ingested the file
+	// with this checksum in an earlier run. Nil when the run keeps no file
+	// history (previews, full refreshes): every file is read.
+	Ingested func(path, checksum string) (bool, error)
+
+	mu       sync.Mutex
+	state    string
+	stateSet bool
+	output   []byte
+	files    []IngestedFile
+	onCommit []func() error
+}
+
+// IngestedFile is a file read by a directory source.
+type IngestedFile struct {
+	Path       string    `json:"path"`
+	Checksum   string    `json:"checksum"` // hex SHA-256 of the contents
+	Rows       int       `json:"rows"`
+	IngestedAt time.Time `json:"ingestedAt"`
+}
+
+// SaveState records the source's latest state. Only the last saved state is
+// kept.
+func (r *SourceRun) SaveState(state string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = state
+	r.stateSet = true
+}
+
+// State returns the last saved state and whether the source saved one.
+func (r *SourceRun) State() (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state, r.stateSet
+}
+
+// Write appends diagnostic output, keeping at most MaxSourceOutput bytes.
+// It implements io.Writer so it can be used as a process's stderr.
+func (r *SourceRun) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = append(r.output, p...)
+	if over := len(r.output) - MaxSourceOutput; over > 0 {
+		r.output = append(r.output[:0], r.output[over:]...)
+	}
+	return len(p), nil
+}
+
+// Output returns the diagnostic output collected so far.
+func (r *SourceRun) Output() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.output)
+}
+
+// AddFile records a file read completely by this run.
+func (r *SourceRun) AddFile(f IngestedFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = append(r.files, f)
+}
+
+// Files returns the files read by this run.
+func (r *SourceRun) Files() []IngestedFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]IngestedFile(nil), r.files...)
+}
+
+// OnCommit registers fn to run once the destination has committed the run.
+// Nothing runs when the run fails.
+func (r *SourceRun) OnCommit(fn func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onCommit = append(r.onCommit, fn)
+}
+
+// commit runs the OnCommit hooks. Their errors cannot undo the committed
+// run, so they are added to the output instead.
+func (r *SourceRun) commit() {
+	r.mu.Lock()
+	hooks := r.onCommit
+	r.onCommit = nil
+	r.mu.Unlock()
+	for _, fn := range hooks {
+		if err := fn(); err != nil {
+			fmt.Fprintf(r, "after commit: %v\n", err)
+		}
+	}
+}
+
+// WithSourceRun returns a copy of cfg carrying run. The original config is
+// left untouched.
+func WithSourceRun(cfg SourceConfig, run *SourceRun) SourceConfig {
+	out := make(SourceConfig, len(cfg)+1)
+	for k, v := range cfg {
+		out[k] = v
+	}
+	out[sourceRunConfigKey] = run
+	return out
+}
+
+// SourceRunFromConfig returns the run handle the engine attached to cfg, if
+// any. Preview and Discover calls carry none.
+func SourceRunFromConfig(cfg SourceConfig) (*SourceRun, bool) {
+	r, ok := cfg[sourceRunConfigKey].(*SourceRun)
+	return r, ok && r != nil
+}
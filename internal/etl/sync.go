@@ -3,8 +3,11 @@ package etl
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
+
+	"notes/internal/domain"
 )
 
 // ── SyncJob ────────────────────────────────────────────────
@@ -14,22 +17,34 @@ import (
 
 // SyncJob holds the configuration for a single ETL sync.
 type SyncJob struct {
-	ID            string            `json:"id"`
-	Name          string            `json:"name"`
-	SourceType    string            `json:"sourceType"`
-	SourceCfg     SourceConfig      `json:"sourceConfig"`
-	Transforms    []TransformConfig `json:"transforms,omitempty"`
-	TargetDBID    string            `json:"targetDbId"`
-	SyncMode      SyncMode          `json:"syncMode"`
-	DedupeKey     string            `json:"dedupeKey,omitempty"`
-	TriggerType   string            `json:"triggerType"`   // "manual" | "schedule" | "file_watch"
-	TriggerConfig string            `json:"triggerConfig"` // cron expression or watch path
-	Enabled       bool              `json:"enabled"`
-	LastRunAt     time.Time         `json:"lastRunAt"`
-	LastStatus    string            `json:"lastStatus"` // "success" | "error" | "running" | ""
-	LastError     string            `json:"lastError"`
-	CreatedAt     time.Time         `json:"createdAt"`
-	UpdatedAt     time.Time         `json:"updatedAt"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	SourceType     string            `json:"sourceType"`
+	SourceCfg      SourceConfig      `json:"sourceConfig"`
+	Transforms     []TransformConfig `json:"transforms,omitempty"`
+	TargetDBID     string            `json:"targetDbId"`
+	DestType       string            `json:"destType,omitempty"`   // registered destination type; "" = LocalDB (TargetDBID)
+	DestCfg        DestinationConfig `json:"destConfig,omitempty"` // config of a registered destination
+	SyncMode       SyncMode          `json:"syncMode"`
+	DedupeKey      string            `json:"dedupeKey,omitempty"`
+	CursorField    string            `json:"cursorField,omitempty"`    // incremental mode: field tracked as high-water mark
+	CursorValue    string            `json:"cursorValue,omitempty"`    // last persisted high-water mark, or source state for stateful sources
+	MergeKeys      []string          `json:"mergeKeys,omitempty"`      // merge mode: key columns matched against existing rows
+	DeleteMissing  bool              `json:"deleteMissing,omitempty"`  // merge mode: delete rows missing from the source
+	Assertions     []Assertion       `json:"assertions,omitempty"`     // data-quality checks on the output
+	QuarantineDBID string            `json:"quarantineDbId,omitempty"` // LocalDB receiving quarantined records
+	Retry          RetryPolicy       `json:"retry"`                    // retries of scheduled runs
+	SchemaPolicy   SchemaPolicy      `json:"schemaPolicy,omitempty"`   // response to schema drift; "" = ignore
+	LastSchema     *Schema           `json:"lastSchema,omitempty"`     // output schema of the last successful run
+	TriggerType    string            `json:"triggerType"`              // "manual" | "schedule" | "file_watch" | "after_job" | "webhook"
+	TriggerConfig  string            `json:"triggerConfig"`            // cron expression, watch path, upstream job IDs (comma-separated) or webhook token
+	Enabled        bool              `json:"enabled"`
+	LastRunAt      time.Time         `json:"lastRunAt"`
+	LastStatus     string            `json:"lastStatus"` // "success" | "error" | "running" | "cancelled" | "skipped" | ""
+	LastError      string            `json:"lastError"`
+	Failures       int               `json:"consecutiveFailures"` // failed scheduled runs in a row
+	CreatedAt      time.Time         `json:"createdAt"`
+	UpdatedAt      time.Time         `json:"updatedAt"`
 }
 
 // TransformConfig is a declarative transform definition (stored as JSON).
@@ -40,102 +55,467 @@ type TransformConfig struct {
 
 // SyncResult is the outcome of running a sync job.
 type SyncResult struct {
-	JobID       string        `json:"jobId"`
-	Status      string        `json:"status"` // "success" | "error"
-	RowsRead    int           `json:"rowsRead"`
-	RowsWritten int           `json:"rowsWritten"`
-	Duration    time.Duration `json:"duration"`
-	Error       string        `json:"error,omitempty"`
+	JobID        string         `json:"jobId"`
+	Status       string         `json:"status"` // "success" | "error" | "cancelled"
+	RowsRead     int            `json:"rowsRead"`
+	RowsWritten  int            `json:"rowsWritten"` // inserted + updated
+	RowsInserted int            `json:"rowsInserted"`
+	RowsUpdated  int            `json:"rowsUpdated"`
+	RowsDeleted  int            `json:"rowsDeleted"`
+	Duration     time.Duration  `json:"duration"`
+	Error        string         `json:"error,omitempty"`
+	Cursor       string         `json:"cursor,omitempty"` // new high-water mark or source state (incremental mode)
+	Output       string         `json:"output,omitempty"` // source diagnostic output, e.g. a connector's stderr
+	Files        []IngestedFile `json:"files,omitempty"`  // files ingested by a directory source, to be skipped next time
+
+	RowsQuarantined int               `json:"rowsQuarantined"`
+	Assertions      []AssertionResult `json:"assertions,omitempty"`
+
+	Schema      *Schema        `json:"schema,omitempty"`      // output schema, kept by a successful run for the next comparison
+	SchemaDrift []SchemaChange `json:"schemaDrift,omitempty"` // changes since the last successful run
 }
 
 // SyncRunLog is a historical record of a sync run.
 type SyncRunLog struct {
-	ID          string    `json:"id"`
-	JobID       string    `json:"jobId"`
-	StartedAt   time.Time `json:"startedAt"`
-	FinishedAt  time.Time `json:"finishedAt"`
-	Status      string    `json:"status"`
-	RowsRead    int       `json:"rowsRead"`
-	RowsWritten int       `json:"rowsWritten"`
-	Error       string    `json:"error,omitempty"`
+	ID           string    `json:"id"`
+	JobID        string    `json:"jobId"`
+	StartedAt    time.Time `json:"startedAt"`
+	FinishedAt   time.Time `json:"finishedAt"`
+	Status       string    `json:"status"` // "success" | "error" | "cancelled" | "skipped"
+	RowsRead     int       `json:"rowsRead"`
+	RowsWritten  int       `json:"rowsWritten"`
+	RowsInserted int       `json:"rowsInserted"`
+	RowsUpdated  int       `json:"rowsUpdated"`
+	RowsDeleted  int       `json:"rowsDeleted"`
+	Error        string    `json:"error,omitempty"`
+	Output       string    `json:"output,omitempty"` // source diagnostic output
+
+	RowsQuarantined int               `json:"rowsQuarantined"`
+	Assertions      []AssertionResult `json:"assertions,omitempty"`
+	SchemaDrift     []SchemaChange    `json:"schemaDrift,omitempty"`
+
+	// Lineage of runs started by an after_job trigger.
+	UpstreamRunID string `json:"upstreamRunId,omitempty"` // run that triggered this one
+	RootRunID     string `json:"rootRunId,omitempty"`     // first run of the chain
+
+	Attempt int `json:"attempt"` // 1-based attempt within a retried run
 }
 
 // ── Engine ─────────────────────────────────────────────────
 // The Engine orchestrates sync execution.
+// Records stream from Source.Read through the transformer chain and into the
+// destination in fixed-size batches, so memory stays bounded by the batch
+// size (plus the sort buffer when a SortTransform is configured).
+
+const (
+	// DefaultBatchSize is the number of records written per destination batch.
+	DefaultBatchSize = 500
+	// DefaultSortBufferSize is the number of records a sort holds in memory
+	// before spilling a sorted run to disk.
+	DefaultSortBufferSize = 50000
+)
+
+// Progress is reported after every batch written to the destination and
+// periodically in between (see progress.go).
+type Progress struct {
+	JobID       string        `json:"jobId"`
+	Stage       string        `json:"stage"` // one of the Stage* constants
+	Batch       int           `json:"batch"`
+	RowsRead    int           `json:"rowsRead"`
+	RowsWritten int           `json:"rowsWritten"`
+	Elapsed     time.Duration `json:"elapsed"`
+}
 
 // Engine runs sync jobs using the registered sources and a destination.
 type Engine struct {
 	Dest Destination
+
+	BatchSize      int            // records per destination batch (default DefaultBatchSize)
+	SortBufferSize int            // records held in memory by a sort (default DefaultSortBufferSize)
+	SpillDir       string         // temp directory for sort spill files ("" = os.TempDir())
+	OnProgress     func(Progress) // optional, called after each batch and every ProgressInterval
+
+	ProgressInterval time.Duration // default DefaultProgressInterval
+
+	// LookupStore provides the LocalDB tables read by lookup transforms.
+	LookupStore domain.LocalDatabaseStore
+
+	// FileHistory lets directory sources skip files ingested by earlier runs.
+	// Without it every matching file is read on each run.
+	FileHistory FileHistory
+}
+
+// FileHistory remembers the files a job's directory source has ingested.
+type FileHistory interface {
+	IsFileIngested(jobID, path, checksum string) (bool, error)
 }
 
 // RunSync executes a sync job end-to-end.
 func (e *Engine) RunSync(ctx context.Context, job *SyncJob) (*SyncResult, error) {
 	start := time.Now()
 	result := &SyncResult{JobID: job.ID}
+	run := &SourceRun{}
 
-	// 1. Resolve source from registry.
-	source, err := GetSource(job.SourceType)
-	if err != nil {
+	fail := func(msg string, err error) (*SyncResult, error) {
 		result.Status = "error"
-		result.Error = err.Error()
+		result.Error = msg
+		result.Output = run.Output()
 		result.Duration = time.Since(start)
 		return result, err
 	}
 
+	// 1. Resolve source from registry.
+	source, err := GetSource(job.SourceType)
+	if err != nil {
+		return fail(err.Error(), err)
+	}
+
+	progress := newProgressReporter(job.ID, e.OnProgress, e.ProgressInterval)
+	defer progress.stop()
+
 	// 2. Discover schema (for column auto-creation).
+	progress.Stage(StageDiscover)
 	schema, err := source.Discover(ctx, job.SourceCfg)
 	if err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("discover: %s", err)
-		result.Duration = time.Since(start)
-		return result, err
+		return fail(fmt.Sprintf("discover: %s", err), err)
+	}
+
+	// Incremental mode: hand the previous high-water mark to the source, or
+	// the previous state to a source that keeps its own.
+	readCfg := job.SourceCfg
+	var cursor *cursorTracker
+	stateful := source.Spec().Stateful
+	if job.SyncMode == SyncIncremental {
+		switch {
+		case stateful:
+			run.PrevState = job.CursorValue
+		case job.CursorField == "":
+			err := fmt.Errorf("incremental sync requires a cursor field")
+			return fail(err.Error(), err)
+		default:
+			cursor = newCursorTracker(job.CursorField, job.CursorValue)
+			readCfg = WithCursor(job.SourceCfg, Cursor{Field: job.CursorField, Value: job.CursorValue})
+		}
 	}
+	// A full refresh re-reads every file; other modes skip ingested ones.
+	trackFiles := e.FileHistory != nil && job.SyncMode != SyncReplace
+	if trackFiles {
+		run.Ingested = func(path, checksum string) (bool, error) {
+			return e.FileHistory.IsFileIngested(job.ID, path, checksum)
+		}
+	}
+	readCfg = WithSourceRun(readCfg, run)
 
-	// 3. Read records from source.
-	recCh, errCh := source.Read(ctx, job.SourceCfg)
+	// 3. Build transformer chain from config.
+	transformers, err := buildTransformers(job.Transforms, job.DedupeKey, e.LookupStore)
+	if err != nil {
+		return fail(fmt.Sprintf("transform: %s", err), err)
+	}
+	quality, err := newQualityChecker(job.Assertions, job.QuarantineDBID)
+	if err != nil {
+		return fail(err.Error(), err)
+	}
 
-	// 4. Build transformer chain from config.
-	transformers := buildTransformers(job.Transforms, job.DedupeKey)
+	// Cancelling readCtx stops the source goroutine if we bail out early.
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	// 5. Collect + transform records.
-	var records []Record
-	for rec := range recCh {
-		result.RowsRead++
-		transformed, keep := ApplyTransformers(rec, transformers)
-		if keep {
-			records = append(records, transformed)
-		}
+	// 4. Open the destination for this run.
+	sess, err := e.openDestination(ctx, job, WriteOptions{
+		Mode:          job.SyncMode,
+		MergeKeys:     job.MergeKeys,
+		DeleteMissing: job.DeleteMissing,
+	})
+	if err != nil {
+		return fail(fmt.Sprintf("write: %s", err), err)
 	}
 
-	// 5b. Apply batch transforms (sort).
-	records = ApplyBatchSort(records, transformers)
+	// 5. Read records from source.
+	progress.Stage(StageRead)
+	recCh, errCh := source.Read(readCtx, readCfg)
+
+	w := &batchWriter{
+		ctx:    ctx,
+		sess:   sess,
+		size:   e.batchSize(),
+		schema: newSchemaTracker(schema, job.Transforms),
+		onFlush: func(batch int, stats WriteStats) {
+			result.setStats(stats)
+			progress.Flush(batch, stats.Written())
+		},
+	}
 
-	// Check for source errors.
-	if err := <-errCh; err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("read: %s", err)
-		result.Duration = time.Since(start)
-		return result, err
+	// 5b. Batch transforms buffer their input and emit it afterwards: a group
+	// or sql transform absorbs records and emits its output through the
+	// transforms after it, and a sort reorders whatever reaches the end of
+	// the chain.
+	var sorter *externalSorter
+	if st := findSort(transformers); st != nil {
+		sorter = newExternalSorter(st, e.sortBufferSize(), e.SpillDir)
+		defer sorter.Cleanup()
+	}
+	quarantine := &quarantineWriter{
+		ctx:      ctx,
+		dest:     e.Dest,
+		targetID: job.QuarantineDBID,
+		size:     e.batchSize(),
+		schema:   newSchemaTracker(schema, job.Transforms),
+	}
+	accepted := 0 // records that passed the assertions, for row_count
+	emit := func(r Record) error {
+		violations, err := quality.Check(r)
+		if err != nil {
+			return err
+		}
+		if violations != nil {
+			if err := quarantine.Add(r, violations); err != nil {
+				return fmt.Errorf("quarantine: %w", err)
+			}
+			return nil
+		}
+		accepted++
+		if sorter != nil {
+			if err := sorter.Add(r); err != nil {
+				return fmt.Errorf("sort: %w", err)
+			}
+			return nil
+		}
+		if err := w.Add(r); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+		return nil
 	}
 
-	// 5c. Derive output schema from actual records (transforms may have changed columns).
-	outputSchema := deriveSchemaFromRecords(records, schema, job.Transforms)
+	runErr := func() error {
+		// 6. Stream + transform records into the destination.
+		for rec := range recCh {
+			result.RowsRead++
+			progress.Read(result.RowsRead)
+			if cursor != nil && !cursor.Accept(rec) {
+				continue
+			}
+			transformed, keep := ApplyTransformers(rec, transformers)
+			if !keep {
+				continue
+			}
+			if err := emit(transformed); err != nil {
+				return err
+			}
+		}
 
-	// 6. Write to destination.
-	written, err := e.Dest.Write(ctx, job.TargetDBID, outputSchema, records, job.SyncMode)
-	if err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("write: %s", err)
-		result.Duration = time.Since(start)
-		return result, err
+		// Check for source errors.
+		if err := <-errCh; err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		// Sources stop quietly when the run is cancelled, so what they sent
+		// is not the whole input.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// The transforms after a batch transform may include the next one.
+		for batch, after := findBatch(transformers); batch != nil; batch, after = findBatch(after) {
+			progress.Stage(StageAggregate)
+			output, derived, err := batch.Emit(ctx)
+			if err != nil {
+				return fmt.Errorf("transform: %w", err)
+			}
+			w.schema.Declare(derived)
+			quarantine.schema.Declare(derived)
+			for _, rec := range output {
+				transformed, keep := ApplyTransformers(rec, after)
+				if !keep {
+					continue
+				}
+				if err := emit(transformed); err != nil {
+					return err
+				}
+			}
+		}
+		if err := quality.CheckRowCount(accepted); err != nil {
+			return err
+		}
+		if sorter != nil {
+			progress.Stage(StageSort)
+			if err := sorter.Drain(w.Add); err != nil {
+				return fmt.Errorf("write: %w", err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+		return nil
+	}()
+
+	// The output is complete: check it for drift before anything is committed.
+	if runErr == nil {
+		runErr = e.applySchemaPolicy(job, sess, w.schema.Schema(), result)
+	}
+
+	// The destination commits what the run wrote only if it succeeded, and
+	// quarantined records are committed or discarded together with it.
+	progress.Stage(StageCommit)
+	closeStats, err := sess.Close(ctx, runErr)
+	if err != nil && runErr == nil {
+		runErr = fmt.Errorf("write: %w", err)
+	}
+	w.stats.Add(closeStats)
+	if err := quarantine.Close(runErr); err != nil && runErr == nil {
+		runErr = fmt.Errorf("quarantine: %w", err)
+	}
+	result.RowsQuarantined = quarantine.count
+	result.Assertions = quality.Results()
+	result.setStats(w.stats)
+	if runErr != nil {
+		return fail(runErr.Error(), runErr)
 	}
 
 	result.Status = "success"
-	result.RowsWritten = written
+	run.commit()
+	result.Output = run.Output()
+	if trackFiles {
+		result.Files = run.Files()
+	}
+	if cursor != nil {
+		result.Cursor = cursor.Value()
+	}
+	if state, ok := run.State(); ok && stateful && job.SyncMode == SyncIncremental {
+		result.Cursor = state
+	}
 	result.Duration = time.Since(start)
 	return result, nil
 }
 
+// applySchemaPolicy compares the run's output schema with the job's last one,
+// records the drift on result and applies the job's SchemaPolicy to fields
+// that changed type or disappeared. Evolution goes through sess, so it is
+// committed only with the run.
+func (e *Engine) applySchemaPolicy(job *SyncJob, sess WriteSession, schema *Schema, result *SyncResult) error {
+	if schema != nil && len(schema.Fields) > 0 {
+		result.Schema = schema
+	}
+	result.SchemaDrift = DiffSchemas(job.LastSchema, schema)
+	breaking := breakingChanges(result.SchemaDrift)
+	if len(breaking) == 0 {
+		return nil
+	}
+	switch job.SchemaPolicy {
+	case SchemaFail:
+		return driftError(breaking)
+	case SchemaEvolve:
+		evolver, ok := sess.(SchemaEvolver)
+		if !ok {
+			return nil
+		}
+		if err := evolver.Evolve(breaking); err != nil {
+			return fmt.Errorf("evolve schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// WritesLocalDB reports whether the job writes to the LocalDB TargetDBID
+// rather than a registered destination.
+func (j *SyncJob) WritesLocalDB() bool {
+	return j.DestType == "" || j.DestType == DestLocalDB
+}
+
+// openDestination opens a write session on the job's destination: a
+// registered destination type, or the engine's LocalDB writer.
+func (e *Engine) openDestination(ctx context.Context, job *SyncJob, opts WriteOptions) (WriteSession, error) {
+	if job.WritesLocalDB() {
+		return e.Dest.Open(ctx, job.TargetDBID, opts)
+	}
+	dest, err := GetDestination(job.DestType)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Mode == "" {
+		opts.Mode = SyncReplace
+	}
+	if spec := dest.Spec(); !spec.SupportsMode(opts.Mode) {
+		return nil, fmt.Errorf("%s destination does not support %s mode", spec.Label, opts.Mode)
+	}
+	return dest.Open(ctx, job.DestCfg, opts)
+}
+
+// setStats copies destination write counts onto the result.
+func (r *SyncResult) setStats(stats WriteStats) {
+	r.RowsWritten = stats.Written()
+	r.RowsInserted = stats.Inserted
+	r.RowsUpdated = stats.Updated
+	r.RowsDeleted = stats.Deleted
+}
+
+func (e *Engine) batchSize() int {
+	if e.BatchSize > 0 {
+		return e.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+func (e *Engine) sortBufferSize() int {
+	if e.SortBufferSize > 0 {
+		return e.SortBufferSize
+	}
+	return DefaultSortBufferSize
+}
+
+// findSort returns the first SortTransform in the chain, if any.
+func findSort(ts []Transformer) *SortTransform {
+	for _, t := range ts {
+		if st, ok := t.(*SortTransform); ok && st.Field != "" {
+			return st
+		}
+	}
+	return nil
+}
+
+// ── Batch Writer ──────────────────────────────────────────
+
+// batchWriter accumulates transformed records and writes them to the
+// destination session whenever a full batch is ready.
+type batchWriter struct {
+	ctx     context.Context
+	sess    WriteSession
+	size    int
+	schema  *schemaTracker
+	onFlush func(batch int, stats WriteStats)
+
+	buf     []Record
+	batches int
+	stats   WriteStats
+}
+
+// Add queues a record, flushing when the batch is full.
+func (w *batchWriter) Add(r Record) error {
+	w.buf = append(w.buf, r)
+	if len(w.buf) >= w.size {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes any queued records as one batch.
+func (w *batchWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	w.schema.Observe(w.buf)
+	stats, err := w.sess.Write(w.ctx, w.schema.Schema(), w.buf)
+	w.stats.Add(stats)
+	if err != nil {
+		return err
+	}
+	w.batches++
+	// Sessions may keep the batch (e.g. to buffer it), so start a new one.
+	w.buf = make([]Record, 0, w.size)
+	if w.onFlush != nil {
+		w.onFlush(w.batches, w.stats)
+	}
+	return nil
+}
+
 // Preview executes only the source read phase and returns up to maxRows records.
 func (e *Engine) Preview(ctx context.Context, sourceType string, cfg SourceConfig, maxRows int) ([]Record, *Schema, error) {
 	source, err := GetSource(sourceType)
@@ -148,12 +528,16 @@ func (e *Engine) Preview(ctx context.Context, sourceType string, cfg SourceConfi
 		return nil, nil, fmt.Errorf("discover: %w", err)
 	}
 
-	recCh, errCh := source.Read(ctx, cfg)
+	// Cancelling readCtx stops a streaming source once enough rows are in.
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	recCh, errCh := source.Read(readCtx, cfg)
 
 	var records []Record
 	for rec := range recCh {
 		records = append(records, rec)
 		if len(records) >= maxRows {
+			cancel()
 			break
 		}
 	}
@@ -163,16 +547,39 @@ func (e *Engine) Preview(ctx context.Context, sourceType string, cfg SourceConfi
 		for range recCh {
 		}
 	}()
-	if err := <-errCh; err != nil {
+	if err := <-errCh; err != nil && len(records) < maxRows {
 		return records, schema, err
 	}
 
 	return records, schema, nil
 }
 
-// buildTransformers converts declarative TransformConfig into Transformer instances.
-func buildTransformers(configs []TransformConfig, dedupeKey string) []Transformer {
+// ValidateTransforms checks a transform chain without running it, so invalid
+// compute expressions are rejected when a job is saved. Lookup tables are
+// only checked when store is non-nil.
+func ValidateTransforms(configs []TransformConfig, store domain.LocalDatabaseStore) error {
+	_, err := buildTransformers(configs, "", store)
+	return err
+}
+
+// transformTypes lists the transform types buildTransformers understands.
+var transformTypes = []string{
+	"filter", "rename", "select", "compute", "group", "lookup", "sort", "limit",
+	"type_cast", "flatten", "string", "date_part", "default_value", "math", "sql",
+}
+
+// IsTransformType reports whether typ is a known transform type. Unknown
+// types are skipped by a run, so imports check for them up front.
+func IsTransformType(typ string) bool {
+	return slices.Contains(transformTypes, typ)
+}
+
+// buildTransformers converts declarative TransformConfig into Transformer
+// instances. Lookup transforms load their tables from store; with a nil store
+// only their config is validated.
+func buildTransformers(configs []TransformConfig, dedupeKey string, store domain.LocalDatabaseStore) ([]Transformer, error) {
 	var ts []Transformer
+	grouped := false
 
 	for _, tc := range configs {
 		switch tc.Type {
@@ -210,6 +617,9 @@ func buildTransformers(configs []TransformConfig, dedupeKey string) []Transforme
 						name, _ := cm["name"].(string)
 						expr, _ := cm["expression"].(string)
 						if name != "" && expr != "" {
+							if _, err := compileCompute(expr); err != nil {
+								return nil, fmt.Errorf("compute %q: %w", name, err)
+							}
 							cols = append(cols, ComputeColumn{Name: name, Expression: expr})
 						}
 					}
@@ -219,6 +629,29 @@ func buildTransformers(configs []TransformConfig, dedupeKey string) []Transforme
 				}
 			}
 
+		case "group":
+			if grouped {
+				return nil, fmt.Errorf("only one group transform is supported")
+			}
+			gt, err := parseGroupConfig(tc.Config)
+			if err != nil {
+				return nil, fmt.Errorf("group: %w", err)
+			}
+			ts = append(ts, gt)
+			grouped = true
+
+		case "lookup":
+			lt, err := parseLookupConfig(tc.Config)
+			if err != nil {
+				return nil, fmt.Errorf("lookup: %w", err)
+			}
+			if store != nil {
+				if err := lt.Load(store); err != nil {
+					return nil, fmt.Errorf("lookup: %w", err)
+				}
+			}
+			ts = append(ts, lt)
+
 		case "sort":
 			field, _ := tc.Config["field"].(string)
 			direction, _ := tc.Config["direction"].(string)
@@ -307,6 +740,13 @@ func buildTransformers(configs []TransformConfig, dedupeKey string) []Transforme
 			if field != "" && op != "" {
 				ts = append(ts, &MathTransform{Field: field, Op: op})
 			}
+
+		case "sql":
+			st, err := parseSQLConfig(tc.Config, store)
+			if err != nil {
+				return nil, fmt.Errorf("sql: %w", err)
+			}
+			ts = append(ts, st)
 		}
 	}
 
@@ -315,16 +755,22 @@ func buildTransformers(configs []TransformConfig, dedupeKey string) []Transforme
 		ts = append(ts, NewDedupeTransform(dedupeKey))
 	}
 
-	return ts
+	return ts, nil
 }
 
-// deriveSchemaFromRecords builds a schema from the actual keys present in transformed records.
+// ── Output Schema ─────────────────────────────────────────
+
+// schemaTracker derives the output schema from the keys present in transformed
+// records as they stream past (transforms may add, drop or rename columns).
 // It preserves field type hints from the original source schema where available.
-func deriveSchemaFromRecords(records []Record, sourceSchema *Schema, transforms []TransformConfig) *Schema {
-	if len(records) == 0 {
-		return sourceSchema
-	}
+type schemaTracker struct {
+	typeMap map[string]string
+	seen    map[string]bool
+	fields  []Field
+	order   []string // declared field order, observed ahead of record keys
+}
 
+func newSchemaTracker(sourceSchema *Schema, transforms []TransformConfig) *schemaTracker {
 	// Build lookup of source field types.
 	typeMap := make(map[string]string)
 	if sourceSchema != nil {
@@ -333,39 +779,74 @@ func deriveSchemaFromRecords(records []Record, sourceSchema *Schema, transforms
 		}
 	}
 
-	// Apply type_cast overrides from transforms.
+	// Apply type_cast overrides and group metric types from transforms.
 	for _, tc := range transforms {
-		if tc.Type == "type_cast" {
+		switch tc.Type {
+		case "type_cast":
 			field, _ := tc.Config["field"].(string)
 			castType, _ := tc.Config["castType"].(string)
 			if field != "" && castType != "" {
 				typeMap[field] = castType
 			}
+		case "group":
+			if gt, err := parseGroupConfig(tc.Config); err == nil {
+				for _, m := range gt.Metrics {
+					if m.numeric() {
+						typeMap[m.OutputName()] = "number"
+					} else if ft, ok := typeMap[m.Column]; ok {
+						typeMap[m.OutputName()] = ft
+					}
+				}
+			}
 		}
 	}
 
-	// Collect all unique keys from records (preserving insertion order via slice).
-	seen := make(map[string]bool)
-	var fieldNames []string
+	return &schemaTracker{typeMap: typeMap, seen: make(map[string]bool)}
+}
+
+// Declare takes the field types and order of a schema derived mid-chain,
+// such as a sql transform's result.
+func (t *schemaTracker) Declare(s *Schema) {
+	if s == nil {
+		return
+	}
+	for _, f := range s.Fields {
+		t.typeMap[f.Name] = f.Type
+		t.order = append(t.order, f.Name)
+	}
+}
+
+// Observe records any fields in records that have not been seen yet,
+// preserving declared, then first-seen order.
+func (t *schemaTracker) Observe(records []Record) {
 	for _, r := range records {
-		for k := range r.Data {
-			if !seen[k] {
-				seen[k] = true
-				fieldNames = append(fieldNames, k)
+		for _, k := range t.order {
+			if _, ok := r.Data[k]; ok {
+				t.observe(k)
 			}
 		}
+		for k := range r.Data {
+			t.observe(k)
+		}
 	}
+}
 
-	// Build output schema.
-	fields := make([]Field, 0, len(fieldNames))
-	for _, name := range fieldNames {
-		ft := typeMap[name]
-		if ft == "" {
-			ft = "string" // default for new fields (e.g. from flatten)
-		}
-		fields = append(fields, Field{Name: name, Type: ft})
+func (t *schemaTracker) observe(k string) {
+	if t.seen[k] {
+		return
 	}
+	t.seen[k] = true
+	ft := t.typeMap[k]
+	if ft == "" {
+		ft = "string" // default for new fields (e.g. from flatten)
+	}
+	t.fields = append(t.fields, Field{Name: k, Type: ft})
+}
 
+// Schema returns the fields observed so far.
+func (t *schemaTracker) Schema() *Schema {
+	fields := make([]Field, len(t.fields))
+	copy(fields, t.fields)
 	return &Schema{Fields: fields}
 }
 
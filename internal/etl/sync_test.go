@@ -2,7 +2,16 @@ package etl
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // mockSource implements etl.Source for testing.
@@ -22,8 +31,9 @@ func (s *mockSource) Discover(_ context.Context, _ SourceConfig) (*Schema, error
 func (s *mockSource) Read(_ context.Context, _ SourceConfig) (<-chan Record, <-chan error) {
 	recCh := make(chan Record, len(s.records))
 	errCh := make(chan error, 1)
+	// Transforms modify records in place; each run gets its own copies.
 	for _, r := range s.records {
-		recCh <- r
+		recCh <- Record{Data: maps.Clone(r.Data)}
 	}
 	close(recCh)
 	errCh <- s.err
@@ -34,20 +44,38 @@ func (s *mockSource) Read(_ context.Context, _ SourceConfig) (<-chan Record, <-c
 type mockDestination struct {
 	written  int
 	records  []Record
+	batches  int
+	kept     [][]Record // batches as passed to Write, like a buffering session
+	schema   *Schema
 	mode     SyncMode
 	targetID string
+	closed   bool
+	runErr   error // as passed to Close
 	err      error
 }
 
-func (d *mockDestination) Write(_ context.Context, targetID string, _ *Schema, records []Record, mode SyncMode) (int, error) {
+func (d *mockDestination) Open(_ context.Context, targetID string, opts WriteOptions) (WriteSession, error) {
 	d.targetID = targetID
-	d.records = records
-	d.mode = mode
+	d.mode = opts.Mode
+	return d, nil
+}
+
+func (d *mockDestination) Write(_ context.Context, schema *Schema, records []Record) (WriteStats, error) {
 	if d.err != nil {
-		return 0, d.err
-	}
-	d.written = len(records)
-	return d.written, nil
+		return WriteStats{}, d.err
+	}
+	d.batches++
+	d.schema = schema
+	d.kept = append(d.kept, records)
+	d.records = append(d.records, records...)
+	d.written += len(records)
+	return WriteStats{Inserted: len(records)}, nil
+}
+
+func (d *mockDestination) Close(_ context.Context, runErr error) (WriteStats, error) {
+	d.closed = true
+	d.runErr = runErr
+	return WriteStats{}, nil
 }
 
 func init() {
@@ -170,6 +198,557 @@ func TestEngine_RunSync_DestinationError(t *testing.T) {
 	}
 }
 
+func TestEngine_RunSync_Batches(t *testing.T) {
+	dest := &mockDestination{}
+	var progress []Progress
+	engine := &Engine{
+		Dest:       dest,
+		BatchSize:  2,
+		OnProgress: func(p Progress) { progress = append(progress, p) },
+	}
+
+	job := &SyncJob{ID: "job-1", SourceType: "test", SourceCfg: map[string]any{}, TargetDBID: "db-1", SyncMode: "replace"}
+
+	result, err := engine.RunSync(context.Background(), job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if dest.batches != 2 {
+		t.Errorf("batches = %d, want 2", dest.batches)
+	}
+	// A session may keep a batch; the next one must not overwrite it.
+	if id := dest.kept[0][0].Data["id"]; id != dest.records[0].Data["id"] {
+		t.Errorf("first kept batch starts with id %v, want %v", id, dest.records[0].Data["id"])
+	}
+	if result.RowsWritten != 3 {
+		t.Errorf("rowsWritten = %d, want 3", result.RowsWritten)
+	}
+	if !dest.closed {
+		t.Error("session should be closed")
+	}
+	if len(progress) != 2 {
+		t.Fatalf("progress events = %d, want 2", len(progress))
+	}
+	if progress[1].RowsWritten != 3 || progress[1].Batch != 2 || progress[1].Stage != StageRead {
+		t.Errorf("last progress = %+v", progress[1])
+	}
+}
+
+// slowSource delays its records, so a run spends time between batches.
+type slowSource struct {
+	mockSource
+	delay time.Duration
+}
+
+func (s *slowSource) Read(ctx context.Context, _ SourceConfig) (<-chan Record, <-chan error) {
+	recCh := make(chan Record)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(recCh)
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			errCh <- ctx.Err()
+			return
+		}
+		for _, r := range s.records {
+			recCh <- r
+		}
+		errCh <- nil
+	}()
+	return recCh, errCh
+}
+
+func TestEngine_RunSync_PeriodicProgress(t *testing.T) {
+	RegisterSource(&slowSource{
+		mockSource: mockSource{
+			spec:    SourceSpec{Type: "slow"},
+			schema:  &Schema{Fields: []Field{{Name: "id", Type: "number"}}},
+			records: []Record{{Data: map[string]any{"id": 1.0}}},
+		},
+		delay: 50 * time.Millisecond,
+	})
+
+	var mu sync.Mutex
+	var progress []Progress
+	engine := &Engine{
+		Dest:             &mockDestination{},
+		ProgressInterval: 5 * time.Millisecond,
+		OnProgress: func(p Progress) {
+			mu.Lock()
+			progress = append(progress, p)
+			mu.Unlock()
+		},
+	}
+	job := &SyncJob{ID: "job-1", SourceType: "slow", SourceCfg: map[string]any{}, TargetDBID: "db-1", SyncMode: "replace"}
+	if _, err := engine.RunSync(context.Background(), job); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) < 3 {
+		t.Fatalf("progress events = %d, want periodic reports while the source is slow", len(progress))
+	}
+	first := progress[0]
+	if first.Stage != StageRead || first.RowsRead != 0 || first.Elapsed <= 0 {
+		t.Errorf("first progress = %+v, want an early read-stage report", first)
+	}
+	if last := progress[len(progress)-1]; last.Elapsed < first.Elapsed {
+		t.Errorf("elapsed went backwards: %s < %s", last.Elapsed, first.Elapsed)
+	}
+}
+
+func TestEngine_RunSync_SortSpillsToDisk(t *testing.T) {
+	dest := &mockDestination{}
+	engine := &Engine{Dest: dest, BatchSize: 1, SortBufferSize: 1, SpillDir: t.TempDir()}
+
+	job := &SyncJob{
+		ID:         "job-1",
+		SourceType: "test",
+		SourceCfg:  map[string]any{},
+		TargetDBID: "db-1",
+		SyncMode:   "replace",
+		Transforms: []TransformConfig{
+			{Type: "sort", Config: map[string]any{"field": "name", "direction": "desc"}},
+		},
+	}
+
+	if _, err := engine.RunSync(context.Background(), job); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(dest.records) != 3 {
+		t.Fatalf("records = %d, want 3", len(dest.records))
+	}
+	want := []string{"charlie", "bob", "alice"}
+	for i, w := range want {
+		if dest.records[i].Data["name"] != w {
+			t.Errorf("records[%d].name = %v, want %s", i, dest.records[i].Data["name"], w)
+		}
+	}
+}
+
+func TestEngine_RunSync_Group(t *testing.T) {
+	dest := &mockDestination{}
+	engine := &Engine{Dest: dest}
+
+	job := &SyncJob{
+		ID:         "job-1",
+		SourceType: "test",
+		SourceCfg:  map[string]any{},
+		TargetDBID: "db-1",
+		SyncMode:   "replace",
+		Transforms: []TransformConfig{
+			{Type: "compute", Config: map[string]any{"columns": []any{
+				map[string]any{"name": "parity", "expression": "if({id} % 2 = 0, 'even', 'odd')"},
+			}}},
+			{Type: "group", Config: map[string]any{
+				"groupBy": []any{"parity"},
+				"metrics": []any{
+					map[string]any{"agg": "count"},
+					map[string]any{"column": "id", "agg": "sum", "as": "total"},
+				},
+			}},
+			// Runs on the summaries, not the source rows.
+			{Type: "compute", Config: map[string]any{"columns": []any{
+				map[string]any{"name": "label", "expression": "{parity} & ':' & {count}"},
+			}}},
+			{Type: "sort", Config: map[string]any{"field": "parity"}},
+		},
+	}
+
+	result, err := engine.RunSync(context.Background(), job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.RowsRead != 3 || result.RowsWritten != 2 {
+		t.Errorf("rowsRead = %d, rowsWritten = %d, want 3 and 2", result.RowsRead, result.RowsWritten)
+	}
+	want := []map[string]any{
+		{"parity": "even", "count": 1.0, "total": 2.0, "label": "even:1"},
+		{"parity": "odd", "count": 2.0, "total": 4.0, "label": "odd:2"},
+	}
+	if len(dest.records) != len(want) {
+		t.Fatalf("records = %v", dest.records)
+	}
+	for i, w := range want {
+		for k, v := range w {
+			if got := dest.records[i].Data[k]; got != v {
+				t.Errorf("records[%d].%s = %v, want %v", i, k, got, v)
+			}
+		}
+	}
+}
+
+func TestEngine_RunSync_SQL(t *testing.T) {
+	dest := &mockDestination{}
+	engine := &Engine{Dest: dest}
+
+	job := &SyncJob{
+		ID:         "job-1",
+		SourceType: "test",
+		SourceCfg:  map[string]any{},
+		TargetDBID: "db-1",
+		SyncMode:   "replace",
+		Transforms: []TransformConfig{
+			{Type: "sql", Config: map[string]any{
+				"query": "SELECT id % 2 = 0 AS even, count(*) AS n, group_concat(name, '+') AS names FROM input GROUP BY 1 ORDER BY 1",
+			}},
+			// Runs on the query result, not the source rows.
+			{Type: "filter", Config: map[string]any{"field": "n", "op": "gt", "value": 1}},
+		},
+	}
+
+	result, err := engine.RunSync(context.Background(), job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.RowsRead != 3 || result.RowsWritten != 1 {
+		t.Errorf("rowsRead = %d, rowsWritten = %d, want 3 and 1", result.RowsRead, result.RowsWritten)
+	}
+	if got := dest.records[0].Data; got["n"] != 2.0 || got["names"] != "alice+charlie" {
+		t.Errorf("record = %v", got)
+	}
+	want := []Field{{Name: "even", Type: "number"}, {Name: "n", Type: "number"}, {Name: "names", Type: "text"}}
+	if fmt.Sprint(dest.schema.Fields) != fmt.Sprint(want) {
+		t.Errorf("schema = %v, want %v", dest.schema.Fields, want)
+	}
+
+	job.Transforms = []TransformConfig{{Type: "sql", Config: map[string]any{"query": "SELECT nope FROM input"}}}
+	if _, err := engine.RunSync(context.Background(), job); err == nil || !strings.Contains(err.Error(), "no such column: nope") {
+		t.Errorf("err = %v, want the query error", err)
+	}
+}
+
+func TestEngine_RunSync_Incremental(t *testing.T) {
+	dest := &mockDestination{}
+	engine := &Engine{Dest: dest}
+
+	job := &SyncJob{
+		ID:          "job-1",
+		SourceType:  "test",
+		SourceCfg:   map[string]any{},
+		TargetDBID:  "db-1",
+		SyncMode:    SyncIncremental,
+		CursorField: "id",
+		CursorValue: "1",
+	}
+
+	result, err := engine.RunSync(context.Background(), job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.RowsWritten != 2 {
+		t.Errorf("rowsWritten = %d, want 2 (id 1 already synced)", result.RowsWritten)
+	}
+	if result.Cursor != "3" {
+		t.Errorf("cursor = %q, want 3", result.Cursor)
+	}
+	if _, ok := job.SourceCfg[cursorConfigKey]; ok {
+		t.Error("job source config should not be mutated")
+	}
+}
+
+func TestEngine_RunSync_IncrementalRequiresCursorField(t *testing.T) {
+	engine := &Engine{Dest: &mockDestination{}}
+
+	job := &SyncJob{ID: "job-1", SourceType: "test", SourceCfg: map[string]any{}, SyncMode: SyncIncremental}
+
+	result, err := engine.RunSync(context.Background(), job)
+	if err == nil {
+		t.Fatal("expected error without cursor field")
+	}
+	if result.Status != "error" {
+		t.Errorf("status = %q", result.Status)
+	}
+}
+
+// statefulSource resumes after the id in its previous state and saves a new
+// one, like a Singer tap.
+type statefulSource struct {
+	mockSource
+	prev string
+}
+
+func (s *statefulSource) Read(_ context.Context, cfg SourceConfig) (<-chan Record, <-chan error) {
+	run, _ := SourceRunFromConfig(cfg)
+	s.prev = run.PrevState
+	fmt.Fprintln(run, "tap: 2 records")
+	run.SaveState(`{"id":2}`)
+	return s.mockSource.Read(context.Background(), cfg)
+}
+
+func TestEngine_RunSync_StatefulSource(t *testing.T) {
+	src := &statefulSource{mockSource: mockSource{
+		spec:    SourceSpec{Type: "test_stateful", Stateful: true},
+		schema:  &Schema{Fields: []Field{{Name: "id", Type: "number"}}},
+		records: []Record{{Data: map[string]any{"id": 1.0}}, {Data: map[string]any{"id": 2.0}}},
+	}}
+	RegisterSource(src)
+	engine := &Engine{Dest: &mockDestination{}}
+
+	// No cursor field needed: the source tracks its own position.
+	job := &SyncJob{ID: "job-1", SourceType: "test_stateful", SourceCfg: map[string]any{}, SyncMode: SyncIncremental, CursorValue: `{"id":0}`}
+	result, err := engine.RunSync(context.Background(), job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if src.prev != `{"id":0}` {
+		t.Errorf("previous state = %q", src.prev)
+	}
+	if result.Cursor != `{"id":2}` {
+		t.Errorf("cursor = %q, want the saved state", result.Cursor)
+	}
+	if result.Output != "tap: 2 records\n" {
+		t.Errorf("output = %q", result.Output)
+	}
+
+	// A full refresh neither resumes nor persists the state.
+	job.SyncMode = SyncReplace
+	result, err = engine.RunSync(context.Background(), job)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if src.prev != "" || result.Cursor != "" {
+		t.Errorf("replace: previous state = %q, cursor = %q", src.prev, result.Cursor)
+	}
+}
+
+func TestCompareCursor(t *testing.T) {
+	tests := []struct {
+		a, b any
+		want int
+	}{
+		{10.0, "9", 1},
+		{"2024-01-02", "2024-01-10", -1},
+		{"2024-01-02T10:00:00Z", "2024-01-02 10:00:00", 0},
+		{"b", "a", 1},
+	}
+	for _, tt := range tests {
+		if got := compareCursor(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareCursor(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// targetDestination routes each target ID to its own mockDestination.
+type targetDestination map[string]*mockDestination
+
+func (d targetDestination) Open(ctx context.Context, targetID string, opts WriteOptions) (WriteSession, error) {
+	if d[targetID] == nil {
+		d[targetID] = &mockDestination{}
+	}
+	return d[targetID].Open(ctx, targetID, opts)
+}
+
+func TestEngine_RunSync_Assertions(t *testing.T) {
+	ptr := func(f float64) *float64 { return &f }
+	run := func(assertions ...Assertion) (targetDestination, *SyncResult, error) {
+		dest := targetDestination{}
+		engine := &Engine{Dest: dest}
+		result, err := engine.RunSync(context.Background(), &SyncJob{
+			SourceType:     "test",
+			TargetDBID:     "db-1",
+			SyncMode:       SyncReplace,
+			Assertions:     assertions,
+			QuarantineDBID: "quarantine",
+		})
+		return dest, result, err
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		_, result, err := run(Assertion{Type: AssertRegex, Column: "name", Pattern: "^[ab]"})
+		if err == nil || !strings.Contains(err.Error(), "regex(name ~ ^[ab]) failed: charlie") {
+			t.Fatalf("err = %v", err)
+		}
+		if len(result.Assertions) != 1 || result.Assertions[0].Violations != 1 {
+			t.Errorf("assertions = %+v", result.Assertions)
+		}
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		dest, result, err := run(
+			Assertion{Type: AssertInSet, Column: "name", Values: []any{"alice"}, Policy: PolicyWarn},
+			Assertion{Type: AssertRowCount, Min: ptr(5), Policy: PolicyWarn},
+		)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if dest["db-1"].written != 3 {
+			t.Errorf("written = %d, want 3", dest["db-1"].written)
+		}
+		got := result.Assertions
+		if len(got) != 2 || got[0].Violations != 2 || got[0].Example != "bob" || got[1].Violations != 1 || got[1].Example != "3" {
+			t.Errorf("assertions = %+v", got)
+		}
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		dest, result, err := run(
+			Assertion{Type: AssertRange, Column: "id", Max: ptr(2), Policy: PolicyQuarantine},
+			Assertion{Type: AssertRowCount, Min: ptr(2), Max: ptr(2)},
+		)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if dest["db-1"].written != 2 || result.RowsQuarantined != 1 {
+			t.Fatalf("written = %d, quarantined = %d", dest["db-1"].written, result.RowsQuarantined)
+		}
+		q := dest["quarantine"]
+		if q == nil || len(q.records) != 1 || q.mode != SyncAppend || !q.closed {
+			t.Fatalf("quarantine = %+v", q)
+		}
+		if got := q.records[0].Data["_violations"]; got != "range(id ..2)" {
+			t.Errorf("_violations = %v", got)
+		}
+	})
+
+	t.Run("unique ignores nulls", func(t *testing.T) {
+		dest := targetDestination{}
+		src := &mockSource{spec: SourceSpec{Type: "test_dupes"}, schema: &Schema{}, records: []Record{
+			{Data: map[string]any{"k": "a"}},
+			{Data: map[string]any{"k": nil}},
+			{Data: map[string]any{"k": nil}},
+			{Data: map[string]any{"k": "a"}},
+		}}
+		RegisterSource(src)
+		engine := &Engine{Dest: dest}
+		result, err := engine.RunSync(context.Background(), &SyncJob{
+			SourceType: "test_dupes",
+			TargetDBID: "db-1",
+			Assertions: []Assertion{{Type: AssertUnique, Column: "k", Policy: PolicyWarn}},
+		})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if v := result.Assertions[0].Violations; v != 1 {
+			t.Errorf("violations = %d, want 1", v)
+		}
+	})
+}
+
+func TestValidateAssertions(t *testing.T) {
+	one := 1.0
+	tests := []struct {
+		assertion  Assertion
+		quarantine string
+		wantErr    string
+	}{
+		{Assertion{Type: AssertNotNull, Column: "id"}, "", ""},
+		{Assertion{Type: AssertNotNull}, "", "column is required"},
+		{Assertion{Type: "nope", Column: "id"}, "", "unknown type"},
+		{Assertion{Type: AssertInSet, Column: "id"}, "", "values are required"},
+		{Assertion{Type: AssertRegex, Column: "id", Pattern: "("}, "", "invalid pattern"},
+		{Assertion{Type: AssertRange, Column: "id"}, "", "min or max is required"},
+		{Assertion{Type: AssertRowCount, Min: &one, Policy: PolicyQuarantine}, "q", "cannot quarantine"},
+		{Assertion{Type: AssertNotNull, Column: "id", Policy: PolicyQuarantine}, "", "requires a quarantine database"},
+		{Assertion{Type: AssertNotNull, Column: "id", Policy: "drop"}, "", "unknown policy"},
+	}
+	for _, tt := range tests {
+		err := ValidateAssertions([]Assertion{tt.assertion}, tt.quarantine)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.assertion, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.assertion, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDiffSchemas(t *testing.T) {
+	prev := &Schema{Fields: []Field{{Name: "id", Type: "number"}, {Name: "amount", Type: "number"}, {Name: "note", Type: "text"}}}
+	cur := &Schema{Fields: []Field{{Name: "id", Type: "number"}, {Name: "amount", Type: "text"}, {Name: "tag", Type: "text"}}}
+
+	got := DiffSchemas(prev, cur)
+	want := []SchemaChange{
+		{Field: "amount", Change: FieldTypeChanged, OldType: "number", NewType: "text"},
+		{Field: "tag", Change: FieldAdded, NewType: "text"},
+		{Field: "note", Change: FieldRemoved, OldType: "text"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("diff = %v, want %v", got, want)
+	}
+	if got := DiffSchemas(nil, cur); got != nil {
+		t.Errorf("diff without baseline = %v", got)
+	}
+	if got := DiffSchemas(prev, &Schema{}); got != nil {
+		t.Errorf("diff with empty schema = %v", got)
+	}
+}
+
+func TestEngine_RunSync_SchemaPolicy(t *testing.T) {
+	last := &Schema{Fields: []Field{{Name: "id", Type: "text"}, {Name: "name", Type: "text"}, {Name: "gone", Type: "text"}}}
+	newJob := func(policy SchemaPolicy) *SyncJob {
+		return &SyncJob{ID: "job-1", SourceType: "test", TargetDBID: "db-1", SyncMode: SyncAppend, SchemaPolicy: policy, LastSchema: last}
+	}
+
+	dest := &mockDestination{}
+	result, err := (&Engine{Dest: dest}).RunSync(context.Background(), newJob(""))
+	if err != nil {
+		t.Fatalf("ignore: %v", err)
+	}
+	if len(result.SchemaDrift) != 2 || dest.written != 3 {
+		t.Errorf("ignore: drift = %v, written = %d", result.SchemaDrift, dest.written)
+	}
+	if result.Schema == nil || len(result.Schema.Fields) != 2 {
+		t.Errorf("ignore: schema = %+v", result.Schema)
+	}
+
+	dest = &mockDestination{}
+	result, err = (&Engine{Dest: dest}).RunSync(context.Background(), newJob(SchemaFail))
+	if err == nil || !strings.Contains(err.Error(), "id changed from text to number") || !strings.Contains(err.Error(), "gone removed") {
+		t.Fatalf("fail: err = %v", err)
+	}
+	// The drift is found once the output is complete; the destination then
+	// discards what it was given.
+	if result.Status != "error" || dest.runErr == nil || len(result.SchemaDrift) != 2 {
+		t.Errorf("fail: status = %q, close err = %v, drift = %v", result.Status, dest.runErr, result.SchemaDrift)
+	}
+
+	// Drift is measured on the output: transforms that cast id back to text
+	// and compute the missing field hide the source's changes.
+	job := newJob(SchemaFail)
+	job.Transforms = []TransformConfig{
+		{Type: "type_cast", Config: map[string]any{"field": "id", "castType": "text"}},
+		{Type: "compute", Config: map[string]any{"columns": []any{map[string]any{"name": "gone", "expression": "'x'"}}}},
+		{Type: "type_cast", Config: map[string]any{"field": "gone", "castType": "text"}},
+	}
+	if result, err := (&Engine{Dest: &mockDestination{}}).RunSync(context.Background(), job); err != nil || len(result.SchemaDrift) != 0 {
+		t.Errorf("output drift: %v, err = %v", result.SchemaDrift, err)
+	}
+
+	if err := SchemaPolicy("strict").Validate(); err == nil {
+		t.Error("expected unknown policy to be rejected")
+	}
+}
+
+func TestCoerceValue(t *testing.T) {
+	tests := []struct {
+		v    any
+		typ  string
+		want any
+	}{
+		{"42", "number", 42.0},
+		{"n/a", "number", "n/a"},
+		{"maybe", "boolean", "maybe"},
+		{"no", "boolean", false},
+		{"soon", "date", "soon"},
+		{true, "number", 1.0},
+		{"yes", "boolean", true},
+		{12.5, "text", "12.5"},
+		{"2024-03-01T10:00:00Z", "date", "2024-03-01"},
+		{nil, "text", nil},
+	}
+	for _, tt := range tests {
+		if got := coerceValue(tt.v, tt.typ); got != tt.want {
+			t.Errorf("coerceValue(%v, %s) = %v, want %v", tt.v, tt.typ, got, tt.want)
+		}
+	}
+}
+
 func TestEngine_Preview(t *testing.T) {
 	dest := &mockDestination{}
 	engine := &Engine{Dest: dest}
@@ -187,6 +766,86 @@ func TestEngine_Preview(t *testing.T) {
 	}
 }
 
+func TestEngine_Debug(t *testing.T) {
+	engine := &Engine{Dest: &mockDestination{}}
+	job := &SyncJob{
+		ID:         "job-1",
+		SourceType: "test",
+		SourceCfg:  map[string]any{},
+		Transforms: []TransformConfig{
+			{Type: "compute", Config: map[string]any{"columns": []any{
+				map[string]any{"name": "score", "expression": "{id} * {weight}"},
+			}}},
+			{Type: "filter", Config: map[string]any{"field": "id", "op": "gt", "value": 1.0}},
+			{Type: "group", Config: map[string]any{"metrics": []any{map[string]any{"agg": "count"}}}},
+			{Type: "sort", Config: map[string]any{"field": "count", "direction": "desc"}},
+		},
+		DedupeKey: "count",
+	}
+
+	res, err := engine.Debug(context.Background(), job, 0)
+	if err != nil {
+		t.Fatalf("debug: %v", err)
+	}
+	if res.Error != "" {
+		t.Fatalf("debug error: %s", res.Error)
+	}
+
+	want := []struct {
+		typ      string
+		index    int
+		in, out  int
+		errCount int
+	}{
+		{"source", -1, 3, 3, 0},
+		{"compute", 0, 3, 3, 3}, // {weight} does not exist
+		{"filter", 1, 3, 2, 0},
+		{"group", 2, 2, 1, 0},
+		{"sort", 3, 1, 1, 0},
+		{"dedupe", -1, 1, 1, 0},
+	}
+	if len(res.Stages) != len(want) {
+		t.Fatalf("stages = %+v", res.Stages)
+	}
+	for i, w := range want {
+		st := res.Stages[i]
+		if st.Type != w.typ || st.Index != w.index || st.RecordsIn != w.in || st.RecordsOut != w.out || st.ErrorCount != w.errCount {
+			t.Errorf("stage %d = %s[%d] %d→%d (%d errors), want %s[%d] %d→%d (%d errors)",
+				i, st.Type, st.Index, st.RecordsIn, st.RecordsOut, st.ErrorCount, w.typ, w.index, w.in, w.out, w.errCount)
+		}
+	}
+
+	compute := res.Stages[1]
+	if len(compute.Errors) == 0 || compute.Errors[0].Row != 1 || !strings.Contains(compute.Errors[0].Error, `"weight"`) {
+		t.Errorf("compute errors = %+v", compute.Errors)
+	}
+	// Samples are snapshots: the source sample does not see the computed column.
+	if _, ok := res.Stages[0].Sample[0].Data["score"]; ok {
+		t.Error("source sample was modified by a later stage")
+	}
+	if got := res.Stages[3].Sample[0].Data["count"]; got != 2.0 {
+		t.Errorf("group sample count = %v, want 2", got)
+	}
+	if names := res.Stages[3].Schema.FieldNames(); len(names) != 1 || names[0] != "count" {
+		t.Errorf("group schema = %v", names)
+	}
+}
+
+func TestEngine_Debug_InvalidTransform(t *testing.T) {
+	engine := &Engine{Dest: &mockDestination{}}
+	job := &SyncJob{
+		SourceType: "test",
+		Transforms: []TransformConfig{
+			{Type: "compute", Config: map[string]any{"columns": []any{
+				map[string]any{"name": "x", "expression": "({id}"},
+			}}},
+		},
+	}
+	if _, err := engine.Debug(context.Background(), job, 0); err == nil || !strings.Contains(err.Error(), "transform 1") {
+		t.Errorf("err = %v, want transform 1 error", err)
+	}
+}
+
 // ── Source Registry ─────────────────────────────────────────
 
 func TestGetSource_Found(t *testing.T) {
@@ -252,3 +911,44 @@ func TestSchema_FieldNames(t *testing.T) {
 		t.Errorf("names = %v", names)
 	}
 }
+
+// ── Retry Policy ──
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 60}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 60 * time.Second, 60 * time.Second}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+	if got := (RetryPolicy{}).Backoff(1); got != DefaultBackoff {
+		t.Errorf("default backoff = %s", got)
+	}
+	if got := (RetryPolicy{}).Attempts(); got != 1 {
+		t.Errorf("default attempts = %d, want 1", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: 503}, true},
+		{fmt.Errorf("read: %w", &StatusError{StatusCode: 429}), true},
+		{&StatusError{StatusCode: 404}, false},
+		{fmt.Errorf("read: %w", context.DeadlineExceeded), true},
+		{context.Canceled, false},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{errors.New("dial tcp 127.0.0.1:5432: connect: connection refused"), true},
+		{errors.New("open data.csv: no such file or directory"), false},
+		{&os.PathError{Op: "open", Path: "data.csv", Err: syscall.ENOENT}, false},
+		{errors.New("assertion not_null(email) failed: <nil>"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
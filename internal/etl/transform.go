@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"notes/internal/expr"
 )
 
 // ── Transformer ────────────────────────────────────────────
@@ -107,10 +109,14 @@ func (t *DedupeTransform) Transform(r Record) (Record, bool) {
 	return r, true
 }
 
-// ComputeTransform adds or overwrites fields using simple expressions.
-// Expression format: {field_name} references, basic math (+, -, *, /).
+// ComputeTransform adds or overwrites fields using expressions (see package
+// expr): {field} references, arithmetic, comparisons, and/or/not, if(),
+// coalesce() and text/date functions. Plain templates such as
+// "{first} {last}" that are not valid expressions still substitute values.
 type ComputeTransform struct {
 	Columns []ComputeColumn
+
+	evals []func(map[string]any) any // compiled lazily, parallel to Columns
 }
 
 type ComputeColumn struct {
@@ -119,19 +125,65 @@ type ComputeColumn struct {
 }
 
 func (t *ComputeTransform) Transform(r Record) (Record, bool) {
-	for _, col := range t.Columns {
-		if col.Name == "" || col.Expression == "" {
+	if t.evals == nil {
+		t.evals = make([]func(map[string]any) any, len(t.Columns))
+		for i, col := range t.Columns {
+			// Invalid expressions are rejected by buildTransformers; here
+			// they just leave the column untouched.
+			t.evals[i], _ = compileCompute(col.Expression)
+		}
+	}
+	for i, col := range t.Columns {
+		if col.Name == "" || t.evals[i] == nil {
 			continue
 		}
-		r.Data[col.Name] = evaluateExpr(r.Data, col.Expression)
+		r.Data[col.Name] = t.evals[i](r.Data)
 	}
 	return r, true
 }
 
-// evaluateExpr resolves {field} references and concatenates string parts.
-func evaluateExpr(data map[string]any, expr string) any {
-	// Simple: replace {field} with values, try numeric math first.
-	resolved := expr
+// compileCompute compiles a compute expression, falling back to template
+// substitution for legacy expressions like "{first} {last}".
+func compileCompute(src string) (func(map[string]any) any, error) {
+	prog, err := expr.Compile(src)
+	if err == nil {
+		return func(data map[string]any) any {
+			v := prog.Eval(data)
+			if tv, ok := v.(time.Time); ok {
+				return expr.ToString(tv)
+			}
+			return v
+		}, nil
+	}
+	if isTemplate(src) {
+		return func(data map[string]any) any { return evaluateTemplate(data, src) }, nil
+	}
+	return nil, err
+}
+
+// isTemplate reports whether src is a legacy template: {field} references
+// mixed with literal text that contains no operators, parentheses or quotes.
+func isTemplate(src string) bool {
+	if !strings.Contains(src, "{") {
+		return false
+	}
+	depth := 0
+	for _, c := range src {
+		switch {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case depth == 0 && strings.ContainsRune(`+-*/%()<>=!&|"'`, c):
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateTemplate resolves {field} references and concatenates string parts.
+func evaluateTemplate(data map[string]any, tmpl string) any {
+	resolved := tmpl
 	for k, v := range data {
 		placeholder := "{" + k + "}"
 		if strings.Contains(resolved, placeholder) {
@@ -146,8 +198,9 @@ func evaluateExpr(data map[string]any, expr string) any {
 }
 
 // SortTransform sorts all collected records by a field.
-// NOTE: This is a batch transform — it must collect ALL records, so it's
-// applied after the streaming phase by the engine, not per-record.
+// NOTE: This is a batch transform — it must see ALL records, so the engine
+// applies it after the streaming phase, spilling to disk once the in-memory
+// buffer is full (see externalSorter).
 type SortTransform struct {
 	Field     string
 	Direction string // "asc" | "desc"
@@ -497,25 +550,6 @@ func ApplyBatchSort(records []Record, ts []Transformer) []Record {
 	return records
 }
 
-func sortRecords(records []Record, field, direction string) {
-	dir := 1
-	if direction == "desc" {
-		dir = -1
-	}
-	// Simple insertion sort (stable, good enough for moderate sizes).
-	for i := 1; i < len(records); i++ {
-		for j := i; j > 0; j-- {
-			a := records[j-1].Data[field]
-			b := records[j].Data[field]
-			if compareValues(a, b)*dir > 0 {
-				records[j-1], records[j] = records[j], records[j-1]
-			} else {
-				break
-			}
-		}
-	}
-}
-
 func compareValues(a, b any) int {
 	fa, aOk := toFloatSafe(a)
 	fb, bOk := toFloatSafe(b)
@@ -1,6 +1,9 @@
 package etl
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -173,6 +176,208 @@ func TestComputeTransform_NumericResult(t *testing.T) {
 	}
 }
 
+func TestComputeTransform_Expressions(t *testing.T) {
+	c := &ComputeTransform{
+		Columns: []ComputeColumn{
+			{Name: "total", Expression: "{qty} * {price} + 1"},
+			{Name: "size", Expression: "if({total} > 20, 'large', 'small')"},
+			{Name: "label", Expression: "upper({name}) & ' #' & {qty}"},
+			{Name: "note", Expression: "coalesce({missing}, 'n/a')"},
+			{Name: "due", Expression: "date_add({ordered}, 7, 'days')"},
+		},
+	}
+
+	result, _ := c.Transform(rec(map[string]any{"qty": 3, "price": 7.5, "name": "bolt", "ordered": "2024-01-29"}))
+	want := map[string]any{"total": 23.5, "size": "large", "label": "BOLT #3", "note": "n/a", "due": "2024-02-05"}
+	for k, v := range want {
+		if result.Data[k] != v {
+			t.Errorf("%s = %v (%T), want %v", k, result.Data[k], result.Data[k], v)
+		}
+	}
+}
+
+func TestValidateTransforms(t *testing.T) {
+	compute := func(expression string) []TransformConfig {
+		return []TransformConfig{{Type: "compute", Config: map[string]any{
+			"columns": []any{map[string]any{"name": "out", "expression": expression}},
+		}}}
+	}
+
+	for _, ok := range []string{"{a} * {b}", "{first} {last}", "Hello {name}", "round({x}, 2)"} {
+		if err := ValidateTransforms(compute(ok), nil); err != nil {
+			t.Errorf("ValidateTransforms(%q) = %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"{a} *", "nosuchfn({a})", "if({a})"} {
+		err := ValidateTransforms(compute(bad), nil)
+		if err == nil || !strings.Contains(err.Error(), `compute "out"`) {
+			t.Errorf("ValidateTransforms(%q) = %v, want compute error", bad, err)
+		}
+	}
+}
+
+// ── GroupTransform ──────────────────────────────────────────
+
+func TestGroupTransform(t *testing.T) {
+	g, err := parseGroupConfig(map[string]any{
+		"groupBy": []any{"region"},
+		"metrics": []any{
+			map[string]any{"agg": "count"},
+			map[string]any{"column": "customer", "agg": "count_distinct"},
+			map[string]any{"column": "amount", "agg": "sum"},
+			map[string]any{"column": "amount", "agg": "avg"},
+			map[string]any{"column": "amount", "agg": "min"},
+			map[string]any{"column": "amount", "agg": "max"},
+			map[string]any{"column": "amount", "agg": "median"},
+			map[string]any{"column": "amount", "agg": "percentile", "percentile": 90.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	rows := []map[string]any{
+		{"region": "eu", "customer": "a", "amount": 10.0},
+		{"region": "us", "customer": "b", "amount": 5.0},
+		{"region": "eu", "customer": "a", "amount": 30.0},
+		{"region": "eu", "customer": "c", "amount": nil},
+		{"region": "eu", "customer": "d", "amount": 20.0},
+	}
+	for _, r := range rows {
+		if _, keep := g.Transform(rec(r)); keep {
+			t.Fatal("group should absorb records")
+		}
+	}
+
+	results := g.Results()
+	if len(results) != 2 {
+		t.Fatalf("groups = %d, want 2", len(results))
+	}
+	eu := results[0].Data
+	want := map[string]any{
+		"region": "eu", "count": 4.0, "customer_count_distinct": 3.0,
+		"amount_sum": 60.0, "amount_avg": 20.0, "amount_min": 10.0, "amount_max": 30.0,
+		"amount_median": 20.0, "amount_p90": 28.0,
+	}
+	for k, v := range want {
+		if eu[k] != v {
+			t.Errorf("eu.%s = %v, want %v", k, eu[k], v)
+		}
+	}
+	if us := results[1].Data; us["region"] != "us" || us["amount_median"] != 5.0 {
+		t.Errorf("us = %v", us)
+	}
+}
+
+func TestGroupTransform_NoKeysEmptyInput(t *testing.T) {
+	g := &GroupTransform{Metrics: []GroupMetric{{Agg: AggCount}, {Column: "x", Agg: AggAvg}}}
+	results := g.Results()
+	if len(results) != 1 || results[0].Data["count"] != 0.0 || results[0].Data["x_avg"] != nil {
+		t.Errorf("results = %v", results)
+	}
+}
+
+func TestParseGroupConfig_Errors(t *testing.T) {
+	tests := []map[string]any{
+		{},
+		{"groupBy": []any{"a"}, "metrics": []any{map[string]any{"agg": "mode", "column": "x"}}},
+		{"metrics": []any{map[string]any{"agg": "sum"}}},
+		{"metrics": []any{map[string]any{"agg": "percentile", "column": "x", "percentile": 150.0}}},
+	}
+	for _, cfg := range tests {
+		if _, err := parseGroupConfig(cfg); err == nil {
+			t.Errorf("parseGroupConfig(%v) succeeded, want error", cfg)
+		}
+	}
+}
+
+// ── SQLTransform ────────────────────────────────────────────
+
+func TestRunSQL(t *testing.T) {
+	records := []Record{
+		rec(map[string]any{"name": "a", "amount": 10.0, "paid": true, "tags": []any{"x", "y"}}),
+		rec(map[string]any{"name": "b", "amount": 5.5, "paid": false, "tags": []any{}}),
+		rec(map[string]any{"name": "c", "amount": nil, "paid": true, "tags": nil}),
+	}
+	out, schema, err := RunSQL(context.Background(), `
+		SELECT name, amount, paid, json_array_length(tags) AS ntags, upper(name) AS shout
+		FROM input WHERE paid ORDER BY name`, records, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := []Field{
+		{Name: "name", Type: "text"}, {Name: "amount", Type: "number"}, {Name: "paid", Type: "boolean"},
+		{Name: "ntags", Type: "number"}, {Name: "shout", Type: "text"},
+	}
+	if fmt.Sprint(schema.Fields) != fmt.Sprint(want) {
+		t.Errorf("schema = %v, want %v", schema.Fields, want)
+	}
+	if len(out) != 2 {
+		t.Fatalf("records = %v", out)
+	}
+	if got := out[0].Data; got["amount"] != 10.0 || got["paid"] != true || got["ntags"] != 2.0 || got["shout"] != "A" {
+		t.Errorf("a = %v", got)
+	}
+	if got := out[1].Data; got["amount"] != nil || got["ntags"] != nil {
+		t.Errorf("c = %v", got)
+	}
+
+	if out, schema, err := RunSQL(context.Background(), "SELECT * FROM input", nil, nil); err != nil || out != nil || schema != nil {
+		t.Errorf("empty input = %v, %v, %v", out, schema, err)
+	}
+	// The query still runs over an empty input.
+	out, _, err = RunSQL(context.Background(), `SELECT count(*) AS n, sum("total amount") AS total FROM input WHERE name <> 'x'`, nil, nil)
+	if err != nil || len(out) != 1 || out[0].Data["n"] != 0.0 || out[0].Data["total"] != nil {
+		t.Errorf("count over empty input = %v, %v", out, err)
+	}
+	if out, _, err = RunSQL(context.Background(), "SELECT 1 AS one", nil, nil); err != nil || len(out) != 1 {
+		t.Errorf("query without input = %v, %v", out, err)
+	}
+}
+
+func TestRunSQL_SelectStarOrder(t *testing.T) {
+	records := []Record{
+		rec(map[string]any{"name": "a", "id": 1.0, "paid": true}),
+		rec(map[string]any{"name": "b", "id": 2.0, "amount": 5.0}),
+	}
+	for range 5 {
+		_, schema, err := RunSQL(context.Background(), "SELECT * FROM input", records, nil)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if got := strings.Join(schema.FieldNames(), ","); got != "id,name,paid,amount" {
+			t.Fatalf("columns = %s", got)
+		}
+	}
+}
+
+func TestParseSQLConfig_Errors(t *testing.T) {
+	for _, query := range []string{
+		"", "  ", ";", "DELETE FROM input", "DROP TABLE input",
+		"SELECT 1; ATTACH DATABASE 'x.db' AS x; CREATE TABLE x.t(a)",
+		"SELECT 1; SELECT 2",
+		"WITH c AS (SELECT 1) DELETE FROM input",
+		"WITH c(a) AS (SELECT 1) INSERT INTO input SELECT a FROM c",
+		"ATTACH DATABASE ':memory:' AS x",
+		"SELECT * FROM pragma_table_info('input') WHERE 1; PRAGMA query_only = OFF",
+		"SELECT 'unterminated",
+	} {
+		if _, err := parseSQLConfig(map[string]any{"query": query}, nil); err == nil {
+			t.Errorf("query %q: expected error", query)
+		}
+	}
+	for _, query := range []string{
+		"with t as (select 1) select * from t;",
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 3) SELECT i FROM n",
+		"SELECT 'a;b', \"odd;name\" FROM input -- trailing; comment",
+		"VALUES (1), (2);;",
+	} {
+		if _, err := parseSQLConfig(map[string]any{"query": query}, nil); err != nil {
+			t.Errorf("query %q: %v", query, err)
+		}
+	}
+}
+
 // ── LimitTransform ──────────────────────────────────────────
 
 func TestLimitTransform(t *testing.T) {
@@ -511,3 +716,54 @@ func TestApplyBatchSort_NoSortTransform(t *testing.T) {
 		t.Errorf("should return original records")
 	}
 }
+
+// ── LookupTransform ─────────────────────────────────────────
+
+func TestLookupTransform(t *testing.T) {
+	index := map[string]map[string]any{
+		"1": {"id": 1.0, "name": "alice", "tier": "gold"},
+	}
+	left := &LookupTransform{
+		LeftKey: "customer_id", RightKey: "id", JoinType: "left", Default: "n/a",
+		Columns: []LookupColumn{{Column: "name", As: "customer"}, {Column: "tier"}},
+		index:   index,
+	}
+
+	r, keep := left.Transform(rec(map[string]any{"customer_id": 1}))
+	if !keep || r.Data["customer"] != "alice" || r.Data["tier"] != "gold" {
+		t.Errorf("hit = %v, keep %v", r.Data, keep)
+	}
+	r, keep = left.Transform(rec(map[string]any{"customer_id": 2}))
+	if !keep || r.Data["customer"] != "n/a" || r.Data["tier"] != "n/a" {
+		t.Errorf("left miss = %v, keep %v", r.Data, keep)
+	}
+
+	inner := *left
+	inner.JoinType = "inner"
+	if _, keep := inner.Transform(rec(map[string]any{"customer_id": nil})); keep {
+		t.Error("inner join should drop misses")
+	}
+}
+
+func TestParseLookupConfig(t *testing.T) {
+	lt, err := parseLookupConfig(map[string]any{
+		"databaseId": "db", "leftKey": "cid", "rightKey": "id",
+		"columns": []any{"name", map[string]any{"column": "tier", "as": "level"}},
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if lt.JoinType != "left" || len(lt.Columns) != 2 || lt.Columns[1].outputName() != "level" {
+		t.Errorf("lookup = %+v", lt)
+	}
+
+	for _, bad := range []map[string]any{
+		{"leftKey": "cid", "rightKey": "id", "columns": []any{"name"}},
+		{"databaseId": "db", "leftKey": "cid", "rightKey": "id"},
+		{"databaseId": "db", "leftKey": "cid", "rightKey": "id", "columns": []any{"name"}, "joinType": "outer"},
+	} {
+		if _, err := parseLookupConfig(bad); err == nil {
+			t.Errorf("parseLookupConfig(%v) succeeded, want error", bad)
+		}
+	}
+}
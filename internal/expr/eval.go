@@ -0,0 +1,352 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Program is a compiled expression, safe for concurrent use.
+type Program struct {
+	src    string
+	root   node
+	fields []string
+}
+
+// Compile parses src. Syntax errors, unknown functions and wrong argument
+// counts are reported here so callers can reject bad expressions on save.
+func Compile(src string) (*Program, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, &SyntaxError{0, "empty expression"}
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+	}
+
+	prog := &Program{src: src, root: root}
+	seen := map[string]bool{}
+	walk(root, func(n node) {
+		if f, ok := n.(*fieldNode); ok && !seen[f.name] {
+			seen[f.name] = true
+			prog.fields = append(prog.fields, f.name)
+		}
+	})
+	return prog, nil
+}
+
+// Validate reports whether src compiles.
+func Validate(src string) error {
+	_, err := Compile(src)
+	return err
+}
+
+// String returns the source text.
+func (p *Program) String() string { return p.src }
+
+// Fields returns the field names the expression references, in order of
+// first appearance.
+func (p *Program) Fields() []string { return p.fields }
+
+// Eval evaluates the expression against vars. Missing fields are null.
+// The result is nil, float64, string, bool or time.Time.
+func (p *Program) Eval(vars map[string]any) any {
+	return p.root.eval(vars)
+}
+
+// ── AST ────────────────────────────────────────────────────
+
+type node interface {
+	eval(vars map[string]any) any
+}
+
+type literalNode struct{ value any }
+
+func (n *literalNode) eval(map[string]any) any { return n.value }
+
+type fieldNode struct{ name string }
+
+func (n *fieldNode) eval(vars map[string]any) any { return normalize(vars[n.name]) }
+
+type negNode struct{ operand node }
+
+func (n *negNode) eval(vars map[string]any) any {
+	f, ok := toNumber(n.operand.eval(vars))
+	if !ok {
+		return nil
+	}
+	return -f
+}
+
+type notNode struct{ operand node }
+
+func (n *notNode) eval(vars map[string]any) any { return !truthy(n.operand.eval(vars)) }
+
+type logicNode struct {
+	op          string // "and" | "or"
+	left, right node
+}
+
+func (n *logicNode) eval(vars map[string]any) any {
+	l := truthy(n.left.eval(vars))
+	if n.op == "and" {
+		return l && truthy(n.right.eval(vars))
+	}
+	return l || truthy(n.right.eval(vars))
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(vars map[string]any) any {
+	l, r := n.left.eval(vars), n.right.eval(vars)
+	switch n.op {
+	case "&":
+		return ToString(l) + ToString(r)
+	case "+", "-", "*", "/", "%":
+		lf, lok := toNumber(l)
+		rf, rok := toNumber(r)
+		if !lok || !rok {
+			return nil
+		}
+		switch n.op {
+		case "+":
+			return finite(lf + rf)
+		case "-":
+			return finite(lf - rf)
+		case "*":
+			return finite(lf * rf)
+		case "/":
+			if rf == 0 {
+				return nil
+			}
+			return finite(lf / rf)
+		default:
+			if rf == 0 {
+				return nil
+			}
+			return finite(math.Mod(lf, rf))
+		}
+	case "=":
+		return equal(l, r)
+	case "!=":
+		return !equal(l, r)
+	default: // < <= > >=
+		if l == nil || r == nil {
+			return false
+		}
+		c := compare(l, r)
+		switch n.op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+	}
+}
+
+type callNode struct {
+	name string
+	fn   *function
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]any) any {
+	if n.fn.lazy != nil {
+		return n.fn.lazy(n.args, vars)
+	}
+	vals := make([]any, len(n.args))
+	for i, a := range n.args {
+		vals[i] = a.eval(vars)
+	}
+	return n.fn.call(vals)
+}
+
+func walk(n node, visit func(node)) {
+	visit(n)
+	switch v := n.(type) {
+	case *negNode:
+		walk(v.operand, visit)
+	case *notNode:
+		walk(v.operand, visit)
+	case *logicNode:
+		walk(v.left, visit)
+		walk(v.right, visit)
+	case *binaryNode:
+		walk(v.left, visit)
+		walk(v.right, visit)
+	case *callNode:
+		for _, a := range v.args {
+			walk(a, visit)
+		}
+	}
+}
+
+// ── Values ─────────────────────────────────────────────────
+// Null rules:
+//   - arithmetic with a null or non-numeric operand, or a non-finite result, yields null
+//   - & and text functions treat null as ""
+//   - = is true only when both sides are null; ordering against null is false
+//   - and/or/not/if treat null as false
+
+// normalize maps host values onto the expression value types.
+func normalize(v any) any {
+	switch tv := v.(type) {
+	case nil, float64, string, bool, time.Time:
+		return v
+	case int:
+		return float64(tv)
+	case int8:
+		return float64(tv)
+	case int16:
+		return float64(tv)
+	case int32:
+		return float64(tv)
+	case int64:
+		return float64(tv)
+	case uint:
+		return float64(tv)
+	case uint8:
+		return float64(tv)
+	case uint16:
+		return float64(tv)
+	case uint32:
+		return float64(tv)
+	case uint64:
+		return float64(tv)
+	case float32:
+		return float64(tv)
+	case json.Number:
+		if f, err := tv.Float64(); err == nil {
+			return f
+		}
+		return tv.String()
+	case []byte:
+		return string(tv)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// toNumber converts numbers, numeric text and booleans to float64.
+func toNumber(v any) (float64, bool) {
+	switch tv := v.(type) {
+	case float64:
+		return tv, true
+	case bool:
+		if tv {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(tv), 64)
+		return f, err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+	}
+	return 0, false
+}
+
+// ToString renders a value as text; null becomes "".
+func ToString(v any) string {
+	switch tv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return tv
+	case float64:
+		return strconv.FormatFloat(tv, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(tv)
+	case time.Time:
+		if tv.Hour() == 0 && tv.Minute() == 0 && tv.Second() == 0 && tv.Nanosecond() == 0 {
+			return tv.Format("2006-01-02")
+		}
+		return tv.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(normalize(v))
+	}
+}
+
+func truthy(v any) bool {
+	switch tv := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return tv
+	case float64:
+		return tv != 0
+	case string:
+		return tv != "" && !strings.EqualFold(tv, "false")
+	default:
+		return true
+	}
+}
+
+func equal(l, r any) bool {
+	if l == nil || r == nil {
+		return l == nil && r == nil
+	}
+	return compare(l, r) == 0
+}
+
+// compare orders two non-null values: numerically when both are numeric,
+// chronologically when both are dates, lexically otherwise.
+func compare(l, r any) int {
+	if lb, ok := l.(bool); ok {
+		if rb, ok := r.(bool); ok {
+			switch {
+			case lb == rb:
+				return 0
+			case !lb:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	_, lNum := l.(float64)
+	_, rNum := r.(float64)
+	if lNum || rNum {
+		if lf, ok := toNumber(l); ok {
+			if rf, ok := toNumber(r); ok {
+				switch {
+				case lf < rf:
+					return -1
+				case lf > rf:
+					return 1
+				}
+				return 0
+			}
+		}
+	}
+	_, lTime := l.(time.Time)
+	_, rTime := r.(time.Time)
+	if lTime || rTime {
+		if lt, ok := toTime(l); ok {
+			if rt, ok := toTime(r); ok {
+				return lt.Compare(rt)
+			}
+		}
+	}
+	return strings.Compare(ToString(l), ToString(r))
+}
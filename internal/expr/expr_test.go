@@ -0,0 +1,133 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func eval(t *testing.T, src string, vars map[string]any) any {
+	t.Helper()
+	p, err := Compile(src)
+	if err != nil {
+		t.Fatalf("compile %q: %v", src, err)
+	}
+	return p.Eval(vars)
+}
+
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		"a": 6.0, "b": 3, "name": "Ada", "last name": "Lovelace",
+		"empty": nil, "flag": true, "price": "12.5",
+		"created": "2024-03-15", "ended": "2024-04-20 10:30:00",
+	}
+	tests := []struct {
+		src  string
+		want any
+	}{
+		// Arithmetic and precedence
+		{"{a} * {b}", 18.0},
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"-{a} + 10 % 4", -4.0},
+		{"{a} / 0", nil},
+		{"{price} * 2", 25.0},
+		{`"1" + "2"`, 3.0},
+		{`"1" + 1`, 2.0},
+		{"{price} + {a}", 18.5},
+		{`{name} + 1`, nil},
+		{"1e308 * 10", nil},
+		{"1e308 + 1e308", nil},
+		{`"Infinity" + 1`, nil},
+		{"1.5e2", 150.0},
+		// Text
+		{`{name} & " " & {last name}`, "Ada Lovelace"},
+		{`{name} & {empty} & "!"`, "Ada!"},
+		{`upper(name)`, "ADA"},
+		{`substr("abcdef", 2, 3)`, "bcd"},
+		{`left({last name}, 4)`, "Love"},
+		{`split_part("a,b,c", ",", 2)`, "b"},
+		{`len("héllo")`, 5.0},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		// Null handling
+		{"{empty} + 1", nil},
+		{"coalesce({empty}, {missing}, 'x')", "x"},
+		{"isnull({empty})", true},
+		{"{empty} = null", true},
+		{"{empty} > 1", false},
+		// Comparison and logic
+		{"{a} > {b} and not {flag} = false", true},
+		{"{a} < 1 or {name} == 'Ada'", true},
+		{"{a} <> 6", false},
+		{"!{flag} || {b} >= 3", true},
+		{"if({a} > 5, 'big', 'small')", "big"},
+		{"if({a} > 50, 'big')", nil},
+		{"max(1, {a}, {b})", 6.0},
+		{"round(2.345, 2)", 2.35},
+		{"round(1e308, 10)", nil},
+		// Dates
+		{"year({created})", 2024.0},
+		{"weekday({created})", 5.0},
+		{"date_diff({ended}, {created}, 'days')", 36.0},
+		{"date_diff({ended}, {created}, 'months')", 1.0},
+		{"format_date(date_add({created}, 1, 'month'), 'DD/MM/YYYY')", "15/04/2024"},
+		{"format_date(date_add('2024-01-31', 1, 'month'), 'YYYY-MM-DD')", "2024-02-29"},
+		{"format_date(date_add('2024-03-31', -1, 'months'), 'YYYY-MM-DD')", "2024-02-29"},
+		{"format_date(date_add('2024-02-29', 1, 'year'), 'YYYY-MM-DD')", "2025-02-28"},
+		{"date({created}) < date({ended})", true},
+	}
+	for _, tt := range tests {
+		if got := eval(t, tt.src, vars); got != tt.want {
+			t.Errorf("%s = %v (%T), want %v (%T)", tt.src, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestEval_Today(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 5, 1, 15, 4, 5, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	got := eval(t, "format_date(today(), 'YYYY-MM-DD HH:mm')", nil)
+	if got != "2024-05-01 00:00" {
+		t.Errorf("today = %v", got)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantMsg string
+	}{
+		{"", "empty expression"},
+		{"{a} *", "unexpected end"},
+		{"(1 + 2", "expected )"},
+		{"{a", "unterminated field"},
+		{"'abc", "unterminated string"},
+		{"frobnicate(1)", "unknown function"},
+		{"if(1)", "expects 2 to 3 arguments"},
+		{"1 < 2 < 3", "cannot be chained"},
+		{"1 2", "unexpected"},
+		{"{a} # 2", "unexpected character"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		if err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", tt.src)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantMsg) {
+			t.Errorf("Compile(%q) error = %q, want it to contain %q", tt.src, err, tt.wantMsg)
+		}
+	}
+}
+
+func TestProgram_Fields(t *testing.T) {
+	p, err := Compile("if({qty} > 0, {price} * qty, {fallback price})")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	got := strings.Join(p.Fields(), ",")
+	if got != "qty,price,fallback price" {
+		t.Errorf("fields = %s", got)
+	}
+}
@@ -0,0 +1,392 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ── Functions ──────────────────────────────────────────────
+// Names are case-insensitive. Text positions are 1-based, like SQL.
+//
+//	logic   if(cond, then, else)  coalesce(a, b, ...)  isnull(x)
+//	text    upper lower trim len concat left right substr(s, start[, n])
+//	        replace(s, old, new) contains startswith endswith
+//	        split_part(s, sep, n)  text(x)
+//	math    abs round(x[, digits]) floor ceil sqrt pow(x, y) min max number(x)
+//	date    now() today() date(x) year month day hour minute weekday (0 = Sunday)
+//	        date_add(d, n, unit)  date_diff(a, b, unit)  format_date(d, layout)
+//	        units: second minute hour day week month year
+//	        layout tokens: YYYY MM DD HH mm ss
+
+type function struct {
+	minArgs, maxArgs int // maxArgs < 0 = variadic
+	call             func(args []any) any
+	lazy             func(args []node, vars map[string]any) any // evaluates its own args
+}
+
+func (f *function) arity() string {
+	switch {
+	case f.maxArgs < 0:
+		return fmt.Sprintf("at least %d argument(s)", f.minArgs)
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d argument(s)", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// now is swapped out in tests.
+var now = time.Now
+
+var functions map[string]*function
+
+func init() {
+	functions = map[string]*function{
+		// Logic
+		"if": {minArgs: 2, maxArgs: 3, lazy: func(args []node, vars map[string]any) any {
+			if truthy(args[0].eval(vars)) {
+				return args[1].eval(vars)
+			}
+			if len(args) == 3 {
+				return args[2].eval(vars)
+			}
+			return nil
+		}},
+		"coalesce": {minArgs: 1, maxArgs: -1, lazy: func(args []node, vars map[string]any) any {
+			for _, a := range args {
+				if v := a.eval(vars); v != nil && v != "" {
+					return v
+				}
+			}
+			return nil
+		}},
+		"isnull": {minArgs: 1, maxArgs: 1, call: func(a []any) any { return a[0] == nil || a[0] == "" }},
+
+		// Text
+		"upper":  textFn(strings.ToUpper),
+		"lower":  textFn(strings.ToLower),
+		"trim":   textFn(strings.TrimSpace),
+		"len":    {minArgs: 1, maxArgs: 1, call: func(a []any) any { return float64(len([]rune(ToString(a[0])))) }},
+		"length": {minArgs: 1, maxArgs: 1, call: func(a []any) any { return float64(len([]rune(ToString(a[0])))) }},
+		"text":   {minArgs: 1, maxArgs: 1, call: func(a []any) any { return ToString(a[0]) }},
+		"concat": {minArgs: 1, maxArgs: -1, call: func(a []any) any {
+			var b strings.Builder
+			for _, v := range a {
+				b.WriteString(ToString(v))
+			}
+			return b.String()
+		}},
+		"left": {minArgs: 2, maxArgs: 2, call: func(a []any) any {
+			r := []rune(ToString(a[0]))
+			n, ok := toInt(a[1])
+			if !ok {
+				return nil
+			}
+			return string(r[:clamp(n, 0, len(r))])
+		}},
+		"right": {minArgs: 2, maxArgs: 2, call: func(a []any) any {
+			r := []rune(ToString(a[0]))
+			n, ok := toInt(a[1])
+			if !ok {
+				return nil
+			}
+			return string(r[len(r)-clamp(n, 0, len(r)):])
+		}},
+		"substr": {minArgs: 2, maxArgs: 3, call: func(a []any) any {
+			r := []rune(ToString(a[0]))
+			start, ok := toInt(a[1])
+			if !ok {
+				return nil
+			}
+			from := clamp(start-1, 0, len(r))
+			to := len(r)
+			if len(a) == 3 {
+				n, ok := toInt(a[2])
+				if !ok {
+					return nil
+				}
+				to = clamp(from+n, from, len(r))
+			}
+			return string(r[from:to])
+		}},
+		"replace": {minArgs: 3, maxArgs: 3, call: func(a []any) any {
+			return strings.ReplaceAll(ToString(a[0]), ToString(a[1]), ToString(a[2]))
+		}},
+		"contains":   textPredicate(strings.Contains),
+		"startswith": textPredicate(strings.HasPrefix),
+		"endswith":   textPredicate(strings.HasSuffix),
+		"split_part": {minArgs: 3, maxArgs: 3, call: func(a []any) any {
+			parts := strings.Split(ToString(a[0]), ToString(a[1]))
+			n, ok := toInt(a[2])
+			if !ok || n < 1 || n > len(parts) {
+				return nil
+			}
+			return parts[n-1]
+		}},
+
+		// Math
+		"abs":   mathFn(math.Abs),
+		"floor": mathFn(math.Floor),
+		"ceil":  mathFn(math.Ceil),
+		"sqrt": mathFn(func(f float64) float64 {
+			if f < 0 {
+				return math.NaN()
+			}
+			return math.Sqrt(f)
+		}),
+		"round": {minArgs: 1, maxArgs: 2, call: func(a []any) any {
+			f, ok := toNumber(a[0])
+			if !ok {
+				return nil
+			}
+			digits := 0
+			if len(a) == 2 {
+				if digits, ok = toInt(a[1]); !ok {
+					return nil
+				}
+			}
+			p := math.Pow(10, float64(digits))
+			return finite(math.Round(f*p) / p)
+		}},
+		"pow": {minArgs: 2, maxArgs: 2, call: func(a []any) any {
+			x, ok1 := toNumber(a[0])
+			y, ok2 := toNumber(a[1])
+			if !ok1 || !ok2 {
+				return nil
+			}
+			return finite(math.Pow(x, y))
+		}},
+		"min":    {minArgs: 1, maxArgs: -1, call: func(a []any) any { return extreme(a, -1) }},
+		"max":    {minArgs: 1, maxArgs: -1, call: func(a []any) any { return extreme(a, 1) }},
+		"number": {minArgs: 1, maxArgs: 1, call: func(a []any) any { return numberOrNil(a[0]) }},
+
+		// Date
+		"now":   {minArgs: 0, maxArgs: 0, call: func([]any) any { return now() }},
+		"today": {minArgs: 0, maxArgs: 0, call: func([]any) any { return truncateDay(now()) }},
+		"date": {minArgs: 1, maxArgs: 1, call: func(a []any) any {
+			if t, ok := toTime(a[0]); ok {
+				return t
+			}
+			return nil
+		}},
+		"year":    datePart(func(t time.Time) int { return t.Year() }),
+		"month":   datePart(func(t time.Time) int { return int(t.Month()) }),
+		"day":     datePart(func(t time.Time) int { return t.Day() }),
+		"hour":    datePart(func(t time.Time) int { return t.Hour() }),
+		"minute":  datePart(func(t time.Time) int { return t.Minute() }),
+		"weekday": datePart(func(t time.Time) int { return int(t.Weekday()) }),
+		"date_add": {minArgs: 3, maxArgs: 3, call: func(a []any) any {
+			t, ok := toTime(a[0])
+			n, nok := toInt(a[1])
+			if !ok || !nok {
+				return nil
+			}
+			switch strings.ToLower(ToString(a[2])) {
+			case "second", "seconds":
+				return t.Add(time.Duration(n) * time.Second)
+			case "minute", "minutes":
+				return t.Add(time.Duration(n) * time.Minute)
+			case "hour", "hours":
+				return t.Add(time.Duration(n) * time.Hour)
+			case "day", "days":
+				return t.AddDate(0, 0, n)
+			case "week", "weeks":
+				return t.AddDate(0, 0, 7*n)
+			case "month", "months":
+				return addMonths(t, n)
+			case "year", "years":
+				return addMonths(t, 12*n)
+			}
+			return nil
+		}},
+		"date_diff": {minArgs: 3, maxArgs: 3, call: func(a []any) any {
+			t1, ok1 := toTime(a[0])
+			t2, ok2 := toTime(a[1])
+			if !ok1 || !ok2 {
+				return nil
+			}
+			d := t1.Sub(t2)
+			switch strings.ToLower(ToString(a[2])) {
+			case "second", "seconds":
+				return math.Trunc(d.Seconds())
+			case "minute", "minutes":
+				return math.Trunc(d.Minutes())
+			case "hour", "hours":
+				return math.Trunc(d.Hours())
+			case "day", "days":
+				return math.Trunc(d.Hours() / 24)
+			case "week", "weeks":
+				return math.Trunc(d.Hours() / (24 * 7))
+			case "month", "months":
+				return float64(monthsBetween(t2, t1))
+			case "year", "years":
+				return float64(monthsBetween(t2, t1) / 12)
+			}
+			return nil
+		}},
+		"format_date": {minArgs: 2, maxArgs: 2, call: func(a []any) any {
+			t, ok := toTime(a[0])
+			if !ok {
+				return nil
+			}
+			return t.Format(goLayout(ToString(a[1])))
+		}},
+	}
+}
+
+// ── Helpers ────────────────────────────────────────────────
+
+func textFn(f func(string) string) *function {
+	return &function{minArgs: 1, maxArgs: 1, call: func(a []any) any {
+		if a[0] == nil {
+			return nil
+		}
+		return f(ToString(a[0]))
+	}}
+}
+
+func textPredicate(f func(s, sub string) bool) *function {
+	return &function{minArgs: 2, maxArgs: 2, call: func(a []any) any {
+		return f(ToString(a[0]), ToString(a[1]))
+	}}
+}
+
+func mathFn(f func(float64) float64) *function {
+	return &function{minArgs: 1, maxArgs: 1, call: func(a []any) any {
+		x, ok := toNumber(a[0])
+		if !ok {
+			return nil
+		}
+		return finite(f(x))
+	}}
+}
+
+func datePart(f func(time.Time) int) *function {
+	return &function{minArgs: 1, maxArgs: 1, call: func(a []any) any {
+		t, ok := toTime(a[0])
+		if !ok {
+			return nil
+		}
+		return float64(f(t))
+	}}
+}
+
+func numberOrNil(v any) any {
+	if f, ok := toNumber(v); ok {
+		return f
+	}
+	return nil
+}
+
+func finite(f float64) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return f
+}
+
+// extreme returns the smallest (dir < 0) or largest (dir > 0) non-null argument.
+func extreme(args []any, dir int) any {
+	var best any
+	for _, v := range args {
+		if v == nil {
+			continue
+		}
+		if best == nil || compare(v, best)*dir > 0 {
+			best = v
+		}
+	}
+	return best
+}
+
+func toInt(v any) (int, bool) {
+	f, ok := toNumber(v)
+	if !ok {
+		return 0, false
+	}
+	return int(f), true
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006",
+	"01/02/2006",
+	"Jan 2, 2006",
+}
+
+// toTime parses dates from time values and text. Numbers are not treated as
+// timestamps: an id of 1700000000 should not silently become a date.
+func toTime(v any) (time.Time, bool) {
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, true
+	case string:
+		s := strings.TrimSpace(tv)
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// addMonths moves t by n calendar months, keeping the day of the month but
+// clamping it to the target month's last day: Jan 31 + 1 month is Feb 28/29.
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// monthsBetween counts whole calendar months from a to b.
+func monthsBetween(a, b time.Time) int {
+	sign := 1
+	if b.Before(a) {
+		a, b = b, a
+		sign = -1
+	}
+	months := (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+	if a.AddDate(0, months, 0).After(b) {
+		months--
+	}
+	return sign * months
+}
+
+var layoutTokens = strings.NewReplacer(
+	"YYYY", "2006",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// goLayout converts a YYYY-MM-DD style layout into a Go time layout.
+func goLayout(layout string) string {
+	return layoutTokens.Replace(layout)
+}
@@ -0,0 +1,415 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ── Expression Language ────────────────────────────────────
+// A small, side-effect-free expression language shared by ETL compute
+// transforms and LocalDB formula columns.
+//
+//	{field name}   field reference (braces allow spaces); bare_identifier also works
+//	1.5  'text'  "text"  true  false  null
+//	+ - * / %      arithmetic on numbers and numeric text (null if the
+//	               result is not finite)
+//	&              text concatenation (null counts as "")
+//	= == != <> < <= > >=
+//	and or not     (also && || !)
+//	fn(args...)    see funcs.go
+//
+// Precedence, lowest first: or, and, not, comparison, + - &, * / %, unary -.
+// Null propagates through arithmetic; see eval.go for the exact rules.
+
+// SyntaxError reports a parse or compile error with its byte offset.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("expression error at position %d: %s", e.Pos+1, e.Msg)
+}
+
+// ── Lexer ──────────────────────────────────────────────────
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokField // {name} or bare identifier
+	tokIdent // identifier followed by "("
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '{':
+			end := strings.IndexByte(src[i+1:], '}')
+			if end < 0 {
+				return nil, &SyntaxError{i, "unterminated field reference"}
+			}
+			name := strings.TrimSpace(src[i+1 : i+1+end])
+			if name == "" {
+				return nil, &SyntaxError{i, "empty field reference"}
+			}
+			toks = append(toks, token{kind: tokField, text: name, pos: i})
+			i += end + 2
+
+		case c == '\'' || c == '"':
+			s, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokString, text: s, pos: i})
+			i += n
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			// Exponent: 1e6, 2.5E-3
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				k := j + 1
+				if k < len(src) && (src[k] == '+' || src[k] == '-') {
+					k++
+				}
+				if k < len(src) && src[k] >= '0' && src[k] <= '9' {
+					for k < len(src) && src[k] >= '0' && src[k] <= '9' {
+						k++
+					}
+					j = k
+				}
+			}
+			f, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, &SyntaxError{i, fmt.Sprintf("invalid number %q", src[i:j])}
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:j], num: f, pos: i})
+			i = j
+
+		case isIdentStart(c):
+			j := i
+			for j < len(src) && (isIdentStart(src[j]) || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			word := src[i:j]
+			switch strings.ToLower(word) {
+			case "and", "or", "not":
+				toks = append(toks, token{kind: tokOp, text: strings.ToLower(word), pos: i})
+			default:
+				kind := tokField
+				k := j
+				for k < len(src) && (src[k] == ' ' || src[k] == '\t') {
+					k++
+				}
+				if k < len(src) && src[k] == '(' {
+					kind = tokIdent
+				}
+				toks = append(toks, token{kind: kind, text: word, pos: i})
+			}
+			i = j
+
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			toks = append(toks, token{kind: tokComma, text: ",", pos: i})
+			i++
+
+		default:
+			raw := ""
+			for _, cand := range []string{"==", "!=", "<>", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "&", "=", "<", ">", "!"} {
+				if strings.HasPrefix(src[i:], cand) {
+					raw = cand
+					break
+				}
+			}
+			if raw == "" {
+				return nil, &SyntaxError{i, fmt.Sprintf("unexpected character %q", c)}
+			}
+			op := raw
+			switch raw {
+			case "==":
+				op = "="
+			case "<>":
+				op = "!="
+			case "&&":
+				op = "and"
+			case "||":
+				op = "or"
+			case "!":
+				op = "not"
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: i})
+			i += len(raw)
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, pos: len(src)})
+	return toks, nil
+}
+
+// isIdentStart reports whether c can start an identifier. Bytes of multi-byte
+// UTF-8 sequences are accepted so non-ASCII field names work unbraced.
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// lexString scans a quoted string starting at src[start], returning its
+// unescaped value and the number of bytes consumed.
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch src[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(src[i])
+			}
+		case c == quote:
+			return b.String(), i - start + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &SyntaxError{start, "unterminated string"}
+}
+
+// ── Parser ─────────────────────────────────────────────────
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("not") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("=", "!=", "<", "<=", ">", ">=") {
+		op := p.next().text
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+		if p.isOp("=", "!=", "<", "<=", ">", ">=") {
+			return nil, &SyntaxError{p.peek().pos, "comparisons cannot be chained; combine them with and/or"}
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+", "-", "&") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*", "/", "%") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("-", "+") {
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return operand, nil
+		}
+		return &negNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literalNode{value: t.num}, nil
+	case tokString:
+		return &literalNode{value: t.text}, nil
+	case tokField:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		return &fieldNode{name: t.text}, nil
+	case tokIdent:
+		return p.parseCall(t)
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, &SyntaxError{p.peek().pos, "expected )"}
+		}
+		p.next()
+		return inner, nil
+	case tokEOF:
+		return nil, &SyntaxError{t.pos, "unexpected end of expression"}
+	default:
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, &SyntaxError{name.pos, fmt.Sprintf("unknown function %q", name.text)}
+	}
+	p.next() // (
+
+	var args []node
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.peek().kind != tokRParen {
+		return nil, &SyntaxError{p.peek().pos, fmt.Sprintf("expected , or ) in call to %s", name.text)}
+	}
+	p.next()
+
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, &SyntaxError{name.pos, fmt.Sprintf("%s expects %s, got %d", name.text, fn.arity(), len(args))}
+	}
+	return &callNode{name: strings.ToLower(name.text), fn: fn, args: args}, nil
+}
@@ -0,0 +1,293 @@
+package httpauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"notes/internal/secret"
+)
+
+// ── HTTP Authentication ────────────────────────────────────
+// Shared by the ETL HTTP source and the HTTP block. A Config describes how a
+// request authenticates; the credential itself (token, password, API key or
+// client secret) lives in the SecretStore and is referenced by SecretRef.
+// The Authenticator resolves it at request time, so nothing sensitive is
+// persisted in SQLite.
+
+// Auth types.
+const (
+	TypeNone   = "none"
+	TypeBearer = "bearer"
+	TypeBasic  = "basic"
+	TypeAPIKey = "api_key"
+	TypeOAuth2 = "oauth2" // client-credentials grant
+)
+
+// Config describes how a request authenticates.
+type Config struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"` // basic
+	KeyName  string `json:"keyName,omitempty"`  // api_key: header or query parameter name
+	KeyIn    string `json:"keyIn,omitempty"`    // api_key: "header" (default) | "query"
+	TokenURL string `json:"tokenUrl,omitempty"` // oauth2
+	ClientID string `json:"clientId,omitempty"` // oauth2
+	Scopes   string `json:"scopes,omitempty"`   // oauth2, space-separated
+
+	// SecretRef is the SecretStore key holding the credential.
+	SecretRef string `json:"secretRef,omitempty"`
+	// Secret is a plaintext credential not yet moved to the SecretStore
+	// (e.g. while previewing an unsaved config). Seal clears it.
+	Secret string `json:"secret,omitempty"`
+}
+
+// Enabled reports whether the config applies any authentication.
+func (c Config) Enabled() bool {
+	return c.Type != "" && c.Type != TypeNone
+}
+
+// Validate checks that the config carries the settings its type needs.
+func (c Config) Validate() error {
+	switch c.Type {
+	case "", TypeNone, TypeBearer:
+	case TypeBasic:
+		if c.Username == "" {
+			return fmt.Errorf("basic auth requires a username")
+		}
+	case TypeAPIKey:
+		if c.KeyName == "" {
+			return fmt.Errorf("api key auth requires a key name")
+		}
+		if c.KeyIn != "" && c.KeyIn != "header" && c.KeyIn != "query" {
+			return fmt.Errorf("api key location must be header or query, got %q", c.KeyIn)
+		}
+	case TypeOAuth2:
+		if c.TokenURL == "" || c.ClientID == "" {
+			return fmt.Errorf("oauth2 auth requires a token url and client id")
+		}
+	default:
+		return fmt.Errorf("unknown auth type %q", c.Type)
+	}
+	return nil
+}
+
+// Authenticator applies auth configs to outgoing requests.
+// It is safe for concurrent use.
+type Authenticator struct {
+	secrets secret.SecretStore
+	client  *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]oauthToken // cache key → token
+	now    func() time.Time
+}
+
+type oauthToken struct {
+	value   string
+	expires time.Time // zero = no expiry reported
+}
+
+// New creates an Authenticator that reads credentials from secrets.
+func New(secrets secret.SecretStore) *Authenticator {
+	return &Authenticator{
+		secrets: secrets,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		tokens:  make(map[string]oauthToken),
+		now:     time.Now,
+	}
+}
+
+// Seal moves a plaintext credential into the SecretStore under ref and
+// leaves only the reference in cfg. A config without a plaintext secret is
+// left untouched so edits keep the previously stored credential.
+func (a *Authenticator) Seal(cfg *Config, ref string) error {
+	if cfg.Secret == "" {
+		return nil
+	}
+	if cfg.SecretRef != "" {
+		ref = cfg.SecretRef
+	}
+	if err := a.secrets.Set(ref, []byte(cfg.Secret)); err != nil {
+		return fmt.Errorf("store credential: %w", err)
+	}
+	cfg.SecretRef = ref
+	cfg.Secret = ""
+	a.Invalidate(*cfg)
+	return nil
+}
+
+// Forget deletes the stored credential referenced by cfg.
+func (a *Authenticator) Forget(cfg Config) error {
+	a.Invalidate(cfg)
+	if cfg.SecretRef == "" {
+		return nil
+	}
+	return a.secrets.Delete(cfg.SecretRef)
+}
+
+// Apply authenticates req according to cfg.
+func (a *Authenticator) Apply(ctx context.Context, req *http.Request, cfg Config) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	cred, err := a.credential(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.Type {
+	case TypeBearer:
+		req.Header.Set("Authorization", "Bearer "+cred)
+	case TypeBasic:
+		raw := cfg.Username + ":" + cred
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(raw)))
+	case TypeAPIKey:
+		if cfg.KeyIn == "query" {
+			q := req.URL.Query()
+			q.Set(cfg.KeyName, cred)
+			req.URL.RawQuery = q.Encode()
+		} else {
+			req.Header.Set(cfg.KeyName, cred)
+		}
+	case TypeOAuth2:
+		token, err := a.oauthToken(ctx, cfg, cred)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// Invalidate drops any cached OAuth2 token for cfg, forcing a new token
+// request on the next Apply. Call it after a 401 response.
+func (a *Authenticator) Invalidate(cfg Config) {
+	if cfg.Type != TypeOAuth2 {
+		return
+	}
+	a.mu.Lock()
+	delete(a.tokens, tokenCacheKey(cfg))
+	a.mu.Unlock()
+}
+
+// credential returns the plaintext credential for cfg.
+func (a *Authenticator) credential(cfg Config) (string, error) {
+	if cfg.Secret != "" {
+		return cfg.Secret, nil
+	}
+	if cfg.SecretRef == "" {
+		return "", fmt.Errorf("%s auth: no credential configured", cfg.Type)
+	}
+	val, err := a.secrets.Get(cfg.SecretRef)
+	if err != nil {
+		return "", fmt.Errorf("%s auth: read credential: %w", cfg.Type, err)
+	}
+	if len(val) == 0 {
+		return "", fmt.Errorf("%s auth: credential %q not found", cfg.Type, cfg.SecretRef)
+	}
+	return string(val), nil
+}
+
+// ── OAuth2 client credentials ──────────────────────────────
+
+// tokenExpiryMargin renews tokens slightly before they expire so a request
+// never goes out with a token that lapses in flight.
+const tokenExpiryMargin = 30 * time.Second
+
+// tokenCacheKey identifies the client a token is issued to. It includes the
+// credential's reference (or a hash of a plaintext one), so configs with
+// different client secrets never share a token.
+func tokenCacheKey(cfg Config) string {
+	cred := cfg.SecretRef
+	if cfg.Secret != "" {
+		sum := sha256.Sum256([]byte(cfg.Secret))
+		cred = hex.EncodeToString(sum[:])
+	}
+	return strings.Join([]string{cfg.TokenURL, cfg.ClientID, cfg.Scopes, cred}, "\x1f")
+}
+
+func (a *Authenticator) oauthToken(ctx context.Context, cfg Config, clientSecret string) (string, error) {
+	key := tokenCacheKey(cfg)
+
+	a.mu.Lock()
+	tok, ok := a.tokens[key]
+	a.mu.Unlock()
+	if ok && (tok.expires.IsZero() || a.now().Before(tok.expires)) {
+		return tok.value, nil
+	}
+
+	tok, err := a.requestToken(ctx, cfg, clientSecret)
+	if err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	a.tokens[key] = tok
+	a.mu.Unlock()
+	return tok.value, nil
+}
+
+func (a *Authenticator) requestToken(ctx context.Context, cfg Config, clientSecret string) (oauthToken, error) {
+	form := neturl.Values{}
+	form.Set("grant_type", "client_credentials")
+	if cfg.Scopes != "" {
+		form.Set("scope", cfg.Scopes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("oauth2: create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(neturl.QueryEscape(cfg.ClientID), neturl.QueryEscape(clientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("oauth2: token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("oauth2: read token response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return oauthToken{}, fmt.Errorf("oauth2: token endpoint returned %d: %s", resp.StatusCode, truncate(string(body), 512))
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return oauthToken{}, fmt.Errorf("oauth2: parse token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("oauth2: token response has no access_token")
+	}
+
+	tok := oauthToken{value: payload.AccessToken}
+	if payload.ExpiresIn > 0 {
+		tok.expires = a.now().Add(time.Duration(payload.ExpiresIn)*time.Second - tokenExpiryMargin)
+	}
+	return tok, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,161 @@
+package httpauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memSecrets is a simple in-memory secret store for testing.
+type memSecrets map[string][]byte
+
+func (m memSecrets) Set(key string, value []byte) error { m[key] = value; return nil }
+func (m memSecrets) Get(key string) ([]byte, error)     { return m[key], nil }
+func (m memSecrets) Delete(key string) error            { delete(m, key); return nil }
+
+func applyTo(t *testing.T, a *Authenticator, cfg Config) *http.Request {
+	t.Helper()
+	req, _ := http.NewRequest("GET", "https://api.example.com/items?page=1", nil)
+	if err := a.Apply(context.Background(), req, cfg); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	return req
+}
+
+func TestApply_StaticSchemes(t *testing.T) {
+	secrets := memSecrets{"cred": []byte("s3cret")}
+	a := New(secrets)
+
+	req := applyTo(t, a, Config{Type: TypeBearer, SecretRef: "cred"})
+	if got := req.Header.Get("Authorization"); got != "Bearer s3cret" {
+		t.Errorf("bearer header = %q", got)
+	}
+
+	req = applyTo(t, a, Config{Type: TypeBasic, Username: "ada", SecretRef: "cred"})
+	if user, pass, ok := req.BasicAuth(); !ok || user != "ada" || pass != "s3cret" {
+		t.Errorf("basic auth = %q/%q/%v", user, pass, ok)
+	}
+
+	req = applyTo(t, a, Config{Type: TypeAPIKey, KeyName: "X-Api-Key", SecretRef: "cred"})
+	if got := req.Header.Get("X-Api-Key"); got != "s3cret" {
+		t.Errorf("api key header = %q", got)
+	}
+
+	req = applyTo(t, a, Config{Type: TypeAPIKey, KeyName: "api_key", KeyIn: "query", SecretRef: "cred"})
+	if got := req.URL.Query().Get("api_key"); got != "s3cret" || req.URL.Query().Get("page") != "1" {
+		t.Errorf("api key query = %q", req.URL.RawQuery)
+	}
+}
+
+func TestApply_MissingCredential(t *testing.T) {
+	a := New(memSecrets{})
+	req, _ := http.NewRequest("GET", "https://api.example.com", nil)
+	if err := a.Apply(context.Background(), req, Config{Type: TypeBearer, SecretRef: "missing"}); err == nil {
+		t.Error("expected error for missing credential")
+	}
+}
+
+func TestSeal(t *testing.T) {
+	secrets := memSecrets{}
+	a := New(secrets)
+
+	cfg := Config{Type: TypeBearer, Secret: "tok"}
+	if err := a.Seal(&cfg, "http-auth:1"); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if cfg.Secret != "" || cfg.SecretRef != "http-auth:1" {
+		t.Errorf("sealed config = %+v", cfg)
+	}
+	if string(secrets["http-auth:1"]) != "tok" {
+		t.Errorf("stored secret = %q", secrets["http-auth:1"])
+	}
+
+	// Re-sealing a new value keeps the existing reference.
+	cfg.Secret = "tok2"
+	a.Seal(&cfg, "http-auth:2")
+	if cfg.SecretRef != "http-auth:1" || string(secrets["http-auth:1"]) != "tok2" {
+		t.Errorf("reseal: ref = %q, value = %q", cfg.SecretRef, secrets["http-auth:1"])
+	}
+
+	if err := a.Forget(cfg); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if _, ok := secrets["http-auth:1"]; ok {
+		t.Error("secret should be deleted")
+	}
+}
+
+func TestApply_OAuth2ClientCredentials(t *testing.T) {
+	var issued int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		r.ParseForm()
+		if user != "client" || pass != "shh" || r.Form.Get("grant_type") != "client_credentials" || r.Form.Get("scope") != "read" {
+			http.Error(w, "bad client", http.StatusUnauthorized)
+			return
+		}
+		issued++
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "tok-" + string(rune('0'+issued)), "expires_in": 3600})
+	}))
+	defer srv.Close()
+
+	a := New(memSecrets{"oauth": []byte("shh"), "wrong": []byte("nope")})
+	now := time.Now()
+	a.now = func() time.Time { return now }
+	cfg := Config{Type: TypeOAuth2, TokenURL: srv.URL, ClientID: "client", Scopes: "read", SecretRef: "oauth"}
+
+	// Cached across requests.
+	for i := 0; i < 3; i++ {
+		req := applyTo(t, a, cfg)
+		if got := req.Header.Get("Authorization"); got != "Bearer tok-1" {
+			t.Fatalf("authorization = %q", got)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("tokens issued = %d, want 1", issued)
+	}
+
+	// Refreshed once expired.
+	now = now.Add(2 * time.Hour)
+	req := applyTo(t, a, cfg)
+	if got := req.Header.Get("Authorization"); got != "Bearer tok-2" {
+		t.Errorf("after expiry authorization = %q", got)
+	}
+
+	// Invalidate forces a new token.
+	a.Invalidate(cfg)
+	applyTo(t, a, cfg)
+	if issued != 3 {
+		t.Errorf("tokens issued = %d, want 3", issued)
+	}
+
+	// Another client secret for the same client does not reuse the token.
+	wrong := cfg
+	wrong.SecretRef = "wrong"
+	req, _ = http.NewRequest("GET", "http://example.com", nil)
+	if err := a.Apply(context.Background(), req, wrong); err == nil {
+		t.Errorf("apply with the wrong secret = %q, want an error", req.Header.Get("Authorization"))
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		wantErr bool
+	}{
+		{Config{}, false},
+		{Config{Type: TypeBearer}, false},
+		{Config{Type: TypeBasic}, true},
+		{Config{Type: TypeAPIKey, KeyName: "k", KeyIn: "cookie"}, true},
+		{Config{Type: TypeOAuth2, TokenURL: "https://x/token"}, true},
+		{Config{Type: "digest"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) err = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
@@ -122,6 +122,8 @@ Correct step ordering for common tasks. Following these workflows prevents field
 
 Do not create charts before running the ETL job. Do not guess column names. Do not skip the preview step.
 
+If a run writes fewer rows than expected, call ` + "`debug_etl_job`" + ` to see which transform stage drops or breaks them.
+
 ## Dashboard with Sample Data
 
 1. Create title markdown block
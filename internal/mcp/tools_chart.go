@@ -12,7 +12,7 @@ import (
 const chartStagesDescription = `Optional JSON array of pipeline stages to apply after the source. Stages transform data before visualization. Each stage has a "type" and stage-specific fields. Available types:
 - filter: {conditions: [{column, op (eq|neq|gt|lt|gte|lte|contains|not_contains|is_empty|is_not_empty), value}], logic: "and"|"or"}
 - group: {groupBy: ["col1"], metrics: [{column, agg (count|sum|avg|min|max), as?: "output_name"}]}
-- compute: {columns: [{name, expression}]} — use {column_name} refs, supports math (+,-,*,/) and string concat
+- compute: {columns: [{name, expression}]} — use {column_name} refs; same expression language as ETL compute (math, comparisons, and/or, & concat, if, coalesce, text and date functions)
 - sort: {column, direction: "asc"|"desc"}
 - limit: {count: number}
 - percent: {column, as?: "output_name"} — adds percentage-of-total column
@@ -23,6 +23,7 @@ const chartStagesDescription = `Optional JSON array of pipeline stages to apply
 - math: {field, op (round|ceil|floor|abs)}
 - type_cast: {field, castType (number|string|bool|date|datetime)}
 - default_value: {field, defaultValue}
+- sql: {query} — SELECT over the rows so far, loaded as an SQLite table named input; other LocalDBs can be joined by their name
 Example: [{"type":"group","groupBy":["category"],"metrics":[{"column":"sales","agg":"sum","as":"total"}]},{"type":"sort","column":"total","direction":"desc"}]`
 
 func (s *Server) registerChartTools() {
@@ -24,7 +24,7 @@ func (s *Server) registerETLTools() {
 - filter: {field, op (eq|neq|gt|lt|contains), value} — drop rows not matching condition
 - rename: {mapping: {oldName: newName}} — rename columns
 - select: {fields: ["col1","col2"]} — keep only specified columns
-- compute: {columns: [{name, expression}]} — add computed columns, use {field} refs
+- compute: {columns: [{name, expression}]} — add computed columns. Expressions use {field} refs, + - * / %, comparisons, and/or/not, & (concat), if(), coalesce(), text (upper, substr, replace, ...) and date (year, date_add, date_diff, format_date, ...) functions
 - sort: {field, direction (asc|desc)} — sort rows
 - limit: {count} — cap number of rows
 - type_cast: {field, castType (number|string|bool|date|datetime)} — convert types
@@ -33,9 +33,25 @@ func (s *Server) registerETLTools() {
 - default_value: {field, defaultValue} — fill nulls
 - math: {field, op (round|ceil|floor|abs)} — math functions
 - flatten: {sourceField, fields: [{path, alias}]} — extract nested JSON fields
+- group: {groupBy: ["col"], metrics: [{column, agg (count|count_distinct|sum|avg|min|max|median|percentile), percentile?: 0-100, as?}]} — aggregate rows into one summary row per group; later transforms see the summaries. Default output names are column_agg (e.g. amount_sum, amount_p90) or "count"
+- lookup: {localdbBlockId, leftKey, rightKey, columns: ["name" | {column, as}], joinType?: left|inner, defaultValue?} — join columns from another LocalDB by key; left keeps unmatched rows with defaultValue, inner drops them
+- sql: {query} — run a SELECT over all rows, loaded into an in-memory SQLite table named input; other LocalDBs can be joined by their name. Later transforms see the query result, typed from the selected columns
 - dedupe: use dedupeKey param instead
 Example: [{"type":"filter","config":{"field":"age","op":"gt","value":18}},{"type":"string","config":{"field":"name","op":"upper"}}]`)),
 		mcp.WithString("dedupeKey", mcp.Description("Column name for deduplication (optional)")),
+		mcp.WithString("syncMode", mcp.Description("replace (default) | append | incremental | merge — incremental only loads records newer than the last run's cursor; merge upserts rows matched on mergeKeys")),
+		mcp.WithString("cursorField", mcp.Description("Incremental mode: field tracked as high-water mark, e.g. updated_at or an auto-increment id")),
+		mcp.WithString("mergeKeys", mcp.Description("Merge mode: comma-separated key columns used to match existing rows, e.g. \"id\" or \"region,sku\"")),
+		mcp.WithBoolean("deleteMissing", mcp.Description("Merge mode: delete rows whose keys are no longer present in the source")),
+		mcp.WithString("assertionsJSON", mcp.Description(`Data-quality assertions on the output, as a JSON array (optional). Each: {type, column, policy?: fail|warn|quarantine (default fail), ...}
+- not_null, unique: {column}
+- in_set: {column, values: [...]}
+- regex: {column, pattern}
+- range: {column, min?, max?}
+- row_count: {min?, max?} — checked on the number of rows written; cannot quarantine
+fail aborts the run, warn only records the violation in the run log, quarantine diverts the record to quarantineLocaldbBlockId`)),
+		mcp.WithString("quarantineLocaldbBlockId", mcp.Description("LocalDB block ID receiving quarantined records (required for quarantine policies)")),
+		mcp.WithString("schemaPolicy", mcp.Description("What a run does when a source field changes type or disappears since the last successful run: ignore (default, only recorded in the run log) | fail (abort before writing) | evolve (change the LocalDB column type and convert existing values)")),
 	), s.handleCreateETLJob)
 
 	s.mcp.AddTool(mcp.NewTool("list_etl_sources",
@@ -53,6 +69,12 @@ Example: [{"type":"filter","config":{"field":"age","op":"gt","value":18}},{"type
 		mcp.WithString("sourceType", mcp.Description("Source type"), mcp.Required()),
 		mcp.WithString("sourceConfigJSON", mcp.Description("Source configuration as JSON"), mcp.Required()),
 	), s.handlePreviewETLSource)
+
+	s.mcp.AddTool(mcp.NewTool("debug_etl_job",
+		mcp.WithDescription("Run an ETL job's source and transforms on a sample without writing anything. Returns, per stage, records in/out, sample output, the schema after the stage and per-record errors — use it to find which transform drops or breaks rows."),
+		mcp.WithString("jobId", mcp.Description("ETL job ID"), mcp.Required()),
+		mcp.WithNumber("maxRows", mcp.Description("Source records to read (default 200)")),
+	), s.handleDebugETLJob)
 }
 
 func (s *Server) handleCreateETLJob(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -62,6 +84,20 @@ func (s *Server) handleCreateETLJob(ctx context.Context, req mcp.CallToolRequest
 	sourceConfigStr, _ := args["sourceConfigJSON"].(string)
 	localdbBlockID, _ := args["localdbBlockId"].(string)
 	dedupeKey, _ := args["dedupeKey"].(string)
+	syncMode, _ := args["syncMode"].(string)
+	cursorField, _ := args["cursorField"].(string)
+	mergeKeysStr, _ := args["mergeKeys"].(string)
+	deleteMissing, _ := args["deleteMissing"].(bool)
+	assertionsStr, _ := args["assertionsJSON"].(string)
+	quarantineBlockID, _ := args["quarantineLocaldbBlockId"].(string)
+	schemaPolicy, _ := args["schemaPolicy"].(string)
+
+	var mergeKeys []string
+	for _, k := range strings.Split(mergeKeysStr, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			mergeKeys = append(mergeKeys, k)
+		}
+	}
 
 	// transformsJSON may come as a string or as a raw JSON array
 	var transformsStr string
@@ -110,6 +146,38 @@ func (s *Server) handleCreateETLJob(ctx context.Context, req mcp.CallToolRequest
 		}
 	}
 
+	var assertions []etl.Assertion
+	if assertionsStr != "" {
+		dec := json.NewDecoder(strings.NewReader(assertionsStr))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&assertions); err != nil {
+			return nil, fmt.Errorf("invalid assertions JSON: %w", err)
+		}
+	}
+	var quarantineDBID string
+	if quarantineBlockID != "" {
+		db, err := s.localdb.GetDatabase(quarantineBlockID)
+		if err != nil {
+			return nil, fmt.Errorf("get quarantine localdb: %w", err)
+		}
+		quarantineDBID = db.ID
+	}
+
+	// Lookup transforms reference their LocalDB by block ID; the engine wants the database ID
+	for i, t := range transforms {
+		if t.Type != "lookup" {
+			continue
+		}
+		if blockID, ok := t.Config["localdbBlockId"].(string); ok {
+			db, err := s.localdb.GetDatabase(blockID)
+			if err != nil {
+				return nil, fmt.Errorf("lookup transform %d: get localdb: %w", i+1, err)
+			}
+			t.Config["databaseId"] = db.ID
+			delete(t.Config, "localdbBlockId")
+		}
+	}
+
 	// Resolve localdb block → database ID
 	localDB, dbErr := s.localdb.GetDatabase(localdbBlockID)
 	if dbErr != nil {
@@ -118,13 +186,20 @@ func (s *Server) handleCreateETLJob(ctx context.Context, req mcp.CallToolRequest
 
 	// Create the ETL job
 	input := service.CreateETLJobInput{
-		Name:         name,
-		SourceType:   sourceType,
-		SourceConfig: sourceConfig,
-		Transforms:   transforms,
-		TargetDBID:   localDB.ID,
-		DedupeKey:    dedupeKey,
-		Enabled:      true,
+		Name:           name,
+		SourceType:     sourceType,
+		SourceConfig:   sourceConfig,
+		Transforms:     transforms,
+		TargetDBID:     localDB.ID,
+		DedupeKey:      dedupeKey,
+		SyncMode:       syncMode,
+		CursorField:    cursorField,
+		MergeKeys:      mergeKeys,
+		DeleteMissing:  deleteMissing,
+		Assertions:     assertions,
+		QuarantineDBID: quarantineDBID,
+		SchemaPolicy:   schemaPolicy,
+		Enabled:        true,
 	}
 	job, err := s.etl.CreateJob(ctx, input)
 	if err != nil {
@@ -190,3 +265,16 @@ func (s *Server) handlePreviewETLSource(ctx context.Context, req mcp.CallToolReq
 	}
 	return jsonResult(preview)
 }
+
+func (s *Server) handleDebugETLJob(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID := req.GetString("jobId", "")
+	if jobID == "" {
+		return nil, fmt.Errorf("jobId is required")
+	}
+
+	result, err := s.etl.DebugJob(ctx, jobID, req.GetInt("maxRows", 0))
+	if err != nil {
+		return nil, fmt.Errorf("debug ETL job: %w", err)
+	}
+	return jsonResult(result)
+}
@@ -14,7 +14,7 @@ func (s *Server) registerLocalDBTools() {
 		mcp.WithDescription("Create a LocalDB block with column definitions"),
 		mcp.WithString("pageId", mcp.Description("Page ID (optional, defaults to active page)")),
 		mcp.WithString("name", mcp.Description("Database name"), mcp.Required()),
-		mcp.WithString("configJSON", mcp.Description("Column definitions as JSON (array of {id, name, type})"), mcp.Required()),
+		mcp.WithString("configJSON", mcp.Description("Column definitions as JSON (array of {id, name, type}). Formula columns (type \"formula\") also take a formula, e.g. \"{Price} * {Qty}\""), mcp.Required()),
 	), s.handleCreateLocalDatabase)
 
 	s.mcp.AddTool(mcp.NewTool("add_localdb_rows",
@@ -75,14 +75,15 @@ func (s *Server) handleCreateLocalDatabase(ctx context.Context, req mcp.CallTool
 		if strings.HasPrefix(trimmed, "[") {
 			// Strict validation of the columns
 			var strictColumns []struct {
-				ID   string `json:"id"`
-				Name string `json:"name"`
-				Type string `json:"type,omitempty"`
+				ID      string `json:"id"`
+				Name    string `json:"name"`
+				Type    string `json:"type,omitempty"`
+				Formula string `json:"formula,omitempty"`
 			}
 			dec := json.NewDecoder(strings.NewReader(trimmed))
 			dec.DisallowUnknownFields()
 			if err := dec.Decode(&strictColumns); err != nil {
-				return nil, fmt.Errorf("invalid column definitions JSON contract (check allowed fields, typically id, name, type): %w", err)
+				return nil, fmt.Errorf("invalid column definitions JSON contract (check allowed fields, typically id, name, type, formula): %w", err)
 			}
 			// Caller passed a raw column array — wrap in the object format the frontend expects
 			configJSON = fmt.Sprintf(`{"columns":%s,"activeView":"table"}`, trimmed)
@@ -0,0 +1,150 @@
+package secret
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ── Named Secrets ──────────────────────────────────────────
+// Config values may reference a named secret as ${secret:name}. The value
+// lives in the SecretStore under NamedKey(name); only the reference is saved
+// with the config, and it is expanded right before use.
+
+// refPattern matches a ${secret:name} reference.
+var refPattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.\-]+)\}`)
+
+// namePattern is the set of valid secret names.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// minRedactLen is the shortest value Redact replaces; shorter values would
+// match too much unrelated text.
+const minRedactLen = 4
+
+// NamedKey returns the SecretStore key of a named secret.
+func NamedKey(name string) string {
+	return "named-secret:" + name
+}
+
+// ValidateName checks that name can be used in a ${secret:name} reference.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '.', '-' or '_'", name)
+	}
+	return nil
+}
+
+// Ref returns the reference to a named secret.
+func Ref(name string) string {
+	return "${secret:" + name + "}"
+}
+
+// HasRefs reports whether s contains a secret reference.
+func HasRefs(s string) bool {
+	return strings.Contains(s, "${secret:") && refPattern.MatchString(s)
+}
+
+// RefNames returns the names of the secrets referenced in s.
+func RefNames(s string) []string {
+	var names []string
+	for _, m := range refPattern.FindAllStringSubmatch(s, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// Resolver expands secret references from a SecretStore. It remembers the
+// values it has handed out so that Redact can mask them in any text shown to
+// the user: logs, previews and error messages.
+type Resolver struct {
+	store SecretStore
+
+	mu    sync.RWMutex
+	known map[string]string // value → name
+}
+
+// NewResolver creates a Resolver reading from store.
+func NewResolver(store SecretStore) *Resolver {
+	return &Resolver{store: store, known: map[string]string{}}
+}
+
+// Expand replaces every reference in s with the secret's value. A reference
+// to a secret that is not set is an error.
+func (r *Resolver) Expand(s string) (string, error) {
+	if !HasRefs(s) {
+		return s, nil
+	}
+	var firstErr error
+	out := refPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := refPattern.FindStringSubmatch(ref)[1]
+		value, err := r.Get(name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// Get returns the value of a named secret.
+func (r *Resolver) Get(name string) (string, error) {
+	b, err := r.store.Get(NamedKey(name))
+	if err != nil {
+		return "", fmt.Errorf("read secret %q: %w", name, err)
+	}
+	if len(b) == 0 {
+		return "", fmt.Errorf("secret %q is not set", name)
+	}
+	r.remember(name, string(b))
+	return string(b), nil
+}
+
+// Set stores the value of a named secret.
+func (r *Resolver) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("secret %q: value is empty", name)
+	}
+	if err := r.store.Set(NamedKey(name), []byte(value)); err != nil {
+		return err
+	}
+	r.remember(name, value)
+	return nil
+}
+
+// Delete removes a named secret. Its old value stays redacted.
+func (r *Resolver) Delete(name string) error {
+	return r.store.Delete(NamedKey(name))
+}
+
+// Redact replaces known secret values in s with their reference, longest
+// values first so a secret containing another is masked whole.
+func (r *Resolver) Redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := slices.Collect(maps.Keys(r.known))
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+	for _, value := range values {
+		if strings.Contains(s, value) {
+			s = strings.ReplaceAll(s, value, Ref(r.known[value]))
+		}
+	}
+	return s
+}
+
+func (r *Resolver) remember(name, value string) {
+	if len(value) < minRedactLen {
+		return
+	}
+	r.mu.Lock()
+	r.known[value] = name
+	r.mu.Unlock()
+}
@@ -20,9 +20,10 @@ import (
 
 // BlockService manages the lifecycle of canvas blocks.
 type BlockService struct {
-	store   *storage.BlockStore
-	dataDir string
-	emitter EventEmitter
+	store    *storage.BlockStore
+	dataDir  string
+	emitter  EventEmitter
+	onDelete func(b domain.Block)
 }
 
 // NewBlockService creates a BlockService.
@@ -30,6 +31,13 @@ func NewBlockService(store *storage.BlockStore, dataDir string, emitter EventEmi
 	return &BlockService{store: store, dataDir: dataDir, emitter: emitter}
 }
 
+// SetDeleteHook registers fn to run after a block is deleted, on its own or
+// with its page, so state kept outside the block store (e.g. an HTTP block's
+// stored credential) goes with it.
+func (s *BlockService) SetDeleteHook(fn func(b domain.Block)) {
+	s.onDelete = fn
+}
+
 // CreateBlock creates a new block on a page.
 func (s *BlockService) CreateBlock(pageID, blockType string, x, y, width, height float64, viewMode string) (*domain.Block, error) {
 	id := uuid.New().String()
@@ -133,7 +141,13 @@ func (s *BlockService) DeleteBlock(_ context.Context, id string) error {
 	if b.FilePath != "" {
 		_ = os.Remove(b.FilePath)
 	}
-	return s.store.DeleteBlock(id)
+	if err := s.store.DeleteBlock(id); err != nil {
+		return err
+	}
+	if s.onDelete != nil {
+		s.onDelete(*b)
+	}
+	return nil
 }
 
 // DeleteBlocksByPage removes all blocks for a page and their associated files.
@@ -145,7 +159,15 @@ func (s *BlockService) DeleteBlocksByPage(pageID string) error {
 			_ = os.Remove(b.FilePath)
 		}
 	}
-	return s.store.DeleteBlocksByPage(pageID)
+	if err := s.store.DeleteBlocksByPage(pageID); err != nil {
+		return err
+	}
+	if s.onDelete != nil {
+		for _, b := range blocks {
+			s.onDelete(b)
+		}
+	}
+	return nil
 }
 
 // SaveImageFile saves base64-encoded image data to disk and updates the block's FilePath.
@@ -257,6 +257,25 @@ func TestBlockService_DeleteBlocksByPage(t *testing.T) {
 	}
 }
 
+func TestBlockService_DeleteHook(t *testing.T) {
+	svc, _, ns, _ := newBlockService(t)
+	pageID := createTestPage(t, ns)
+	var deleted []string
+	svc.SetDeleteHook(func(b domain.Block) { deleted = append(deleted, b.ID) })
+
+	b1, _ := svc.CreateBlock(pageID, "http", 0, 0, 300, 200, "dashboard")
+	b2, _ := svc.CreateBlock(pageID, "http", 400, 0, 300, 200, "dashboard")
+	if err := svc.DeleteBlock(context.Background(), b1.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.DeleteBlocksByPage(pageID); err != nil {
+		t.Fatalf("delete by page: %v", err)
+	}
+	if strings.Join(deleted, ",") != b1.ID+","+b2.ID {
+		t.Errorf("deleted = %v, want [%s %s]", deleted, b1.ID, b2.ID)
+	}
+}
+
 func TestBlockService_SaveAndGetImageFile(t *testing.T) {
 	svc, _, ns, _ := newBlockService(t)
 	pageID := createTestPage(t, ns)
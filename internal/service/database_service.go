@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"sync"
 	"time"
@@ -232,6 +233,21 @@ func (s *DatabaseService) ApplyMutations(
 	return connector.ApplyMutations(ctx, table, mutations)
 }
 
+// SQLHandle returns the database handle of a SQL connection and its driver
+// name, for writers that need parameterized statements (e.g. ETL jobs).
+func (s *DatabaseService) SQLHandle(connectionID string) (*sql.DB, string, error) {
+	connector, err := s.getOrCreate(connectionID)
+	if err != nil {
+		return nil, "", err
+	}
+	h, ok := connector.(dbclient.SQLHandle)
+	if !ok {
+		return nil, "", fmt.Errorf("connection %s is not a SQL database", connectionID)
+	}
+	db, driver := h.SQLDB()
+	return db, driver, nil
+}
+
 // ── Connector Pool ─────────────────────────────────────────
 
 func (s *DatabaseService) getOrCreate(id string) (dbclient.Connector, error) {
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── Job Dependencies ───────────────────────────────────────
+// A job with the after_job trigger runs after its upstream jobs, listed by ID
+// in TriggerConfig. When a run finishes, every enabled job reachable from it
+// runs once, in dependency order. A job whose upstream failed or was skipped
+// in the same chain is skipped as well, with a "skipped" run log. Each run log
+// in the chain records the run that triggered it and the chain's first run.
+
+const triggerAfterJob = "after_job"
+
+// runLineage ties a run to the dependency chain that started it.
+type runLineage struct {
+	upstreamRunID string
+	rootRunID     string
+}
+
+// upstreamIDs parses an after_job trigger config.
+func upstreamIDs(triggerConfig string) []string {
+	var ids []string
+	for _, id := range strings.Split(triggerConfig, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// dependsOn returns the upstream job IDs of an after_job job.
+func dependsOn(job *etl.SyncJob) []string {
+	if job.TriggerType != triggerAfterJob {
+		return nil
+	}
+	return upstreamIDs(job.TriggerConfig)
+}
+
+// validateDependencies checks an after_job trigger: the upstream jobs must
+// exist and the new edges must not close a cycle. id is "" for a new job,
+// which nothing can depend on yet.
+func (s *ETLService) validateDependencies(id string, input CreateETLJobInput) error {
+	if input.TriggerType != triggerAfterJob {
+		return nil
+	}
+	ups := upstreamIDs(input.TriggerConfig)
+	if len(ups) == 0 {
+		return fmt.Errorf("after_job trigger requires at least one upstream job")
+	}
+
+	jobs, err := s.store.ListJobs()
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*etl.SyncJob, len(jobs))
+	for i := range jobs {
+		byID[jobs[i].ID] = &jobs[i]
+	}
+	for _, u := range ups {
+		if u == id {
+			return fmt.Errorf("a job cannot run after itself")
+		}
+		if byID[u] == nil {
+			return fmt.Errorf("upstream job %s not found", u)
+		}
+	}
+	if id == "" {
+		return nil
+	}
+
+	// The new edges close a cycle if this job is already upstream of one of
+	// its new upstream jobs.
+	visited := make(map[string]bool)
+	var path []string
+	var reaches func(jobID string) bool
+	reaches = func(jobID string) bool {
+		if jobID == id {
+			return true
+		}
+		if visited[jobID] || byID[jobID] == nil {
+			return false
+		}
+		visited[jobID] = true
+		path = append(path, jobID)
+		for _, u := range dependsOn(byID[jobID]) {
+			if reaches(u) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	for _, u := range ups {
+		if !reaches(u) {
+			continue
+		}
+		// path runs from u back up to this job; print it in run order.
+		names := []string{input.Name}
+		for i := len(path) - 1; i >= 0; i-- {
+			names = append(names, byID[path[i]].Name)
+		}
+		names = append(names, input.Name)
+		return fmt.Errorf("dependency cycle: %s", strings.Join(names, " → "))
+	}
+	return nil
+}
+
+// checkNoDependents refuses to remove a job that other jobs run after, which
+// would leave their after_job triggers pointing at nothing.
+func (s *ETLService) checkNoDependents(id string) error {
+	jobs, err := s.store.ListJobs()
+	if err != nil {
+		return err
+	}
+	var names []string
+	for i := range jobs {
+		for _, u := range dependsOn(&jobs[i]) {
+			if u == id {
+				names = append(names, jobs[i].Name)
+				break
+			}
+		}
+	}
+	if len(names) > 0 {
+		return fmt.Errorf("jobs run after this one: %s; change their triggers first", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// downstreamOrder returns the enabled jobs that run after rootID, directly or
+// transitively, in dependency order.
+func downstreamOrder(rootID string, jobs []etl.SyncJob) []*etl.SyncJob {
+	children := make(map[string][]*etl.SyncJob)
+	for i := range jobs {
+		j := &jobs[i]
+		if !j.Enabled {
+			continue
+		}
+		for _, u := range dependsOn(j) {
+			children[u] = append(children[u], j)
+		}
+	}
+
+	// Collect everything reachable from the root.
+	reachable := make(map[string]*etl.SyncJob)
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, c := range children[id] {
+			if _, seen := reachable[c.ID]; !seen && c.ID != rootID {
+				reachable[c.ID] = c
+				queue = append(queue, c.ID)
+			}
+		}
+	}
+
+	// Kahn's algorithm over the reachable subgraph, in job list order so the
+	// result is deterministic. Jobs caught in a cycle are left out.
+	indegree := make(map[string]int, len(reachable))
+	for _, j := range reachable {
+		for _, u := range dependsOn(j) {
+			if _, ok := reachable[u]; ok || u == rootID {
+				indegree[j.ID]++
+			}
+		}
+	}
+	var order []*etl.SyncJob
+	ready := []string{rootID}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		for _, c := range children[id] {
+			if _, ok := reachable[c.ID]; !ok {
+				continue
+			}
+			if indegree[c.ID]--; indegree[c.ID] == 0 {
+				order = append(order, c)
+				ready = append(ready, c.ID)
+			}
+		}
+	}
+	if len(order) < len(reachable) {
+		log.Printf("etl dag: %d job(s) after %s are part of a dependency cycle and were not run", len(reachable)-len(order), rootID)
+	}
+	return order
+}
+
+// startDownstream runs the jobs that depend on a finished run in the
+// background. WaitRunning waits for them.
+func (s *ETLService) startDownstream(ctx context.Context, rootID string, rootLog *etl.SyncRunLog) {
+	jobs, err := s.store.ListJobs()
+	if err != nil {
+		log.Printf("etl dag: failed to list jobs: %v", err)
+		return
+	}
+	order := downstreamOrder(rootID, jobs)
+	if len(order) == 0 {
+		return
+	}
+	names := make(map[string]string, len(jobs))
+	for _, j := range jobs {
+		names[j.ID] = j.Name
+	}
+
+	// The chain outlives the request that started it.
+	ctx = context.WithoutCancel(ctx)
+	s.dagRuns.Add(1)
+	go func() {
+		defer s.dagRuns.Done()
+		outcome := map[string]*etl.SyncRunLog{rootID: rootLog}
+		for _, job := range order {
+			lineage := runLineage{rootRunID: rootLog.ID}
+			var blocked string
+			for _, u := range dependsOn(job) {
+				up, ok := outcome[u]
+				if !ok {
+					continue // not part of this chain
+				}
+				if lineage.upstreamRunID == "" {
+					lineage.upstreamRunID = up.ID
+				}
+				if up.Status != "success" && blocked == "" {
+					lineage.upstreamRunID = up.ID
+					blocked = fmt.Sprintf("upstream job %q did not succeed (%s)", names[u], up.Status)
+				}
+			}
+			if blocked != "" {
+				outcome[job.ID] = s.skipJob(job.ID, lineage, blocked)
+				continue
+			}
+
+			log.Printf("etl dag: running job %s after %s", job.ID, lineage.upstreamRunID)
+			runLog, err := s.runWithRetry(ctx, job.ID, runOptions{lineage: lineage})
+			if runLog == nil {
+				runLog = s.skipJob(job.ID, lineage, err.Error())
+			} else if err != nil {
+				log.Printf("etl dag: job %s failed: %v", job.ID, err)
+			}
+			outcome[job.ID] = runLog
+			s.emitter.Emit(ctx, "etl:job-completed", job.ID)
+		}
+	}()
+}
+
+// skipJob records a skipped run for a job whose upstream did not succeed.
+func (s *ETLService) skipJob(id string, lineage runLineage, reason string) *etl.SyncRunLog {
+	now := time.Now()
+	runLog := &etl.SyncRunLog{
+		JobID:         id,
+		StartedAt:     now,
+		FinishedAt:    now,
+		Status:        "skipped",
+		Error:         reason,
+		UpstreamRunID: lineage.upstreamRunID,
+		RootRunID:     lineage.rootRunID,
+	}
+	if err := s.store.CreateRunLog(runLog); err != nil {
+		log.Printf("etl dag: failed to record skipped run for job %s: %v", id, err)
+	}
+	s.store.UpdateJobStatus(id, "skipped", reason)
+	return runLog
+}
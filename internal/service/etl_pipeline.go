@@ -0,0 +1,835 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"notes/internal/domain"
+	"notes/internal/etl"
+	"notes/internal/secret"
+)
+
+// ── Pipeline Files ─────────────────────────────────────────
+// Jobs can be exported to a self-contained YAML or JSON document, to be kept
+// under version control or shared, and imported again on another machine.
+// A document holds no IDs, run state or credentials:
+//   - LocalDBs, database connections and upstream jobs are named, not IDs
+//   - credentials (password fields, and credential-like keys of JSON fields
+//     such as request headers) become ${secret:<name>} references to named
+//     secrets (see SecretService); existing references are kept
+//   - webhook tokens are left out; importing generates new ones
+//
+// Import matches jobs by name. A job whose name is already taken is a
+// conflict: it is skipped, or replaces the existing job with Overwrite, which
+// keeps the job's credentials where the document references a secret that is
+// not set. Every job is validated before anything is written, and DryRun
+// stops there.
+
+const pipelineVersion = 1
+
+// PipelineDocument is the file format of exported jobs.
+type PipelineDocument struct {
+	Version int           `json:"version"`
+	Jobs    []PipelineJob `json:"jobs"`
+}
+
+// PipelineJob is one job of a pipeline document.
+type PipelineJob struct {
+	Name          string                `json:"name"`
+	Source        PipelineSource        `json:"source"`
+	Transforms    []etl.TransformConfig `json:"transforms,omitempty"`
+	Target        PipelineTarget        `json:"target"`
+	SyncMode      string                `json:"syncMode,omitempty"`
+	DedupeKey     string                `json:"dedupeKey,omitempty"`
+	CursorField   string                `json:"cursorField,omitempty"`
+	MergeKeys     []string              `json:"mergeKeys,omitempty"`
+	DeleteMissing bool                  `json:"deleteMissing,omitempty"`
+	Assertions    []etl.Assertion       `json:"assertions,omitempty"`
+	QuarantineDB  string                `json:"quarantineDatabase,omitempty"` // LocalDB name
+	Retry         *etl.RetryPolicy      `json:"retry,omitempty"`
+	SchemaPolicy  string                `json:"schemaPolicy,omitempty"`
+	Trigger       PipelineTrigger       `json:"trigger"`
+	Enabled       bool                  `json:"enabled"`
+}
+
+// PipelineSource is a job's source type and config.
+type PipelineSource struct {
+	Type   string         `json:"type"`
+	Config map[string]any `json:"config,omitempty"`
+}
+
+// PipelineTarget is a LocalDB, by name, or a registered destination.
+type PipelineTarget struct {
+	Database string         `json:"database,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Config   map[string]any `json:"config,omitempty"`
+}
+
+// PipelineTrigger is a job's trigger. Config holds the cron expression or the
+// watched path; After names the upstream jobs of an after_job trigger.
+type PipelineTrigger struct {
+	Type   string   `json:"type,omitempty"`
+	Config string   `json:"config,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// PipelineImportOptions controls ImportPipelines.
+type PipelineImportOptions struct {
+	DryRun    bool `json:"dryRun"`    // validate only
+	Overwrite bool `json:"overwrite"` // replace jobs with the same name instead of skipping them
+}
+
+// PipelineImportResult reports what an import did, or would do.
+type PipelineImportResult struct {
+	DryRun  bool                `json:"dryRun"`
+	Jobs    []PipelineImportJob `json:"jobs"`
+	Secrets []PipelineSecret    `json:"secrets,omitempty"`
+}
+
+// PipelineImportJob is the outcome for one job of the document.
+type PipelineImportJob struct {
+	Name     string   `json:"name"`
+	Action   string   `json:"action"`          // "create" | "update" | "skip"
+	JobID    string   `json:"jobId,omitempty"` // set once written
+	Conflict string   `json:"conflict,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// PipelineSecret is a named secret referenced by an imported job. The job
+// keeps the reference; runs fail until the secret is set on this machine.
+type PipelineSecret struct {
+	Name  string `json:"name"`
+	Job   string `json:"job"`
+	Field string `json:"field"`
+	Set   bool   `json:"set"` // the secret already has a value
+}
+
+// ConnectionLister lists the saved database connections, so pipeline files
+// can name them.
+type ConnectionLister interface {
+	ListConnections() ([]domain.DatabaseConnection, error)
+}
+
+// SetConnectionLister enables naming database connections in pipeline files.
+// Without it connection IDs are kept as they are.
+func (s *ETLService) SetConnectionLister(l ConnectionLister) {
+	s.connections = l
+}
+
+// SecretLister lists the named secrets, so imports can report which
+// referenced secrets still need a value.
+type SecretLister interface {
+	ListSecrets() ([]domain.NamedSecret, error)
+}
+
+// SetSecretLister enables reporting whether imported secret references are
+// set. Without it every reference is reported as unset.
+func (s *ETLService) SetSecretLister(l SecretLister) {
+	s.secretNames = l
+}
+
+// ── Export ─────────────────────────────────────────────────
+
+// ExportPipelines encodes the given jobs (all jobs if ids is empty) as a
+// "yaml" or "json" pipeline document.
+func (s *ETLService) ExportPipelines(ids []string, format string) (string, error) {
+	if format != "yaml" && format != "json" {
+		return "", fmt.Errorf("unknown pipeline format %q", format)
+	}
+	jobs, err := s.store.ListJobs()
+	if err != nil {
+		return "", err
+	}
+	refs, err := s.pipelineRefs(jobs)
+	if err != nil {
+		return "", err
+	}
+
+	byID := make(map[string]*etl.SyncJob, len(jobs))
+	for i := range jobs {
+		byID[jobs[i].ID] = &jobs[i]
+	}
+	selected := jobs
+	if len(ids) > 0 {
+		selected = make([]etl.SyncJob, 0, len(ids))
+		for _, id := range ids {
+			job, ok := byID[id]
+			if !ok {
+				return "", fmt.Errorf("etl job not found: %s", id)
+			}
+			selected = append(selected, *job)
+		}
+	}
+
+	doc := PipelineDocument{Version: pipelineVersion, Jobs: make([]PipelineJob, 0, len(selected))}
+	for i := range selected {
+		doc.Jobs = append(doc.Jobs, refs.exportJob(&selected[i]))
+	}
+	return encodePipeline(doc, format)
+}
+
+func (r *pipelineRefs) exportJob(job *etl.SyncJob) PipelineJob {
+	pj := PipelineJob{
+		Name:          job.Name,
+		Source:        PipelineSource{Type: job.SourceType, Config: maps.Clone(job.SourceCfg)},
+		SyncMode:      string(job.SyncMode),
+		DedupeKey:     job.DedupeKey,
+		CursorField:   job.CursorField,
+		MergeKeys:     job.MergeKeys,
+		DeleteMissing: job.DeleteMissing,
+		Assertions:    job.Assertions,
+		QuarantineDB:  r.dbName(job.QuarantineDBID),
+		SchemaPolicy:  string(job.SchemaPolicy),
+		Trigger:       PipelineTrigger{Type: job.TriggerType},
+		Enabled:       job.Enabled,
+	}
+	if job.Retry != (etl.RetryPolicy{}) {
+		retry := job.Retry
+		pj.Retry = &retry
+	}
+	if source, err := etl.GetSource(job.SourceType); err == nil {
+		r.exportFields(pj.Name, source.Spec().ConfigFields, pj.Source.Config)
+	}
+
+	for _, tc := range job.Transforms {
+		tc.Config = maps.Clone(tc.Config)
+		if tc.Type == "lookup" {
+			if id, ok := tc.Config["databaseId"].(string); ok {
+				tc.Config["databaseId"] = r.dbName(id)
+			}
+		}
+		pj.Transforms = append(pj.Transforms, tc)
+	}
+
+	if job.WritesLocalDB() {
+		pj.Target.Database = r.dbName(job.TargetDBID)
+	} else {
+		pj.Target.Type = job.DestType
+		pj.Target.Config = maps.Clone(job.DestCfg)
+		if dest, err := etl.GetDestination(job.DestType); err == nil {
+			r.exportFields(pj.Name, dest.Spec().ConfigFields, pj.Target.Config)
+		}
+	}
+
+	switch job.TriggerType {
+	case triggerAfterJob:
+		for _, id := range upstreamIDs(job.TriggerConfig) {
+			pj.Trigger.After = append(pj.Trigger.After, r.jobName(id))
+		}
+	case triggerWebhook:
+		// The token is a credential; the importing side generates its own.
+	default:
+		pj.Trigger.Config = job.TriggerConfig
+	}
+	return pj
+}
+
+// exportFields replaces IDs in a source or destination config by names, and
+// credentials by secret references.
+func (r *pipelineRefs) exportFields(jobName string, fields []etl.ConfigField, cfg map[string]any) {
+	for _, f := range fields {
+		if v, _ := cfg[f.Key].(string); f.Sensitive && v != "" {
+			cfg[f.Key] = exportCredentialJSON(jobName, f.Key, v)
+		}
+		switch f.Type {
+		case "password":
+			// Sealed credentials are stored as <key>Ref.
+			v, _ := cfg[f.Key].(string)
+			ref, _ := cfg[f.Key+"Ref"].(string)
+			delete(cfg, f.Key+"Ref")
+			if _, named := parseSecretRef(v); !named && (v != "" || ref != "") {
+				cfg[f.Key] = secret.Ref(pipelineSecretName(jobName, f.Key))
+			}
+		case "localdb":
+			if id, ok := cfg[f.Key].(string); ok {
+				cfg[f.Key] = r.dbName(id)
+			}
+		case "db_connection":
+			if id, ok := cfg[f.Key].(string); ok {
+				cfg[f.Key] = r.connName(id)
+			}
+		}
+	}
+}
+
+// credentialKeyRe matches JSON keys and header names that hold credentials.
+var credentialKeyRe = regexp.MustCompile(`(?i)auth|token|secret|passw|api[-_]?key|access[-_]?key|private[-_]?key|cookie|session|credential|signature`)
+
+// exportCredentialJSON replaces the credential-like values of a JSON config
+// field with secret references named after their path, e.g.
+// "github-issues.headers.Authorization". A value that is not JSON is
+// replaced as a whole.
+func exportCredentialJSON(jobName, key, v string) string {
+	var doc any
+	if err := json.Unmarshal([]byte(v), &doc); err != nil {
+		if secret.HasRefs(v) {
+			return v
+		}
+		return secret.Ref(pipelineSecretName(jobName, key))
+	}
+	changed := false
+	var walk func(path string, v any) any
+	walk = func(path string, v any) any {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, x := range t {
+				p := path + "." + secretKeyRe.ReplaceAllString(k, "-")
+				if str, ok := x.(string); ok && credentialKeyRe.MatchString(k) && str != "" && !secret.HasRefs(str) {
+					t[k] = secret.Ref(pipelineSecretName(jobName, p))
+					changed = true
+					continue
+				}
+				t[k] = walk(p, x)
+			}
+		case []any:
+			for i, x := range t {
+				t[i] = walk(fmt.Sprintf("%s.%d", path, i), x)
+			}
+		}
+		return v
+	}
+	doc = walk(key, doc)
+	if !changed {
+		return v
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return secret.Ref(pipelineSecretName(jobName, key))
+	}
+	return string(b)
+}
+
+// ── Import ─────────────────────────────────────────────────
+
+// ImportPipelines validates a YAML or JSON pipeline document and creates or
+// updates its jobs. Nothing is written if any job is invalid; the result then
+// lists the errors of each job.
+func (s *ETLService) ImportPipelines(ctx context.Context, data []byte, opts PipelineImportOptions) (*PipelineImportResult, error) {
+	doc, err := decodePipeline(data)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := s.store.ListJobs()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := s.pipelineRefs(jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]*etl.SyncJob, len(jobs))
+	for i := range jobs {
+		existing[jobs[i].Name] = &jobs[i]
+	}
+	inDoc := make(map[string]int, len(doc.Jobs))
+	result := &PipelineImportResult{DryRun: opts.DryRun, Jobs: make([]PipelineImportJob, len(doc.Jobs))}
+	inputs := make([]CreateETLJobInput, len(doc.Jobs))
+	valid := true
+
+	for i, pj := range doc.Jobs {
+		item := &result.Jobs[i]
+		item.Name, item.Action = pj.Name, "create"
+		switch _, dup := inDoc[pj.Name]; {
+		case pj.Name == "":
+			item.Errors = append(item.Errors, "name is required")
+		case dup:
+			item.Errors = append(item.Errors, "another job in the document has the same name")
+		}
+		inDoc[pj.Name] = i
+
+		if prev := existing[pj.Name]; prev != nil && pj.Name != "" {
+			item.Conflict = fmt.Sprintf("a job named %q already exists", pj.Name)
+			item.Action = "skip"
+			if opts.Overwrite {
+				item.Action = "update"
+				item.JobID = prev.ID
+			}
+		}
+
+		var secrets []PipelineSecret
+		inputs[i], secrets = refs.importJob(pj, item)
+		result.Secrets = append(result.Secrets, secrets...)
+		if item.Action == "update" {
+			refs.keepCredentials(&inputs[i], existing[pj.Name], item)
+		}
+		if len(item.Errors) == 0 {
+			s.validatePipelineInput(inputs[i], item)
+		}
+		if len(item.Errors) > 0 {
+			valid = false
+		}
+	}
+
+	order, err := pipelineOrder(doc.Jobs, inDoc)
+	if err != nil {
+		return result, err
+	}
+	for i, pj := range doc.Jobs {
+		for _, up := range pj.Trigger.After {
+			if _, ok := inDoc[up]; !ok && existing[up] == nil {
+				result.Jobs[i].Errors = append(result.Jobs[i].Errors, fmt.Sprintf("upstream job %q not found", up))
+				valid = false
+			}
+		}
+	}
+
+	if !valid {
+		return result, fmt.Errorf("pipeline has invalid jobs; nothing was imported")
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// Write upstream jobs first so after_job triggers can refer to their IDs.
+	for _, i := range order {
+		item, input := &result.Jobs[i], inputs[i]
+		if input.TriggerType == triggerAfterJob {
+			ids := make([]string, 0, len(doc.Jobs[i].Trigger.After))
+			for _, up := range doc.Jobs[i].Trigger.After {
+				if j, ok := inDoc[up]; ok && result.Jobs[j].JobID != "" {
+					ids = append(ids, result.Jobs[j].JobID)
+				} else {
+					ids = append(ids, existing[up].ID)
+				}
+			}
+			input.TriggerConfig = strings.Join(ids, ",")
+		}
+
+		switch item.Action {
+		case "skip":
+			item.JobID = existing[item.Name].ID
+		case "update":
+			if err := s.UpdateJob(ctx, item.JobID, input); err != nil {
+				return result, fmt.Errorf("update %q: %w", item.Name, err)
+			}
+			if err := s.store.SetJobEnabled(item.JobID, input.Enabled); err != nil {
+				return result, fmt.Errorf("update %q: %w", item.Name, err)
+			}
+		default:
+			job, err := s.CreateJob(ctx, input)
+			if err != nil {
+				return result, fmt.Errorf("create %q: %w", item.Name, err)
+			}
+			item.JobID = job.ID
+		}
+	}
+	return result, nil
+}
+
+// importJob converts a document job to a job input, resolving names to IDs.
+// Problems are added to item; the secret references found are returned.
+func (r *pipelineRefs) importJob(pj PipelineJob, item *PipelineImportJob) (CreateETLJobInput, []PipelineSecret) {
+	input := CreateETLJobInput{
+		Name:          pj.Name,
+		SourceType:    pj.Source.Type,
+		SourceConfig:  maps.Clone(pj.Source.Config),
+		SyncMode:      pj.SyncMode,
+		DedupeKey:     pj.DedupeKey,
+		CursorField:   pj.CursorField,
+		MergeKeys:     pj.MergeKeys,
+		DeleteMissing: pj.DeleteMissing,
+		Assertions:    pj.Assertions,
+		SchemaPolicy:  pj.SchemaPolicy,
+		TriggerType:   pj.Trigger.Type,
+		TriggerConfig: pj.Trigger.Config,
+		Enabled:       pj.Enabled,
+	}
+	if input.SourceConfig == nil {
+		input.SourceConfig = map[string]any{}
+	}
+	if pj.Retry != nil {
+		input.Retry = *pj.Retry
+	}
+	fail := func(format string, args ...any) { item.Errors = append(item.Errors, fmt.Sprintf(format, args...)) }
+	var secrets []PipelineSecret
+
+	if source, err := etl.GetSource(pj.Source.Type); err != nil {
+		fail("unknown source type %q", pj.Source.Type)
+	} else {
+		secrets = append(secrets, r.importFields(pj.Name, "source", source.Spec().ConfigFields, input.SourceConfig, item)...)
+	}
+
+	for i, tc := range pj.Transforms {
+		if !etl.IsTransformType(tc.Type) {
+			fail("transform %d: unknown transform type %q", i+1, tc.Type)
+			continue
+		}
+		tc.Config = maps.Clone(tc.Config)
+		if tc.Type == "lookup" {
+			if name, ok := tc.Config["databaseId"].(string); ok {
+				id, err := r.dbID(name)
+				if err != nil {
+					fail("transform %d: %v", i+1, err)
+				}
+				tc.Config["databaseId"] = id
+			}
+		}
+		input.Transforms = append(input.Transforms, tc)
+	}
+
+	switch {
+	case pj.Target.Type != "" && pj.Target.Type != etl.DestLocalDB:
+		input.DestType = pj.Target.Type
+		input.DestConfig = maps.Clone(pj.Target.Config)
+		if input.DestConfig == nil {
+			input.DestConfig = map[string]any{}
+		}
+		if dest, err := etl.GetDestination(pj.Target.Type); err != nil {
+			fail("unknown destination type %q", pj.Target.Type)
+		} else {
+			secrets = append(secrets, r.importFields(pj.Name, "target", dest.Spec().ConfigFields, input.DestConfig, item)...)
+		}
+	case pj.Target.Database == "":
+		fail("target database is required")
+	default:
+		id, err := r.dbID(pj.Target.Database)
+		if err != nil {
+			fail("target: %v", err)
+		}
+		input.TargetDBID = id
+	}
+
+	if pj.QuarantineDB != "" {
+		id, err := r.dbID(pj.QuarantineDB)
+		if err != nil {
+			fail("quarantine: %v", err)
+		}
+		input.QuarantineDBID = id
+	}
+	if pj.Trigger.Type == triggerAfterJob && len(pj.Trigger.After) == 0 {
+		fail("after_job trigger requires at least one upstream job")
+	}
+	return input, secrets
+}
+
+// importFields resolves names in a source or destination config and returns
+// the secret references it holds.
+func (r *pipelineRefs) importFields(jobName, section string, fields []etl.ConfigField, cfg map[string]any, item *PipelineImportJob) []PipelineSecret {
+	var secrets []PipelineSecret
+	for _, f := range fields {
+		v, _ := cfg[f.Key].(string)
+		if v == "" {
+			continue
+		}
+		if f.Sensitive {
+			for _, name := range secret.RefNames(v) {
+				secrets = append(secrets, PipelineSecret{Name: name, Job: jobName, Field: section + "." + f.Key, Set: r.secrets[name]})
+			}
+		}
+		switch f.Type {
+		case "password":
+			if name, ok := parseSecretRef(v); ok {
+				secrets = append(secrets, PipelineSecret{Name: name, Job: jobName, Field: section + "." + f.Key, Set: r.secrets[name]})
+			}
+		case "localdb":
+			id, err := r.dbID(v)
+			if err != nil {
+				item.Errors = append(item.Errors, fmt.Sprintf("%s %s: %v", section, f.Label, err))
+			}
+			cfg[f.Key] = id
+		case "db_connection":
+			id, err := r.connID(v)
+			if err != nil {
+				item.Errors = append(item.Errors, fmt.Sprintf("%s %s: %v", section, f.Label, err))
+			}
+			cfg[f.Key] = id
+		case "db_block", "http_block":
+			item.Warnings = append(item.Warnings, fmt.Sprintf("%s %s refers to block %s, which must exist in this workspace", section, f.Label, v))
+		}
+	}
+	return secrets
+}
+
+// keepCredentials keeps the credentials of a job that an import overwrites
+// where the document references a secret that is not set: importing a job's
+// own export must not swap its working credentials for references that
+// cannot be resolved.
+func (r *pipelineRefs) keepCredentials(input *CreateETLJobInput, prev *etl.SyncJob, item *PipelineImportJob) {
+	keep := func(section string, fields []etl.ConfigField, cfg, prevCfg map[string]any) {
+		for _, f := range fields {
+			v, _ := cfg[f.Key].(string)
+			if f.Type != "password" && !f.Sensitive || !r.hasUnsetRefs(v) {
+				continue
+			}
+			switch {
+			case f.Type == "password" && prevCfg[f.Key+"Ref"] != nil:
+				// The job's sealed credential is carried over when saved.
+				delete(cfg, f.Key)
+			case prevCfg[f.Key] != nil:
+				cfg[f.Key] = prevCfg[f.Key]
+			default:
+				continue
+			}
+			item.Warnings = append(item.Warnings, fmt.Sprintf("%s %s references a secret that is not set; the job keeps its current value", section, f.Label))
+		}
+	}
+	if source, err := etl.GetSource(input.SourceType); err == nil && input.SourceType == prev.SourceType {
+		keep("source", source.Spec().ConfigFields, input.SourceConfig, prev.SourceCfg)
+	}
+	if dest, err := etl.GetDestination(input.DestType); err == nil && input.DestType == prev.DestType {
+		keep("target", dest.Spec().ConfigFields, input.DestConfig, prev.DestCfg)
+	}
+}
+
+// hasUnsetRefs reports whether v references a secret that is not set.
+func (r *pipelineRefs) hasUnsetRefs(v string) bool {
+	for _, name := range secret.RefNames(v) {
+		if !r.secrets[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePipelineInput runs the checks CreateJob and UpdateJob apply, other
+// than upstream jobs, which may be created by the same import.
+func (s *ETLService) validatePipelineInput(input CreateETLJobInput, item *PipelineImportJob) {
+	checks := []func() error{
+		func() error { return validateSyncMode(input) },
+		func() error {
+			return etl.ValidateDestination(input.DestType, input.DestConfig, etl.SyncMode(input.SyncMode))
+		},
+		func() error { return etl.ValidateTransforms(input.Transforms, s.localDB) },
+		func() error { return etl.ValidateAssertions(input.Assertions, input.QuarantineDBID) },
+		input.Retry.Validate,
+		etl.SchemaPolicy(input.SchemaPolicy).Validate,
+	}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			item.Errors = append(item.Errors, err.Error())
+		}
+	}
+}
+
+// pipelineOrder returns the document's job indexes with upstream jobs first.
+func pipelineOrder(jobs []PipelineJob, inDoc map[string]int) ([]int, error) {
+	state := make([]int, len(jobs)) // 0 = new, 1 = visiting, 2 = done
+	order := make([]int, 0, len(jobs))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("jobs %q form a dependency cycle", jobs[i].Name)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		if jobs[i].Trigger.Type == triggerAfterJob {
+			for _, up := range jobs[i].Trigger.After {
+				if j, ok := inDoc[up]; ok {
+					if err := visit(j); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		state[i] = 2
+		order = append(order, i)
+		return nil
+	}
+	for i := range jobs {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// ── Name References ────────────────────────────────────────
+
+// pipelineRefs maps the IDs of LocalDBs, connections and jobs to names.
+type pipelineRefs struct {
+	dbs     []domain.LocalDatabase
+	conns   []domain.DatabaseConnection
+	jobs    []etl.SyncJob
+	secrets map[string]bool // names of the secrets that are set
+}
+
+func (s *ETLService) pipelineRefs(jobs []etl.SyncJob) (*pipelineRefs, error) {
+	r := &pipelineRefs{jobs: jobs}
+	var err error
+	if s.localDB != nil {
+		if r.dbs, err = s.localDB.ListDatabases(); err != nil {
+			return nil, fmt.Errorf("list databases: %w", err)
+		}
+	}
+	if s.connections != nil {
+		if r.conns, err = s.connections.ListConnections(); err != nil {
+			return nil, fmt.Errorf("list connections: %w", err)
+		}
+	}
+	if s.secretNames != nil {
+		named, err := s.secretNames.ListSecrets()
+		if err != nil {
+			return nil, fmt.Errorf("list secrets: %w", err)
+		}
+		r.secrets = make(map[string]bool, len(named))
+		for _, n := range named {
+			r.secrets[n.Name] = true
+		}
+	}
+	return r, nil
+}
+
+// dbName returns the name of a LocalDB, or id if there is none.
+func (r *pipelineRefs) dbName(id string) string {
+	for _, db := range r.dbs {
+		if db.ID == id && db.Name != "" {
+			return db.Name
+		}
+	}
+	return id
+}
+
+// dbID resolves a LocalDB name (or ID) to its ID.
+func (r *pipelineRefs) dbID(name string) (string, error) {
+	var ids []string
+	for _, db := range r.dbs {
+		if db.Name == name {
+			ids = append(ids, db.ID)
+		}
+	}
+	switch {
+	case len(ids) == 1:
+		return ids[0], nil
+	case len(ids) > 1:
+		return "", fmt.Errorf("%d databases are named %q", len(ids), name)
+	}
+	for _, db := range r.dbs {
+		if db.ID == name {
+			return db.ID, nil
+		}
+	}
+	return "", fmt.Errorf("database %q not found", name)
+}
+
+func (r *pipelineRefs) connName(id string) string {
+	for _, c := range r.conns {
+		if c.ID == id && c.Name != "" {
+			return c.Name
+		}
+	}
+	return id
+}
+
+// connID resolves a connection name (or ID) to its ID. Without a connection
+// lister the value is kept as it is.
+func (r *pipelineRefs) connID(name string) (string, error) {
+	if r.conns == nil {
+		return name, nil
+	}
+	for _, c := range r.conns {
+		if c.Name == name || c.ID == name {
+			return c.ID, nil
+		}
+	}
+	return "", fmt.Errorf("connection %q not found", name)
+}
+
+func (r *pipelineRefs) jobName(id string) string {
+	for _, j := range r.jobs {
+		if j.ID == id {
+			return j.Name
+		}
+	}
+	return id
+}
+
+// ── Secret References ──────────────────────────────────────
+
+var secretRefRe = regexp.MustCompile(`^\$\{secret:([^}]+)\}$`)
+
+// parseSecretRef returns the name of a "${secret:<name>}" value.
+func parseSecretRef(v string) (string, bool) {
+	m := secretRefRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+var (
+	secretNameRe = regexp.MustCompile(`[^a-z0-9]+`)
+	// secretKeyRe matches what cannot appear in the key part of a name.
+	secretKeyRe = regexp.MustCompile(`[^A-Za-z0-9_\-]+`)
+)
+
+// pipelineSecretName names the credential of a job's field, e.g.
+// "github-issues.authSecret".
+func pipelineSecretName(jobName, key string) string {
+	slug := strings.Trim(secretNameRe.ReplaceAllString(strings.ToLower(jobName), "-"), "-")
+	if slug == "" {
+		slug = "job"
+	}
+	return slug + "." + key
+}
+
+// ── Encoding ───────────────────────────────────────────────
+
+// encodePipeline writes doc as indented JSON, or as YAML with the same keys
+// in the same order.
+func encodePipeline(doc PipelineDocument, format string) (string, error) {
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if format == "json" {
+		return string(b) + "\n", nil
+	}
+	// JSON is YAML: parse it into a node tree to keep the key order, then
+	// drop the flow style and quoting it was written with.
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return "", err
+	}
+	blockStyle(&node)
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// decodePipeline reads a YAML or JSON pipeline document, rejecting unknown
+// keys so that typos are reported rather than ignored.
+func decodePipeline(data []byte) (*PipelineDocument, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse pipeline: %w", err)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse pipeline: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var doc PipelineDocument
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("parse pipeline: %w", err)
+	}
+	if doc.Version > pipelineVersion {
+		return nil, fmt.Errorf("pipeline version %d is not supported (max %d)", doc.Version, pipelineVersion)
+	}
+	if len(doc.Jobs) == 0 {
+		return nil, fmt.Errorf("pipeline has no jobs")
+	}
+	return &doc, nil
+}
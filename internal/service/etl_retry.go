@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"notes/internal/etl"
+)
+
+// ── Retries & Failure Tracking ─────────────────────────────
+// Runs started by a trigger (cron, file watch, after_job) follow the job's
+// retry policy: a transient failure is retried with exponential backoff, each
+// attempt getting its own run log. A run that fails after its last attempt
+// increments the job's consecutive failures and emits etl:job-failed; after
+// RetryPolicy.DisableAfter failures in a row the job is disabled. Any
+// successful run, manual ones included, resets the count; a cancelled run
+// leaves it alone.
+
+// JobFailedEvent is the payload of the etl:job-failed event.
+type JobFailedEvent struct {
+	JobID               string `json:"jobId"`
+	JobName             string `json:"jobName"`
+	Error               string `json:"error"`
+	Attempts            int    `json:"attempts"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	Disabled            bool   `json:"disabled"` // the job was disabled by this failure
+}
+
+// retryWait sleeps for d before a retry, returning early when ctx is done.
+// Tests replace it to avoid real delays.
+var retryWait = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runScheduled runs a triggered job with retries, then starts the jobs that
+// run after it.
+func (s *ETLService) runScheduled(ctx context.Context, id string, opts runOptions) error {
+	runLog, err := s.runWithRetry(ctx, id, opts)
+	if runLog != nil {
+		s.startDownstream(ctx, id, runLog)
+	}
+	return err
+}
+
+// runWithRetry runs a job until it succeeds, fails with a permanent error or
+// runs out of attempts, and returns the last attempt's run log. The run log
+// is nil when the job could not be started.
+func (s *ETLService) runWithRetry(ctx context.Context, id string, opts runOptions) (*etl.SyncRunLog, error) {
+	job, err := s.store.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	// The trigger may fire between the job being disabled and the watchers
+	// being rebuilt.
+	if !job.Enabled {
+		return nil, fmt.Errorf("job %s is disabled", id)
+	}
+
+	var runLog *etl.SyncRunLog
+	attempt := 1
+	for ; ; attempt++ {
+		opts.attempt = attempt
+		_, runLog, err = s.runJob(ctx, id, opts)
+		if runLog == nil || runLog.Status != "error" || attempt >= job.Retry.Attempts() || !etl.IsRetryable(err) {
+			break
+		}
+		delay := job.Retry.Backoff(attempt)
+		log.Printf("etl retry: job %s attempt %d failed, retrying in %s: %v", id, attempt, delay, err)
+		if retryWait(ctx, delay) != nil {
+			break
+		}
+	}
+	if runLog != nil && runLog.Status == "error" {
+		s.recordFailure(ctx, job, err, attempt)
+	}
+	return runLog, err
+}
+
+// recordFailure counts a failed run towards the job's consecutive failures,
+// disables the job when the policy says so and notifies the frontend.
+func (s *ETLService) recordFailure(ctx context.Context, job *etl.SyncJob, runErr error, attempts int) {
+	failures := job.Failures + 1
+	disable := job.Retry.DisableAfter > 0 && failures >= job.Retry.DisableAfter
+	if err := s.store.UpdateJobFailures(job.ID, failures, disable); err != nil {
+		log.Printf("etl retry: failed to record failure for job %s: %v", job.ID, err)
+		return
+	}
+	if disable {
+		log.Printf("etl retry: disabled job %s after %d consecutive failures", job.ID, failures)
+	}
+	s.emitter.Emit(ctx, "etl:job-failed", JobFailedEvent{
+		JobID:               job.ID,
+		JobName:             job.Name,
+		Error:               runErr.Error(),
+		Attempts:            attempts,
+		ConsecutiveFailures: failures,
+		Disabled:            disable,
+	})
+}
@@ -3,16 +3,23 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"os"
 	"path/filepath"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/robfig/cron/v3"
 
 	"notes/internal/etl"
+	_ "notes/internal/etl/destinations" // registers SQL, file and HTTP destinations
 	"notes/internal/etl/sources"
+	"notes/internal/secret"
 	"notes/internal/storage"
 )
 
@@ -26,12 +33,17 @@ type ETLService struct {
 	store       *storage.ETLStore
 	localDB     *storage.LocalDatabaseStore
 	emitter     EventEmitter
+	connections ConnectionLister // optional: names connections in pipeline files
+	secretNames SecretLister     // optional: reports unset secrets on import
 	runningJobs runningJobsGuard
+	dagRuns     sync.WaitGroup // background runs: after_job chains, webhooks
 
-	// watcher / cron lifecycle
+	// watcher / cron / webhook lifecycle
 	watchCancel context.CancelFunc
 	watcher     *fsnotify.Watcher
 	cronSched   *cron.Cron
+	webhookAddr string // "" = no webhook listener
+	webhook     *webhookListener
 }
 
 // NewETLService creates an ETLService ready for use.
@@ -40,6 +52,10 @@ func NewETLService(
 	localDB *storage.LocalDatabaseStore,
 	emitter EventEmitter,
 ) *ETLService {
+	if localDB != nil {
+		// The localdb source reads the same tables jobs write to.
+		sources.SetLocalDBReader(localDB)
+	}
 	return &ETLService{
 		store:   store,
 		localDB: localDB,
@@ -50,34 +66,83 @@ func NewETLService(
 // ── Job CRUD ───────────────────────────────────────────────
 
 type CreateETLJobInput struct {
-	Name          string                `json:"name"`
-	SourceType    string                `json:"sourceType"`
-	SourceConfig  map[string]any        `json:"sourceConfig"`
-	Transforms    []etl.TransformConfig `json:"transforms"`
-	TargetDBID    string                `json:"targetDbId"`
-	SyncMode      string                `json:"syncMode"`
-	DedupeKey     string                `json:"dedupeKey"`
-	TriggerType   string                `json:"triggerType"`
-	TriggerConfig string                `json:"triggerConfig"`
-	Enabled       bool                  `json:"enabled"`
+	Name           string                `json:"name"`
+	SourceType     string                `json:"sourceType"`
+	SourceConfig   map[string]any        `json:"sourceConfig"`
+	Transforms     []etl.TransformConfig `json:"transforms"`
+	TargetDBID     string                `json:"targetDbId"`
+	DestType       string                `json:"destType"`
+	DestConfig     map[string]any        `json:"destConfig"`
+	SyncMode       string                `json:"syncMode"`
+	DedupeKey      string                `json:"dedupeKey"`
+	CursorField    string                `json:"cursorField"`
+	MergeKeys      []string              `json:"mergeKeys"`
+	DeleteMissing  bool                  `json:"deleteMissing"`
+	Assertions     []etl.Assertion       `json:"assertions"`
+	QuarantineDBID string                `json:"quarantineDbId"`
+	Retry          etl.RetryPolicy       `json:"retry"`
+	SchemaPolicy   string                `json:"schemaPolicy"`
+	TriggerType    string                `json:"triggerType"`
+	TriggerConfig  string                `json:"triggerConfig"`
+	Enabled        bool                  `json:"enabled"`
 }
 
 func (s *ETLService) CreateJob(ctx context.Context, input CreateETLJobInput) (*etl.SyncJob, error) {
 	if _, err := etl.GetSource(input.SourceType); err != nil {
 		return nil, err
 	}
+	if err := validateSyncMode(input); err != nil {
+		return nil, err
+	}
+	if err := etl.ValidateDestination(input.DestType, input.DestConfig, etl.SyncMode(input.SyncMode)); err != nil {
+		return nil, err
+	}
+	if err := etl.ValidateTransforms(input.Transforms, s.localDB); err != nil {
+		return nil, err
+	}
+	if err := etl.ValidateAssertions(input.Assertions, input.QuarantineDBID); err != nil {
+		return nil, err
+	}
+	if err := s.validateDependencies("", input); err != nil {
+		return nil, err
+	}
+	if err := input.Retry.Validate(); err != nil {
+		return nil, err
+	}
+	if err := etl.SchemaPolicy(input.SchemaPolicy).Validate(); err != nil {
+		return nil, err
+	}
+	if err := sealSourceSecrets(input.SourceType, input.SourceConfig, nil); err != nil {
+		return nil, err
+	}
+	if input.TriggerType == triggerWebhook {
+		token, err := webhookToken(input, nil)
+		if err != nil {
+			return nil, err
+		}
+		input.TriggerConfig = token
+	}
 
 	job := &etl.SyncJob{
-		Name:          input.Name,
-		SourceType:    input.SourceType,
-		SourceCfg:     input.SourceConfig,
-		Transforms:    input.Transforms,
-		TargetDBID:    input.TargetDBID,
-		SyncMode:      etl.SyncMode(input.SyncMode),
-		DedupeKey:     input.DedupeKey,
-		TriggerType:   input.TriggerType,
-		TriggerConfig: input.TriggerConfig,
-		Enabled:       input.Enabled,
+		Name:           input.Name,
+		SourceType:     input.SourceType,
+		SourceCfg:      input.SourceConfig,
+		Transforms:     input.Transforms,
+		TargetDBID:     input.TargetDBID,
+		DestType:       input.DestType,
+		DestCfg:        input.DestConfig,
+		SyncMode:       etl.SyncMode(input.SyncMode),
+		DedupeKey:      input.DedupeKey,
+		CursorField:    input.CursorField,
+		MergeKeys:      input.MergeKeys,
+		DeleteMissing:  input.DeleteMissing,
+		Assertions:     input.Assertions,
+		QuarantineDBID: input.QuarantineDBID,
+		Retry:          input.Retry,
+		SchemaPolicy:   etl.SchemaPolicy(input.SchemaPolicy),
+		TriggerType:    input.TriggerType,
+		TriggerConfig:  input.TriggerConfig,
+		Enabled:        input.Enabled,
 	}
 	if job.SyncMode == "" {
 		job.SyncMode = etl.SyncReplace
@@ -102,30 +167,158 @@ func (s *ETLService) ListJobs() ([]etl.SyncJob, error) {
 }
 
 func (s *ETLService) UpdateJob(ctx context.Context, id string, input CreateETLJobInput) error {
+	if err := validateSyncMode(input); err != nil {
+		return err
+	}
+	if err := etl.ValidateDestination(input.DestType, input.DestConfig, etl.SyncMode(input.SyncMode)); err != nil {
+		return err
+	}
+	if err := etl.ValidateTransforms(input.Transforms, s.localDB); err != nil {
+		return err
+	}
+	if err := etl.ValidateAssertions(input.Assertions, input.QuarantineDBID); err != nil {
+		return err
+	}
+	if err := s.validateDependencies(id, input); err != nil {
+		return err
+	}
+	if err := input.Retry.Validate(); err != nil {
+		return err
+	}
+	if err := etl.SchemaPolicy(input.SchemaPolicy).Validate(); err != nil {
+		return err
+	}
 	job, err := s.store.GetJob(id)
 	if err != nil {
 		return err
 	}
+	if err := sealSourceSecrets(input.SourceType, input.SourceConfig, job.SourceCfg); err != nil {
+		return err
+	}
+	if input.TriggerType == triggerWebhook {
+		if input.TriggerConfig, err = webhookToken(input, job); err != nil {
+			return err
+		}
+	}
+	// Changing what the cursor tracks invalidates the stored high-water mark,
+	// and reading another source invalidates the schema drift baseline.
+	resetCursor := job.CursorField != input.CursorField || etl.SyncMode(input.SyncMode) != job.SyncMode
+	resetSchema := job.SourceType != input.SourceType || !reflect.DeepEqual(map[string]any(job.SourceCfg), input.SourceConfig)
 	job.Name = input.Name
 	job.SourceType = input.SourceType
 	job.SourceCfg = input.SourceConfig
 	job.Transforms = input.Transforms
 	job.TargetDBID = input.TargetDBID
+	job.DestType = input.DestType
+	job.DestCfg = input.DestConfig
 	job.SyncMode = etl.SyncMode(input.SyncMode)
 	job.DedupeKey = input.DedupeKey
+	job.CursorField = input.CursorField
+	job.MergeKeys = input.MergeKeys
+	job.DeleteMissing = input.DeleteMissing
+	job.Assertions = input.Assertions
+	job.QuarantineDBID = input.QuarantineDBID
+	job.Retry = input.Retry
+	job.SchemaPolicy = etl.SchemaPolicy(input.SchemaPolicy)
 	job.TriggerType = input.TriggerType
 	job.TriggerConfig = input.TriggerConfig
 
 	if err := s.store.UpdateJob(job); err != nil {
 		return err
 	}
+	if resetCursor {
+		if err := s.store.UpdateJobCursor(id, ""); err != nil {
+			return err
+		}
+	}
+	if resetSchema {
+		if err := s.store.UpdateJobSchema(id, nil); err != nil {
+			return err
+		}
+	}
+	s.RestartWatchers(ctx)
+	return nil
+}
+
+// SetJobEnabled turns a job's trigger on or off, e.g. to resume a job that
+// was disabled after repeated failures.
+func (s *ETLService) SetJobEnabled(ctx context.Context, id string, enabled bool) error {
+	if _, err := s.store.GetJob(id); err != nil {
+		return err
+	}
+	if err := s.store.SetJobEnabled(id, enabled); err != nil {
+		return err
+	}
 	s.RestartWatchers(ctx)
 	return nil
 }
 
+// ResetCursor clears an incremental job's high-water mark and its history of
+// ingested files so the next run re-reads the source from the beginning.
+func (s *ETLService) ResetCursor(id string) error {
+	if _, err := s.store.GetJob(id); err != nil {
+		return err
+	}
+	if err := s.store.ClearIngestedFiles(id); err != nil {
+		return err
+	}
+	return s.store.UpdateJobCursor(id, "")
+}
+
+// validateSyncMode checks mode-specific job settings.
+func validateSyncMode(input CreateETLJobInput) error {
+	switch etl.SyncMode(input.SyncMode) {
+	case etl.SyncIncremental:
+		// Stateful sources track their own position.
+		if source, err := etl.GetSource(input.SourceType); err == nil && source.Spec().Stateful {
+			break
+		}
+		if input.CursorField == "" {
+			return fmt.Errorf("incremental sync requires a cursor field")
+		}
+	case etl.SyncMerge:
+		if len(input.MergeKeys) == 0 {
+			return fmt.Errorf("merge sync requires at least one merge key")
+		}
+	}
+	return nil
+}
+
+// sealSourceSecrets moves credentials typed into an HTTP source config into
+// the SecretStore. On update, prev is the stored config so an unchanged
+// credential keeps its existing reference. A ${secret:<name>} credential is
+// kept as typed and replaces the sealed one.
+func sealSourceSecrets(sourceType string, cfg, prev map[string]any) error {
+	if sourceType != "http" || cfg == nil {
+		return nil
+	}
+	if v, _ := cfg["authSecret"].(string); secret.HasRefs(v) {
+		if err := sources.ForgetHTTPAuth(prev); err != nil {
+			log.Printf("etl: failed to delete replaced credential: %v", err)
+		}
+		delete(cfg, "authSecretRef")
+	} else if ref, ok := prev["authSecretRef"]; ok {
+		if _, set := cfg["authSecretRef"]; !set {
+			cfg["authSecretRef"] = ref
+		}
+	}
+	return sources.SealHTTPAuth(cfg)
+}
+
+// DeleteJob removes a job. A job that others run after (after_job) is kept
+// until their triggers no longer name it.
 func (s *ETLService) DeleteJob(ctx context.Context, id string) error {
+	if err := s.checkNoDependents(id); err != nil {
+		return err
+	}
+	job, _ := s.store.GetJob(id)
 	err := s.store.DeleteJob(id)
 	if err == nil {
+		if job != nil && job.SourceType == "http" {
+			if ferr := sources.ForgetHTTPAuth(job.SourceCfg); ferr != nil {
+				log.Printf("etl: failed to delete credential for job %s: %v", id, ferr)
+			}
+		}
 		s.RestartWatchers(ctx)
 	}
 	return err
@@ -134,41 +327,94 @@ func (s *ETLService) DeleteJob(ctx context.Context, id string) error {
 // ── Run ────────────────────────────────────────────────────
 
 // RunJob executes a single ETL sync job synchronously and emits frontend events on success.
+// Jobs triggered by it (after_job) then run in the background.
 func (s *ETLService) RunJob(ctx context.Context, id string) (*etl.SyncResult, error) {
+	result, runLog, err := s.runJob(ctx, id, runOptions{})
+	if runLog != nil {
+		s.startDownstream(ctx, id, runLog)
+	}
+	return result, err
+}
+
+// runOptions describes how a run was started.
+type runOptions struct {
+	lineage   runLineage       // after_job chain
+	attempt   int              // 1-based attempt of a retried run (0 = 1)
+	overrides etl.SourceConfig // source config keys replaced for this run (webhook)
+}
+
+// runJob executes one attempt of a job and records its run log, tagged with
+// its lineage and attempt number. The run log is nil when the job could not
+// be started.
+func (s *ETLService) runJob(ctx context.Context, id string, opts runOptions) (*etl.SyncResult, *etl.SyncRunLog, error) {
+	// A run has no time limit: large loads take as long as they take, and
+	// CancelJob stops one that should not.
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+	defer cancelRun(nil)
+
 	// Prevent concurrent execution of the same job.
 	if !s.runningJobs.TryLock(id) {
-		return nil, fmt.Errorf("job %s is already running", id)
+		return nil, nil, fmt.Errorf("job %s is already running", id)
 	}
 	defer s.runningJobs.Unlock(id)
+	s.runningJobs.SetCancel(id, cancelRun)
 
 	job, err := s.store.GetJob(id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if len(opts.overrides) > 0 {
+		cfg := make(etl.SourceConfig, len(job.SourceCfg)+len(opts.overrides))
+		maps.Copy(cfg, job.SourceCfg)
+		maps.Copy(cfg, opts.overrides)
+		job.SourceCfg = cfg
 	}
 
 	s.store.UpdateJobStatus(id, "running", "")
 
 	engine := &etl.Engine{
-		Dest: &etl.LocalDBWriter{Store: s.localDB},
+		Dest:        &etl.LocalDBWriter{Store: s.localDB},
+		LookupStore: s.localDB,
+		FileHistory: s.store,
+		OnProgress: func(p etl.Progress) {
+			s.emitter.Emit(ctx, "etl:progress", p)
+		},
 	}
 
-	runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer cancel()
-
 	start := time.Now()
 	result, runErr := engine.RunSync(runCtx, job)
+	if runErr != nil && errors.Is(runCtx.Err(), context.Canceled) {
+		result.Status = "cancelled"
+		runErr = context.Cause(runCtx)
+		result.Error = runErr.Error()
+	}
+	// Run logs and results are shown to the user: mask resolved secrets.
+	runErr = etl.RedactError(runErr)
+	result.Error = etl.RedactSecrets(result.Error)
+	result.Output = etl.RedactSecrets(result.Output)
 
 	runLog := &etl.SyncRunLog{
-		JobID:       id,
-		StartedAt:   start,
-		FinishedAt:  time.Now(),
-		Status:      result.Status,
-		RowsRead:    result.RowsRead,
-		RowsWritten: result.RowsWritten,
+		JobID:        id,
+		StartedAt:    start,
+		FinishedAt:   time.Now(),
+		Status:       result.Status,
+		RowsRead:     result.RowsRead,
+		RowsWritten:  result.RowsWritten,
+		RowsInserted: result.RowsInserted,
+		RowsUpdated:  result.RowsUpdated,
+		RowsDeleted:  result.RowsDeleted,
+
+		RowsQuarantined: result.RowsQuarantined,
+		Assertions:      result.Assertions,
+		SchemaDrift:     result.SchemaDrift,
+		UpstreamRunID:   opts.lineage.upstreamRunID,
+		RootRunID:       opts.lineage.rootRunID,
+		Attempt:         max(opts.attempt, 1),
 	}
 	if runErr != nil {
 		runLog.Error = runErr.Error()
 	}
+	runLog.Output = result.Output
 	s.store.CreateRunLog(runLog)
 
 	errMsg := ""
@@ -177,15 +423,62 @@ func (s *ETLService) RunJob(ctx context.Context, id string) (*etl.SyncResult, er
 	}
 	s.store.UpdateJobStatus(id, result.Status, errMsg)
 
+	// Persist the new high-water mark only after a successful run.
+	if runErr == nil && result.Cursor != "" && result.Cursor != job.CursorValue {
+		if err := s.store.UpdateJobCursor(id, result.Cursor); err != nil {
+			log.Printf("etl: failed to persist cursor for job %s: %v", id, err)
+		}
+	}
+
+	// The schema of a successful run is the baseline for the next one.
+	if runErr == nil && result.Schema != nil {
+		if err := s.store.UpdateJobSchema(id, result.Schema); err != nil {
+			log.Printf("etl: failed to persist schema for job %s: %v", id, err)
+		}
+	}
+
+	// The run's rows are committed by now, and only then are its files
+	// recorded: a failed run wrote nothing, so its files must be read again.
+	if runErr == nil && len(result.Files) > 0 {
+		if err := s.store.RecordIngestedFiles(id, result.Files); err != nil {
+			log.Printf("etl: failed to record ingested files for job %s: %v", id, err)
+		}
+	}
+
+	if runErr == nil && job.Failures > 0 {
+		if err := s.store.UpdateJobFailures(id, 0, false); err != nil {
+			log.Printf("etl: failed to reset failures for job %s: %v", id, err)
+		}
+	}
+
 	// Notify frontend on success.
-	if result.Status == "success" && job.TargetDBID != "" {
+	if result.Status == "success" && job.WritesLocalDB() && job.TargetDBID != "" {
 		s.emitter.Emit(ctx, "db:updated", map[string]string{
 			"databaseId": job.TargetDBID,
 			"jobId":      id,
 		})
 	}
+	if result.Status == "success" && result.RowsQuarantined > 0 {
+		s.emitter.Emit(ctx, "db:updated", map[string]string{
+			"databaseId": job.QuarantineDBID,
+			"jobId":      id,
+		})
+	}
 
-	return result, runErr
+	return result, runLog, runErr
+}
+
+// errRunCancelled is the cause of a run stopped with CancelJob.
+var errRunCancelled = errors.New("cancelled by user")
+
+// CancelJob stops a running job. The run ends with status "cancelled" and,
+// like a failed run, discards what it wrote to a LocalDB, SQL database or
+// file; an HTTP destination keeps the batches it already received.
+func (s *ETLService) CancelJob(id string) error {
+	if !s.runningJobs.Cancel(id, errRunCancelled) {
+		return fmt.Errorf("job %s is not running", id)
+	}
+	return nil
 }
 
 // ListSources returns the available ETL source descriptors.
@@ -193,6 +486,12 @@ func (s *ETLService) ListSources() []etl.SourceSpec {
 	return etl.ListSources()
 }
 
+// ListDestinations returns the registered destination types. Jobs without a
+// destination type write to a LocalDB.
+func (s *ETLService) ListDestinations() []etl.DestinationSpec {
+	return etl.ListDestinations()
+}
+
 // ListRunLogs returns the last 50 run logs for a job.
 func (s *ETLService) ListRunLogs(jobID string) ([]etl.SyncRunLog, error) {
 	return s.store.ListRunLogs(jobID, 50)
@@ -217,15 +516,70 @@ func (s *ETLService) PreviewSource(ctx context.Context, sourceType string, cfgJS
 	if err != nil {
 		return nil, err
 	}
+	etl.RedactRecords(records)
 	return &PreviewResult{Schema: schema, Records: records}, nil
 }
 
-// PreviewResult is the response from PreviewSource.
+// DebugJob runs a saved job's source and transform chain on a sample,
+// reporting record counts, samples, schema and errors for every stage.
+// Nothing is written to the target database.
+func (s *ETLService) DebugJob(ctx context.Context, id string, maxRows int) (*etl.DebugResult, error) {
+	job, err := s.store.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := &etl.Engine{LookupStore: s.localDB}
+
+	debugCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := engine.Debug(debugCtx, job, maxRows)
+	if err != nil {
+		return nil, err
+	}
+	redactDebugResult(result)
+	return result, nil
+}
+
+// redactDebugResult masks resolved secrets in the samples and errors of a
+// debug run.
+func redactDebugResult(r *etl.DebugResult) {
+	r.Error = etl.RedactSecrets(r.Error)
+	for i := range r.Stages {
+		st := &r.Stages[i]
+		etl.RedactRecords(st.Sample)
+		for j := range st.Errors {
+			st.Errors[j].Error = etl.RedactSecrets(st.Errors[j].Error)
+		}
+	}
+}
+
+// PreviewResult is the response from PreviewSource and QuerySQL.
 type PreviewResult struct {
 	Schema  *etl.Schema  `json:"schema"`
 	Records []etl.Record `json:"records"`
 }
 
+// QuerySQL runs a SELECT over rows loaded as the "input" table, the way a sql
+// transform does; chart pipelines use it for their sql stage. LocalDBs named
+// in the query are available as tables.
+func (s *ETLService) QuerySQL(ctx context.Context, query string, rows []map[string]any) (*PreviewResult, error) {
+	records := make([]etl.Record, len(rows))
+	for i, row := range rows {
+		records[i] = etl.Record{Data: row}
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	out, schema, err := etl.RunSQL(queryCtx, query, records, s.localDB)
+	if err != nil {
+		return nil, err
+	}
+	return &PreviewResult{Schema: schema, Records: out}, nil
+}
+
 func (s *ETLService) DiscoverSchema(ctx context.Context, sourceType string, cfgJSON string) (*etl.Schema, error) {
 	var cfg etl.SourceConfig
 	if err := json.Unmarshal([]byte(cfgJSON), &cfg); err != nil {
@@ -245,7 +599,8 @@ func (s *ETLService) DiscoverSchema(ctx context.Context, sourceType string, cfgJ
 
 // ── Watchers (cron + file_watch) ──────────────────────────
 
-// RestartWatchers tears down the current watcher/cron and rebuilds them from scratch.
+// RestartWatchers tears down the current watcher/cron and rebuilds them from
+// scratch. The webhook listener keeps running while webhook jobs remain.
 func (s *ETLService) RestartWatchers(ctx context.Context) {
 	s.stopWatchers()
 
@@ -254,6 +609,7 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 		log.Printf("etl watcher: failed to list jobs: %v", err)
 		return
 	}
+	s.updateWebhooks(ctx, jobs)
 
 	// ── Cron jobs ──
 	var cronJobs []struct {
@@ -275,7 +631,7 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 			jid := cj.jobID
 			_, err := c.AddFunc(cj.expr, func() {
 				log.Printf("etl cron: running job %s", jid)
-				if _, err := s.RunJob(ctx, jid); err != nil {
+				if err := s.runScheduled(ctx, jid, runOptions{}); err != nil {
 					log.Printf("etl cron: job %s failed: %v", jid, err)
 				}
 				s.emitter.Emit(ctx, "etl:job-completed", jid)
@@ -290,14 +646,18 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 	}
 
 	// ── File watchers ──
+	// A watched directory (directory ingestion) triggers its job when a file
+	// matching the source's pattern is created or written.
 	type watchEntry struct {
-		jobID string
-		path  string
+		jobID   string
+		path    string
+		pattern string
 	}
 	var entries []watchEntry
 	for _, j := range jobs {
 		if j.TriggerType == "file_watch" && j.TriggerConfig != "" {
-			entries = append(entries, watchEntry{jobID: j.ID, path: j.TriggerConfig})
+			pattern, _ := j.SourceCfg["pattern"].(string)
+			entries = append(entries, watchEntry{jobID: j.ID, path: j.TriggerConfig, pattern: pattern})
 		}
 	}
 
@@ -313,6 +673,7 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 	s.watcher = watcher
 
 	pathToJob := make(map[string]string)
+	dirToJobs := make(map[string][]watchEntry)
 	watchedDirs := make(map[string]bool)
 	for _, e := range entries {
 		absPath, err := filepath.Abs(e.path)
@@ -320,9 +681,14 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 			log.Printf("etl watcher: bad path %q: %v", e.path, err)
 			continue
 		}
-		pathToJob[absPath] = e.jobID
-
 		dir := filepath.Dir(absPath)
+		if info, err := os.Stat(absPath); err == nil && info.IsDir() {
+			dirToJobs[absPath] = append(dirToJobs[absPath], e)
+			dir = absPath
+		} else {
+			pathToJob[absPath] = e.jobID
+		}
+
 		if !watchedDirs[dir] {
 			if err := watcher.Add(dir); err != nil {
 				log.Printf("etl watcher: failed to watch dir %q: %v", dir, err)
@@ -349,20 +715,27 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 					continue
 				}
 				absPath, _ := filepath.Abs(event.Name)
-				jobID, ok := pathToJob[absPath]
-				if !ok {
-					continue
+				var jobIDs []string
+				if jobID, ok := pathToJob[absPath]; ok {
+					jobIDs = append(jobIDs, jobID)
 				}
-				if t, exists := timers[jobID]; exists {
-					t.Stop()
+				for _, e := range dirToJobs[filepath.Dir(absPath)] {
+					if ok, _ := filepath.Match(e.pattern, filepath.Base(absPath)); ok || e.pattern == "" {
+						jobIDs = append(jobIDs, e.jobID)
+					}
 				}
-				jid := jobID
-				timers[jobID] = time.AfterFunc(500*time.Millisecond, func() {
-					log.Printf("etl watcher: file changed %q, running job %s", absPath, jid)
-					if _, err := s.RunJob(ctx, jid); err != nil {
-						log.Printf("etl watcher: run failed for job %s: %v", jid, err)
+				for _, jobID := range jobIDs {
+					if t, exists := timers[jobID]; exists {
+						t.Stop()
 					}
-				})
+					jid := jobID
+					timers[jobID] = time.AfterFunc(500*time.Millisecond, func() {
+						log.Printf("etl watcher: file changed %q, running job %s", absPath, jid)
+						if err := s.runScheduled(ctx, jid, runOptions{}); err != nil {
+							log.Printf("etl watcher: run failed for job %s: %v", jid, err)
+						}
+					})
+				}
 			case err, ok := <-watcher.Errors:
 				if !ok {
 					return
@@ -372,18 +745,28 @@ func (s *ETLService) RestartWatchers(ctx context.Context) {
 		}
 	}()
 
-	log.Printf("etl watcher: watching %d file(s)", len(pathToJob))
+	log.Printf("etl watcher: watching %d file(s) and %d directory(ies)", len(pathToJob), len(dirToJobs))
 }
 
-// WaitRunning blocks until all running jobs finish or ctx is cancelled.
-// Used for graceful shutdown.
+// WaitRunning blocks until all running jobs and after_job chains finish or
+// ctx is cancelled. Used for graceful shutdown.
 func (s *ETLService) WaitRunning(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.dagRuns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
 	s.runningJobs.WaitAll(ctx)
 }
 
-// Stop tears down all watchers and schedulers.
+// Stop tears down all watchers, schedulers and the webhook listener.
 func (s *ETLService) Stop() {
 	s.stopWatchers()
+	s.stopWebhooks()
 }
 
 func (s *ETLService) stopWatchers() {
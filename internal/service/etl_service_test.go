@@ -3,12 +3,22 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"notes/internal/domain"
-	_ "notes/internal/etl/sources" // register CSV, JSON, etc.
+	"notes/internal/etl"
+	"notes/internal/etl/sources" // also registers CSV, JSON, etc.
+	"notes/internal/httpauth"
 	"notes/internal/storage"
 	"notes/internal/testutil"
 )
@@ -263,82 +273,1245 @@ func TestETLService_RunJob_CSVFile(t *testing.T) {
 	}
 }
 
+func TestETLService_RunJob_FailureLeavesTargetUnchanged(t *testing.T) {
+	for _, mode := range []string{"replace", "append", "merge"} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			env := newETLService(t)
+			env.createTargetDB(t, "db-1", []string{"id", "name"})
+			for i, name := range []string{"alice", "bob"} {
+				env.localDB.CreateRow(&domain.LocalDBRow{
+					ID:         fmt.Sprintf("row-%d", i),
+					DatabaseID: "db-1",
+					DataJSON:   fmt.Sprintf(`{"id":"%d","name":%q}`, i+1, name),
+				})
+			}
+			before, _ := env.localDB.ListRows("db-1")
+			dbBefore, _ := env.localDB.GetDatabase("db-1")
+
+			// The first batch is written before the malformed last line fails the read.
+			var csv strings.Builder
+			csv.WriteString("id,name,city\n")
+			for i := 1; i <= etl.DefaultBatchSize+50; i++ {
+				fmt.Fprintf(&csv, "%d,name-%d,paris\n", i, i)
+			}
+			csv.WriteString("oops\n")
+			csvPath := t.TempDir() + "/test.csv"
+			writeTestFile(t, csvPath, csv.String())
+
+			input := CreateETLJobInput{
+				Name:         "Failing",
+				SourceType:   "csv_file",
+				SourceConfig: map[string]any{"filePath": csvPath},
+				TargetDBID:   "db-1",
+				SyncMode:     mode,
+			}
+			if mode == "merge" {
+				input.MergeKeys = []string{"id"}
+				input.DeleteMissing = true
+			}
+			job, err := env.svc.CreateJob(ctx, input)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if _, err := env.svc.RunJob(ctx, job.ID); err == nil {
+				t.Fatal("expected the run to fail")
+			}
+
+			after, _ := env.localDB.ListRows("db-1")
+			if len(after) != len(before) {
+				t.Fatalf("rows = %d, want %d", len(after), len(before))
+			}
+			for i := range before {
+				if after[i].ID != before[i].ID || after[i].DataJSON != before[i].DataJSON {
+					t.Errorf("row %d = %+v, want %+v", i, after[i], before[i])
+				}
+			}
+			if dbAfter, _ := env.localDB.GetDatabase("db-1"); dbAfter.ConfigJSON != dbBefore.ConfigJSON {
+				t.Errorf("config = %s, want %s", dbAfter.ConfigJSON, dbBefore.ConfigJSON)
+			}
+		})
+	}
+}
+
 func TestETLService_RunJob_ConcurrencyGuard(t *testing.T) {
 	env := newETLService(t)
 	env.createTargetDB(t, "db-1", []string{"id"})
 
-	csvPath := t.TempDir() + "/slow.csv"
-	writeTestFile(t, csvPath, "id\n1\n2\n3\n4\n5\n")
+	csvPath := t.TempDir() + "/slow.csv"
+	writeTestFile(t, csvPath, "id\n1\n2\n3\n4\n5\n")
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:       "Slow Job",
+		SourceType: "csv_file",
+		SourceConfig: map[string]any{
+			"filePath": csvPath,
+		},
+		TargetDBID: "db-1",
+		SyncMode:   "replace",
+	})
+	if err != nil {
+		t.Fatalf("setup create: %v", err)
+	}
+
+	// Start first run in background
+	done := make(chan struct{})
+	go func() {
+		env.svc.RunJob(context.Background(), job.ID)
+		close(done)
+	}()
+
+	// Give it a moment to start
+	time.Sleep(10 * time.Millisecond)
+
+	// Second run should fail with "already running" or succeed if first finished
+	env.svc.RunJob(context.Background(), job.ID)
+
+	<-done
+}
+
+func TestETLService_RunJob_NotFound(t *testing.T) {
+	env := newETLService(t)
+
+	_, err := env.svc.RunJob(context.Background(), "nonexistent")
+	if err == nil {
+		t.Fatal("expected error for nonexistent job")
+	}
+}
+
+func TestETLService_RunLogs(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+
+	csvPath := t.TempDir() + "/test.csv"
+	writeTestFile(t, csvPath, "id\n1\n")
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:       "Log Job",
+		SourceType: "csv_file",
+		SourceConfig: map[string]any{
+			"filePath": csvPath,
+		},
+		TargetDBID: "db-1",
+		SyncMode:   "replace",
+	})
+	if err != nil {
+		t.Fatalf("setup create: %v", err)
+	}
+
+	env.svc.RunJob(context.Background(), job.ID)
+
+	logs, logsErr := env.svc.ListRunLogs(job.ID)
+	if logsErr != nil {
+		t.Fatalf("list logs: %v", logsErr)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("logs = %d, want 1", len(logs))
+	}
+	if logs[0].Status != "success" {
+		t.Errorf("log status = %q", logs[0].Status)
+	}
+}
+
+func TestETLService_RunJob_Assertions(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id", "email"})
+	env.createTargetDB(t, "rejects", nil)
+
+	csvPath := t.TempDir() + "/test.csv"
+	writeTestFile(t, csvPath, "id,email\n1,a@x.io\n2,\n3,c@x.io\n")
+
+	input := CreateETLJobInput{
+		Name:         "Quality",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": csvPath},
+		TargetDBID:   "db-1",
+		Assertions: []etl.Assertion{
+			{Type: etl.AssertNotNull, Column: "email", Policy: etl.PolicyQuarantine},
+		},
+	}
+	if _, err := env.svc.CreateJob(context.Background(), input); err == nil {
+		t.Fatal("expected quarantine without a quarantine database to be rejected")
+	}
+
+	input.QuarantineDBID = "rejects"
+	job, err := env.svc.CreateJob(context.Background(), input)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if _, err := env.svc.RunJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 2 {
+		t.Errorf("target rows = %d, want 2", len(rows))
+	}
+	if rows, _ := env.localDB.ListRows("rejects"); len(rows) != 1 {
+		t.Errorf("quarantined rows = %d, want 1", len(rows))
+	}
+
+	logs, err := env.svc.ListRunLogs(job.ID)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("logs = %v, %v", logs, err)
+	}
+	l := logs[0]
+	if l.RowsQuarantined != 1 || len(l.Assertions) != 1 || l.Assertions[0].Assertion != "not_null(email)" || l.Assertions[0].Violations != 1 {
+		t.Errorf("run log = %+v", l)
+	}
+}
+
+func TestETLService_RunJob_FailedAssertionLeavesTarget(t *testing.T) {
+	maxRows := 10.0
+	cases := map[string][]etl.Assertion{
+		// Checked once every record has been written.
+		"row_count": {{Type: etl.AssertRowCount, Max: &maxRows, Policy: etl.PolicyFail}},
+		// Violated by the last record, after the first batch; emails of ids
+		// ending in 0 are quarantined on the way.
+		"not_null": {
+			{Type: etl.AssertNotNull, Column: "email", Policy: etl.PolicyFail},
+			{Type: etl.AssertRegex, Column: "email", Pattern: `^u\d*[1-9]@`, Policy: etl.PolicyQuarantine},
+		},
+	}
+	for name, assertions := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			env := newETLService(t)
+			env.createTargetDB(t, "db-1", []string{"id", "email"})
+			env.createTargetDB(t, "rejects", nil)
+			env.localDB.CreateRow(&domain.LocalDBRow{ID: "kept", DatabaseID: "db-1", DataJSON: `{"id":"0","email":"old@x.io"}`})
+
+			var csv strings.Builder
+			csv.WriteString("id,email\n")
+			for i := 1; i <= 2*etl.DefaultBatchSize; i++ {
+				fmt.Fprintf(&csv, "%d,u%d@x.io\n", i, i)
+			}
+			csv.WriteString("999,\n")
+			csvPath := t.TempDir() + "/test.csv"
+			writeTestFile(t, csvPath, csv.String())
+
+			job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+				Name:           "Quality",
+				SourceType:     "csv_file",
+				SourceConfig:   map[string]any{"filePath": csvPath},
+				TargetDBID:     "db-1",
+				SyncMode:       "replace",
+				Assertions:     assertions,
+				QuarantineDBID: "rejects",
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if _, err := env.svc.RunJob(ctx, job.ID); err == nil || !strings.Contains(err.Error(), "assertion") {
+				t.Fatalf("err = %v, want a failed assertion", err)
+			}
+
+			rows, _ := env.localDB.ListRows("db-1")
+			if len(rows) != 1 || rows[0].ID != "kept" {
+				t.Errorf("target rows = %+v, want only the existing row", rows)
+			}
+			if rows, _ := env.localDB.ListRows("rejects"); len(rows) != 0 {
+				t.Errorf("quarantined rows = %d, want 0", len(rows))
+			}
+		})
+	}
+}
+
+func TestETLService_RunJob_Incremental(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id", "name"})
+
+	csvPath := t.TempDir() + "/orders.csv"
+	writeTestFile(t, csvPath, "id,name\n1,alice\n2,bob\n")
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:         "Incremental",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": csvPath},
+		TargetDBID:   "db-1",
+		SyncMode:     "incremental",
+		CursorField:  "id",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if _, err := env.svc.RunJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// New rows arrive upstream; only they should be appended.
+	writeTestFile(t, csvPath, "id,name\n1,alice\n2,bob\n3,charlie\n")
+	result, err := env.svc.RunJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if result.RowsWritten != 1 {
+		t.Errorf("rowsWritten = %d, want 1", result.RowsWritten)
+	}
+
+	rows, _ := env.localDB.ListRows("db-1")
+	if len(rows) != 3 {
+		t.Errorf("rows = %d, want 3", len(rows))
+	}
+	got, _ := env.svc.GetJob(job.ID)
+	if got.CursorValue != "3" {
+		t.Errorf("cursorValue = %q, want 3", got.CursorValue)
+	}
+
+	if err := env.svc.ResetCursor(job.ID); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	got, _ = env.svc.GetJob(job.ID)
+	if got.CursorValue != "" {
+		t.Errorf("cursorValue after reset = %q", got.CursorValue)
+	}
+}
+
+func TestETLService_CreateJob_IncrementalRequiresCursor(t *testing.T) {
+	env := newETLService(t)
+
+	_, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:       "Bad",
+		SourceType: "csv_file",
+		SyncMode:   "incremental",
+	})
+	if err == nil {
+		t.Fatal("expected error for incremental job without cursor field")
+	}
+}
+
+func TestETLService_RunJob_Subprocess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("connector script needs a POSIX shell")
+	}
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+
+	// A minimal Singer tap: resumes after the id in its state file.
+	dir := t.TempDir()
+	tap := dir + "/tap.sh"
+	writeTestFile(t, tap, `#!/bin/sh
+last=0
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--state" ]; then last=$(sed 's/[^0-9]//g' "$2"); fi
+  shift
+done
+echo "tap resuming after $last" >&2
+for i in 1 2; do
+  echo "{\"type\":\"RECORD\",\"stream\":\"s\",\"record\":{\"id\":$((last+i))}}"
+done
+echo "{\"type\":\"STATE\",\"value\":{\"last\":$((last+2))}}"
+`)
+	if err := os.Chmod(tap, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stateful sources need no cursor field for incremental runs.
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:         "Tap",
+		SourceType:   "subprocess",
+		SourceConfig: map[string]any{"command": tap},
+		TargetDBID:   "db-1",
+		SyncMode:     "incremental",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for range 2 {
+		if _, err := env.svc.RunJob(context.Background(), job.ID); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+
+	rows, _ := env.localDB.ListRows("db-1")
+	if len(rows) != 4 {
+		t.Errorf("rows = %d, want 4", len(rows))
+	}
+	got, _ := env.svc.GetJob(job.ID)
+	if got.CursorValue != `{"last":4}` {
+		t.Errorf("cursorValue = %q", got.CursorValue)
+	}
+	logs, _ := env.svc.ListRunLogs(job.ID)
+	if len(logs) == 0 || logs[0].Output != "tap resuming after 2\n" {
+		t.Errorf("run log output = %+v", logs)
+	}
+
+	// The executable cannot be swapped for a single run.
+	if _, err := webhookOverrides("subprocess", strings.NewReader(`{"command":"/bin/false"}`)); err == nil {
+		t.Error("expected command override to be rejected")
+	}
+	if _, err := webhookOverrides("subprocess", strings.NewReader(`{"stream":"users"}`)); err != nil {
+		t.Errorf("stream override: %v", err)
+	}
+}
+
+func TestETLService_RunJob_Directory(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+	env.createTargetDB(t, "db-2", []string{"id"})
+
+	dir := t.TempDir()
+	writeTestFile(t, dir+"/a.csv", "id\n1\n2\n")
+	writeTestFile(t, dir+"/b.csv", "id\n3\n")
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:         "Drops",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"directory": dir, "pattern": "*.csv"},
+		TargetDBID:   "db-1",
+		SyncMode:     "append",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	run := func(id string) *etl.SyncResult {
+		t.Helper()
+		result, err := env.svc.RunJob(context.Background(), id)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return result
+	}
+
+	if r := run(job.ID); r.RowsRead != 3 || len(r.Files) != 2 {
+		t.Fatalf("first run: read %d rows from %d files", r.RowsRead, len(r.Files))
+	}
+	// Nothing new: every file is skipped.
+	if r := run(job.ID); r.RowsRead != 0 {
+		t.Errorf("second run read %d rows, want 0", r.RowsRead)
+	}
+	// A new file and a changed file are read once more.
+	writeTestFile(t, dir+"/c.csv", "id\n4\n")
+	writeTestFile(t, dir+"/b.csv", "id\n3\n5\n")
+	if r := run(job.ID); r.RowsRead != 3 {
+		t.Errorf("third run read %d rows, want 3", r.RowsRead)
+	}
+	rows, _ := env.localDB.ListRows("db-1")
+	if len(rows) != 6 {
+		t.Errorf("rows = %d, want 6", len(rows))
+	}
+
+	// Resetting the cursor forgets the history.
+	if err := env.svc.ResetCursor(job.ID); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if r := run(job.ID); r.RowsRead != 5 {
+		t.Errorf("run after reset read %d rows, want 5", r.RowsRead)
+	}
+
+	// With an archive folder, processed files are moved after the commit.
+	archive := t.TempDir()
+	archived, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:         "Archived",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"directory": dir, "archiveDir": archive},
+		TargetDBID:   "db-2",
+		SyncMode:     "append",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	run(archived.ID)
+	left, _ := os.ReadDir(dir)
+	moved, _ := os.ReadDir(archive)
+	if len(left) != 0 || len(moved) != 3 {
+		t.Errorf("left %d files, archived %d; want 0 and 3", len(left), len(moved))
+	}
+	// The next run finds the folder empty and succeeds without reading.
+	if r := run(archived.ID); r.RowsRead != 0 || r.Status != "success" {
+		t.Errorf("run on empty folder: status %q, read %d rows", r.Status, r.RowsRead)
+	}
+}
+
+func TestETLService_RunJob_DirectoryFailsPartway(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+
+	// a.csv fills more than a batch before b.csv fails the read.
+	dir := t.TempDir()
+	var a strings.Builder
+	a.WriteString("id\n")
+	for i := 1; i <= etl.DefaultBatchSize+100; i++ {
+		fmt.Fprintf(&a, "%d\n", i)
+	}
+	writeTestFile(t, dir+"/a.csv", a.String())
+	writeTestFile(t, dir+"/b.csv", "id\n1,2\n")
+
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "Drops",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"directory": dir},
+		TargetDBID:   "db-1",
+		SyncMode:     "append",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for range 2 {
+		if _, err := env.svc.RunJob(ctx, job.ID); err == nil || !strings.Contains(err.Error(), "b.csv") {
+			t.Fatalf("err = %v, want b.csv to fail the run", err)
+		}
+		if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 0 {
+			t.Fatalf("rows after failed run = %d, want 0", len(rows))
+		}
+	}
+
+	// Once b.csv is fixed, every file is ingested exactly once.
+	writeTestFile(t, dir+"/b.csv", "id\n0\n")
+	result, err := env.svc.RunJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Errorf("files = %d, want 2", len(result.Files))
+	}
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != etl.DefaultBatchSize+101 {
+		t.Errorf("rows = %d, want %d", len(rows), etl.DefaultBatchSize+101)
+	}
+}
+
+func TestETLService_RunJob_Merge(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id", "name"})
+
+	csvPath := t.TempDir() + "/customers.csv"
+	writeTestFile(t, csvPath, "id,name\n1,alice\n2,bob\n3,charlie\n")
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:          "Merge",
+		SourceType:    "csv_file",
+		SourceConfig:  map[string]any{"filePath": csvPath},
+		TargetDBID:    "db-1",
+		SyncMode:      "merge",
+		MergeKeys:     []string{"id"},
+		DeleteMissing: true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if _, err := env.svc.RunJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// bob is renamed, charlie disappears, dave is new.
+	writeTestFile(t, csvPath, "id,name\n1,alice\n2,robert\n4,dave\n")
+	result, err := env.svc.RunJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	// alice is unchanged and not counted.
+	if result.RowsInserted != 1 || result.RowsUpdated != 1 || result.RowsDeleted != 1 {
+		t.Errorf("inserted/updated/deleted = %d/%d/%d, want 1/1/1",
+			result.RowsInserted, result.RowsUpdated, result.RowsDeleted)
+	}
+
+	rows, _ := env.localDB.ListRows("db-1")
+	names := map[string]bool{}
+	for _, r := range rows {
+		var data map[string]any
+		json.Unmarshal([]byte(r.DataJSON), &data)
+		names[data["name"].(string)] = true
+	}
+	if len(rows) != 3 || !names["robert"] || !names["dave"] || names["charlie"] {
+		t.Errorf("rows after merge = %v", names)
+	}
+
+	logs, _ := env.svc.ListRunLogs(job.ID)
+	if len(logs) != 2 || logs[0].RowsUpdated != 1 || logs[0].RowsDeleted != 1 {
+		t.Errorf("latest run log = %+v", logs[0])
+	}
+}
+
+func TestETLService_RunJob_MergeKeyTypes(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", nil)
+
+	// Keys compare as stored: the number 1 and the string "1" are different
+	// keys, and each matches its own row on the next run.
+	jsonPath := t.TempDir() + "/accounts.json"
+	writeTestFile(t, jsonPath, `[{"id": 1, "name": "one"}, {"id": "1", "name": "uno"}, {"id": 1000000, "name": "big"}]`)
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "Accounts",
+		SourceType:   "json_file",
+		SourceConfig: map[string]any{"filePath": jsonPath},
+		TargetDBID:   "db-1",
+		SyncMode:     "merge",
+		MergeKeys:    []string{"id"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for i, want := range []etl.WriteStats{{Inserted: 3}, {}} {
+		result, err := env.svc.RunJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+		if got := (etl.WriteStats{Inserted: result.RowsInserted, Updated: result.RowsUpdated}); got != want {
+			t.Errorf("run %d: stats = %+v, want %+v", i+1, got, want)
+		}
+	}
+
+	writeTestFile(t, jsonPath, `[{"id": "1", "name": "eins"}]`)
+	result, err := env.svc.RunJob(ctx, job.ID)
+	if err != nil || result.RowsInserted != 0 || result.RowsUpdated != 1 {
+		t.Errorf("rename: %+v err=%v", result, err)
+	}
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 3 {
+		t.Errorf("rows = %d, want 3", len(rows))
+	}
+
+	// A record without a key cannot be merged.
+	writeTestFile(t, jsonPath, `[{"id": 1, "name": "one"}, {"id": null, "name": "nobody"}]`)
+	if _, err := env.svc.RunJob(ctx, job.ID); err == nil || !strings.Contains(err.Error(), `merge key "id" is null`) {
+		t.Errorf("null key: err = %v", err)
+	}
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 3 {
+		t.Errorf("rows after null key = %d, want 3", len(rows))
+	}
+}
+
+func TestETLService_RunJob_SchemaDrift(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", nil)
+
+	jsonPath := t.TempDir() + "/orders.json"
+	writeTestFile(t, jsonPath, `[{"id": 1, "amount": 10}, {"id": 2, "amount": 20}]`)
+
+	input := CreateETLJobInput{
+		Name:         "Drift",
+		SourceType:   "json_file",
+		SourceConfig: map[string]any{"filePath": jsonPath},
+		TargetDBID:   "db-1",
+		SyncMode:     "append",
+		SchemaPolicy: "fail",
+	}
+	job, err := env.svc.CreateJob(ctx, input)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := env.svc.RunJob(ctx, job.ID); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	got, _ := env.svc.GetJob(job.ID)
+	if got.LastSchema == nil || len(got.LastSchema.Fields) != 2 {
+		t.Fatalf("lastSchema = %+v, want the discovered schema", got.LastSchema)
+	}
+
+	// amount turns into text upstream.
+	writeTestFile(t, jsonPath, `[{"id": 3, "amount": "thirty"}]`)
+	_, err = env.svc.RunJob(ctx, job.ID)
+	if err == nil || !strings.Contains(err.Error(), "schema drift: amount changed from number to text") {
+		t.Fatalf("err = %v, want schema drift", err)
+	}
+	logs, _ := env.svc.ListRunLogs(job.ID)
+	if len(logs) != 2 || len(logs[0].SchemaDrift) != 1 || logs[0].SchemaDrift[0].Change != etl.FieldTypeChanged {
+		t.Errorf("latest run log drift = %+v", logs[0].SchemaDrift)
+	}
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 2 {
+		t.Errorf("rows = %d, want 2 (failed run writes nothing)", len(rows))
+	}
+
+	amountColumn := func() (id, typ string) {
+		db, _ := env.localDB.GetDatabase("db-1")
+		var cfg struct {
+			Columns []struct{ ID, Name, Type string } `json:"columns"`
+		}
+		json.Unmarshal([]byte(db.ConfigJSON), &cfg)
+		for _, c := range cfg.Columns {
+			if c.Name == "amount" {
+				return c.ID, c.Type
+			}
+		}
+		return "", ""
+	}
+	amountID, _ := amountColumn()
+	amounts := func() map[any]bool {
+		rows, _ := env.localDB.ListRows("db-1")
+		got := map[any]bool{}
+		for _, r := range rows {
+			var data map[string]any
+			json.Unmarshal([]byte(r.DataJSON), &data)
+			got[data[amountID]] = true
+		}
+		return got
+	}
+
+	// A run that fails evolves nothing.
+	input.SchemaPolicy = "evolve"
+	zero := 0.0
+	input.Assertions = []etl.Assertion{{Type: etl.AssertRowCount, Max: &zero, Policy: etl.PolicyFail}}
+	if err := env.svc.UpdateJob(ctx, job.ID, input); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := env.svc.RunJob(ctx, job.ID); err == nil {
+		t.Fatal("expected the row_count assertion to fail the run")
+	}
+	if _, typ := amountColumn(); typ != "number" || !amounts()[10.0] {
+		t.Errorf("after failed run: amount column type = %q, amounts = %v; want number, unchanged", typ, amounts())
+	}
+
+	input.Assertions = nil
+	if err := env.svc.UpdateJob(ctx, job.ID, input); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := env.svc.RunJob(ctx, job.ID); err != nil {
+		t.Fatalf("evolve run: %v", err)
+	}
+	if _, typ := amountColumn(); typ != "text" {
+		t.Errorf("amount column type = %q, want text", typ)
+	}
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 3 || !amounts()["10"] || !amounts()["20"] || !amounts()["thirty"] {
+		t.Errorf("amounts after evolve = %v", amounts())
+	}
+	got, _ = env.svc.GetJob(job.ID)
+	for _, f := range got.LastSchema.Fields {
+		if f.Name == "amount" && f.Type != "text" {
+			t.Errorf("baseline amount type = %q, want text", f.Type)
+		}
+	}
+}
+
+func TestETLService_RunJob_Lookup(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "customers", []string{"cid", "name"})
+	for i, name := range []string{"alice", "bob"} {
+		env.localDB.CreateRow(&domain.LocalDBRow{
+			ID:         name,
+			DatabaseID: "customers",
+			DataJSON:   fmt.Sprintf(`{"cid":"c%d","name":%q}`, i+1, name),
+		})
+	}
+	env.createTargetDB(t, "orders", []string{"order", "customer_id", "customer"})
+
+	csvPath := t.TempDir() + "/orders.csv"
+	writeTestFile(t, csvPath, "order,customer_id\n1,c2\n2,c9\n3,c1\n")
+
+	lookup := func(joinType string) []etl.TransformConfig {
+		return []etl.TransformConfig{{Type: "lookup", Config: map[string]any{
+			"databaseId":   "customers",
+			"leftKey":      "customer_id",
+			"rightKey":     "cid",
+			"columns":      []any{map[string]any{"column": "name", "as": "customer"}},
+			"joinType":     joinType,
+			"defaultValue": "unknown",
+		}}}
+	}
+	customers := func() map[string]any {
+		rows, _ := env.localDB.ListRows("orders")
+		got := map[string]any{}
+		for _, r := range rows {
+			var data map[string]any
+			json.Unmarshal([]byte(r.DataJSON), &data)
+			got[fmt.Sprint(data["order"])] = data["customer"]
+		}
+		return got
+	}
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:          "Orders",
+		SourceType:    "csv_file",
+		SourceConfig:  map[string]any{"filePath": csvPath},
+		Transforms:    lookup("left"),
+		TargetDBID:    "orders",
+		SyncMode:      "merge",
+		MergeKeys:     []string{"order"},
+		DeleteMissing: true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := env.svc.RunJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := customers(); got["1"] != "bob" || got["2"] != "unknown" || got["3"] != "alice" {
+		t.Errorf("left join = %v", got)
+	}
+
+	input := CreateETLJobInput{
+		Name:          "Orders",
+		SourceType:    "csv_file",
+		SourceConfig:  map[string]any{"filePath": csvPath},
+		Transforms:    lookup("inner"),
+		TargetDBID:    "orders",
+		SyncMode:      "merge",
+		MergeKeys:     []string{"order"},
+		DeleteMissing: true,
+	}
+	if err := env.svc.UpdateJob(context.Background(), job.ID, input); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if _, err := env.svc.RunJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := customers(); len(got) != 2 || got["1"] != "bob" || got["3"] != "alice" {
+		t.Errorf("inner join = %v", got)
+	}
+
+	// Unknown lookup columns are rejected on save.
+	input.Transforms[0].Config["rightKey"] = "missing"
+	if err := env.svc.UpdateJob(context.Background(), job.ID, input); err == nil {
+		t.Error("expected error for unknown lookup key column")
+	}
+}
+
+func TestETLService_RunJob_SQL(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+	env.createTargetDB(t, "customers", []string{"cid", "name"})
+	for i, name := range []string{"alice", "bob"} {
+		env.localDB.CreateRow(&domain.LocalDBRow{
+			ID:         name,
+			DatabaseID: "customers",
+			DataJSON:   fmt.Sprintf(`{"cid":"c%d","name":%q}`, i+1, name),
+		})
+	}
+	env.createTargetDB(t, "summary", nil)
+
+	jsonPath := t.TempDir() + "/orders.json"
+	writeTestFile(t, jsonPath, `[{"customer_id": "c2", "amount": 5}, {"customer_id": "c1", "amount": 7}, {"customer_id": "c2", "amount": 3}]`)
+
+	// The query joins the customers LocalDB by name.
+	query := `SELECT c.name AS customer, count(*) AS orders, sum(i.amount) AS total
+		FROM input i JOIN customers c ON c.cid = i.customer_id
+		GROUP BY c.name ORDER BY total DESC`
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "Summary",
+		SourceType:   "json_file",
+		SourceConfig: map[string]any{"filePath": jsonPath},
+		Transforms:   []etl.TransformConfig{{Type: "sql", Config: map[string]any{"query": query}}},
+		TargetDBID:   "summary",
+		SyncMode:     "replace",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if result, err := env.svc.RunJob(ctx, job.ID); err != nil || result.RowsWritten != 2 {
+		t.Fatalf("run: %+v err=%v", result, err)
+	}
+
+	db, _ := env.localDB.GetDatabase("summary")
+	var cfg struct {
+		Columns []struct{ Name, Type string } `json:"columns"`
+	}
+	json.Unmarshal([]byte(db.ConfigJSON), &cfg)
+	if got := fmt.Sprint(cfg.Columns); got != "[{customer text} {orders number} {total number}]" {
+		t.Errorf("columns = %s", got)
+	}
+
+	// Chart pipelines run the same query over rows they already hold.
+	res, err := env.svc.QuerySQL(ctx, "SELECT name FROM customers WHERE cid IN (SELECT id FROM input)", []map[string]any{{"id": "c1"}})
+	if err != nil || len(res.Records) != 1 || res.Records[0].Data["name"] != "alice" {
+		t.Errorf("query = %+v err=%v", res, err)
+	}
+
+	if _, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "Bad",
+		SourceType:   "json_file",
+		SourceConfig: map[string]any{"filePath": jsonPath},
+		Transforms:   []etl.TransformConfig{{Type: "sql", Config: map[string]any{"query": "DELETE FROM input"}}},
+		TargetDBID:   "summary",
+	}); err == nil || !strings.Contains(err.Error(), "SELECT") {
+		t.Errorf("err = %v, want non-SELECT query rejected", err)
+	}
+}
+
+func TestETLService_RunJob_LocalDBSource(t *testing.T) {
+	env := newETLService(t)
+	// Source rows are keyed by column ID, not name.
+	env.localDB.CreateDatabase(&domain.LocalDatabase{
+		ID:         "sales",
+		BlockID:    "block-sales",
+		Name:       "Sales",
+		ConfigJSON: `{"columns":[{"id":"c1","name":"region","type":"text"},{"id":"c2","name":"amount","type":"number"}]}`,
+	})
+	for i, row := range []string{
+		`{"c1":"north","c2":10}`, `{"c1":"south","c2":5}`, `{"c1":"north","c2":7,"gone":1}`,
+	} {
+		env.localDB.CreateRow(&domain.LocalDBRow{ID: fmt.Sprintf("r%d", i), DatabaseID: "sales", DataJSON: row})
+	}
+	env.createTargetDB(t, "summary", nil)
+
+	schema, err := env.svc.DiscoverSchema(context.Background(), "localdb", `{"databaseId":"sales"}`)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if len(schema.Fields) != 2 || schema.Fields[1] != (etl.Field{Name: "amount", Type: "number"}) {
+		t.Errorf("schema = %v", schema.Fields)
+	}
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:         "Sales by region",
+		SourceType:   "localdb",
+		SourceConfig: map[string]any{"databaseId": "sales"},
+		Transforms: []etl.TransformConfig{{Type: "group", Config: map[string]any{
+			"groupBy": []any{"region"},
+			"metrics": []any{map[string]any{"column": "amount", "agg": "sum", "as": "total"}},
+		}}},
+		TargetDBID: "summary",
+		SyncMode:   "replace",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	result, err := env.svc.RunJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.RowsRead != 3 || result.RowsWritten != 2 {
+		t.Errorf("read %d, wrote %d; want 3, 2", result.RowsRead, result.RowsWritten)
+	}
+
+	rows, _ := env.localDB.ListRows("summary")
+	db, _ := env.localDB.GetDatabase("summary")
+	var cfg struct {
+		Columns []struct{ ID, Name string } `json:"columns"`
+	}
+	json.Unmarshal([]byte(db.ConfigJSON), &cfg)
+	ids := map[string]string{}
+	for _, c := range cfg.Columns {
+		ids[c.Name] = c.ID
+	}
+	totals := map[string]any{}
+	for _, r := range rows {
+		var data map[string]any
+		json.Unmarshal([]byte(r.DataJSON), &data)
+		totals[fmt.Sprint(data[ids["region"]])] = data[ids["total"]]
+	}
+	if totals["north"] != 17.0 || totals["south"] != 5.0 {
+		t.Errorf("totals = %v", totals)
+	}
+}
+
+func TestETLService_RunJob_FormulaColumns(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+	env.localDB.CreateDatabase(&domain.LocalDatabase{
+		ID:      "prices",
+		BlockID: "block-prices",
+		Name:    "prices",
+		ConfigJSON: `{"columns":[{"id":"c1","name":"sku","type":"text"},{"id":"c2","name":"qty","type":"number"},` +
+			`{"id":"c3","name":"price","type":"number"},{"id":"c4","name":"total","type":"formula","formula":"{qty} * {c3}"}]}`,
+	})
+	env.localDB.CreateRow(&domain.LocalDBRow{ID: "r1", DatabaseID: "prices", DataJSON: `{"c1":"a","c2":2,"c3":3}`})
+	env.localDB.CreateRow(&domain.LocalDBRow{ID: "r2", DatabaseID: "prices", DataJSON: `{"c1":"b","c2":1,"c3":10}`})
+
+	csvPath := t.TempDir() + "/skus.csv"
+	writeTestFile(t, csvPath, "sku\na\nb\n")
+
+	// Every reader of a LocalDB sees its formula columns computed.
+	for name, input := range map[string]CreateETLJobInput{
+		"localdb source": {
+			SourceType:   "localdb",
+			SourceConfig: map[string]any{"databaseId": "prices"},
+		},
+		"lookup": {
+			SourceType:   "csv_file",
+			SourceConfig: map[string]any{"filePath": csvPath},
+			Transforms: []etl.TransformConfig{{Type: "lookup", Config: map[string]any{
+				"databaseId": "prices", "leftKey": "sku", "rightKey": "sku", "columns": []any{"total"},
+			}}},
+		},
+		"sql": {
+			SourceType:   "csv_file",
+			SourceConfig: map[string]any{"filePath": csvPath},
+			Transforms: []etl.TransformConfig{{Type: "sql", Config: map[string]any{
+				"query": "SELECT i.sku, p.total FROM input i JOIN prices p ON p.sku = i.sku",
+			}}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			target := strings.ReplaceAll(name, " ", "-")
+			env.createTargetDB(t, target, nil)
+			input.Name = name
+			input.TargetDBID = target
+			job, err := env.svc.CreateJob(ctx, input)
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if _, err := env.svc.RunJob(ctx, job.ID); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			db, _ := env.localDB.GetDatabase(target)
+			table, err := etl.NewLocalDBTable(db)
+			if err != nil {
+				t.Fatalf("table: %v", err)
+			}
+			rows, _ := env.localDB.ListRows(target)
+			totals := map[string]any{}
+			for _, row := range rows {
+				rec, _ := table.Record(row)
+				totals[fmt.Sprint(rec.Data["sku"])] = rec.Data["total"]
+			}
+			if totals["a"] != 6.0 || totals["b"] != 10.0 {
+				t.Errorf("totals = %v", totals)
+			}
+		})
+	}
+}
+
+func TestETLService_RunJob_FileDestination(t *testing.T) {
+	env := newETLService(t)
+	dir := t.TempDir()
+	writeTestFile(t, dir+"/in.csv", "id,name\n1,alice\n2,bob\n")
+	outPath := dir + "/out.jsonl"
+
+	// Destinations reject modes they cannot write and missing config.
+	input := CreateETLJobInput{
+		Name:         "Export",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": dir + "/in.csv"},
+		DestType:     "jsonl_file",
+		DestConfig:   map[string]any{},
+		SyncMode:     "merge",
+		MergeKeys:    []string{"id"},
+	}
+	if _, err := env.svc.CreateJob(context.Background(), input); err == nil {
+		t.Error("expected merge mode to be rejected")
+	}
+	input.SyncMode, input.MergeKeys = "replace", nil
+	if _, err := env.svc.CreateJob(context.Background(), input); err == nil || !strings.Contains(err.Error(), "File Path is required") {
+		t.Errorf("err = %v, want missing file path", err)
+	}
+
+	input.DestConfig["filePath"] = outPath
+	job, err := env.svc.CreateJob(context.Background(), input)
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	result, err := env.svc.RunJob(context.Background(), job.ID)
+	if err != nil || result.RowsWritten != 2 {
+		t.Fatalf("run: result=%+v err=%v", result, err)
+	}
+	got, _ := os.ReadFile(outPath)
+	if lines := strings.Split(strings.TrimSpace(string(got)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"bob"`) {
+		t.Errorf("output = %q", got)
+	}
+	for _, e := range env.emitter.Events {
+		if e.Event == "db:updated" {
+			t.Error("unexpected db:updated event for a file destination")
+		}
+	}
+}
+
+func TestETLService_ExportImportPipelines(t *testing.T) {
+	ctx := context.Background()
+	createDB := func(env *etlTestEnv, id string) {
+		t.Helper()
+		if err := env.localDB.CreateDatabase(&domain.LocalDatabase{ID: id, BlockID: "block-" + id, Name: "Orders", ConfigJSON: `{"columns":[{"id":"c1","name":"id","type":"text"}]}`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := newETLService(t)
+	createDB(src, "orders-src")
+	api := &etl.SyncJob{
+		Name: "GitHub Issues", SourceType: "http", TargetDBID: "orders-src", SyncMode: etl.SyncReplace,
+		SourceCfg: etl.SourceConfig{
+			"url": "https://api.example.com/issues", "authType": "bearer", "authSecretRef": "http-auth:abc",
+			"headers": `{"Accept": "application/json", "X-Api-Key": "k-123"}`,
+		},
+		TriggerType: "schedule", TriggerConfig: "0 * * * *", Enabled: true,
+	}
+	if err := src.svc.store.CreateJob(api); err != nil {
+		t.Fatal(err)
+	}
+	csvJob, err := src.svc.CreateJob(ctx, CreateETLJobInput{
+		Name: "Enrich", SourceType: "csv_file", SourceConfig: map[string]any{"filePath": "/tmp/in.csv"},
+		Transforms: []etl.TransformConfig{{Type: "lookup", Config: map[string]any{
+			"databaseId": "orders-src", "leftKey": "order", "rightKey": "id", "columns": []any{"id"},
+		}}},
+		TargetDBID: "orders-src", SyncMode: "replace", TriggerType: "after_job", TriggerConfig: api.ID,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	doc, err := src.svc.ExportPipelines(nil, "yaml")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	for _, want := range []string{
+		"name: GitHub Issues", "authSecret: ${secret:github-issues.authSecret}", "${secret:github-issues.headers.X-Api-Key}",
+		"application/json", "database: Orders", "databaseId: Orders", "- GitHub Issues",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("export lacks %q:\n%s", want, doc)
+		}
+	}
+	for _, leaked := range []string{"http-auth:abc", "k-123", "orders-src", api.ID, csvJob.ID} {
+		if strings.Contains(doc, leaked) {
+			t.Errorf("export contains %q:\n%s", leaked, doc)
+		}
+	}
+
+	// Overwriting a job with its own export keeps the credentials whose
+	// secrets are not set.
+	res, err := src.svc.ImportPipelines(ctx, []byte(doc), PipelineImportOptions{Overwrite: true})
+	if err != nil {
+		t.Fatalf("overwrite: %v (%+v)", err, res)
+	}
+	if got, _ := src.svc.GetJob(api.ID); got.SourceCfg["authSecretRef"] != "http-auth:abc" || got.SourceCfg["authSecret"] != nil ||
+		got.SourceCfg["headers"] != api.SourceCfg["headers"] {
+		t.Errorf("overwritten job config = %+v", got.SourceCfg)
+	}
+	if len(res.Jobs[0].Warnings) != 2 {
+		t.Errorf("overwrite warnings = %v, want one per kept credential", res.Jobs[0].Warnings)
+	}
 
-	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
-		Name:       "Slow Job",
-		SourceType: "csv_file",
-		SourceConfig: map[string]any{
-			"filePath": csvPath,
-		},
-		TargetDBID: "db-1",
-		SyncMode:   "replace",
-	})
+	// A dry run on another machine validates without writing.
+	dst := newETLService(t)
+	createDB(dst, "orders-dst")
+	res, err = dst.svc.ImportPipelines(ctx, []byte(doc), PipelineImportOptions{DryRun: true})
 	if err != nil {
-		t.Fatalf("setup create: %v", err)
+		t.Fatalf("dry run: %v (%+v)", err, res)
+	}
+	if jobs, _ := dst.svc.ListJobs(); len(jobs) != 0 || res.Jobs[0].Action != "create" {
+		t.Fatalf("dry run wrote %d jobs, result %+v", len(jobs), res)
 	}
 
-	// Start first run in background
-	done := make(chan struct{})
-	go func() {
-		env.svc.RunJob(context.Background(), job.ID)
-		close(done)
-	}()
+	res, err = dst.svc.ImportPipelines(ctx, []byte(doc), PipelineImportOptions{})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(res.Secrets) != 2 || res.Secrets[0].Name != "github-issues.headers.X-Api-Key" || res.Secrets[0].Field != "source.headers" ||
+		res.Secrets[1].Name != "github-issues.authSecret" || res.Secrets[1].Field != "source.authSecret" || res.Secrets[1].Set {
+		t.Errorf("secrets = %+v", res.Secrets)
+	}
+	imported, _ := dst.svc.GetJob(res.Jobs[1].JobID)
+	if imported.TargetDBID != "orders-dst" || imported.TriggerConfig != res.Jobs[0].JobID ||
+		imported.Transforms[0].Config["databaseId"] != "orders-dst" {
+		t.Errorf("imported = %+v", imported)
+	}
+	// The secret reference is kept, to be resolved once the secret is set.
+	if first, _ := dst.svc.GetJob(res.Jobs[0].JobID); first.SourceCfg["authSecret"] != "${secret:github-issues.authSecret}" || !first.Enabled {
+		t.Errorf("imported http job = %+v", first)
+	}
 
-	// Give it a moment to start
-	time.Sleep(10 * time.Millisecond)
+	// Importing again reports conflicts; the same document in JSON round-trips.
+	jsonDoc, err := dst.svc.ExportPipelines(nil, "json")
+	if err != nil {
+		t.Fatalf("export json: %v", err)
+	}
+	res, err = dst.svc.ImportPipelines(ctx, []byte(jsonDoc), PipelineImportOptions{})
+	if err != nil || res.Jobs[0].Action != "skip" || res.Jobs[0].Conflict == "" {
+		t.Errorf("re-import: %+v err=%v", res, err)
+	}
+	if jobs, _ := dst.svc.ListJobs(); len(jobs) != 2 {
+		t.Errorf("jobs after re-import = %d, want 2", len(jobs))
+	}
 
-	// Second run should fail with "already running" or succeed if first finished
-	env.svc.RunJob(context.Background(), job.ID)
+	// Unknown types and names fail validation; nothing is written.
+	bad := `version: 1
+jobs:
+  - name: Bad
+    source: {type: carrier_pigeon}
+    transforms: [{type: teleport}]
+    target: {database: Missing}
+`
+	res, err = dst.svc.ImportPipelines(ctx, []byte(bad), PipelineImportOptions{DryRun: true})
+	if err == nil || len(res.Jobs[0].Errors) != 3 {
+		t.Errorf("bad import: %+v err=%v", res, err)
+	}
+	if _, err := dst.svc.ImportPipelines(ctx, []byte("version: 1\njobs:\n  - nmae: typo\n"), PipelineImportOptions{}); err == nil {
+		t.Error("expected unknown key to be rejected")
+	}
+}
 
-	<-done
+func TestExportCredentialJSON(t *testing.T) {
+	got := exportCredentialJSON("Tap", "config", `{"start_date": "2024-01-01", "api_key": "k", "oauth": {"client_secret": "s", "client_id": "id"}}`)
+	want := `{"api_key":"${secret:tap.config.api_key}","oauth":{"client_id":"id","client_secret":"${secret:tap.config.oauth.client_secret}"},"start_date":"2024-01-01"}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	// Values without credentials are exported as written.
+	if v := `{"Accept": "text/csv"}`; exportCredentialJSON("Tap", "headers", v) != v {
+		t.Error("expected a header without credentials to be kept")
+	}
+	if got := exportCredentialJSON("Tap", "headers", "not json"); got != "${secret:tap.headers}" {
+		t.Errorf("invalid JSON = %q", got)
+	}
 }
 
-func TestETLService_RunJob_NotFound(t *testing.T) {
+func TestETLService_CreateJob_MergeRequiresKeys(t *testing.T) {
 	env := newETLService(t)
 
-	_, err := env.svc.RunJob(context.Background(), "nonexistent")
+	_, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:       "Bad",
+		SourceType: "csv_file",
+		SyncMode:   "merge",
+	})
 	if err == nil {
-		t.Fatal("expected error for nonexistent job")
+		t.Fatal("expected error for merge job without merge keys")
 	}
 }
 
-func TestETLService_RunLogs(t *testing.T) {
+func TestETLService_CreateJob_RejectsInvalidExpression(t *testing.T) {
 	env := newETLService(t)
-	env.createTargetDB(t, "db-1", []string{"id"})
 
-	csvPath := t.TempDir() + "/test.csv"
-	writeTestFile(t, csvPath, "id\n1\n")
+	_, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:       "Bad",
+		SourceType: "csv_file",
+		Transforms: []etl.TransformConfig{{Type: "compute", Config: map[string]any{
+			"columns": []any{map[string]any{"name": "total", "expression": "{qty} * ("}},
+		}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "expression error") {
+		t.Fatalf("err = %v, want expression error", err)
+	}
+}
+
+func TestETLService_CreateJob_SealsHTTPAuth(t *testing.T) {
+	env := newETLService(t)
+	secrets := newMockSecretStore()
+	sources.SetHTTPAuthenticator(httpauth.New(secrets))
+	t.Cleanup(func() { sources.SetHTTPAuthenticator(nil) })
 
 	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
-		Name:       "Log Job",
-		SourceType: "csv_file",
+		Name:       "API",
+		SourceType: "http",
 		SourceConfig: map[string]any{
-			"filePath": csvPath,
+			"url":        "https://api.example.com/items",
+			"authType":   "bearer",
+			"authSecret": "tok-123",
 		},
-		TargetDBID: "db-1",
-		SyncMode:   "replace",
 	})
 	if err != nil {
-		t.Fatalf("setup create: %v", err)
+		t.Fatalf("create: %v", err)
 	}
 
-	env.svc.RunJob(context.Background(), job.ID)
+	got, _ := env.svc.GetJob(job.ID)
+	if _, ok := got.SourceCfg["authSecret"]; ok {
+		t.Error("plaintext credential persisted in job config")
+	}
+	ref, _ := got.SourceCfg["authSecretRef"].(string)
+	if ref == "" || string(secrets.secrets[ref]) != "tok-123" {
+		t.Fatalf("secret ref = %q, stored = %q", ref, secrets.secrets[ref])
+	}
 
-	logs, logsErr := env.svc.ListRunLogs(job.ID)
-	if logsErr != nil {
-		t.Fatalf("list logs: %v", logsErr)
+	// Editing without re-entering the credential keeps the reference.
+	err = env.svc.UpdateJob(context.Background(), job.ID, CreateETLJobInput{
+		Name:         "API v2",
+		SourceType:   "http",
+		SourceConfig: map[string]any{"url": "https://api.example.com/v2/items", "authType": "bearer"},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
 	}
-	if len(logs) != 1 {
-		t.Fatalf("logs = %d, want 1", len(logs))
+	got, _ = env.svc.GetJob(job.ID)
+	if got.SourceCfg["authSecretRef"] != ref {
+		t.Errorf("secret ref after update = %v, want %q", got.SourceCfg["authSecretRef"], ref)
 	}
-	if logs[0].Status != "success" {
-		t.Errorf("log status = %q", logs[0].Status)
+
+	if err := env.svc.DeleteJob(context.Background(), job.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := secrets.secrets[ref]; ok {
+		t.Error("credential should be deleted with the job")
 	}
 }
 
@@ -386,6 +1559,48 @@ func TestETLService_PreviewSource(t *testing.T) {
 	}
 }
 
+func TestETLService_DebugJob(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "target-db", []string{"id", "name"})
+
+	csvPath := t.TempDir() + "/test.csv"
+	writeTestFile(t, csvPath, "id,name\n1,alice\n2,bob\n3,carol\n")
+
+	job, err := env.svc.CreateJob(context.Background(), CreateETLJobInput{
+		Name:         "Debug",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": csvPath},
+		Transforms: []etl.TransformConfig{
+			{Type: "filter", Config: map[string]any{"field": "nmae", "op": "eq", "value": "bob"}},
+		},
+		TargetDBID: "target-db",
+	})
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	res, err := env.svc.DebugJob(context.Background(), job.ID, 0)
+	if err != nil {
+		t.Fatalf("debug: %v", err)
+	}
+	if len(res.Stages) != 2 {
+		t.Fatalf("stages = %+v", res.Stages)
+	}
+	filter := res.Stages[1]
+	if filter.RecordsIn != 3 || filter.RecordsOut != 0 || filter.ErrorCount != 3 {
+		t.Errorf("filter stage = %d→%d, %d errors", filter.RecordsIn, filter.RecordsOut, filter.ErrorCount)
+	}
+	if len(filter.Errors) == 0 || !strings.Contains(filter.Errors[0].Error, `"nmae" not found`) {
+		t.Errorf("filter errors = %+v", filter.Errors)
+	}
+
+	// Nothing is written.
+	rows, _ := env.localDB.ListRows("target-db")
+	if len(rows) != 0 {
+		t.Errorf("debug wrote %d rows", len(rows))
+	}
+}
+
 func TestETLService_PreviewSource_BadJSON(t *testing.T) {
 	env := newETLService(t)
 
@@ -434,6 +1649,443 @@ func TestETLService_DiscoverSchema_UnknownSource(t *testing.T) {
 
 // ── WaitRunning / Stop ──
 
+// ── Job Dependencies ──
+
+func TestETLService_AfterJob(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+	ctx := context.Background()
+
+	csvPath := t.TempDir() + "/test.csv"
+	writeTestFile(t, csvPath, "id\n1\n")
+
+	create := func(name, trigger, upstream string) *etl.SyncJob {
+		t.Helper()
+		job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+			Name:          name,
+			SourceType:    "csv_file",
+			SourceConfig:  map[string]any{"filePath": csvPath},
+			TargetDBID:    "db-1",
+			TriggerType:   trigger,
+			TriggerConfig: upstream,
+			Enabled:       true,
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		return job
+	}
+	raw := create("raw", "manual", "")
+	enrich := create("enrich", "after_job", raw.ID)
+	report := create("report", "after_job", enrich.ID)
+	lastLog := func(id string) etl.SyncRunLog {
+		t.Helper()
+		logs, err := env.svc.ListRunLogs(id)
+		if err != nil || len(logs) == 0 {
+			t.Fatalf("logs for %s = %v, %v", id, logs, err)
+		}
+		return logs[0]
+	}
+
+	// Success runs the whole chain, recording lineage.
+	if _, err := env.svc.RunJob(ctx, raw.ID); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	env.svc.WaitRunning(ctx)
+	rawLog, enrichLog, reportLog := lastLog(raw.ID), lastLog(enrich.ID), lastLog(report.ID)
+	if enrichLog.Status != "success" || reportLog.Status != "success" {
+		t.Fatalf("statuses = %s, %s", enrichLog.Status, reportLog.Status)
+	}
+	if enrichLog.UpstreamRunID != rawLog.ID || reportLog.UpstreamRunID != enrichLog.ID || reportLog.RootRunID != rawLog.ID {
+		t.Errorf("lineage: enrich=%+v report=%+v", enrichLog, reportLog)
+	}
+
+	// An upstream failure skips everything after it.
+	os.Remove(csvPath)
+	env.svc.RunJob(ctx, raw.ID)
+	env.svc.WaitRunning(ctx)
+	if l := lastLog(enrich.ID); l.Status != "skipped" || !strings.Contains(l.Error, `"raw"`) {
+		t.Errorf("enrich = %+v, want skipped because of raw", l)
+	}
+	if l := lastLog(report.ID); l.Status != "skipped" {
+		t.Errorf("report status = %s, want skipped", l.Status)
+	}
+	if job, _ := env.svc.GetJob(report.ID); job.LastStatus != "skipped" {
+		t.Errorf("report last status = %s", job.LastStatus)
+	}
+}
+
+func TestETLService_AfterJob_Validation(t *testing.T) {
+	env := newETLService(t)
+	ctx := context.Background()
+	input := func(name, trigger, upstream string) CreateETLJobInput {
+		return CreateETLJobInput{
+			Name:          name,
+			SourceType:    "csv_file",
+			SourceConfig:  map[string]any{"filePath": "/tmp/x.csv"},
+			TriggerType:   trigger,
+			TriggerConfig: upstream,
+		}
+	}
+
+	if _, err := env.svc.CreateJob(ctx, input("x", "after_job", "")); err == nil {
+		t.Error("expected an after_job trigger without upstream to be rejected")
+	}
+	if _, err := env.svc.CreateJob(ctx, input("x", "after_job", "missing")); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want upstream not found", err)
+	}
+
+	a, _ := env.svc.CreateJob(ctx, input("a", "manual", ""))
+	b, _ := env.svc.CreateJob(ctx, input("b", "after_job", a.ID))
+	c, _ := env.svc.CreateJob(ctx, input("c", "after_job", b.ID))
+
+	err := env.svc.UpdateJob(ctx, a.ID, input("a", "after_job", c.ID))
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: a → b → c → a") {
+		t.Errorf("err = %v, want cycle a → b → c → a", err)
+	}
+	if err := env.svc.UpdateJob(ctx, b.ID, input("b", "after_job", b.ID)); err == nil {
+		t.Error("expected a self-dependency to be rejected")
+	}
+
+	if err := env.svc.DeleteJob(ctx, b.ID); err == nil || !strings.Contains(err.Error(), "after this one: c;") {
+		t.Errorf("err = %v, want delete refused while c runs after b", err)
+	}
+	if err := env.svc.DeleteJob(ctx, c.ID); err != nil {
+		t.Fatalf("delete c: %v", err)
+	}
+	if err := env.svc.DeleteJob(ctx, b.ID); err != nil {
+		t.Errorf("delete b after c: %v", err)
+	}
+}
+
+// ── Cancellation ──
+
+func TestETLService_CancelJob(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+	ctx := context.Background()
+
+	// The first page fills a batch; the second hangs until the run is
+	// cancelled.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			<-r.Context().Done()
+			return
+		}
+		items := make([]map[string]any, etl.DefaultBatchSize)
+		for i := range items {
+			items[i] = map[string]any{"id": i}
+		}
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:       "api",
+		SourceType: "http",
+		SourceConfig: map[string]any{
+			"url":        srv.URL,
+			"pagination": "page",
+			"pageSize":   strconv.Itoa(etl.DefaultBatchSize),
+		},
+		TargetDBID: "db-1",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := env.svc.CancelJob(job.ID); err == nil {
+		t.Error("expected cancelling an idle job to fail")
+	}
+
+	type outcome struct {
+		result *etl.SyncResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := env.svc.RunJob(ctx, job.ID)
+		done <- outcome{result, err}
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for env.svc.CancelJob(job.ID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("job never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var out outcome
+	select {
+	case out = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not stop after CancelJob")
+	}
+	if out.err == nil || out.result.Status != "cancelled" {
+		t.Errorf("run = %+v, %v; want cancelled", out.result, out.err)
+	}
+	logs, _ := env.svc.ListRunLogs(job.ID)
+	if len(logs) != 1 || logs[0].Status != "cancelled" || logs[0].Error != "cancelled by user" {
+		t.Errorf("run logs = %+v", logs)
+	}
+	if got, _ := env.svc.GetJob(job.ID); got.LastStatus != "cancelled" {
+		t.Errorf("last status = %s", got.LastStatus)
+	}
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 0 {
+		t.Errorf("cancelled run committed %d rows", len(rows))
+	}
+}
+
+// ── Webhooks ──
+
+func TestETLService_Webhook(t *testing.T) {
+	env := newETLService(t)
+	env.svc.SetWebhookAddr("127.0.0.1:0")
+	env.createTargetDB(t, "db-1", []string{"id"})
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	writeTestFile(t, dir+"/a.csv", "id\n1\n")
+	writeTestFile(t, dir+"/b.csv", "id\n1\n2\n3\n")
+
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "hook",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": dir + "/a.csv"},
+		TargetDBID:   "db-1",
+		TriggerType:  "webhook",
+		Enabled:      true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(job.TriggerConfig) < 32 {
+		t.Fatalf("token = %q, want a generated secret", job.TriggerConfig)
+	}
+	weak := CreateETLJobInput{
+		Name: "weak", SourceType: "csv_file", SourceConfig: map[string]any{"filePath": dir + "/a.csv"},
+		TargetDBID: "db-1", TriggerType: "webhook", TriggerConfig: "a",
+	}
+	if _, err := env.svc.CreateJob(ctx, weak); err == nil || !strings.Contains(err.Error(), "48 hex characters") {
+		t.Errorf("err = %v, want a weak token rejected", err)
+	}
+	weak.TriggerConfig = strings.Repeat("g", 48)
+	if err := env.svc.UpdateJob(ctx, job.ID, weak); err == nil {
+		t.Error("expected a non-hex token to be rejected")
+	}
+	url, err := env.svc.WebhookURL(job.ID)
+	if err != nil {
+		t.Fatalf("webhook url: %v", err)
+	}
+
+	post := func(url, body string) (int, string) {
+		t.Helper()
+		resp, err := http.Post(url, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+
+	// The body overrides the source config for that run only.
+	if code, body := post(url+"?wait=true", `{"filePath": "`+dir+`/b.csv"}`); code != http.StatusOK {
+		t.Fatalf("post = %d %s", code, body)
+	}
+	rows, _ := env.localDB.ListRows("db-1")
+	if len(rows) != 3 {
+		t.Errorf("rows = %d, want 3 from the override", len(rows))
+	}
+	if got, _ := env.svc.GetJob(job.ID); got.SourceCfg["filePath"] != dir+"/a.csv" {
+		t.Errorf("stored config changed: %v", got.SourceCfg)
+	}
+
+	// Without wait the run starts in the background.
+	if code, body := post(url, ""); code != http.StatusAccepted {
+		t.Fatalf("post = %d %s", code, body)
+	}
+	env.svc.WaitRunning(ctx)
+	if rows, _ := env.localDB.ListRows("db-1"); len(rows) != 1 {
+		t.Errorf("rows = %d, want 1", len(rows))
+	}
+
+	base := strings.TrimSuffix(url, job.TriggerConfig)
+	if code, _ := post(base+"wrong-token", ""); code != http.StatusNotFound {
+		t.Errorf("wrong token = %d, want 404", code)
+	}
+	if code, _ := post(url, `{"nope": 1}`); code != http.StatusBadRequest {
+		t.Errorf("unknown key = %d, want 400", code)
+	}
+	// Only allowlisted fields can be overridden, and never with a secret.
+	for _, body := range []string{
+		`{"url": "http://evil.test"}`,
+		`{"headers": "{}"}`,
+		`{"authTokenUrl": "http://evil.test/token"}`,
+		`{"authKeyIn": "query"}`,
+		`{"blockId": "other"}`,
+		`{"dataPath": "${secret:github}"}`,
+	} {
+		if _, err := webhookOverrides("http", strings.NewReader(body)); err == nil {
+			t.Errorf("override %s: expected error", body)
+		}
+	}
+	if _, err := webhookOverrides("http", strings.NewReader(`{"dataPath": "items", "maxPages": 3}`)); err != nil {
+		t.Errorf("allowed overrides: %v", err)
+	}
+	if resp, err := http.Get(url); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("GET = %d, want 405", resp.StatusCode)
+		}
+	}
+
+	// The listener stops once no webhook job is enabled.
+	if err := env.svc.SetJobEnabled(ctx, job.ID, false); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if _, err := http.Post(url, "application/json", nil); err == nil {
+		t.Error("expected the listener to be closed")
+	}
+}
+
+// ── Retries ──
+
+func TestETLService_Retry(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+	ctx := context.Background()
+
+	var delays []time.Duration
+	wait := retryWait
+	retryWait = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	t.Cleanup(func() { retryWait = wait })
+
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id": 1}]`))
+	}))
+	defer srv.Close()
+
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "api",
+		SourceType:   "http",
+		SourceConfig: map[string]any{"url": srv.URL},
+		TargetDBID:   "db-1",
+		TriggerType:  "schedule",
+		Retry:        etl.RetryPolicy{MaxAttempts: 3, BackoffSeconds: 1, DisableAfter: 2},
+		Enabled:      true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	failedEvents := func() []JobFailedEvent {
+		var out []JobFailedEvent
+		for _, e := range env.emitter.Events {
+			if e.Event == "etl:job-failed" {
+				out = append(out, e.Data.(JobFailedEvent))
+			}
+		}
+		return out
+	}
+
+	// A 503 is retried with exponential backoff, one run log per attempt.
+	if err := env.svc.runScheduled(ctx, job.ID, runOptions{}); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if len(delays) != 2 || delays[0] != time.Second || delays[1] != 2*time.Second {
+		t.Errorf("delays = %v, want [1s 2s]", delays)
+	}
+	logs, _ := env.svc.ListRunLogs(job.ID)
+	if len(logs) != 3 {
+		t.Fatalf("run logs = %d, want 3", len(logs))
+	}
+	attempts := map[int]bool{}
+	for _, l := range logs {
+		attempts[l.Attempt] = true
+	}
+	if !attempts[1] || !attempts[2] || !attempts[3] {
+		t.Errorf("attempts = %v, want 1..3", attempts)
+	}
+	if ev := failedEvents(); len(ev) != 1 || ev[0].Attempts != 3 || ev[0].ConsecutiveFailures != 1 || ev[0].Disabled {
+		t.Errorf("job-failed events = %+v", ev)
+	}
+
+	// The second failed run in a row disables the job.
+	env.svc.runScheduled(ctx, job.ID, runOptions{})
+	if ev := failedEvents(); len(ev) != 2 || !ev[1].Disabled || ev[1].ConsecutiveFailures != 2 {
+		t.Errorf("job-failed events = %+v, want the second to disable", ev)
+	}
+	got, _ := env.svc.GetJob(job.ID)
+	if got.Enabled || got.Failures != 2 {
+		t.Errorf("job enabled=%v failures=%d, want disabled with 2", got.Enabled, got.Failures)
+	}
+	if err := env.svc.runScheduled(ctx, job.ID, runOptions{}); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("err = %v, want disabled job to be skipped", err)
+	}
+
+	// Re-enabling clears the count; a success runs once.
+	if err := env.svc.SetJobEnabled(ctx, job.ID, true); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	healthy.Store(true)
+	if err := env.svc.runScheduled(ctx, job.ID, runOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	logs, _ = env.svc.ListRunLogs(job.ID)
+	if logs[0].Status != "success" || logs[0].Attempt != 1 {
+		t.Errorf("latest run = %+v, want success on attempt 1", logs[0])
+	}
+	if got, _ := env.svc.GetJob(job.ID); !got.Enabled || got.Failures != 0 {
+		t.Errorf("job enabled=%v failures=%d", got.Enabled, got.Failures)
+	}
+}
+
+func TestETLService_Retry_PermanentError(t *testing.T) {
+	env := newETLService(t)
+	env.createTargetDB(t, "db-1", []string{"id"})
+	ctx := context.Background()
+
+	wait := retryWait
+	retryWait = func(context.Context, time.Duration) error { return nil }
+	t.Cleanup(func() { retryWait = wait })
+
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "missing",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": t.TempDir() + "/missing.csv"},
+		TargetDBID:   "db-1",
+		TriggerType:  "schedule",
+		Retry:        etl.RetryPolicy{MaxAttempts: 3},
+		Enabled:      true,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := env.svc.runScheduled(ctx, job.ID, runOptions{}); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if logs, _ := env.svc.ListRunLogs(job.ID); len(logs) != 1 {
+		t.Errorf("run logs = %d, want a single attempt", len(logs))
+	}
+
+	_, err = env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name:         "bad",
+		SourceType:   "csv_file",
+		SourceConfig: map[string]any{"filePath": "/tmp/x.csv"},
+		Retry:        etl.RetryPolicy{MaxAttempts: 50},
+	})
+	if err == nil {
+		t.Error("expected an excessive max attempts to be rejected")
+	}
+}
+
 func TestETLService_WaitRunning_Immediate(t *testing.T) {
 	env := newETLService(t)
 
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"notes/internal/etl"
+	"notes/internal/secret"
+)
+
+// ── Webhook Trigger ────────────────────────────────────────
+// A job with the webhook trigger runs when something POSTs to its URL, e.g.
+// a git hook, a CI script or a cron job on another machine:
+//
+//	curl -X POST http://127.0.0.1:51780/etl/webhook/<token>
+//
+// The listener binds to loopback only and runs while at least one enabled
+// webhook job exists. Each job's TriggerConfig holds its secret token. A JSON
+// object in the request body overrides source config keys for that run only;
+// only fields a source marks Overridable (file paths, sheet, paging size and
+// the like) can be set, never URLs, headers, credentials or commands.
+//
+// The request returns 202 as soon as the run starts. With ?wait=true it
+// responds once the run finishes: 200 on success, 500 with the error.
+
+const (
+	triggerWebhook = "webhook"
+
+	// DefaultWebhookAddr is the loopback address of the webhook listener.
+	DefaultWebhookAddr = "127.0.0.1:51780"
+
+	webhookPath    = "/etl/webhook/"
+	webhookMaxBody = 1 << 20
+)
+
+// webhookListener serves job webhooks. The token table is replaced by
+// RestartWatchers while the listener keeps running.
+type webhookListener struct {
+	srv  *http.Server
+	base string // http://host:port
+
+	mu     sync.RWMutex
+	tokens map[string]string // token → job ID
+}
+
+// SetWebhookAddr enables the webhook listener on addr, which should be a
+// loopback address. It takes effect on the next RestartWatchers.
+func (s *ETLService) SetWebhookAddr(addr string) {
+	s.webhookAddr = addr
+}
+
+// WebhookURL returns the URL that triggers a webhook job.
+func (s *ETLService) WebhookURL(id string) (string, error) {
+	job, err := s.store.GetJob(id)
+	if err != nil {
+		return "", err
+	}
+	if job.TriggerType != triggerWebhook {
+		return "", fmt.Errorf("job %s does not have a webhook trigger", id)
+	}
+	base := "http://" + s.webhookAddr
+	if s.webhook != nil {
+		base = s.webhook.base // the bound address, e.g. for port 0
+	} else if s.webhookAddr == "" {
+		return "", fmt.Errorf("webhook listener is not enabled")
+	}
+	return base + webhookPath + job.TriggerConfig, nil
+}
+
+// webhookTokenBytes is the size of a webhook token before hex encoding.
+const webhookTokenBytes = 24
+
+// newWebhookToken returns a random URL-safe token.
+func newWebhookToken() (string, error) {
+	b := make([]byte, webhookTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// webhookToken returns the token to store for a job saved with a webhook
+// trigger: the one given, the job's current one, or a new one. A given token
+// must look like a generated one, so a caller cannot set a guessable secret.
+func webhookToken(input CreateETLJobInput, prev *etl.SyncJob) (string, error) {
+	if input.TriggerConfig != "" {
+		b, err := hex.DecodeString(input.TriggerConfig)
+		if err != nil || len(b) != webhookTokenBytes {
+			return "", fmt.Errorf("webhook token must be %d hex characters; leave it empty to generate one", 2*webhookTokenBytes)
+		}
+		return input.TriggerConfig, nil
+	}
+	if prev != nil && prev.TriggerType == triggerWebhook && prev.TriggerConfig != "" {
+		return prev.TriggerConfig, nil
+	}
+	return newWebhookToken()
+}
+
+// updateWebhooks starts, updates or stops the listener for the enabled
+// webhook jobs.
+func (s *ETLService) updateWebhooks(ctx context.Context, jobs []etl.SyncJob) {
+	tokens := make(map[string]string)
+	for _, j := range jobs {
+		if j.TriggerType == triggerWebhook && j.TriggerConfig != "" {
+			tokens[j.TriggerConfig] = j.ID
+		}
+	}
+	if s.webhookAddr == "" || len(tokens) == 0 {
+		s.stopWebhooks()
+		return
+	}
+	if s.webhook != nil {
+		s.webhook.mu.Lock()
+		s.webhook.tokens = tokens
+		s.webhook.mu.Unlock()
+		return
+	}
+
+	ln, err := net.Listen("tcp", s.webhookAddr)
+	if err != nil {
+		log.Printf("etl webhook: failed to listen on %s: %v", s.webhookAddr, err)
+		return
+	}
+	wl := &webhookListener{tokens: tokens, base: "http://" + ln.Addr().String()}
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, func(w http.ResponseWriter, r *http.Request) {
+		s.serveWebhook(ctx, wl, w, r)
+	})
+	wl.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	s.webhook = wl
+	go func() {
+		if err := wl.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("etl webhook: server stopped: %v", err)
+		}
+	}()
+	log.Printf("etl webhook: listening on %s for %d job(s)", wl.base, len(tokens))
+}
+
+func (s *ETLService) stopWebhooks() {
+	if s.webhook == nil {
+		return
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	s.webhook.srv.Shutdown(shutdownCtx)
+	s.webhook = nil
+}
+
+// lookup returns the job ID for token, comparing in constant time.
+func (wl *webhookListener) lookup(token string) (string, bool) {
+	wl.mu.RLock()
+	defer wl.mu.RUnlock()
+	for t, id := range wl.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func (s *ETLService) serveWebhook(ctx context.Context, wl *webhookListener, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		webhookError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	jobID, ok := wl.lookup(strings.TrimPrefix(r.URL.Path, webhookPath))
+	if !ok {
+		webhookError(w, http.StatusNotFound, "unknown webhook")
+		return
+	}
+	job, err := s.store.GetJob(jobID)
+	if err != nil {
+		webhookError(w, http.StatusNotFound, "unknown webhook")
+		return
+	}
+	overrides, err := webhookOverrides(job.SourceType, http.MaxBytesReader(w, r.Body, webhookMaxBody))
+	if err != nil {
+		webhookError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The run outlives the request unless the caller waits for it.
+	opts := runOptions{overrides: overrides}
+	if r.URL.Query().Get("wait") == "true" {
+		if err := s.runScheduled(ctx, jobID, opts); err != nil {
+			webhookError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeWebhookJSON(w, http.StatusOK, map[string]string{"jobId": jobID, "status": "success"})
+		return
+	}
+
+	log.Printf("etl webhook: running job %s", jobID)
+	s.dagRuns.Add(1)
+	go func() {
+		defer s.dagRuns.Done()
+		if err := s.runScheduled(ctx, jobID, opts); err != nil {
+			log.Printf("etl webhook: job %s failed: %v", jobID, err)
+		}
+		s.emitter.Emit(ctx, "etl:job-completed", jobID)
+	}()
+	writeWebhookJSON(w, http.StatusAccepted, map[string]string{"jobId": jobID, "status": "started"})
+}
+
+// webhookOverrides parses a webhook body into source config overrides. Only
+// the source's Overridable config fields may be set, and never to a value
+// referencing a named secret.
+func webhookOverrides(sourceType string, body io.Reader) (etl.SourceConfig, error) {
+	var raw map[string]any
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil // empty body
+		}
+		return nil, fmt.Errorf("body must be a JSON object of source config overrides: %v", err)
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	source, err := etl.GetSource(sourceType)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]etl.ConfigField)
+	for _, f := range source.Spec().ConfigFields {
+		fields[f.Key] = f
+	}
+
+	overrides := make(etl.SourceConfig, len(raw))
+	for k, v := range raw {
+		f, ok := fields[k]
+		if !ok {
+			return nil, fmt.Errorf("unknown %s config key %q", sourceType, k)
+		}
+		if !f.Overridable {
+			return nil, fmt.Errorf("%q cannot be overridden", k)
+		}
+		// Source configs hold strings; structured values are passed as JSON.
+		str, ok := v.(string)
+		if !ok {
+			b, _ := json.Marshal(v)
+			str = string(b)
+		}
+		if secret.HasRefs(str) {
+			return nil, fmt.Errorf("%q cannot reference secrets", k)
+		}
+		overrides[k] = str
+	}
+	return overrides, nil
+}
+
+func webhookError(w http.ResponseWriter, status int, msg string) {
+	writeWebhookJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeWebhookJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
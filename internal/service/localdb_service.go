@@ -1,12 +1,15 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 
 	"notes/internal/domain"
+	"notes/internal/etl"
+	"notes/internal/expr"
 	"notes/internal/storage"
 )
 
@@ -50,6 +53,9 @@ func (s *LocalDBService) GetDatabase(blockID string) (*domain.LocalDatabase, err
 }
 
 func (s *LocalDBService) UpdateConfig(dbID, configJSON string) error {
+	if err := validateFormulas(configJSON); err != nil {
+		return err
+	}
 	db, err := s.store.GetDatabase(dbID)
 	if err != nil {
 		return err
@@ -97,8 +103,21 @@ func (s *LocalDBService) CreateRow(dbID, dataJSON string) (*domain.LocalDBRow, e
 	return row, nil
 }
 
+// ListRows returns the database's rows with formula columns evaluated.
 func (s *LocalDBService) ListRows(dbID string) ([]domain.LocalDBRow, error) {
-	return s.store.ListRows(dbID)
+	rows, err := s.store.ListRows(dbID)
+	if err != nil || len(rows) == 0 {
+		return rows, err
+	}
+	db, err := s.store.GetDatabase(dbID)
+	if err != nil {
+		return nil, err
+	}
+	// A config that does not parse has no formulas to apply.
+	if table, err := etl.NewLocalDBTable(db); err == nil {
+		applyFormulas(rows, table)
+	}
+	return rows, nil
 }
 
 func (s *LocalDBService) UpdateRow(rowID, dataJSON string) error {
@@ -144,3 +163,38 @@ func (s *LocalDBService) BatchUpdateRows(dbID, mutationsJSON string) error {
 	_ = mutationsJSON
 	return nil
 }
+
+// ── Formula Columns ────────────────────────────────────────
+// Formula columns are evaluated on read; see etl.LocalDBTable, which every
+// reader of a LocalDB shares.
+
+// validateFormulas rejects a config whose formula columns do not compile.
+func validateFormulas(configJSON string) error {
+	cols, _ := etl.ParseLocalDBColumns(configJSON)
+	for _, col := range cols {
+		if !col.IsFormula() {
+			continue
+		}
+		if err := expr.Validate(col.Formula); err != nil {
+			return fmt.Errorf("formula column %q: %w", col.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyFormulas fills formula column values into each row's DataJSON.
+func applyFormulas(rows []domain.LocalDBRow, table *etl.LocalDBTable) {
+	if !table.HasFormulas() {
+		return
+	}
+	for i := range rows {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(rows[i].DataJSON), &data); err != nil || data == nil {
+			data = make(map[string]any)
+		}
+		table.EvalFormulas(data)
+		if b, err := json.Marshal(data); err == nil {
+			rows[i].DataJSON = string(b)
+		}
+	}
+}
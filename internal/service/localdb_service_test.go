@@ -243,3 +243,44 @@ func TestLocalDBService_BatchUpdateRows_Noop(t *testing.T) {
 		t.Errorf("BatchUpdateRows noop returned error: %v", err)
 	}
 }
+
+func TestLocalDBService_FormulaColumns(t *testing.T) {
+	svc := newLocalDBService(t)
+
+	db, _ := svc.CreateDatabase("block-1", "Orders")
+	config := `{"columns":[
+		{"id":"c1","name":"Qty","type":"number"},
+		{"id":"c2","name":"Price","type":"number"},
+		{"id":"c3","name":"Total","type":"formula","formula":"{Qty} * {Price}"},
+		{"id":"c4","name":"Size","type":"formula","formula":"if({Total} >= 10, 'big', 'small')"}
+	]}`
+	if err := svc.UpdateConfig(db.ID, config); err != nil {
+		t.Fatalf("update config: %v", err)
+	}
+	svc.CreateRow(db.ID, `{"c1":4,"c2":2.5}`)
+	svc.CreateRow(db.ID, `{"c1":1,"c2":3}`)
+
+	rows, err := svc.ListRows(db.ID)
+	if err != nil {
+		t.Fatalf("list rows: %v", err)
+	}
+	want := []string{
+		`{"c1":4,"c2":2.5,"c3":10,"c4":"big"}`,
+		`{"c1":1,"c2":3,"c3":3,"c4":"small"}`,
+	}
+	for i, row := range rows {
+		if row.DataJSON != want[i] {
+			t.Errorf("row %d = %s, want %s", i, row.DataJSON, want[i])
+		}
+	}
+}
+
+func TestLocalDBService_UpdateConfig_RejectsInvalidFormula(t *testing.T) {
+	svc := newLocalDBService(t)
+
+	db, _ := svc.CreateDatabase("block-1", "Orders")
+	config := `{"columns":[{"id":"c1","name":"Total","type":"formula","formula":"{Qty} *"}]}`
+	if err := svc.UpdateConfig(db.ID, config); err == nil {
+		t.Fatal("expected error for invalid formula")
+	}
+}
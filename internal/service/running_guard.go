@@ -13,10 +13,11 @@ type ExportedRunningGuard = runningJobsGuard
 // ─────────────────────────────────────────────────────────────
 
 // runningJobsGuard is a concurrency guard that ensures only one
-// instance of a given job ID runs at a time.
+// instance of a given job ID runs at a time. It also holds each run's
+// cancel function so a run can be stopped by job ID.
 type runningJobsGuard struct {
 	mu      sync.Mutex
-	running map[string]struct{}
+	running map[string]context.CancelCauseFunc
 	wg      sync.WaitGroup
 }
 
@@ -26,12 +27,12 @@ func (g *runningJobsGuard) TryLock(jobID string) bool {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	if g.running == nil {
-		g.running = make(map[string]struct{})
+		g.running = make(map[string]context.CancelCauseFunc)
 	}
 	if _, ok := g.running[jobID]; ok {
 		return false // already running
 	}
-	g.running[jobID] = struct{}{}
+	g.running[jobID] = nil
 	g.wg.Add(1)
 	return true
 }
@@ -44,6 +45,28 @@ func (g *runningJobsGuard) Unlock(jobID string) {
 	g.wg.Done()
 }
 
+// SetCancel registers the cancel function of a running job's context.
+func (g *runningJobsGuard) SetCancel(jobID string, cancel context.CancelCauseFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.running[jobID]; ok {
+		g.running[jobID] = cancel
+	}
+}
+
+// Cancel cancels a running job's context with cause. Returns false if the
+// job is not running or has not registered a cancel function yet.
+func (g *runningJobsGuard) Cancel(jobID string, cause error) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cancel := g.running[jobID]
+	if cancel == nil {
+		return false
+	}
+	cancel(cause)
+	return true
+}
+
 // WaitAll blocks until all currently running jobs complete or ctx is cancelled.
 func (g *runningJobsGuard) WaitAll(ctx context.Context) {
 	done := make(chan struct{})
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+
+	"notes/internal/domain"
+	"notes/internal/secret"
+	"notes/internal/storage"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Secret Service — named secrets referenced as ${secret:<name>}
+// ─────────────────────────────────────────────────────────────
+
+// SecretService manages named secrets. Values go to the secret store through
+// the resolver; SQLite only records which names are set, so secrets can be
+// listed without reading them back.
+type SecretService struct {
+	store    *storage.NamedSecretStore
+	resolver *secret.Resolver
+}
+
+// NewSecretService creates a SecretService.
+func NewSecretService(store *storage.NamedSecretStore, resolver *secret.Resolver) *SecretService {
+	return &SecretService{store: store, resolver: resolver}
+}
+
+// ListSecrets returns the named secrets, without their values.
+func (s *SecretService) ListSecrets() ([]domain.NamedSecret, error) {
+	return s.store.ListSecrets()
+}
+
+// SetSecret creates or replaces the value of a named secret.
+func (s *SecretService) SetSecret(name, value string) error {
+	if err := s.resolver.Set(name, value); err != nil {
+		return fmt.Errorf("set secret: %w", err)
+	}
+	return s.store.UpsertSecret(name)
+}
+
+// DeleteSecret removes a named secret. Configs referencing it fail until it
+// is set again.
+func (s *SecretService) DeleteSecret(name string) error {
+	if err := s.resolver.Delete(name); err != nil {
+		return fmt.Errorf("delete secret: %w", err)
+	}
+	return s.store.DeleteSecret(name)
+}
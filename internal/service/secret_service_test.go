@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notes/internal/etl"
+	"notes/internal/secret"
+	"notes/internal/storage"
+	"notes/internal/testutil"
+)
+
+const testSecretValue = "tok-s3cret-123"
+
+func TestSecretService_SetListDelete(t *testing.T) {
+	store := newMockSecretStore()
+	svc := NewSecretService(storage.NewNamedSecretStore(testutil.NewTestDB(t)), secret.NewResolver(store))
+
+	if err := svc.SetSecret("has space", "x"); err == nil {
+		t.Error("expected invalid name to be rejected")
+	}
+	if err := svc.SetSecret("api-token", testSecretValue); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := svc.SetSecret("api-token", testSecretValue+"-v2"); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if got := string(store.secrets[secret.NamedKey("api-token")]); got != testSecretValue+"-v2" {
+		t.Errorf("stored = %q", got)
+	}
+
+	list, err := svc.ListSecrets()
+	if err != nil || len(list) != 1 || list[0].Name != "api-token" {
+		t.Fatalf("list = %+v err=%v", list, err)
+	}
+	if b, _ := json.Marshal(list); strings.Contains(string(b), testSecretValue) {
+		t.Errorf("list leaks the value: %s", b)
+	}
+
+	if err := svc.DeleteSecret("api-token"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if list, _ := svc.ListSecrets(); len(list) != 0 {
+		t.Errorf("list after delete = %+v", list)
+	}
+	if _, ok := store.secrets[secret.NamedKey("api-token")]; ok {
+		t.Error("value should be deleted from the secret store")
+	}
+}
+
+func TestETLService_SecretRefs(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+	env.createTargetDB(t, "target-db", []string{"auth"})
+
+	resolver := secret.NewResolver(newMockSecretStore())
+	if err := resolver.Set("api-token", testSecretValue); err != nil {
+		t.Fatal(err)
+	}
+	etl.SetSecretResolver(resolver)
+	t.Cleanup(func() { etl.SetSecretResolver(nil) })
+
+	// The endpoint echoes the credential, in records and in errors.
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Token")
+		if fail {
+			http.Error(w, "token "+token+" revoked", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `[{"auth": %q}]`, token)
+	}))
+	defer srv.Close()
+
+	cfg := map[string]any{"url": srv.URL, "headers": `{"X-Token": "${secret:api-token}"}`}
+	cfgJSON, _ := json.Marshal(cfg)
+	preview, err := env.svc.PreviewSource(ctx, "http", string(cfgJSON))
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if got := preview.Records[0].Data["auth"]; got != "${secret:api-token}" {
+		t.Errorf("preview auth = %v, want the reference", got)
+	}
+
+	job, err := env.svc.CreateJob(ctx, CreateETLJobInput{
+		Name: "API", SourceType: "http", SourceConfig: cfg, TargetDBID: "target-db", SyncMode: "replace",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if stored, _ := env.svc.GetJob(job.ID); stored.SourceCfg["headers"] != cfg["headers"] {
+		t.Errorf("stored headers = %v, want the reference kept", stored.SourceCfg["headers"])
+	}
+	if result, err := env.svc.RunJob(ctx, job.ID); err != nil || result.RowsWritten != 1 {
+		t.Fatalf("run: %+v err=%v", result, err)
+	}
+
+	fail = true
+	result, err := env.svc.RunJob(ctx, job.ID)
+	if err == nil || strings.Contains(err.Error(), testSecretValue) || strings.Contains(result.Error, testSecretValue) {
+		t.Errorf("run error = %v / %q, want the value redacted", err, result.Error)
+	}
+	logs, _ := env.svc.ListRunLogs(job.ID)
+	if len(logs) == 0 || strings.Contains(logs[0].Error, testSecretValue) || !strings.Contains(logs[0].Error, "${secret:api-token}") {
+		t.Errorf("run log error = %q", logs[0].Error)
+	}
+
+	// An unset secret fails the read.
+	cfgJSON, _ = json.Marshal(map[string]any{"url": srv.URL, "headers": `{"X-Token": "${secret:missing}"}`})
+	if _, err := env.svc.PreviewSource(ctx, "http", string(cfgJSON)); err == nil || !strings.Contains(err.Error(), `secret "missing" is not set`) {
+		t.Errorf("err = %v, want unset secret", err)
+	}
+}
+
+func TestETLService_DestinationSecretRefs(t *testing.T) {
+	ctx := context.Background()
+	env := newETLService(t)
+
+	resolver := secret.NewResolver(newMockSecretStore())
+	if err := resolver.Set("api-token", testSecretValue); err != nil {
+		t.Fatal(err)
+	}
+	etl.SetSecretResolver(resolver)
+	t.Cleanup(func() { etl.SetSecretResolver(nil) })
+
+	// The endpoint rejects other credentials, echoing what it got.
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if auth != "Bearer "+testSecretValue || r.URL.Query().Get("fail") != "" {
+			http.Error(w, "bad credential "+auth, http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	csvPath := t.TempDir() + "/rows.csv"
+	writeTestFile(t, csvPath, "id\n1\n")
+	destCfg := map[string]any{"url": srv.URL, "headers": `{"Authorization": "Bearer ${secret:api-token}"}`}
+	input := CreateETLJobInput{
+		Name: "Push", SourceType: "csv_file", SourceConfig: map[string]any{"filePath": csvPath},
+		DestType: "http", DestConfig: destCfg, SyncMode: "append",
+	}
+	job, err := env.svc.CreateJob(ctx, input)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if result, err := env.svc.RunJob(ctx, job.ID); err != nil || result.RowsWritten != 1 {
+		t.Fatalf("run: %+v err=%v (auth %q)", result, err, auth)
+	}
+	if stored, _ := env.svc.GetJob(job.ID); stored.DestCfg["headers"] != destCfg["headers"] {
+		t.Errorf("stored headers = %v, want the reference kept", stored.DestCfg["headers"])
+	}
+
+	destCfg["url"] = srv.URL + "?fail=1"
+	if err := env.svc.UpdateJob(ctx, job.ID, input); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	result, err := env.svc.RunJob(ctx, job.ID)
+	if err == nil || strings.Contains(err.Error(), testSecretValue) || strings.Contains(result.Error, testSecretValue) {
+		t.Errorf("run error = %v / %q, want the value redacted", err, result.Error)
+	}
+}
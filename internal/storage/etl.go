@@ -31,44 +31,74 @@ func (s *ETLStore) CreateJob(job *etl.SyncJob) error {
 
 	srcCfg, _ := json.Marshal(job.SourceCfg)
 	transforms, _ := json.Marshal(job.Transforms)
+	mergeKeys, _ := json.Marshal(job.MergeKeys)
+	assertions, _ := json.Marshal(job.Assertions)
+	retry, _ := json.Marshal(job.Retry)
+	destCfg, _ := json.Marshal(job.DestCfg)
 
 	_, err := s.db.conn.Exec(
 		`INSERT INTO etl_jobs (id, name, source_type, source_config, transforms, target_db_id,
-		 sync_mode, dedupe_key, trigger_type, trigger_config, enabled, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		 sync_mode, dedupe_key, trigger_type, trigger_config, enabled, created_at, updated_at,
+		 cursor_field, merge_keys, delete_missing, assertions, quarantine_db_id, retry_policy,
+		 dest_type, dest_config, schema_policy)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		job.ID, job.Name, job.SourceType, string(srcCfg), string(transforms),
 		job.TargetDBID, job.SyncMode, job.DedupeKey,
 		job.TriggerType, job.TriggerConfig, job.Enabled,
 		job.CreatedAt, job.UpdatedAt,
+		job.CursorField, string(mergeKeys), job.DeleteMissing,
+		string(assertions), job.QuarantineDBID, string(retry),
+		job.DestType, string(destCfg), job.SchemaPolicy,
 	)
 	return err
 }
 
-func (s *ETLStore) GetJob(id string) (*etl.SyncJob, error) {
-	job := &etl.SyncJob{}
-	var srcCfg, transforms string
-
-	err := s.db.conn.QueryRow(
-		`SELECT id, name, source_type, source_config, transforms, target_db_id,
+// etlJobColumns is the column list shared by every SyncJob SELECT; keep in sync with scanJob.
+const etlJobColumns = `id, name, source_type, source_config, transforms, target_db_id,
 		 sync_mode, dedupe_key, trigger_type, trigger_config, enabled,
-		 last_run_at, last_status, last_error, created_at, updated_at
-		 FROM etl_jobs WHERE id = ?`, id,
-	).Scan(
+		 last_run_at, last_status, last_error, created_at, updated_at,
+		 cursor_field, cursor_value, merge_keys, delete_missing,
+		 assertions, quarantine_db_id, retry_policy, consecutive_failures,
+		 dest_type, dest_config, schema_policy, last_schema`
+
+// scanJob scans a row selected with etlJobColumns into a SyncJob.
+func scanJob(row interface{ Scan(...any) error }) (*etl.SyncJob, error) {
+	job := &etl.SyncJob{}
+	var srcCfg, transforms, mergeKeys, assertions, retry, destCfg, lastSchema string
+	if err := row.Scan(
 		&job.ID, &job.Name, &job.SourceType, &srcCfg, &transforms,
 		&job.TargetDBID, &job.SyncMode, &job.DedupeKey,
 		&job.TriggerType, &job.TriggerConfig, &job.Enabled,
 		&job.LastRunAt, &job.LastStatus, &job.LastError,
 		&job.CreatedAt, &job.UpdatedAt,
-	)
+		&job.CursorField, &job.CursorValue, &mergeKeys, &job.DeleteMissing,
+		&assertions, &job.QuarantineDBID, &retry, &job.Failures,
+		&job.DestType, &destCfg, &job.SchemaPolicy, &lastSchema,
+	); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(srcCfg), &job.SourceCfg)
+	json.Unmarshal([]byte(transforms), &job.Transforms)
+	json.Unmarshal([]byte(mergeKeys), &job.MergeKeys)
+	json.Unmarshal([]byte(assertions), &job.Assertions)
+	json.Unmarshal([]byte(retry), &job.Retry)
+	json.Unmarshal([]byte(destCfg), &job.DestCfg)
+	if lastSchema != "" {
+		json.Unmarshal([]byte(lastSchema), &job.LastSchema)
+	}
+	return job, nil
+}
+
+func (s *ETLStore) GetJob(id string) (*etl.SyncJob, error) {
+	job, err := scanJob(s.db.conn.QueryRow(
+		`SELECT `+etlJobColumns+` FROM etl_jobs WHERE id = ?`, id,
+	))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("etl job not found: %s", id)
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	json.Unmarshal([]byte(srcCfg), &job.SourceCfg)
-	json.Unmarshal([]byte(transforms), &job.Transforms)
 	return job, nil
 }
 
@@ -76,15 +106,23 @@ func (s *ETLStore) UpdateJob(job *etl.SyncJob) error {
 	job.UpdatedAt = time.Now()
 	srcCfg, _ := json.Marshal(job.SourceCfg)
 	transforms, _ := json.Marshal(job.Transforms)
+	mergeKeys, _ := json.Marshal(job.MergeKeys)
+	assertions, _ := json.Marshal(job.Assertions)
+	retry, _ := json.Marshal(job.Retry)
+	destCfg, _ := json.Marshal(job.DestCfg)
 
 	_, err := s.db.conn.Exec(
 		`UPDATE etl_jobs SET name=?, source_type=?, source_config=?, transforms=?,
 		 target_db_id=?, sync_mode=?, dedupe_key=?, trigger_type=?, trigger_config=?,
-		 enabled=?, updated_at=? WHERE id=?`,
+		 enabled=?, updated_at=?, cursor_field=?, merge_keys=?, delete_missing=?,
+		 assertions=?, quarantine_db_id=?, retry_policy=?, dest_type=?, dest_config=?,
+		 schema_policy=? WHERE id=?`,
 		job.Name, job.SourceType, string(srcCfg), string(transforms),
 		job.TargetDBID, job.SyncMode, job.DedupeKey,
 		job.TriggerType, job.TriggerConfig, job.Enabled,
-		job.UpdatedAt, job.ID,
+		job.UpdatedAt, job.CursorField, string(mergeKeys), job.DeleteMissing,
+		string(assertions), job.QuarantineDBID, string(retry),
+		job.DestType, string(destCfg), job.SchemaPolicy, job.ID,
 	)
 	return err
 }
@@ -97,22 +135,57 @@ func (s *ETLStore) UpdateJobStatus(id, status, errMsg string) error {
 	return err
 }
 
+// UpdateJobFailures records the number of consecutive failed scheduled runs.
+// disable also turns the job off.
+func (s *ETLStore) UpdateJobFailures(id string, failures int, disable bool) error {
+	query := `UPDATE etl_jobs SET consecutive_failures=? WHERE id=?`
+	if disable {
+		query = `UPDATE etl_jobs SET consecutive_failures=?, enabled=0 WHERE id=?`
+	}
+	_, err := s.db.conn.Exec(query, failures, id)
+	return err
+}
+
+// SetJobEnabled turns a job's trigger on or off. Enabling a job clears its
+// consecutive failures.
+func (s *ETLStore) SetJobEnabled(id string, enabled bool) error {
+	query := `UPDATE etl_jobs SET enabled=?, updated_at=? WHERE id=?`
+	if enabled {
+		query = `UPDATE etl_jobs SET enabled=?, updated_at=?, consecutive_failures=0 WHERE id=?`
+	}
+	_, err := s.db.conn.Exec(query, enabled, time.Now(), id)
+	return err
+}
+
 func (s *ETLStore) DeleteJob(id string) error {
-	// Delete run logs first.
+	// Delete run logs and file history first.
 	if _, err := s.db.conn.Exec(`DELETE FROM etl_run_logs WHERE job_id = ?`, id); err != nil {
 		return err
 	}
+	if err := s.ClearIngestedFiles(id); err != nil {
+		return err
+	}
 	_, err := s.db.conn.Exec(`DELETE FROM etl_jobs WHERE id = ?`, id)
 	return err
 }
 
 func (s *ETLStore) ListJobs() ([]etl.SyncJob, error) {
-	rows, err := s.db.conn.Query(
-		`SELECT id, name, source_type, source_config, transforms, target_db_id,
-		 sync_mode, dedupe_key, trigger_type, trigger_config, enabled,
-		 last_run_at, last_status, last_error, created_at, updated_at
-		 FROM etl_jobs ORDER BY created_at ASC`,
+	return s.queryJobs(`SELECT ` + etlJobColumns + ` FROM etl_jobs ORDER BY created_at ASC`)
+}
+
+// ListEnabledScheduledJobs returns enabled jobs with a schedule, file_watch
+// or webhook trigger.
+func (s *ETLStore) ListEnabledScheduledJobs() ([]etl.SyncJob, error) {
+	return s.queryJobs(
+		`SELECT ` + etlJobColumns + ` FROM etl_jobs
+		 WHERE enabled = 1 AND trigger_type IN ('schedule', 'file_watch', 'webhook')
+		 ORDER BY created_at ASC`,
 	)
+}
+
+// queryJobs runs a SELECT over etlJobColumns and scans every row.
+func (s *ETLStore) queryJobs(query string, args ...any) ([]etl.SyncJob, error) {
+	rows, err := s.db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -120,73 +193,121 @@ func (s *ETLStore) ListJobs() ([]etl.SyncJob, error) {
 
 	var jobs []etl.SyncJob
 	for rows.Next() {
-		var job etl.SyncJob
-		var srcCfg, transforms string
-		if err := rows.Scan(
-			&job.ID, &job.Name, &job.SourceType, &srcCfg, &transforms,
-			&job.TargetDBID, &job.SyncMode, &job.DedupeKey,
-			&job.TriggerType, &job.TriggerConfig, &job.Enabled,
-			&job.LastRunAt, &job.LastStatus, &job.LastError,
-			&job.CreatedAt, &job.UpdatedAt,
-		); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			return nil, err
 		}
-		json.Unmarshal([]byte(srcCfg), &job.SourceCfg)
-		json.Unmarshal([]byte(transforms), &job.Transforms)
-		jobs = append(jobs, job)
+		jobs = append(jobs, *job)
 	}
 	return jobs, rows.Err()
 }
 
-// ListEnabledScheduledJobs returns jobs that are enabled with a schedule trigger.
-func (s *ETLStore) ListEnabledScheduledJobs() ([]etl.SyncJob, error) {
+// UpdateJobCursor persists the incremental-sync high-water mark for a job.
+// It is kept separate from UpdateJob so editing a job never rewinds its cursor.
+func (s *ETLStore) UpdateJobCursor(id, value string) error {
+	_, err := s.db.conn.Exec(
+		`UPDATE etl_jobs SET cursor_value=? WHERE id=?`, value, id,
+	)
+	return err
+}
+
+// UpdateJobSchema persists the schema discovered by a job's last successful
+// run, the baseline for schema drift. nil clears it.
+func (s *ETLStore) UpdateJobSchema(id string, schema *etl.Schema) error {
+	value := ""
+	if schema != nil {
+		b, _ := json.Marshal(schema)
+		value = string(b)
+	}
+	_, err := s.db.conn.Exec(`UPDATE etl_jobs SET last_schema=? WHERE id=?`, value, id)
+	return err
+}
+
+// ── Ingested Files ─────────────────────────────────────────
+
+// IsFileIngested reports whether a job already ingested the file at path with
+// the given checksum. It implements etl.FileHistory.
+func (s *ETLStore) IsFileIngested(jobID, path, checksum string) (bool, error) {
+	var n int
+	err := s.db.conn.QueryRow(
+		`SELECT COUNT(*) FROM etl_ingested_files WHERE job_id = ? AND path = ? AND checksum = ?`,
+		jobID, path, checksum,
+	).Scan(&n)
+	return n > 0, err
+}
+
+// RecordIngestedFiles remembers the files a successful run ingested.
+func (s *ETLStore) RecordIngestedFiles(jobID string, files []etl.IngestedFile) error {
+	tx, err := s.db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, f := range files {
+		if _, err := tx.Exec(
+			`INSERT OR REPLACE INTO etl_ingested_files (job_id, path, checksum, rows, ingested_at)
+			 VALUES (?, ?, ?, ?, ?)`,
+			jobID, f.Path, f.Checksum, f.Rows, f.IngestedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListIngestedFiles returns the files a job has ingested, newest first.
+func (s *ETLStore) ListIngestedFiles(jobID string, limit int) ([]etl.IngestedFile, error) {
 	rows, err := s.db.conn.Query(
-		`SELECT id, name, source_type, source_config, transforms, target_db_id,
-		 sync_mode, dedupe_key, trigger_type, trigger_config, enabled,
-		 last_run_at, last_status, last_error, created_at, updated_at
-		 FROM etl_jobs WHERE enabled = 1 AND trigger_type IN ('schedule', 'file_watch')
-		 ORDER BY created_at ASC`,
+		`SELECT path, checksum, rows, ingested_at FROM etl_ingested_files
+		 WHERE job_id = ? ORDER BY ingested_at DESC LIMIT ?`,
+		jobID, limit,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var jobs []etl.SyncJob
+	var files []etl.IngestedFile
 	for rows.Next() {
-		var job etl.SyncJob
-		var srcCfg, transforms string
-		if err := rows.Scan(
-			&job.ID, &job.Name, &job.SourceType, &srcCfg, &transforms,
-			&job.TargetDBID, &job.SyncMode, &job.DedupeKey,
-			&job.TriggerType, &job.TriggerConfig, &job.Enabled,
-			&job.LastRunAt, &job.LastStatus, &job.LastError,
-			&job.CreatedAt, &job.UpdatedAt,
-		); err != nil {
+		var f etl.IngestedFile
+		if err := rows.Scan(&f.Path, &f.Checksum, &f.Rows, &f.IngestedAt); err != nil {
 			return nil, err
 		}
-		json.Unmarshal([]byte(srcCfg), &job.SourceCfg)
-		json.Unmarshal([]byte(transforms), &job.Transforms)
-		jobs = append(jobs, job)
+		files = append(files, f)
 	}
-	return jobs, rows.Err()
+	return files, rows.Err()
+}
+
+// ClearIngestedFiles forgets a job's file history so every file is read again.
+func (s *ETLStore) ClearIngestedFiles(jobID string) error {
+	_, err := s.db.conn.Exec(`DELETE FROM etl_ingested_files WHERE job_id = ?`, jobID)
+	return err
 }
 
 // ── Run Logs ───────────────────────────────────────────────
 
 func (s *ETLStore) CreateRunLog(log *etl.SyncRunLog) error {
 	log.ID = uuid.New().String()
+	assertions, _ := json.Marshal(log.Assertions)
+	drift, _ := json.Marshal(log.SchemaDrift)
 	_, err := s.db.conn.Exec(
-		`INSERT INTO etl_run_logs (id, job_id, started_at, finished_at, status, rows_read, rows_written, error)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		log.ID, log.JobID, log.StartedAt, log.FinishedAt, log.Status, log.RowsRead, log.RowsWritten, log.Error,
+		`INSERT INTO etl_run_logs (id, job_id, started_at, finished_at, status, rows_read, rows_written,
+		 rows_inserted, rows_updated, rows_deleted, error, rows_quarantined, assertions,
+		 upstream_run_id, root_run_id, attempt, output, schema_drift)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		log.ID, log.JobID, log.StartedAt, log.FinishedAt, log.Status, log.RowsRead, log.RowsWritten,
+		log.RowsInserted, log.RowsUpdated, log.RowsDeleted, log.Error,
+		log.RowsQuarantined, string(assertions),
+		log.UpstreamRunID, log.RootRunID, max(log.Attempt, 1), log.Output, string(drift),
 	)
 	return err
 }
 
 func (s *ETLStore) ListRunLogs(jobID string, limit int) ([]etl.SyncRunLog, error) {
 	rows, err := s.db.conn.Query(
-		`SELECT id, job_id, started_at, finished_at, status, rows_read, rows_written, error
+		`SELECT id, job_id, started_at, finished_at, status, rows_read, rows_written,
+		 rows_inserted, rows_updated, rows_deleted, error, rows_quarantined, assertions,
+		 upstream_run_id, root_run_id, attempt, output, schema_drift
 		 FROM etl_run_logs WHERE job_id = ? ORDER BY started_at DESC LIMIT ?`,
 		jobID, limit,
 	)
@@ -198,9 +319,14 @@ func (s *ETLStore) ListRunLogs(jobID string, limit int) ([]etl.SyncRunLog, error
 	var logs []etl.SyncRunLog
 	for rows.Next() {
 		var l etl.SyncRunLog
-		if err := rows.Scan(&l.ID, &l.JobID, &l.StartedAt, &l.FinishedAt, &l.Status, &l.RowsRead, &l.RowsWritten, &l.Error); err != nil {
+		var assertions, drift string
+		if err := rows.Scan(&l.ID, &l.JobID, &l.StartedAt, &l.FinishedAt, &l.Status, &l.RowsRead, &l.RowsWritten,
+			&l.RowsInserted, &l.RowsUpdated, &l.RowsDeleted, &l.Error, &l.RowsQuarantined, &assertions,
+			&l.UpstreamRunID, &l.RootRunID, &l.Attempt, &l.Output, &drift); err != nil {
 			return nil, err
 		}
+		json.Unmarshal([]byte(assertions), &l.Assertions)
+		json.Unmarshal([]byte(drift), &l.SchemaDrift)
 		logs = append(logs, l)
 	}
 	return logs, rows.Err()
@@ -241,6 +241,63 @@ func TestETLStore_ListEnabledScheduledJobs(t *testing.T) {
 	}
 }
 
+func TestETLStore_UpdateJobCursor(t *testing.T) {
+	s := newETLStore(t)
+
+	job := &etl.SyncJob{Name: "Inc", SourceType: "csv", SourceCfg: map[string]any{}, SyncMode: "incremental", CursorField: "id"}
+	s.CreateJob(job)
+
+	if err := s.UpdateJobCursor(job.ID, "42"); err != nil {
+		t.Fatalf("update cursor: %v", err)
+	}
+
+	// Editing the job must not rewind the cursor.
+	job.Name = "Renamed"
+	if err := s.UpdateJob(job); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	got, _ := s.GetJob(job.ID)
+	if got.CursorField != "id" {
+		t.Errorf("cursorField = %q", got.CursorField)
+	}
+	if got.CursorValue != "42" {
+		t.Errorf("cursorValue = %q, want 42", got.CursorValue)
+	}
+}
+
+func TestETLStore_MergeSettingsRoundtrip(t *testing.T) {
+	s := newETLStore(t)
+
+	job := &etl.SyncJob{
+		Name: "Merge", SourceType: "csv", SourceCfg: map[string]any{},
+		SyncMode: "merge", MergeKeys: []string{"region", "sku"}, DeleteMissing: true,
+	}
+	if err := s.CreateJob(job); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	got, _ := s.GetJob(job.ID)
+	if len(got.MergeKeys) != 2 || got.MergeKeys[0] != "region" || got.MergeKeys[1] != "sku" {
+		t.Errorf("mergeKeys = %v", got.MergeKeys)
+	}
+	if !got.DeleteMissing {
+		t.Error("deleteMissing should be true")
+	}
+
+	log := &etl.SyncRunLog{
+		JobID: job.ID, StartedAt: time.Now(), FinishedAt: time.Now(), Status: "success",
+		RowsRead: 5, RowsWritten: 4, RowsInserted: 1, RowsUpdated: 3, RowsDeleted: 2,
+	}
+	if err := s.CreateRunLog(log); err != nil {
+		t.Fatalf("create log: %v", err)
+	}
+	logs, _ := s.ListRunLogs(job.ID, 10)
+	if len(logs) != 1 || logs[0].RowsInserted != 1 || logs[0].RowsUpdated != 3 || logs[0].RowsDeleted != 2 {
+		t.Errorf("run log counts = %+v", logs)
+	}
+}
+
 // ── RunLog Tests ────────────────────────────────────────────
 
 func TestETLStore_CreateAndListRunLogs(t *testing.T) {
@@ -179,6 +179,97 @@ func (s *LocalDatabaseStore) ReorderRows(databaseID string, rowIDs []string) err
 	return tx.Commit()
 }
 
+// ── Staged Writes ──────────────────────────────────────────
+
+// StageRows stages rows under stageID, replacing rows already staged with the
+// same ID. Staged rows are not visible until CommitStage.
+func (s *LocalDatabaseStore) StageRows(stageID string, rows []domain.LocalDBRow) error {
+	tx, err := s.db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO local_db_staged_rows (stage_id, id, data_json, sort_order, staged_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (stage_id, id) DO UPDATE SET data_json = excluded.data_json`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().Unix()
+	for _, r := range rows {
+		if _, err := stmt.Exec(stageID, r.ID, r.DataJSON, r.SortOrder, now); err != nil {
+			return fmt.Errorf("stage row %s: %w", r.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetStagedRow returns a row staged under stageID.
+func (s *LocalDatabaseStore) GetStagedRow(stageID, rowID string) (*domain.LocalDBRow, error) {
+	r := &domain.LocalDBRow{ID: rowID}
+	err := s.db.conn.QueryRow(
+		`SELECT data_json, sort_order FROM local_db_staged_rows WHERE stage_id = ? AND id = ?`,
+		stageID, rowID,
+	).Scan(&r.DataJSON, &r.SortOrder)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("staged row not found: %s", rowID)
+	}
+	return r, err
+}
+
+// CommitStage applies the rows staged under stageID to c.DatabaseID in one
+// transaction and drops the stage.
+func (s *LocalDatabaseStore) CommitStage(stageID string, c domain.LocalDBStageCommit) error {
+	tx, err := s.db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if c.ConfigJSON != "" {
+		if _, err := tx.Exec(
+			`UPDATE local_databases SET config_json = ?, updated_at = ? WHERE id = ?`,
+			c.ConfigJSON, now, c.DatabaseID,
+		); err != nil {
+			return fmt.Errorf("update config: %w", err)
+		}
+	}
+	if c.ClearRows {
+		if _, err := tx.Exec(`DELETE FROM local_db_rows WHERE database_id = ?`, c.DatabaseID); err != nil {
+			return fmt.Errorf("clear rows: %w", err)
+		}
+	}
+	for _, id := range c.DeleteRowIDs {
+		if _, err := tx.Exec(`DELETE FROM local_db_rows WHERE id = ? AND database_id = ?`, id, c.DatabaseID); err != nil {
+			return fmt.Errorf("delete row %s: %w", id, err)
+		}
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO local_db_rows (id, database_id, data_json, sort_order, created_at, updated_at)
+		 SELECT id, ?, data_json, sort_order, ?, ? FROM local_db_staged_rows WHERE stage_id = ?
+		 ON CONFLICT (id) DO UPDATE SET data_json = excluded.data_json, updated_at = excluded.updated_at`,
+		c.DatabaseID, now, now, stageID,
+	); err != nil {
+		return fmt.Errorf("apply staged rows: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM local_db_staged_rows WHERE stage_id = ?`, stageID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DiscardStage drops the rows staged under stageID.
+func (s *LocalDatabaseStore) DiscardStage(stageID string) error {
+	_, err := s.db.conn.Exec(`DELETE FROM local_db_staged_rows WHERE stage_id = ?`, stageID)
+	return err
+}
+
 // ListDatabases returns all local databases (used by chart/ETL blocks to pick a source).
 func (s *LocalDatabaseStore) ListDatabases() ([]domain.LocalDatabase, error) {
 	rows, err := s.db.conn.Query(
@@ -250,4 +341,8 @@ var _ interface {
 	DeleteRow(string) error
 	DeleteRowsByDatabase(string) error
 	ReorderRows(string, []string) error
+	StageRows(string, []domain.LocalDBRow) error
+	GetStagedRow(string, string) (*domain.LocalDBRow, error)
+	CommitStage(string, domain.LocalDBStageCommit) error
+	DiscardStage(string) error
 } = (*LocalDatabaseStore)(nil)
@@ -312,6 +312,71 @@ func TestLocalDatabaseStore_ReorderRows(t *testing.T) {
 	}
 }
 
+func TestLocalDatabaseStore_CommitStage(t *testing.T) {
+	s := newLocalDBStore(t)
+	s.CreateDatabase(&domain.LocalDatabase{ID: "db-1", BlockID: "block-1", Name: "Test", ConfigJSON: "{}"})
+	for _, id := range []string{"r1", "r2"} {
+		s.CreateRow(&domain.LocalDBRow{ID: id, DatabaseID: "db-1", DataJSON: `{"v":"old"}`})
+	}
+
+	staged := []domain.LocalDBRow{
+		{ID: "r1", DataJSON: `{"v":"new"}`, SortOrder: 1},
+		{ID: "r3", DataJSON: `{"v":"added"}`, SortOrder: 3},
+	}
+	if err := s.StageRows("stage-1", staged); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if got, err := s.GetStagedRow("stage-1", "r3"); err != nil || got.DataJSON != `{"v":"added"}` {
+		t.Fatalf("staged row = %+v, %v", got, err)
+	}
+	if rows, _ := s.ListRows("db-1"); len(rows) != 2 {
+		t.Fatalf("staged rows visible before commit: %d rows", len(rows))
+	}
+
+	err := s.CommitStage("stage-1", domain.LocalDBStageCommit{
+		DatabaseID:   "db-1",
+		ConfigJSON:   `{"columns":[]}`,
+		DeleteRowIDs: []string{"r2"},
+	})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	rows, _ := s.ListRows("db-1")
+	got := map[string]string{}
+	for _, r := range rows {
+		got[r.ID] = r.DataJSON
+	}
+	want := map[string]string{"r1": `{"v":"new"}`, "r3": `{"v":"added"}`}
+	if len(got) != len(want) || got["r1"] != want["r1"] || got["r3"] != want["r3"] {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if d, _ := s.GetDatabase("db-1"); d.ConfigJSON != `{"columns":[]}` {
+		t.Errorf("config = %q", d.ConfigJSON)
+	}
+	if _, err := s.GetStagedRow("stage-1", "r3"); err == nil {
+		t.Error("stage should be dropped after commit")
+	}
+}
+
+func TestLocalDatabaseStore_DiscardStage(t *testing.T) {
+	s := newLocalDBStore(t)
+	s.CreateDatabase(&domain.LocalDatabase{ID: "db-1", BlockID: "block-1", Name: "Test", ConfigJSON: "{}"})
+	s.CreateRow(&domain.LocalDBRow{ID: "r1", DatabaseID: "db-1", DataJSON: "{}"})
+
+	s.StageRows("stage-1", []domain.LocalDBRow{{ID: "r2", DataJSON: "{}"}})
+	if err := s.DiscardStage("stage-1"); err != nil {
+		t.Fatalf("discard: %v", err)
+	}
+	// A discarded stage has no rows left to apply.
+	if err := s.CommitStage("stage-1", domain.LocalDBStageCommit{DatabaseID: "db-1"}); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if rows, _ := s.ListRows("db-1"); len(rows) != 1 || rows[0].ID != "r1" {
+		t.Errorf("rows = %+v, want only r1", rows)
+	}
+}
+
 func TestLocalDatabaseStore_GetDatabaseStats(t *testing.T) {
 	s := newLocalDBStore(t)
 	d := &domain.LocalDatabase{ID: "db-1", BlockID: "block-1", Name: "Test", ConfigJSON: "{}"}
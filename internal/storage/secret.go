@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"time"
+
+	"notes/internal/domain"
+)
+
+// NamedSecretStore keeps the names of the secrets set through the secrets
+// API. Their values live in the secret store.
+type NamedSecretStore struct {
+	db *DB
+}
+
+// NewNamedSecretStore creates a new NamedSecretStore.
+func NewNamedSecretStore(db *DB) *NamedSecretStore {
+	return &NamedSecretStore{db: db}
+}
+
+func (s *NamedSecretStore) ListSecrets() ([]domain.NamedSecret, error) {
+	rows, err := s.db.Conn().Query(`SELECT name, created_at, updated_at FROM named_secrets ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.NamedSecret
+	for rows.Next() {
+		var n domain.NamedSecret
+		if err := rows.Scan(&n.Name, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// UpsertSecret records name, keeping its creation time if it exists.
+func (s *NamedSecretStore) UpsertSecret(name string) error {
+	now := time.Now()
+	_, err := s.db.Conn().Exec(
+		`INSERT INTO named_secrets (name, created_at, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(name) DO UPDATE SET updated_at = excluded.updated_at`,
+		name, now, now,
+	)
+	return err
+}
+
+func (s *NamedSecretStore) DeleteSecret(name string) error {
+	_, err := s.db.Conn().Exec(`DELETE FROM named_secrets WHERE name = ?`, name)
+	return err
+}
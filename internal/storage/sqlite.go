@@ -293,6 +293,62 @@ func (db *DB) migrate() error {
 		`CREATE INDEX IF NOT EXISTS idx_meetings_date ON meetings(date)`,
 		`CREATE INDEX IF NOT EXISTS idx_meetings_status ON meetings(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_meetings_page ON meetings(page_id)`,
+		// ETL incremental sync: cursor field + persisted high-water mark per job
+		`ALTER TABLE etl_jobs ADD COLUMN cursor_field TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE etl_jobs ADD COLUMN cursor_value TEXT NOT NULL DEFAULT ''`,
+		// ETL merge sync: key columns + delete-missing flag, per-run change counts
+		`ALTER TABLE etl_jobs ADD COLUMN merge_keys TEXT NOT NULL DEFAULT '[]'`,
+		`ALTER TABLE etl_jobs ADD COLUMN delete_missing INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE etl_run_logs ADD COLUMN rows_inserted INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE etl_run_logs ADD COLUMN rows_updated INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE etl_run_logs ADD COLUMN rows_deleted INTEGER NOT NULL DEFAULT 0`,
+		// ETL data-quality assertions: per-job checks + quarantine target, per-run summary
+		`ALTER TABLE etl_jobs ADD COLUMN assertions TEXT NOT NULL DEFAULT '[]'`,
+		`ALTER TABLE etl_jobs ADD COLUMN quarantine_db_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE etl_run_logs ADD COLUMN rows_quarantined INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE etl_run_logs ADD COLUMN assertions TEXT NOT NULL DEFAULT '[]'`,
+		// ETL job dependencies: lineage of runs started by an after_job trigger
+		`ALTER TABLE etl_run_logs ADD COLUMN upstream_run_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE etl_run_logs ADD COLUMN root_run_id TEXT NOT NULL DEFAULT ''`,
+		// ETL retries: per-job policy + consecutive failed runs, attempt number per run
+		`ALTER TABLE etl_jobs ADD COLUMN retry_policy TEXT NOT NULL DEFAULT '{}'`,
+		`ALTER TABLE etl_jobs ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE etl_run_logs ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1`,
+		// ETL subprocess sources: connector stderr per run
+		`ALTER TABLE etl_run_logs ADD COLUMN output TEXT NOT NULL DEFAULT ''`,
+		// ETL destinations: registered destination type + config per job ('' = LocalDB)
+		`ALTER TABLE etl_jobs ADD COLUMN dest_type TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE etl_jobs ADD COLUMN dest_config TEXT NOT NULL DEFAULT '{}'`,
+		// ETL directory ingestion: files already read by each job
+		`CREATE TABLE IF NOT EXISTS etl_ingested_files (
+			job_id TEXT NOT NULL REFERENCES etl_jobs(id),
+			path TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			rows INTEGER NOT NULL DEFAULT 0,
+			ingested_at DATETIME NOT NULL,
+			PRIMARY KEY (job_id, path, checksum)
+		)`,
+		// ETL schema drift: per-job policy + last discovered schema, per-run changes
+		`ALTER TABLE etl_jobs ADD COLUMN schema_policy TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE etl_jobs ADD COLUMN last_schema TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE etl_run_logs ADD COLUMN schema_drift TEXT NOT NULL DEFAULT '[]'`,
+		// Named secrets referenced as ${secret:<name>}; values live in the keychain
+		`CREATE TABLE IF NOT EXISTS named_secrets (
+			name TEXT PRIMARY KEY,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		// LocalDB staged writes: rows of an ETL run, applied when the run commits
+		`CREATE TABLE IF NOT EXISTS local_db_staged_rows (
+			stage_id TEXT NOT NULL,
+			id TEXT NOT NULL,
+			data_json TEXT NOT NULL DEFAULT '{}',
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			staged_at INTEGER NOT NULL,
+			PRIMARY KEY (stage_id, id)
+		)`,
+		// Drop stages left behind by runs that crashed before committing
+		`DELETE FROM local_db_staged_rows WHERE staged_at < strftime('%s', 'now') - 7 * 86400`,
 	}
 
 	for _, m := range migrations {